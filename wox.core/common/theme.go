@@ -15,6 +15,11 @@ type Theme struct {
 	IsSystem    bool
 	IsInstalled bool
 
+	Extends   string                     `json:",omitempty"` // id of the theme this theme extends, the theme only sets the fields it changes
+	Variables map[string]string          `json:",omitempty"` // named colours, a colour field set to "$name" uses the colour of the variable
+	Light     map[string]json.RawMessage `json:",omitempty"` // fields and variables that change when the OS uses the light appearance
+	Dark      map[string]json.RawMessage `json:",omitempty"` // fields and variables that change when the OS uses the dark appearance
+
 	AppBackgroundColor                   string
 	AppPaddingLeft                       int
 	AppPaddingTop                        int
@@ -65,6 +70,8 @@ type Theme struct {
 	ToolbarBackgroundColor               string
 	ToolbarPaddingLeft                   int
 	ToolbarPaddingRight                  int
+
+	fields map[string]json.RawMessage // style fields set in the json of the theme, nil when the theme was not parsed from json
 }
 
 func (t *Theme) UnmarshalJSON(data []byte) error {
@@ -87,9 +94,23 @@ func (t *Theme) UnmarshalJSON(data []byte) error {
 	t.ResultItemBorderLeftWidth = parseJSONInt(raw, "ResultItemBorderLeftWidth", "ResultItemBorderLeft")
 	t.ResultItemActiveBorderLeftWidth = parseJSONInt(raw, "ResultItemActiveBorderLeftWidth", "ResultItemActiveBorderLeft")
 
+	renameThemeField(raw, "ResultItemBorderLeft", "ResultItemBorderLeftWidth")
+	renameThemeField(raw, "ResultItemActiveBorderLeft", "ResultItemActiveBorderLeftWidth")
+	t.fields = filterStyleFields(raw)
+
 	return nil
 }
 
+// renameThemeField moves a field of older themes to its current name
+func renameThemeField(raw map[string]json.RawMessage, oldName string, newName string) {
+	if value, ok := raw[oldName]; ok {
+		if _, exists := raw[newName]; !exists {
+			raw[newName] = value
+		}
+		delete(raw, oldName)
+	}
+}
+
 func parseJSONInt(raw map[string]json.RawMessage, keys ...string) int {
 	for _, key := range keys {
 		value, ok := raw[key]
@@ -0,0 +1,347 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	ThemeAppearanceLight = "light"
+	ThemeAppearanceDark  = "dark"
+)
+
+// themeMetadataFields are the fields that describe a theme instead of styling it, they are not inherited
+var themeMetadataFields = map[string]bool{
+	"ThemeId": true, "ThemeName": true, "ThemeAuthor": true, "ThemeUrl": true, "Version": true, "Description": true,
+	"IsSystem": true, "IsInstalled": true, "IsSystemTheme": true,
+	"Extends": true, "Variables": true, "Light": true, "Dark": true,
+}
+
+type themeStyleField struct {
+	name    string
+	isColor bool
+}
+
+// themeStyleFields are the colours and sizes every resolved theme must set
+var themeStyleFields = func() []themeStyleField {
+	var fields []themeStyleField
+	themeType := reflect.TypeOf(Theme{})
+	for i := 0; i < themeType.NumField(); i++ {
+		field := themeType.Field(i)
+		if !field.IsExported() || themeMetadataFields[field.Name] {
+			continue
+		}
+		fields = append(fields, themeStyleField{name: field.Name, isColor: field.Type.Kind() == reflect.String})
+	}
+	return fields
+}()
+
+var colorRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`^#([0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`),
+	regexp.MustCompile(`^(rgb|hsl)a?\(\s*[\d.]+%?\s*,\s*[\d.]+%?\s*,\s*[\d.]+%?\s*(,\s*[\d.]+%?\s*)?\)$`),
+}
+
+func filterStyleFields(raw map[string]json.RawMessage) map[string]json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	for _, field := range themeStyleFields {
+		if value, ok := raw[field.name]; ok {
+			fields[field.name] = value
+		}
+	}
+	return fields
+}
+
+// styleFields returns the style fields the theme sets, all of them when the theme was not parsed from json
+func (t Theme) styleFields() map[string]json.RawMessage {
+	if t.fields != nil {
+		return t.fields
+	}
+
+	data, _ := json.Marshal(t)
+	var raw map[string]json.RawMessage
+	json.Unmarshal(data, &raw)
+	return filterStyleFields(raw)
+}
+
+// NewExtendingTheme returns a theme that sets no fields of its own, every field comes from the theme it extends until it is set by SetStyleField
+func NewExtendingTheme(themeId string, themeName string, extends string) Theme {
+	return Theme{
+		ThemeId:   themeId,
+		ThemeName: themeName,
+		Extends:   extends,
+		fields:    map[string]json.RawMessage{},
+	}
+}
+
+// Clone returns a copy of the theme that can be changed without changing the theme
+func (t Theme) Clone() Theme {
+	t.Variables = maps.Clone(t.Variables)
+	t.Light = maps.Clone(t.Light)
+	t.Dark = maps.Clone(t.Dark)
+	t.fields = maps.Clone(t.fields)
+	return t
+}
+
+// ThemeStyleFieldNames returns the names of the colour and size fields of a theme, in the order they are declared
+func ThemeStyleFieldNames() []string {
+	var names []string
+	for _, field := range themeStyleFields {
+		names = append(names, field.name)
+	}
+	return names
+}
+
+// IsThemeColorField reports whether the style field is a colour, the other style fields are sizes
+func IsThemeColorField(name string) bool {
+	return slices.ContainsFunc(themeStyleFields, func(field themeStyleField) bool { return field.name == name && field.isColor })
+}
+
+// GetStyleField returns the value of a colour or size field as it is written in the json of the theme
+func (t Theme) GetStyleField(name string) string {
+	field := reflect.ValueOf(t).FieldByName(name)
+	if !field.IsValid() {
+		return ""
+	}
+	if field.Kind() == reflect.Int {
+		return strconv.Itoa(int(field.Int()))
+	}
+	return field.String()
+}
+
+// SetStyleField sets a colour or size field of the theme, an empty value removes the field so it comes from the theme it extends again
+func (t *Theme) SetStyleField(name string, value string) error {
+	index := slices.IndexFunc(themeStyleFields, func(field themeStyleField) bool { return field.name == name })
+	if index == -1 {
+		return fmt.Errorf("unknown field %s", name)
+	}
+
+	field := reflect.ValueOf(t).Elem().FieldByName(name)
+	value = strings.TrimSpace(value)
+	if value == "" {
+		field.SetZero()
+		if t.fields != nil {
+			delete(t.fields, name)
+		}
+		return nil
+	}
+
+	var raw json.RawMessage
+	if themeStyleFields[index].isColor {
+		field.SetString(value)
+		raw, _ = json.Marshal(value)
+	} else {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return fmt.Errorf("%s: %s is not a size, expected a whole number not less than 0", name, value)
+		}
+		field.SetInt(int64(size))
+		raw, _ = json.Marshal(size)
+	}
+	if t.fields != nil {
+		t.fields[name] = raw
+	}
+	return nil
+}
+
+// SourceJSON returns the theme as it should be saved, only with the fields it sets, so it keeps extending its parent theme
+func (t Theme) SourceJSON() ([]byte, error) {
+	if t.fields == nil {
+		return json.Marshal(t)
+	}
+
+	source := maps.Clone(t.fields)
+	metadata, err := json.Marshal(struct {
+		ThemeId     string
+		ThemeName   string
+		ThemeAuthor string
+		ThemeUrl    string
+		Version     string
+		Description string
+		Extends     string                     `json:",omitempty"`
+		Variables   map[string]string          `json:",omitempty"`
+		Light       map[string]json.RawMessage `json:",omitempty"`
+		Dark        map[string]json.RawMessage `json:",omitempty"`
+	}{t.ThemeId, t.ThemeName, t.ThemeAuthor, t.ThemeUrl, t.Version, t.Description, t.Extends, t.Variables, t.Light, t.Dark})
+	if err != nil {
+		return nil, err
+	}
+	var metadataFields map[string]json.RawMessage
+	if err := json.Unmarshal(metadata, &metadataFields); err != nil {
+		return nil, err
+	}
+	maps.Copy(source, metadataFields)
+	return json.Marshal(source)
+}
+
+// getThemeChain returns the theme and the themes it extends, the theme first
+func getThemeChain(theme Theme, getTheme func(themeId string) (Theme, bool)) ([]Theme, error) {
+	chain := []Theme{theme}
+	visited := map[string]bool{theme.ThemeId: true}
+	for current := theme; current.Extends != ""; {
+		parent, found := getTheme(current.Extends)
+		if !found {
+			return nil, fmt.Errorf("theme %s extends theme %s, which is not installed", current.ThemeName, current.Extends)
+		}
+		if visited[parent.ThemeId] {
+			return nil, fmt.Errorf("theme %s extends itself through theme %s", theme.ThemeName, parent.ThemeName)
+		}
+		visited[parent.ThemeId] = true
+		chain = append(chain, parent)
+		current = parent
+	}
+	return chain, nil
+}
+
+// ResolveTheme returns the theme with all fields set, the fields it doesn't set come from the themes it extends, its variables are replaced by
+// their colours and the fields of the appearance (ThemeAppearanceLight or ThemeAppearanceDark) replace the other fields.
+// getTheme returns the installed themes, every problem of the theme is reported at once.
+func ResolveTheme(theme Theme, appearance string, getTheme func(themeId string) (Theme, bool)) (Theme, error) {
+	chain, err := getThemeChain(theme, getTheme)
+	if err != nil {
+		return Theme{}, err
+	}
+
+	var errs []error
+
+	// the fields of each theme, including those of its appearance, replace the fields of the theme it extends
+	fields := map[string]json.RawMessage{}
+	variables := map[string]string{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		maps.Copy(fields, chain[i].styleFields())
+		maps.Copy(variables, chain[i].Variables)
+
+		appearanceFields := chain[i].Light
+		if appearance == ThemeAppearanceDark {
+			appearanceFields = chain[i].Dark
+		}
+		for _, key := range slices.Sorted(maps.Keys(appearanceFields)) {
+			value := appearanceFields[key]
+			if key == "Variables" {
+				var appearanceVariables map[string]string
+				if err := json.Unmarshal(value, &appearanceVariables); err != nil {
+					return Theme{}, fmt.Errorf("%s variables of theme %s: %w", appearance, chain[i].ThemeName, err)
+				}
+				maps.Copy(variables, appearanceVariables)
+				continue
+			}
+			if !slices.ContainsFunc(themeStyleFields, func(field themeStyleField) bool { return field.name == key }) {
+				errs = append(errs, fmt.Errorf("%s: unknown field %s", appearance, key))
+				continue
+			}
+			fields[key] = value
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		if value := variables[name]; !isValidColor(value) {
+			errs = append(errs, fmt.Errorf("variable $%s: invalid colour %q", name, value))
+		}
+	}
+	for _, field := range themeStyleFields {
+		value, ok := fields[field.name]
+		if !ok || string(value) == "null" {
+			errs = append(errs, fmt.Errorf("%s: missing", field.name))
+			continue
+		}
+
+		if !field.isColor {
+			var size int
+			if err := json.Unmarshal(value, &size); err != nil || size < 0 {
+				errs = append(errs, fmt.Errorf("%s: %s is not a size, expected a whole number not less than 0", field.name, string(value)))
+			}
+			continue
+		}
+
+		var color string
+		if err := json.Unmarshal(value, &color); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s is not a colour", field.name, string(value)))
+			continue
+		}
+		if name, isVariable := strings.CutPrefix(color, "$"); isVariable {
+			variableColor, found := variables[name]
+			if !found {
+				errs = append(errs, fmt.Errorf("%s: unknown variable $%s", field.name, name))
+				continue
+			}
+			color = variableColor
+			fields[field.name], _ = json.Marshal(color)
+		}
+		if !isValidColor(color) {
+			errs = append(errs, fmt.Errorf("%s: invalid colour %q", field.name, color))
+		}
+	}
+	if len(errs) > 0 {
+		return Theme{}, errors.Join(errs...)
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return Theme{}, err
+	}
+	var resolved Theme
+	if err := json.Unmarshal(data, &resolved); err != nil {
+		return Theme{}, err
+	}
+	resolved.ThemeId = theme.ThemeId
+	resolved.ThemeName = theme.ThemeName
+	resolved.ThemeAuthor = theme.ThemeAuthor
+	resolved.ThemeUrl = theme.ThemeUrl
+	resolved.Version = theme.Version
+	resolved.Description = theme.Description
+	resolved.IsSystem = theme.IsSystem
+	resolved.IsInstalled = theme.IsInstalled
+	resolved.Extends = theme.Extends
+	resolved.fields = nil
+	return resolved, nil
+}
+
+// ValidateTheme checks that the theme resolves in both appearances
+func ValidateTheme(theme Theme, getTheme func(themeId string) (Theme, bool)) error {
+	if strings.TrimSpace(theme.ThemeId) == "" {
+		return errors.New("ThemeId: missing")
+	}
+	if strings.TrimSpace(theme.ThemeName) == "" {
+		return errors.New("ThemeName: missing")
+	}
+	for _, appearance := range []string{ThemeAppearanceLight, ThemeAppearanceDark} {
+		if _, err := ResolveTheme(theme, appearance, getTheme); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsThemeAppearanceDependent reports whether the theme, or a theme it extends, changes with the appearance of the OS
+func IsThemeAppearanceDependent(theme Theme, getTheme func(themeId string) (Theme, bool)) bool {
+	chain, err := getThemeChain(theme, getTheme)
+	if err != nil {
+		return false
+	}
+	for _, t := range chain {
+		if len(t.Light) > 0 || len(t.Dark) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidColor accepts the hex, rgb(a) and hsl(a) colours of css and transparent
+func isValidColor(color string) bool {
+	color = strings.TrimSpace(color)
+	if strings.EqualFold(color, "transparent") {
+		return true
+	}
+	for _, colorRegex := range colorRegexes {
+		if colorRegex.MatchString(color) {
+			return true
+		}
+	}
+	return false
+}
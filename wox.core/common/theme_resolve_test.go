@@ -0,0 +1,143 @@
+package common
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestTheme(t *testing.T, themeJson string) Theme {
+	var theme Theme
+	require.NoError(t, json.Unmarshal([]byte(themeJson), &theme))
+	return theme
+}
+
+func newTestThemeLookup(t *testing.T, themes ...Theme) func(themeId string) (Theme, bool) {
+	darkJson, err := os.ReadFile("../resource/ui/themes/dark.json")
+	require.NoError(t, err)
+	installed := map[string]Theme{"dark": parseTestTheme(t, string(darkJson))}
+	for _, theme := range themes {
+		installed[theme.ThemeId] = theme
+	}
+	return func(themeId string) (Theme, bool) {
+		theme, ok := installed[themeId]
+		return theme, ok
+	}
+}
+
+func TestResolveThemeExtends(t *testing.T) {
+	child := parseTestTheme(t, `{
+		"ThemeId": "child", "ThemeName": "Child", "Extends": "dark",
+		"Variables": {"accent": "#FF8800", "text": "#EEEEEE"},
+		"ResultItemActiveBackgroundColor": "$accent",
+		"QueryBoxCursorColor": "$accent",
+		"AppPaddingLeft": 20,
+		"Light": {"Variables": {"text": "#111111"}, "AppBackgroundColor": "rgba(255, 255, 255, 0.9)"},
+		"Dark": {"ResultItemTitleColor": "$text"}
+	}`)
+	grandChild := parseTestTheme(t, `{"ThemeId": "grandchild", "ThemeName": "Grandchild", "Extends": "child", "Variables": {"accent": "#00FF00"}}`)
+	getTheme := newTestThemeLookup(t, child, grandChild)
+
+	dark, err := ResolveTheme(child, ThemeAppearanceDark, getTheme)
+	require.NoError(t, err)
+	assert.Equal(t, "child", dark.ThemeId)
+	assert.Equal(t, "#FF8800", dark.ResultItemActiveBackgroundColor)
+	assert.Equal(t, "#EEEEEE", dark.ResultItemTitleColor)
+	assert.Equal(t, "rgba(35, 41, 51, 0.9)", dark.AppBackgroundColor)
+	assert.Equal(t, 20, dark.AppPaddingLeft)
+	assert.Equal(t, 10, dark.AppPaddingTop)
+	assert.Equal(t, 4, dark.ResultItemActiveBorderLeftWidth)
+
+	light, err := ResolveTheme(child, ThemeAppearanceLight, getTheme)
+	require.NoError(t, err)
+	assert.Equal(t, "rgba(255, 255, 255, 0.9)", light.AppBackgroundColor)
+	assert.Equal(t, "#E2E8F0", light.ResultItemTitleColor)
+
+	// a variable changed by a theme extending the theme changes the fields using it
+	resolved, err := ResolveTheme(grandChild, ThemeAppearanceDark, getTheme)
+	require.NoError(t, err)
+	assert.Equal(t, "#00FF00", resolved.QueryBoxCursorColor)
+	assert.Equal(t, "#EEEEEE", resolved.ResultItemTitleColor)
+
+	assert.True(t, IsThemeAppearanceDependent(grandChild, getTheme))
+	parent, _ := getTheme("dark")
+	assert.False(t, IsThemeAppearanceDependent(parent, getTheme))
+}
+
+func TestValidateTheme(t *testing.T) {
+	getTheme := newTestThemeLookup(t)
+	for _, name := range []string{"dark", "light"} {
+		themeJson, err := os.ReadFile("../resource/ui/themes/" + name + ".json")
+		require.NoError(t, err)
+		assert.NoError(t, ValidateTheme(parseTestTheme(t, string(themeJson)), getTheme), name)
+	}
+
+	incomplete := parseTestTheme(t, `{
+		"ThemeId": "incomplete", "ThemeName": "Incomplete",
+		"AppBackgroundColor": "blueish", "AppPaddingLeft": -1, "QueryBoxCursorColor": "$accent"
+	}`)
+	err := ValidateTheme(incomplete, getTheme)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `AppBackgroundColor: invalid colour "blueish"`)
+	assert.Contains(t, err.Error(), "AppPaddingLeft: -1 is not a size")
+	assert.Contains(t, err.Error(), "QueryBoxCursorColor: unknown variable $accent")
+	assert.Contains(t, err.Error(), "ToolbarFontColor: missing")
+
+	wrongVariant := parseTestTheme(t, `{"ThemeId": "variant", "ThemeName": "Variant", "Extends": "dark", "Dark": {"AppColor": "#000"}}`)
+	assert.ErrorContains(t, ValidateTheme(wrongVariant, getTheme), "dark: unknown field AppColor")
+
+	missingParent := parseTestTheme(t, `{"ThemeId": "orphan", "ThemeName": "Orphan", "Extends": "gone"}`)
+	assert.ErrorContains(t, ValidateTheme(missingParent, getTheme), "extends theme gone, which is not installed")
+
+	loopA := parseTestTheme(t, `{"ThemeId": "a", "ThemeName": "A", "Extends": "b"}`)
+	loopB := parseTestTheme(t, `{"ThemeId": "b", "ThemeName": "B", "Extends": "a"}`)
+	assert.ErrorContains(t, ValidateTheme(loopA, newTestThemeLookup(t, loopA, loopB)), "extends itself")
+
+	for _, color := range []string{"#FFF", "#00A88E", "#00A88ECC", "rgb(0, 168, 142)", "rgba(0,168,142,0.7)", "hsla(170, 100%, 33%, 0.5)", "transparent"} {
+		assert.True(t, isValidColor(color), color)
+	}
+}
+
+func TestThemeSourceJSON(t *testing.T) {
+	theme := parseTestTheme(t, `{"ThemeId": "child", "ThemeName": "Child", "Extends": "dark", "ResultItemBorderLeft": 2, "Dark": {"AppPaddingTop": 4}}`)
+	theme.ThemeAuthor = "Wox launcher AI"
+
+	data, err := theme.SourceJSON()
+	require.NoError(t, err)
+	var source map[string]any
+	require.NoError(t, json.Unmarshal(data, &source))
+	assert.Equal(t, "dark", source["Extends"])
+	assert.Equal(t, "Wox launcher AI", source["ThemeAuthor"])
+	assert.Equal(t, float64(2), source["ResultItemBorderLeftWidth"])
+	assert.NotContains(t, source, "AppBackgroundColor", "fields of the parent theme are not saved")
+	assert.Equal(t, map[string]any{"AppPaddingTop": float64(4)}, source["Dark"])
+}
+
+func TestThemeSetStyleField(t *testing.T) {
+	getTheme := newTestThemeLookup(t)
+	dark, _ := getTheme("dark")
+	theme := NewExtendingTheme("draft", "Draft", "dark")
+
+	require.NoError(t, theme.SetStyleField("AppBackgroundColor", "#123456"))
+	require.NoError(t, theme.SetStyleField("AppPaddingLeft", " 30 "))
+	assert.Error(t, theme.SetStyleField("AppPaddingTop", "-1"))
+	assert.Error(t, theme.SetStyleField("ThemeName", "Other"), "only style fields can be set")
+	assert.Equal(t, "30", theme.GetStyleField("AppPaddingLeft"))
+
+	resolved, err := ResolveTheme(theme, ThemeAppearanceDark, getTheme)
+	require.NoError(t, err)
+	assert.Equal(t, "#123456", resolved.AppBackgroundColor)
+	assert.Equal(t, 30, resolved.AppPaddingLeft)
+	assert.Equal(t, dark.ResultItemTitleColor, resolved.ResultItemTitleColor)
+
+	require.NoError(t, theme.SetStyleField("AppBackgroundColor", ""))
+	data, err := theme.SourceJSON()
+	require.NoError(t, err)
+	var source map[string]any
+	require.NoError(t, json.Unmarshal(data, &source))
+	assert.NotContains(t, source, "AppBackgroundColor", "an emptied field comes from the parent theme again")
+	assert.Equal(t, float64(30), source["AppPaddingLeft"])
+}
@@ -46,7 +46,7 @@ type UI interface {
 	GetServerPort(ctx context.Context) int
 	GetAllThemes(ctx context.Context) []Theme
 	ChangeTheme(ctx context.Context, theme Theme)
-	InstallTheme(ctx context.Context, theme Theme)
+	InstallTheme(ctx context.Context, theme Theme) error
 	UninstallTheme(ctx context.Context, theme Theme)
 	RestoreTheme(ctx context.Context)
 	Notify(ctx context.Context, msg NotifyMsg)
@@ -44,14 +44,16 @@ type PluginSetting struct {
 }
 
 type Oplog struct {
-	ID            uint `gorm:"primaryKey;autoIncrement"`
-	EntityType    string
-	EntityID      string
+	ID            uint   `gorm:"primaryKey;autoIncrement"`
+	EntityType    string `gorm:"index:idx_oplog_entity"`
+	EntityID      string `gorm:"index:idx_oplog_entity"`
 	Operation     string
-	Key           string
+	Key           string `gorm:"index:idx_oplog_entity"`
 	Value         string
 	Timestamp     int64
-	SyncedToCloud bool `gorm:"default:false"`
+	DeviceID      string // device that made the change
+	Clock         int64  // lamport clock of the change, the higher clock wins a conflict
+	SyncedToCloud bool   `gorm:"default:false"`
 }
 
 type MRURecord struct {
@@ -135,6 +137,24 @@ func GetDB() *gorm.DB {
 	return db
 }
 
+// Close closes the database, Init opens it again, e.g. after the user data directory is restored from a backup
+func Close() error {
+	if db == nil {
+		return nil
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+	if err := sqlDB.Close(); err != nil {
+		return err
+	}
+
+	db = nil
+	return nil
+}
+
 // runIntegrityChecks runs a lightweight PRAGMA quick_check only to detect corruption.
 func runIntegrityChecks(ctx context.Context, sqlDB *sql.DB) {
 	logger := util.GetLogger()
@@ -19,6 +19,7 @@ import (
 	"wox/ui"
 	"wox/updater"
 	"wox/util"
+	"wox/util/screen"
 	"wox/util/selection"
 
 	"golang.design/x/hotkey/mainthread"
@@ -45,6 +46,8 @@ import (
 	_ "wox/plugin/system/shell"
 
 	_ "wox/plugin/system/emoji"
+
+	_ "wox/plugin/system/snippet"
 )
 
 func main() {
@@ -74,6 +77,11 @@ func main() {
 		util.GetLogger().Error(ctx, fmt.Sprintf("failed to run migration: %s", err.Error()))
 		// In some cases, we might want to exit if migration fails, but for now we just log it.
 	}
+	// a restored backup may be from an older version, so it is migrated the same way
+	setting.GetSettingManager().RegisterRestoreHook(setting.RestoreHook{
+		Name:   "migration",
+		Reload: migration.Run,
+	})
 
 	serverPort := 34987
 	if util.IsProd() {
@@ -172,6 +180,15 @@ func main() {
 	// Start MRU cleanup
 	setting.GetSettingManager().StartMRUCleanup(ctx)
 
+	// Start settings sync if enabled
+	setting.GetSettingManager().StartSettingSync(ctx)
+
+	// Apply and watch the settings dotfile if set
+	setting.GetSettingManager().WatchDotfile(ctx)
+
+	// Switch setting profiles by their rules
+	setting.GetSettingManager().StartProfileRules(ctx, screen.GetDisplayLayout)
+
 	// Start auto update checker if enabled
 	updater.StartAutoUpdateChecker(ctx)
 
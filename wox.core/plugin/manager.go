@@ -12,6 +12,7 @@ import (
 	"runtime"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -21,6 +22,7 @@ import (
 	"wox/common"
 	"wox/i18n"
 	"wox/setting"
+	"wox/setting/definition"
 
 	"wox/util"
 	"wox/util/notifier"
@@ -92,9 +94,88 @@ func (m *Manager) Start(ctx context.Context, ui common.UI) error {
 		GetStoreManager().Start(util.NewTraceContext())
 	})
 
+	// plugins keep their settings and data files open, they are loaded again when a backup is restored
+	setting.GetSettingManager().RegisterRestoreHook(setting.RestoreHook{
+		Name:    "plugins",
+		Quiesce: m.unloadAllPlugins,
+		Reload:  m.reloadAllPlugins,
+	})
+	setting.GetSettingManager().RegisterSettingChangeListener(m.onSettingsChanged)
+	setting.GetSettingManager().SetPluginSettingValidator(m.validatePluginSetting)
+
 	return nil
 }
 
+// onSettingsChanged reloads the settings of the plugins changed by a settings sync or a dotfile import, and tells the plugins what changed
+func (m *Manager) onSettingsChanged(ctx context.Context, changes []setting.SettingChange) {
+	for _, instance := range m.instances {
+		var pluginChanges []setting.SettingChange
+		for _, change := range changes {
+			if change.PluginId == instance.Metadata.Id {
+				pluginChanges = append(pluginChanges, change)
+			}
+		}
+		if len(pluginChanges) == 0 {
+			continue
+		}
+
+		pluginSetting, settingErr := setting.GetSettingManager().LoadPluginSetting(ctx, instance.Metadata.Id, instance.Metadata.SettingDefinitions.ToMap(), instance.Metadata.SettingDefinitions.GetSecretFields())
+		if settingErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to reload changed setting of plugin %s: %s", instance.Metadata.Name, settingErr.Error()))
+			continue
+		}
+		instance.Setting = pluginSetting
+
+		for _, change := range pluginChanges {
+			// a platform specific setting of another platform doesn't change anything here
+			key, platform, isPlatformSpecific := strings.Cut(change.Key, "@")
+			if isPlatformSpecific && platform != util.GetCurrentPlatform() {
+				continue
+			}
+
+			value := instance.API.GetSetting(ctx, key)
+			for _, callback := range instance.SettingChangeCallbacks {
+				callback(key, value)
+			}
+		}
+	}
+}
+
+// validatePluginSetting checks a plugin setting of a dotfile against the setting definitions of the plugin.
+// Settings of plugins that are not installed are not checked, they are used once the plugin is installed.
+func (m *Manager) validatePluginSetting(ctx context.Context, pluginId string, key string, value string) error {
+	instance, found := lo.Find(m.instances, func(item *Instance) bool {
+		return item.Metadata.Id == pluginId
+	})
+	if !found {
+		return nil
+	}
+
+	switch key {
+	case "Disabled":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.New("value must be true or false")
+		}
+		return nil
+	case "TriggerKeywords":
+		var triggerKeywords []string
+		return json.Unmarshal([]byte(value), &triggerKeywords)
+	case "QueryCommands":
+		var queryCommands []setting.PluginQueryCommand
+		return json.Unmarshal([]byte(value), &queryCommands)
+	}
+
+	// plugins may save settings that are not in their setting definitions, those are not checked
+	definitionKey, _, _ := strings.Cut(key, "@")
+	settingDefinition, found := lo.Find(instance.Metadata.SettingDefinitions, func(item definition.PluginSettingDefinitionItem) bool {
+		return item.Value != nil && item.Value.GetKey() == definitionKey
+	})
+	if !found {
+		return nil
+	}
+	return settingDefinition.Validate(value)
+}
+
 func (m *Manager) Stop(ctx context.Context) {
 	// Stop script plugin monitoring
 	if m.scriptPluginWatcher != nil {
@@ -289,7 +370,7 @@ func (m *Manager) loadHostPlugin(ctx context.Context, host Host, metadata Metada
 		DevPluginDirectory:    metadata.DevPluginDirectory,
 	}
 	instance.API = NewAPI(instance)
-	pluginSetting, settingErr := setting.GetSettingManager().LoadPluginSetting(ctx, metadata.Metadata.Id, metadata.Metadata.SettingDefinitions.ToMap())
+	pluginSetting, settingErr := setting.GetSettingManager().LoadPluginSetting(ctx, metadata.Metadata.Id, metadata.Metadata.SettingDefinitions.ToMap(), metadata.Metadata.SettingDefinitions.GetSecretFields())
 	if settingErr != nil {
 		instance.API.Log(ctx, LogLevelError, fmt.Errorf("[SYS] failed to load plugin[%s] setting: %w", metadata.Metadata.Name, settingErr).Error())
 		return settingErr
@@ -354,6 +435,46 @@ func (m *Manager) UnloadPlugin(ctx context.Context, pluginInstance *Instance) {
 	m.instances = newInstances
 }
 
+// unloadAllPlugins unloads every plugin and stops the plugin hosts, so no plugin uses the user data directory
+func (m *Manager) unloadAllPlugins(ctx context.Context) error {
+	if m.scriptPluginWatcher != nil {
+		m.scriptPluginWatcher.Close()
+		m.scriptPluginWatcher = nil
+	}
+
+	for _, instance := range m.instances {
+		logger.Info(ctx, fmt.Sprintf("unloading plugin: %s", instance.Metadata.Name))
+		for _, callback := range instance.UnloadCallbacks {
+			callback()
+		}
+		if instance.Host != nil {
+			instance.Host.UnloadPlugin(ctx, instance.Metadata)
+		}
+	}
+	m.instances = nil
+	m.resultCache.Clear()
+
+	for _, host := range AllHosts {
+		host.Stop(ctx)
+	}
+
+	return nil
+}
+
+// reloadAllPlugins loads the plugins again with the settings of the current user data directory
+func (m *Manager) reloadAllPlugins(ctx context.Context) error {
+	loadErr := m.loadPlugins(ctx)
+	if loadErr != nil {
+		return fmt.Errorf("failed to load plugins: %w", loadErr)
+	}
+
+	util.Go(ctx, "start script plugin monitoring", func() {
+		m.startScriptPluginMonitoring(util.NewTraceContext())
+	})
+
+	return nil
+}
+
 func (m *Manager) loadSystemPlugins(ctx context.Context) {
 	start := util.GetSystemTimestamp()
 	logger.Info(ctx, fmt.Sprintf("start loading system plugins, found %d system plugins", len(AllSystemPlugin)))
@@ -372,7 +493,7 @@ func (m *Manager) loadSystemPlugins(ctx context.Context) {
 			instance.API = NewAPI(instance)
 
 			startTimestamp := util.GetSystemTimestamp()
-			pluginSetting, settingErr := setting.GetSettingManager().LoadPluginSetting(ctx, metadata.Id, metadata.SettingDefinitions.ToMap())
+			pluginSetting, settingErr := setting.GetSettingManager().LoadPluginSetting(ctx, metadata.Id, metadata.SettingDefinitions.ToMap(), metadata.SettingDefinitions.GetSecretFields())
 			if settingErr != nil {
 				logger.Error(ctx, fmt.Sprintf("failed to load system plugin[%s] setting, use default plugin setting. err: %s", metadata.Name, settingErr.Error()))
 				return
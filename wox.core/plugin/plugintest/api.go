@@ -0,0 +1,34 @@
+// Package plugintest has the plugin API stub shared by the tests of the system plugins
+package plugintest
+
+import (
+	"context"
+	"sync"
+	"wox/plugin"
+)
+
+// API drops the logs and keeps the plugin settings in memory, the other API calls are not expected in tests
+type API struct {
+	plugin.API
+	Settings map[string]string
+
+	lock sync.Mutex
+}
+
+func NewAPI() *API {
+	return &API{Settings: map[string]string{}}
+}
+
+func (a *API) Log(ctx context.Context, level plugin.LogLevel, msg string) {}
+
+func (a *API) GetSetting(ctx context.Context, key string) string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.Settings[key]
+}
+
+func (a *API) SaveSetting(ctx context.Context, key string, value string, isPlatformSpecific bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.Settings[key] = value
+}
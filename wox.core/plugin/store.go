@@ -11,19 +11,15 @@ import (
 	"path"
 	"strings"
 	"sync"
-	"time"
 	"wox/i18n"
+	"wox/setting"
+	"wox/store"
 	"wox/util"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/samber/lo"
 )
 
-type storeManifest struct {
-	Name string
-	Url  string
-}
-
 type StorePluginManifest struct {
 	Id             string
 	Name           string
@@ -46,106 +42,51 @@ var storeInstance *Store
 var storeOnce sync.Once
 
 type Store struct {
-	pluginManifests []StorePluginManifest
+	catalog *store.Catalog[StorePluginManifest]
 }
 
 func GetStoreManager() *Store {
 	storeOnce.Do(func() {
-		storeInstance = &Store{}
+		storeInstance = &Store{
+			catalog: store.NewCatalog(store.Kind[StorePluginManifest]{
+				Type:       setting.StoreTypePlugin,
+				GetId:      func(manifest StorePluginManifest) string { return manifest.Id },
+				GetName:    func(manifest StorePluginManifest) string { return manifest.Name },
+				GetVersion: func(manifest StorePluginManifest) string { return manifest.Version },
+				GetInstalledVersion: func(ctx context.Context, id string) (string, bool) {
+					instance, found := lo.Find(GetPluginManager().GetPluginInstances(), func(item *Instance) bool {
+						return item.Metadata.Id == id && !item.IsDevPlugin
+					})
+					if !found {
+						return "", false
+					}
+					return instance.Metadata.Version, true
+				},
+				Normalize: func(manifest StorePluginManifest) StorePluginManifest {
+					if IsSupportedRuntime(string(manifest.Runtime)) {
+						manifest.Runtime = ConvertToRuntime(string(manifest.Runtime))
+					}
+					return manifest
+				},
+			}),
+		}
 	})
 	return storeInstance
 }
 
-func (s *Store) getStoreManifests(ctx context.Context) []storeManifest {
-	return []storeManifest{
-		{
-			Name: "Wox Official Plugin Store",
-			Url:  "https://raw.githubusercontent.com/Wox-launcher/Wox/master/store-plugin.json",
-		},
-	}
-}
-
 // get plugin manifests from plugin stores, and update in the background every 10 minutes
 func (s *Store) Start(ctx context.Context) {
-	s.pluginManifests = s.GetStorePluginManifests(ctx)
-
-	util.Go(ctx, "load store plugins immediately", func() {
-		pluginManifests := s.GetStorePluginManifests(util.NewTraceContext())
-		if len(pluginManifests) > 0 {
-			s.pluginManifests = pluginManifests
-		}
-	})
-
-	util.Go(ctx, "load store plugins", func() {
-		for range time.NewTicker(time.Minute * 10).C {
-			pluginManifests := s.GetStorePluginManifests(util.NewTraceContext())
-			if len(pluginManifests) > 0 {
-				s.pluginManifests = pluginManifests
-			}
-		}
-	})
+	store.GetStoreManager().Register(ctx, s.catalog)
 }
 
+// GetStorePluginManifests reads the plugin stores again and returns their plugins
 func (s *Store) GetStorePluginManifests(ctx context.Context) []StorePluginManifest {
-	var storePluginManifests []StorePluginManifest
-
-	for _, store := range s.getStoreManifests(ctx) {
-		pluginManifest, manifestErr := s.GetStorePluginManifest(ctx, store)
-		if manifestErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to get plugin manifest from %s store: %s", store.Name, manifestErr.Error()))
-			continue
-		}
-
-		for _, manifest := range pluginManifest {
-			existingManifest, found := lo.Find(storePluginManifests, func(manifest StorePluginManifest) bool {
-				return manifest.Id == manifest.Id
-			})
-			if found {
-				existingVersion, existingErr := semver.NewVersion(existingManifest.Version)
-				currentVersion, currentErr := semver.NewVersion(manifest.Version)
-				if existingErr != nil && currentErr != nil {
-					if existingVersion.GreaterThan(currentVersion) {
-						logger.Info(ctx, fmt.Sprintf("skip %s(%s) from %s store, because it's already installed(%s)", manifest.Name, manifest.Version, store.Name, existingManifest.Version))
-						continue
-					}
-				}
-			}
-
-			storePluginManifests = append(storePluginManifests, manifest)
-		}
-	}
-
-	logger.Info(ctx, fmt.Sprintf("found %d plugins from stores", len(storePluginManifests)))
-	return storePluginManifests
-}
-
-func (s *Store) GetStorePluginManifest(ctx context.Context, store storeManifest) ([]StorePluginManifest, error) {
-	logger.Info(ctx, fmt.Sprintf("start to get plugin manifest from %s(%s)", store.Name, store.Url))
-
-	response, getErr := util.HttpGet(ctx, store.Url)
-	if getErr != nil {
-		return nil, getErr
-	}
-
-	var storePluginManifests []StorePluginManifest
-	unmarshalErr := json.Unmarshal(response, &storePluginManifests)
-	if unmarshalErr != nil {
-		return nil, unmarshalErr
-	}
-
-	for i := range storePluginManifests {
-		if IsSupportedRuntime(string(storePluginManifests[i].Runtime)) {
-			storePluginManifests[i].Runtime = ConvertToRuntime(string(storePluginManifests[i].Runtime))
-		}
-	}
-
-	return storePluginManifests, nil
+	s.catalog.Refresh(ctx)
+	return s.catalog.GetItems()
 }
 
 func (s *Store) GetStorePluginManifestById(ctx context.Context, id string) (StorePluginManifest, error) {
-	manifest, found := lo.Find(s.pluginManifests, func(manifest StorePluginManifest) bool {
-		return manifest.Id == id
-	})
+	manifest, found := s.catalog.Find(id)
 	if found {
 		return manifest, nil
 	}
@@ -154,16 +95,20 @@ func (s *Store) GetStorePluginManifestById(ctx context.Context, id string) (Stor
 }
 
 func (s *Store) Search(ctx context.Context, keyword string) []StorePluginManifest {
-	return lo.Filter(s.pluginManifests, func(manifest StorePluginManifest, _ int) bool {
-		if !IsSupportedOSAny(manifest.SupportedOS) {
-			return false
-		}
+	return lo.Filter(s.catalog.Search(keyword), func(manifest StorePluginManifest, _ int) bool {
+		return IsSupportedOSAny(manifest.SupportedOS)
+	})
+}
 
-		if keyword == "" {
-			return true
-		}
+// IsUpgradable reports whether the plugin is installed in an older version
+func (s *Store) IsUpgradable(ctx context.Context, manifest StorePluginManifest) bool {
+	return s.catalog.IsUpgradable(ctx, manifest)
+}
 
-		return util.IsStringMatch(manifest.Name, keyword, false)
+// GetUpdates returns the store plugins that are installed in an older version
+func (s *Store) GetUpdates(ctx context.Context) []StorePluginManifest {
+	return lo.Filter(s.catalog.GetUpdates(ctx), func(manifest StorePluginManifest, _ int) bool {
+		return IsSupportedOSAny(manifest.SupportedOS)
 	})
 }
 
@@ -233,7 +178,7 @@ func (s *Store) installNormalPluginWithProgress(ctx context.Context, manifest St
 	pluginZipPath := path.Join(pluginDirectory, "plugin.zip")
 
 	// Download with progress tracking
-	downloadErr := util.HttpDownloadWithProgress(ctx, manifest.DownloadUrl, pluginZipPath, func(downloaded int64, total int64) {
+	downloadErr := store.Download(ctx, manifest.DownloadUrl, pluginZipPath, func(downloaded int64, total int64) {
 		if progressCallback != nil {
 			if total > 0 {
 				percentage := float64(downloaded) / float64(total) * 100
@@ -439,7 +384,7 @@ func (s *Store) installScriptPluginWithProgress(ctx context.Context, manifest St
 		progressCallback(i18n.GetI18nManager().TranslateWox(ctx, "i18n:plugin_install_progress_starting_download"))
 	}
 
-	downloadErr := util.HttpDownloadWithProgress(ctx, manifest.DownloadUrl, newScriptPath, func(downloaded int64, total int64) {
+	downloadErr := store.Download(ctx, manifest.DownloadUrl, newScriptPath, func(downloaded int64, total int64) {
 		if progressCallback != nil {
 			if total > 0 {
 				percentage := float64(downloaded) / float64(total) * 100
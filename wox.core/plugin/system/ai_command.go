@@ -9,6 +9,7 @@ import (
 	"wox/i18n"
 	"wox/plugin"
 	"wox/setting/definition"
+	"wox/store"
 	"wox/util"
 	"wox/util/clipboard"
 	"wox/util/selection"
@@ -25,7 +26,8 @@ type commandSetting struct {
 	Command string `json:"command"`
 	Model   string `json:"model"`
 	Prompt  string `json:"prompt"`
-	Vision  bool   `json:"vision"` // does the command interact with vision
+	Vision  bool   `json:"vision"`            // does the command interact with vision
+	Version string `json:"version,omitempty"` // version of a command installed from an AI command store
 }
 
 func (c *commandSetting) AIModel() (model common.Model) {
@@ -42,7 +44,8 @@ func init() {
 }
 
 type Plugin struct {
-	api plugin.API
+	api     plugin.API
+	catalog *store.Catalog[aiCommandStoreItem]
 }
 
 func (c *Plugin) GetMetadata() plugin.Metadata {
@@ -128,6 +131,7 @@ func (c *Plugin) GetMetadata() plugin.Metadata {
 
 func (c *Plugin) Init(ctx context.Context, initParams plugin.InitParams) {
 	c.api = initParams.API
+	c.catalog = c.newStoreCatalog()
 	c.api.OnSettingChanged(ctx, func(key string, value string) {
 		if key == "commands" {
 			c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("ai command setting changed: %s", value))
@@ -144,6 +148,9 @@ func (c *Plugin) Init(ctx context.Context, initParams plugin.InitParams) {
 			c.api.RegisterQueryCommands(ctx, commands)
 		}
 	})
+
+	// loads the AI command stores, last so that a slow store doesn't delay the setting listener
+	store.GetStoreManager().Register(ctx, c.catalog)
 }
 
 func (c *Plugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryResult {
@@ -320,21 +327,23 @@ func (c *Plugin) listAllCommands(ctx context.Context, query plugin.Query) []plug
 		}
 	}
 
+	var results []plugin.QueryResult
 	if len(commands) == 0 {
-		return []plugin.QueryResult{
-			{
-				Title: "i18n:plugin_ai_command_no_commands",
-				Icon:  aiCommandIcon,
-			},
-		}
+		results = append(results, plugin.QueryResult{
+			Title:      "i18n:plugin_ai_command_no_commands",
+			Icon:       aiCommandIcon,
+			Group:      "i18n:plugin_ai_command_group_installed",
+			GroupScore: 100,
+		})
 	}
 
-	var results []plugin.QueryResult
 	for _, command := range commands {
-		results = append(results, plugin.QueryResult{
-			Title:    command.Command,
-			SubTitle: command.Name,
-			Icon:     aiCommandIcon,
+		result := plugin.QueryResult{
+			Title:      command.Command,
+			SubTitle:   command.Name,
+			Icon:       aiCommandIcon,
+			Group:      "i18n:plugin_ai_command_group_installed",
+			GroupScore: 100,
 			Actions: []plugin.QueryResultAction{
 				{
 					Name:                   "i18n:plugin_ai_command_run",
@@ -347,9 +356,19 @@ func (c *Plugin) listAllCommands(ctx context.Context, query plugin.Query) []plug
 					},
 				},
 			},
-		})
+		}
+
+		// installed store commands with a newer version in the store are listed first
+		if storeItem, found := c.catalog.Find(command.Command); found && c.catalog.IsUpgradable(ctx, storeItem) {
+			result.SubTitle = fmt.Sprintf("%s (%s → %s)", command.Name, command.Version, storeItem.Version)
+			result.Group = "i18n:plugin_ai_command_group_updates"
+			result.GroupScore = 200
+			result.Actions = append([]plugin.QueryResultAction{c.createStoreCommandAction(storeItem, true)}, result.Actions...)
+		}
+		results = append(results, result)
 	}
-	return results
+
+	return append(results, c.getStoreResults(ctx, query, commands)...)
 }
 
 func (c *Plugin) getAllCommands(ctx context.Context) (commands []commandSetting, err error) {
@@ -0,0 +1,109 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"wox/common"
+	"wox/plugin"
+	"wox/setting"
+	"wox/store"
+
+	"github.com/samber/lo"
+)
+
+// aiCommandStoreItem is an AI command of the AI command stores, the command is its id
+type aiCommandStoreItem struct {
+	Name    string
+	Author  string
+	Command string
+	Prompt  string
+	Version string
+	Vision  bool
+}
+
+func (c *Plugin) newStoreCatalog() *store.Catalog[aiCommandStoreItem] {
+	return store.NewCatalog(store.Kind[aiCommandStoreItem]{
+		Type:       setting.StoreTypeAICommand,
+		GetId:      func(item aiCommandStoreItem) string { return item.Command },
+		GetName:    func(item aiCommandStoreItem) string { return item.Name },
+		GetVersion: func(item aiCommandStoreItem) string { return item.Version },
+		GetInstalledVersion: func(ctx context.Context, id string) (string, bool) {
+			commands, _ := c.getAllCommands(ctx)
+			command, found := lo.Find(commands, func(command commandSetting) bool { return command.Command == id })
+			return command.Version, found
+		},
+	})
+}
+
+// installStoreCommand adds the store command to the commands, an installed command with the same name is upgraded and keeps its model
+func (c *Plugin) installStoreCommand(ctx context.Context, item aiCommandStoreItem) error {
+	commands, commandsErr := c.getAllCommands(ctx)
+	if commandsErr != nil {
+		return commandsErr
+	}
+
+	command := commandSetting{
+		Name:    item.Name,
+		Command: item.Command,
+		Prompt:  item.Prompt,
+		Vision:  item.Vision,
+		Version: item.Version,
+	}
+	if index := slices.IndexFunc(commands, func(command commandSetting) bool { return command.Command == item.Command }); index != -1 {
+		command.Model = commands[index].Model
+		commands[index] = command
+	} else {
+		commands = append(commands, command)
+	}
+
+	commandsJson, marshalErr := json.Marshal(commands)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	c.api.SaveSetting(ctx, "commands", string(commandsJson), false)
+	return nil
+}
+
+func (c *Plugin) createStoreCommandAction(item aiCommandStoreItem, isUpgrade bool) plugin.QueryResultAction {
+	name, icon := "i18n:plugin_ai_command_install", common.InstallIcon
+	if isUpgrade {
+		name, icon = "i18n:plugin_ai_command_upgrade", common.UpdateIcon
+	}
+
+	return plugin.QueryResultAction{
+		Name:                   name,
+		Icon:                   icon,
+		PreventHideAfterAction: true,
+		Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+			if installErr := c.installStoreCommand(ctx, item); installErr != nil {
+				c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to install ai command %s: %s", item.Command, installErr.Error()))
+				c.api.Notify(ctx, installErr.Error())
+				return
+			}
+			c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: true})
+		},
+	}
+}
+
+// getStoreResults lists the store commands that are not installed and match the search
+func (c *Plugin) getStoreResults(ctx context.Context, query plugin.Query, commands []commandSetting) []plugin.QueryResult {
+	var results []plugin.QueryResult
+	for _, item := range c.catalog.Search(query.Search) {
+		if lo.ContainsBy(commands, func(command commandSetting) bool { return command.Command == item.Command }) {
+			continue
+		}
+
+		results = append(results, plugin.QueryResult{
+			Title:      item.Command,
+			SubTitle:   fmt.Sprintf("%s - %s", item.Name, item.Author),
+			Icon:       aiCommandIcon,
+			Group:      "i18n:plugin_ai_command_group_store",
+			GroupScore: 0,
+			Preview:    plugin.WoxPreview{PreviewType: plugin.WoxPreviewTypeText, PreviewData: item.Prompt},
+			Actions:    []plugin.QueryResultAction{c.createStoreCommandAction(item, false)},
+		})
+	}
+	return results
+}
@@ -83,6 +83,9 @@ type ApplicationPlugin struct {
 
 	// Track results that need periodic refresh (running apps with CPU/memory stats)
 	trackedResults *util.HashMap[string, appInfo] // resultId -> appInfo
+
+	// Init runs again when a backup is restored, the watchers must only start once
+	watchOnce sync.Once
 }
 
 func (a *ApplicationPlugin) GetMetadata() plugin.Metadata {
@@ -143,15 +146,17 @@ func (a *ApplicationPlugin) Init(ctx context.Context, initParams plugin.InitPara
 	util.Go(ctx, "index apps", func() {
 		a.indexApps(util.NewTraceContext())
 	})
-	util.Go(ctx, "watch app changes", func() {
-		a.watchAppChanges(util.NewTraceContext())
-	})
-	util.Go(ctx, "refresh running apps", func() {
-		ticker := time.NewTicker(time.Second)
-		defer ticker.Stop()
-		for range ticker.C {
-			a.refreshRunningApps(util.NewTraceContext())
-		}
+	a.watchOnce.Do(func() {
+		util.Go(ctx, "watch app changes", func() {
+			a.watchAppChanges(util.NewTraceContext())
+		})
+		util.Go(ctx, "refresh running apps", func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for range ticker.C {
+				a.refreshRunningApps(util.NewTraceContext())
+			}
+		})
 	})
 
 	a.api.OnSettingChanged(ctx, func(key string, value string) {
@@ -2,8 +2,10 @@ package system
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"slices"
+	"strings"
 	"wox/common"
 	"wox/i18n"
 	"wox/plugin"
@@ -98,18 +100,31 @@ func (c *BackupPlugin) restore(ctx context.Context, query plugin.Query) []plugin
 		return int(j.Timestamp - i.Timestamp)
 	})
 
+	// encrypted backups are restored with the current backup passphrase, or the one typed after the keyword
+	passphrase := strings.TrimSpace(query.Search)
+
 	var results []plugin.QueryResult
 	for index, backup := range backups {
+		subTitle := fmt.Sprintf("%s - %s", backup.Type, util.FormatTimestamp(backup.Timestamp))
+		if backup.Encrypted {
+			subTitle = fmt.Sprintf("%s - %s", subTitle, i18n.GetI18nManager().TranslateWox(ctx, "plugin_backup_encrypted"))
+		}
+		if backup.Legacy {
+			subTitle = fmt.Sprintf("%s - %s", subTitle, i18n.GetI18nManager().TranslateWox(ctx, "plugin_backup_legacy"))
+		}
+
 		results = append(results, plugin.QueryResult{
 			Title:    fmt.Sprintf("#%d", index+1),
-			SubTitle: fmt.Sprintf("%s - %s", backup.Type, util.FormatTimestamp(backup.Timestamp)),
+			SubTitle: subTitle,
 			Icon:     backupIcon,
 			Actions: []plugin.QueryResultAction{
 				{
 					Name: "i18n:plugin_backup_restore",
 					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-						restoreErr := setting.GetSettingManager().Restore(ctx, backup.Id)
-						if restoreErr != nil {
+						restoreErr := setting.GetSettingManager().Restore(ctx, backup.Id, passphrase)
+						if errors.Is(restoreErr, setting.ErrBackupPassphraseRequired) || errors.Is(restoreErr, setting.ErrBackupWrongPassphrase) {
+							c.api.Notify(ctx, i18n.GetI18nManager().TranslateWox(ctx, "plugin_backup_passphrase_hint"))
+						} else if restoreErr != nil {
 							c.api.Notify(ctx, restoreErr.Error())
 						} else {
 							c.api.Notify(ctx, i18n.GetI18nManager().TranslateWox(ctx, "plugin_backup_restore_success"))
@@ -3,7 +3,6 @@ package calculator
 import (
 	"fmt"
 	"math"
-	"strings"
 
 	"github.com/shopspring/decimal"
 )
@@ -79,111 +78,113 @@ func call(funcName string, args []decimal.Decimal) (decimal.Decimal, error) {
 	}
 }
 
-func calculate(n *node) (decimal.Decimal, error) {
+func calculate(n *node, env *environment) (decimal.Decimal, error) {
 	switch n.kind {
 	case addNode:
-		left, err := calculate(n.left)
+		left, err := calculate(n.left, env)
 		if err != nil {
 			return decimal.Zero, err
 		}
-		right, err := calculate(n.right)
+		right, err := calculate(n.right, env)
 		if err != nil {
 			return decimal.Zero, err
 		}
 		return left.Add(right), nil
 	case subNode:
-		left, err := calculate(n.left)
+		left, err := calculate(n.left, env)
 		if err != nil {
 			return decimal.Zero, err
 		}
-		right, err := calculate(n.right)
+		right, err := calculate(n.right, env)
 		if err != nil {
 			return decimal.Zero, err
 		}
 		return left.Sub(right), nil
 	case mulNode:
-		left, err := calculate(n.left)
+		left, err := calculate(n.left, env)
 		if err != nil {
 			return decimal.Zero, err
 		}
-		right, err := calculate(n.right)
+		right, err := calculate(n.right, env)
 		if err != nil {
 			return decimal.Zero, err
 		}
 		return left.Mul(right), nil
 	case divNode:
-		left, err := calculate(n.left)
+		left, err := calculate(n.left, env)
 		if err != nil {
 			return decimal.Zero, err
 		}
-		right, err := calculate(n.right)
+		right, err := calculate(n.right, env)
 		if err != nil {
 			return decimal.Zero, err
 		}
 		return left.Div(right), nil
 	case powNode:
-		left, err := calculate(n.left)
+		left, err := calculate(n.left, env)
 		if err != nil {
 			return decimal.Zero, err
 		}
-		right, err := calculate(n.right)
+		right, err := calculate(n.right, env)
 		if err != nil {
 			return decimal.Zero, err
 		}
 		// Use math.Pow for power calculation
 		result := math.Pow(left.InexactFloat64(), right.InexactFloat64())
 		return decimal.NewFromFloat(result), nil
+	case andNode, orNode, xorNode, shlNode, shrNode:
+		left, err := calculate(n.left, env)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		right, err := calculate(n.right, env)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return calculateBitwise(n.kind, left, right)
+	case notNode:
+		val, err := calculate(n.left, env)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return calculateBitwise(n.kind, val, decimal.Zero)
 	case numNode:
 		return n.val, nil
+	case varNode:
+		val, ok := env.getVariable(n.varName)
+		if !ok {
+			return decimal.Zero, fmt.Errorf("unknown identifier: %s", n.varName)
+		}
+		return val, nil
 	case funcNode:
 		var args []decimal.Decimal
 		for _, arg := range n.args {
-			val, err := calculate(arg)
+			val, err := calculate(arg, env)
 			if err != nil {
 				return decimal.Zero, err
 			}
 			args = append(args, val)
 		}
+		if _, ok := functions[n.funcName]; !ok {
+			return env.callFunction(n.funcName, args)
+		}
 		return call(n.funcName, args)
 	}
 	return decimal.Zero, fmt.Errorf("unknown node type: %s", n.kind)
 }
 
+// Calculate evaluates a single stateless expression, use a Session for variables, ans and user functions
 func Calculate(expr string) (decimal.Decimal, error) {
 	tokens, err := tokenize(expr)
 	if err != nil {
 		return decimal.Zero, err
 	}
 
-	// Check if any identifier token is not a valid function name or constant
-	for _, t := range tokens {
-		if t.kind == identToken {
-			// Check if it's a function name
-			if _, ok := functions[t.str]; !ok {
-				// If not a function, check if it's a constant
-				if _, ok := map[string]float64{
-					"e":       math.E,
-					"pi":      math.Pi,
-					"phi":     math.Phi,
-					"sqrt2":   math.Sqrt2,
-					"sqrte":   math.SqrtE,
-					"sqrtpi":  math.SqrtPi,
-					"sqrtphi": math.SqrtPhi,
-					"ln2":     math.Ln2,
-					"log2e":   math.Log2E,
-					"ln10":    math.Ln10,
-					"log10e":  math.Log10E,
-				}[strings.ToLower(t.str)]; !ok {
-					return decimal.Zero, fmt.Errorf("unknown identifier: %s", t.str)
-				}
-			}
-		}
-	}
-
-	p := newParser(tokens)
+	env := newEnvironment()
+	p := newParser(tokens, env)
 	n, err := p.parse()
 	if err != nil {
 		return decimal.Zero, err
 	}
-	return calculate(n)
+	return calculate(n, env)
 }
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 	"wox/common"
 	"wox/plugin"
+	"wox/plugin/system/calculator/history"
 	"wox/setting"
 	"wox/util"
 	"wox/util/clipboard"
@@ -15,22 +18,34 @@ import (
 
 var calculatorIcon = common.PluginCalculatorIcon
 
+const (
+	userFunctionsSettingKey = "calculatorFunctions"
+	historyCommand          = "history"
+)
+
 func init() {
 	plugin.AllSystemPlugin = append(plugin.AllSystemPlugin, &CalculatorPlugin{})
 }
 
+// CalculatorHistory is the history saved in the plugin settings before it was shared with the converter plugin
 type CalculatorHistory struct {
 	Expression string
 	Result     string
 	AddDate    string
 }
 
+type calculatorContextData struct {
+	Expression string `json:"expression"`
+}
+
 type CalculatorPlugin struct {
 	api              plugin.API
-	histories        []CalculatorHistory
+	historyManager   *history.Manager
 	lastQueryText    string
 	debounceTimer    *time.Timer
 	debounceInterval time.Duration
+	session          *Session
+	sessionLock      sync.Mutex
 }
 
 func (c *CalculatorPlugin) GetMetadata() plugin.Metadata {
@@ -49,119 +64,71 @@ func (c *CalculatorPlugin) GetMetadata() plugin.Metadata {
 			"*",
 			"calculator",
 		},
-		Commands: []plugin.MetadataCommand{},
+		Commands: []plugin.MetadataCommand{
+			{
+				Command:     historyCommand,
+				Description: "i18n:plugin_calculator_command_history",
+			},
+		},
 		SupportedOS: []string{
 			"Windows",
 			"Macos",
 			"Linux",
 		},
+		Features: []plugin.MetadataFeature{
+			{
+				Name: plugin.MetadataFeatureMRU,
+			},
+		},
 	}
 }
 
 func (c *CalculatorPlugin) Init(ctx context.Context, initParams plugin.InitParams) {
 	c.api = initParams.API
 	c.debounceInterval = 500 * time.Millisecond // 500ms debounce interval
-	c.histories = c.loadHistories(ctx)
+	c.historyManager = history.GetManager()
+	if err := c.historyManager.Init(ctx); err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, err.Error())
+	}
+	c.migrateHistories(ctx)
+	c.session = c.loadSession(ctx)
+	c.api.OnMRURestore(ctx, c.handleMRURestore)
 }
 
 func (c *CalculatorPlugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryResult {
 	var results []plugin.QueryResult
 
+	if query.Command == historyCommand {
+		return c.getHistoryResults(ctx, query.Search)
+	}
+
 	if query.TriggerKeyword == "" {
 		if !c.hasOperator(query.Search) {
 			return []plugin.QueryResult{}
 		}
 
 		// Try to calculate the expression, if it fails then it's not a valid calculator expression
-		val, err := Calculate(query.Search)
+		session, lines, err := c.evaluate(query.Search)
 		if err != nil {
 			c.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("Calculator failed to parse expression: %v", err))
 			return []plugin.QueryResult{}
 		}
-		result := val.String()
-
-		// Add to query history with debounce when calculation is successful
-		c.addQueryHistoryDebounced(ctx, query.Search, result)
 
-		results = append(results, plugin.QueryResult{
-			Title: result,
-			Icon:  calculatorIcon,
-			Actions: []plugin.QueryResultAction{
-				{
-					Name: "i18n:plugin_calculator_copy_result",
-					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-						c.histories = append(c.histories, CalculatorHistory{
-							Expression: query.Search,
-							Result:     result,
-							AddDate:    util.FormatDateTime(util.GetSystemTime()),
-						})
-						clipboard.WriteText(result)
-					},
-				},
-			},
-		})
+		results = append(results, c.getSessionResult(ctx, query, session, lines))
+		c.addQueryHistoryDebounced(ctx, query.Search, lines)
 	}
 
 	// only show history if query has trigger keyword
 	if query.TriggerKeyword != "" {
-		val, err := Calculate(query.Search)
+		session, lines, err := c.evaluate(query.Search)
 		if err == nil {
-			result := val.String()
-
-			// Add to query history with debounce when calculation is successful
-			c.addQueryHistoryDebounced(ctx, query.Search, result)
-
-			results = append(results, plugin.QueryResult{
-				Title: result,
-				Icon:  calculatorIcon,
-				Actions: []plugin.QueryResultAction{
-					{
-						Name: "i18n:plugin_calculator_copy_result",
-						Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-
-							clipboard.WriteText(result)
-						},
-					},
-				},
-			})
+			results = append(results, c.getSessionResult(ctx, query, session, lines))
+			c.addQueryHistoryDebounced(ctx, query.Search, lines)
 		}
 
-		//show top 500 histories order by desc
-		var count = 0
-		for i := len(c.histories) - 1; i >= 0; i-- {
-			h := c.histories[i]
+		results = append(results, c.getUserFunctionResults(ctx, query)...)
 
-			count++
-			if count >= 500 {
-				break
-			}
-
-			if strings.Contains(h.Expression, query.Search) || strings.Contains(h.Result, query.Search) {
-				results = append(results, plugin.QueryResult{
-					Title:    h.Expression,
-					SubTitle: h.Result,
-					Icon:     calculatorIcon,
-					Actions: []plugin.QueryResultAction{
-						{
-							Name:      "i18n:plugin_calculator_copy_result",
-							IsDefault: true,
-							Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-								clipboard.WriteText(h.Result)
-							},
-						},
-						{
-							Name: "i18n:plugin_calculator_recalculate",
-							Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-								c.api.ChangeQuery(ctx, common.PlainQuery{
-									QueryType: plugin.QueryTypeInput,
-									QueryText: h.Expression,
-								})
-							},
-						},
-					},
-				})
-			}
-		}
+		results = append(results, c.getHistoryResults(ctx, query.Search)...)
 
 		if len(results) == 0 {
 			results = append(results, plugin.QueryResult{
@@ -175,34 +142,429 @@ func (c *CalculatorPlugin) Query(ctx context.Context, query plugin.Query) []plug
 	return results
 }
 
+// evaluate evaluates the statements on a copy of the session, the copy becomes the session when the user commits the result
+func (c *CalculatorPlugin) evaluate(input string) (*Session, []SessionLine, error) {
+	c.sessionLock.Lock()
+	session := c.session.Clone()
+	c.sessionLock.Unlock()
+
+	lines, err := session.EvaluateAll(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, lines, nil
+}
+
+func (c *CalculatorPlugin) getSessionResult(ctx context.Context, query plugin.Query, session *Session, lines []SessionLine) plugin.QueryResult {
+	last := lines[len(lines)-1]
+	result := plugin.QueryResult{
+		Title: last.Result,
+		Icon:  calculatorIcon,
+	}
+
+	var previews []string
+	var representations []integerRepresentation
+	if last.Format != nil {
+		representations = c.getIntegerRepresentations(ctx, last)
+		previews = append(previews, renderIntegerPreview(representations))
+	}
+	if len(session.Lines()) > 1 {
+		previews = append(previews, renderSessionPreview(session.Lines()))
+	}
+	if len(previews) > 0 {
+		result.Preview = plugin.WoxPreview{
+			PreviewType: plugin.WoxPreviewTypeMarkdown,
+			PreviewData: strings.Join(previews, "\n\n"),
+		}
+	}
+
+	// go back to an empty query of this plugin, so the user can type the next line
+	nextLine := func(ctx context.Context) {
+		queryText := ""
+		if query.TriggerKeyword != "" {
+			queryText = query.TriggerKeyword + " "
+		}
+		c.api.ChangeQuery(ctx, common.PlainQuery{
+			QueryType: plugin.QueryTypeInput,
+			QueryText: queryText,
+		})
+	}
+
+	if last.Kind == SessionLineKindFunction {
+		result.SubTitle = "i18n:plugin_calculator_function_subtitle"
+		result.Actions = []plugin.QueryResultAction{
+			{
+				Name:                   "i18n:plugin_calculator_save_function",
+				PreventHideAfterAction: true,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					c.commitSession(ctx, session)
+					nextLine(ctx)
+				},
+			},
+		}
+		return result
+	}
+
+	if last.Kind == SessionLineKindAssignment {
+		result.SubTitle = last.Input
+	}
+	contextDataJson, _ := json.Marshal(calculatorContextData{Expression: query.Search})
+	result.ContextData = string(contextDataJson)
+	result.Actions = []plugin.QueryResultAction{
+		{
+			Name: "i18n:plugin_calculator_copy_result",
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				c.addHistory(ctx, query.Search, last.Result, false)
+				c.commitSession(ctx, session)
+				clipboard.WriteText(last.Result)
+			},
+		},
+		{
+			Name: "i18n:plugin_calculator_add_to_favorite",
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				c.addHistory(ctx, query.Search, last.Result, true)
+			},
+		},
+		{
+			Name:                   "i18n:plugin_calculator_add_to_session",
+			PreventHideAfterAction: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				c.commitSession(ctx, session)
+				nextLine(ctx)
+			},
+		},
+	}
+	for _, representation := range representations {
+		result.Actions = append(result.Actions, plugin.QueryResultAction{
+			Name: representation.copyAction,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				c.commitSession(ctx, session)
+				clipboard.WriteText(representation.value)
+			},
+		})
+	}
+	if len(session.Lines()) > len(lines) {
+		result.Actions = append(result.Actions, plugin.QueryResultAction{
+			Name:                   "i18n:plugin_calculator_clear_session",
+			PreventHideAfterAction: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				c.sessionLock.Lock()
+				c.session.ClearLines()
+				c.sessionLock.Unlock()
+				c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: false})
+			},
+		})
+	}
+	return result
+}
+
+// getUserFunctionResults lists the saved user functions matching the search, so they can be reused or removed
+func (c *CalculatorPlugin) getUserFunctionResults(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	c.sessionLock.Lock()
+	definitions := c.session.FunctionDefinitions()
+	c.sessionLock.Unlock()
+
+	var results []plugin.QueryResult
+	for _, definition := range definitions {
+		if !strings.Contains(definition, query.Search) {
+			continue
+		}
+		name := definition[:strings.Index(definition, "(")]
+		results = append(results, plugin.QueryResult{
+			Title:    definition,
+			SubTitle: "i18n:plugin_calculator_user_function",
+			Icon:     calculatorIcon,
+			Actions: []plugin.QueryResultAction{
+				{
+					Name: "i18n:plugin_calculator_use_function",
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						c.api.ChangeQuery(ctx, common.PlainQuery{
+							QueryType: plugin.QueryTypeInput,
+							QueryText: fmt.Sprintf("%s %s(", query.TriggerKeyword, name),
+						})
+					},
+					PreventHideAfterAction: true,
+				},
+				{
+					Name:                   "i18n:plugin_calculator_remove_function",
+					PreventHideAfterAction: true,
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						c.sessionLock.Lock()
+						removed := c.session.RemoveFunction(name)
+						session := c.session
+						c.sessionLock.Unlock()
+						if removed {
+							c.saveFunctions(ctx, session)
+						}
+						c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: false})
+					},
+				},
+			},
+		})
+	}
+	return results
+}
+
+type integerRepresentation struct {
+	name       string
+	value      string
+	copyAction string
+}
+
+// getIntegerRepresentations returns the result of a programmer expression in every base
+func (c *CalculatorPlugin) getIntegerRepresentations(ctx context.Context, line SessionLine) []integerRepresentation {
+	representations, err := line.Format.Representations(line.Value)
+	if err != nil {
+		return nil
+	}
+
+	return []integerRepresentation{
+		{c.api.GetTranslation(ctx, "plugin_calculator_decimal"), representations.Decimal, "i18n:plugin_calculator_copy_decimal"},
+		{c.api.GetTranslation(ctx, "plugin_calculator_hex"), representations.Hex, "i18n:plugin_calculator_copy_hex"},
+		{c.api.GetTranslation(ctx, "plugin_calculator_octal"), representations.Octal, "i18n:plugin_calculator_copy_octal"},
+		{c.api.GetTranslation(ctx, "plugin_calculator_binary"), representations.Binary, "i18n:plugin_calculator_copy_binary"},
+	}
+}
+
+func renderIntegerPreview(representations []integerRepresentation) string {
+	var sb strings.Builder
+	sb.WriteString("| | |\n|---|---|\n")
+	for _, representation := range representations {
+		sb.WriteString(fmt.Sprintf("| %s | `%s` |\n", representation.name, representation.value))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// renderSessionPreview lists every line of the session with its result, like a scratchpad
+func renderSessionPreview(lines []SessionLine) string {
+	width := 0
+	for _, line := range lines {
+		width = max(width, len([]rune(line.Input)))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("```\n")
+	for _, line := range lines {
+		if line.Kind == SessionLineKindFunction {
+			sb.WriteString(line.Input + "\n")
+			continue
+		}
+		sb.WriteString(line.Input + strings.Repeat(" ", width-len([]rune(line.Input))) + "   " + line.Result + "\n")
+	}
+	sb.WriteString("```")
+	return sb.String()
+}
+
 func (c *CalculatorPlugin) hasOperator(query string) bool {
-	return strings.ContainsAny(query, "+-*/(^")
+	if strings.ContainsAny(query, "+-*/(^=&|~<>") {
+		return true
+	}
+
+	// programmer expressions without operators, e.g. "0xFF" or "255 in hex"
+	tokens, err := tokenize(query)
+	return err == nil && isProgrammerExpression(tokens)
 }
 
-func (c *CalculatorPlugin) loadHistories(ctx context.Context) []CalculatorHistory {
+// migrateHistories moves the history saved in the plugin settings to the history shared with the converter plugin
+func (c *CalculatorPlugin) migrateHistories(ctx context.Context) {
 	historiesJson := c.api.GetSetting(ctx, "calculatorHistories")
 	if historiesJson == "" {
-		return []CalculatorHistory{}
+		return
 	}
 
 	var histories []CalculatorHistory
 	err := json.Unmarshal([]byte(historiesJson), &histories)
 	if err != nil {
 		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to unmarshal calculator history: %s", err.Error()))
-		return []CalculatorHistory{}
+		return
+	}
+
+	for i, h := range histories {
+		// AddDate only has the day, the index keeps the order of the entries of the same day
+		var timestamp int64
+		if addDate, parseErr := time.ParseInLocation("20060102", h.AddDate, time.Local); parseErr == nil {
+			timestamp = addDate.UnixMilli() + int64(i)
+		}
+		addErr := c.historyManager.Add(ctx, history.Entry{
+			Source:     history.SourceCalculator,
+			Expression: h.Expression,
+			Result:     h.Result,
+			Timestamp:  timestamp,
+		})
+		if addErr != nil {
+			c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to migrate calculator history: %s", addErr.Error()))
+			return
+		}
+	}
+
+	c.api.SaveSetting(ctx, "calculatorHistories", "", false)
+	c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("Calculator history migrated: %d", len(histories)))
+}
+
+// addHistory adds a calculation to the history shared with the converter plugin
+func (c *CalculatorPlugin) addHistory(ctx context.Context, expression string, result string, favorite bool) {
+	err := c.historyManager.Add(ctx, history.Entry{
+		Source:     history.SourceCalculator,
+		Expression: expression,
+		Result:     result,
+		Favorite:   favorite,
+	})
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to add calculator history: %s", err.Error()))
+	}
+}
+
+// getHistoryResults lists the calculations of the calculator and converter plugins, favorites first
+func (c *CalculatorPlugin) getHistoryResults(ctx context.Context, search string) []plugin.QueryResult {
+	entries, err := c.historyManager.Search(ctx, search, 500)
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to search calculator history: %s", err.Error()))
+		return []plugin.QueryResult{}
+	}
+
+	var results []plugin.QueryResult
+	for _, entry := range entries {
+		contextDataJson, _ := json.Marshal(calculatorContextData{Expression: entry.Expression})
+		result := plugin.QueryResult{
+			Title:       entry.Expression,
+			SubTitle:    entry.Result,
+			Icon:        calculatorIcon,
+			ContextData: string(contextDataJson),
+			Tails:       plugin.NewQueryResultTailTexts(util.FormatTimestamp(entry.Timestamp)),
+			Actions: []plugin.QueryResultAction{
+				{
+					Name:      "i18n:plugin_calculator_copy_result",
+					IsDefault: true,
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						clipboard.WriteText(entry.Result)
+					},
+				},
+				{
+					Name:                   "i18n:plugin_calculator_recalculate",
+					PreventHideAfterAction: true,
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						c.api.ChangeQuery(ctx, common.PlainQuery{
+							QueryType: plugin.QueryTypeInput,
+							QueryText: entry.Expression,
+						})
+					},
+				},
+			},
+		}
+
+		favoriteAction := plugin.QueryResultAction{
+			Name:                   "i18n:plugin_calculator_add_to_favorite",
+			PreventHideAfterAction: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				c.setFavorite(ctx, entry.Expression, true)
+			},
+		}
+		if entry.Favorite {
+			result.Tails = append([]plugin.QueryResultTail{{Type: plugin.QueryResultTailTypeImage, Image: common.NewWoxImageEmoji("⭐")}}, result.Tails...)
+			favoriteAction = plugin.QueryResultAction{
+				Name:                   "i18n:plugin_calculator_remove_from_favorite",
+				PreventHideAfterAction: true,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					c.setFavorite(ctx, entry.Expression, false)
+				},
+			}
+		}
+		result.Actions = append(result.Actions, favoriteAction, plugin.QueryResultAction{
+			Name:                   "i18n:plugin_calculator_delete_history",
+			PreventHideAfterAction: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				if err := c.historyManager.Delete(ctx, entry.Expression); err != nil {
+					c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to delete calculator history: %s", err.Error()))
+				}
+				c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: true})
+			},
+		})
+		results = append(results, result)
 	}
+	return results
+}
 
-	c.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("Calculator history loaded: %d", len(histories)))
+func (c *CalculatorPlugin) setFavorite(ctx context.Context, expression string, favorite bool) {
+	if err := c.historyManager.SetFavorite(ctx, expression, favorite); err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to update calculator favorite: %s", err.Error()))
+	}
+	c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: true})
+}
 
-	return histories
+// handleMRURestore calculates the expression again, the result may have changed, e.g. when it uses a user function
+func (c *CalculatorPlugin) handleMRURestore(mruData plugin.MRUData) (*plugin.QueryResult, error) {
+	var contextData calculatorContextData
+	if err := json.Unmarshal([]byte(mruData.ContextData), &contextData); err != nil {
+		return nil, fmt.Errorf("failed to parse context data: %w", err)
+	}
+
+	session, lines, err := c.evaluate(contextData.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate %s: %w", contextData.Expression, err)
+	}
+
+	result := c.getSessionResult(context.Background(), plugin.Query{Search: contextData.Expression}, session, lines)
+	if result.SubTitle == "" {
+		result.SubTitle = contextData.Expression
+	}
+	return &result, nil
+}
+
+// loadSession restores the user functions saved in the settings, variables only live until Wox restarts
+func (c *CalculatorPlugin) loadSession(ctx context.Context) *Session {
+	session := NewSession()
+	functionsJson := c.api.GetSetting(ctx, userFunctionsSettingKey)
+	if functionsJson == "" {
+		return session
+	}
+
+	var definitions []string
+	err := json.Unmarshal([]byte(functionsJson), &definitions)
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to unmarshal calculator functions: %s", err.Error()))
+		return session
+	}
+
+	for _, definition := range definitions {
+		if _, evaluateErr := session.Evaluate(definition); evaluateErr != nil {
+			c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to load calculator function %s: %s", definition, evaluateErr.Error()))
+		}
+	}
+	session.ClearLines()
+
+	c.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("Calculator functions loaded: %d", len(session.FunctionDefinitions())))
+	return session
+}
+
+// commitSession makes the evaluated copy the current session, and saves the user functions if they changed
+func (c *CalculatorPlugin) commitSession(ctx context.Context, session *Session) {
+	c.sessionLock.Lock()
+	functionsChanged := !slices.Equal(c.session.FunctionDefinitions(), session.FunctionDefinitions())
+	c.session = session
+	c.sessionLock.Unlock()
+
+	if functionsChanged {
+		c.saveFunctions(ctx, session)
+	}
+}
+
+func (c *CalculatorPlugin) saveFunctions(ctx context.Context, session *Session) {
+	functionsJson, err := json.Marshal(session.FunctionDefinitions())
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to marshal calculator functions: %s", err.Error()))
+		return
+	}
+	c.api.SaveSetting(ctx, userFunctionsSettingKey, string(functionsJson), false)
 }
 
 // addQueryHistoryDebounced adds query to history with debounce mechanism
 // Only records the last valid calculation when user stops typing
-func (c *CalculatorPlugin) addQueryHistoryDebounced(ctx context.Context, queryText string, result string) {
-	if !c.hasOperator(queryText) {
+func (c *CalculatorPlugin) addQueryHistoryDebounced(ctx context.Context, queryText string, lines []SessionLine) {
+	last := lines[len(lines)-1]
+	if !c.hasOperator(queryText) || last.Kind == SessionLineKindFunction {
 		return
 	}
+	result := last.Result
 
 	// Cancel existing timer if any
 	if c.debounceTimer != nil {
@@ -232,18 +594,7 @@ func (c *CalculatorPlugin) addQueryHistoryDebounced(ctx context.Context, queryTe
 			}
 			settingManager.AddQueryHistory(ctx, plainQuery)
 
-			c.histories = append(c.histories, CalculatorHistory{
-				Expression: queryText,
-				Result:     result,
-				AddDate:    util.FormatDateTime(util.GetSystemTime()),
-			})
-
-			historiesJson, err := json.Marshal(c.histories)
-			if err != nil {
-				c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to marshal calculator history: %s", err.Error()))
-			} else {
-				c.api.SaveSetting(ctx, "calculatorHistories", string(historiesJson), false)
-			}
+			c.addHistory(ctx, queryText, result, false)
 
 			c.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("Calculator query added to history: %s", queryText))
 		}
@@ -0,0 +1,155 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"wox/database"
+	"wox/util"
+
+	"gorm.io/gorm"
+)
+
+// maxEntries limits the history, favorites are never removed
+const maxEntries = 1000
+
+const (
+	SourceCalculator = "calculator"
+	SourceConverter  = "converter"
+)
+
+// Entry is a calculation of the calculator or the converter plugin, both plugins share the history
+type Entry struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement"`
+	Source     string `gorm:"not null"` // plugin that calculated the entry
+	Expression string `gorm:"not null;uniqueIndex"`
+	Result     string `gorm:"not null"`
+	Unit       string // unit of the result, empty for plain numbers
+	Favorite   bool   `gorm:"default:false;index"`
+	Timestamp  int64  `gorm:"not null;index"` // last time the expression was calculated, in milliseconds
+	UseCount   int    `gorm:"default:1"`
+}
+
+func (Entry) TableName() string {
+	return "calculation_histories"
+}
+
+// Manager manages the calculation history in database
+type Manager struct {
+	db *gorm.DB // nil uses the wox database, which is reopened when a backup is restored
+}
+
+var managerInstance *Manager
+var managerOnce sync.Once
+
+// GetManager returns the history shared by the calculator and converter plugins
+func GetManager() *Manager {
+	managerOnce.Do(func() {
+		managerInstance = &Manager{}
+	})
+	return managerInstance
+}
+
+func (m *Manager) getDB() *gorm.DB {
+	if m.db != nil {
+		return m.db
+	}
+	return database.GetDB()
+}
+
+// Init initializes the history table
+func (m *Manager) Init(ctx context.Context) error {
+	err := m.getDB().AutoMigrate(&Entry{})
+	if err != nil {
+		return fmt.Errorf("failed to migrate calculation history table: %w", err)
+	}
+	return nil
+}
+
+// Add adds a calculation, calculating an expression again updates its result and moves it to the top
+func (m *Manager) Add(ctx context.Context, entry Entry) error {
+	if entry.Timestamp == 0 {
+		entry.Timestamp = util.GetSystemTimestamp()
+	}
+
+	existing, err := m.Get(ctx, entry.Expression)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		entry.ID = 0
+		entry.UseCount = 1
+		if err := m.getDB().WithContext(ctx).Create(&entry).Error; err != nil {
+			return err
+		}
+		return m.enforceMaxCount(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return m.getDB().WithContext(ctx).Model(&Entry{}).
+		Where("id = ?", existing.ID).
+		Updates(map[string]interface{}{
+			"source":    entry.Source,
+			"result":    entry.Result,
+			"unit":      entry.Unit,
+			"favorite":  existing.Favorite || entry.Favorite,
+			"timestamp": max(entry.Timestamp, existing.Timestamp),
+			"use_count": existing.UseCount + 1,
+		}).Error
+}
+
+// Get retrieves the entry of an expression
+func (m *Manager) Get(ctx context.Context, expression string) (*Entry, error) {
+	var entry Entry
+	err := m.getDB().WithContext(ctx).Where("expression = ?", expression).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Search searches the expressions and results, favorites first and then most recent first
+func (m *Manager) Search(ctx context.Context, keyword string, limit int) ([]Entry, error) {
+	var entries []Entry
+	err := m.getDB().WithContext(ctx).
+		Where("expression LIKE ? OR result LIKE ?", "%"+keyword+"%", "%"+keyword+"%").
+		Order("favorite DESC").
+		Order("timestamp DESC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+// SetFavorite marks or unmarks an expression as favorite
+func (m *Manager) SetFavorite(ctx context.Context, expression string, favorite bool) error {
+	return m.getDB().WithContext(ctx).Model(&Entry{}).
+		Where("expression = ?", expression).
+		Update("favorite", favorite).Error
+}
+
+// Delete deletes the entry of an expression
+func (m *Manager) Delete(ctx context.Context, expression string) error {
+	return m.getDB().WithContext(ctx).Delete(&Entry{}, "expression = ?", expression).Error
+}
+
+// enforceMaxCount removes the oldest entries that are not favorites when there are more than maxEntries
+func (m *Manager) enforceMaxCount(ctx context.Context) error {
+	var count int64
+	if err := m.getDB().WithContext(ctx).Model(&Entry{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count <= maxEntries {
+		return nil
+	}
+
+	var oldIDs []uint
+	err := m.getDB().WithContext(ctx).Model(&Entry{}).
+		Where("favorite = ?", false).
+		Order("timestamp ASC").
+		Limit(int(count-maxEntries)).
+		Pluck("id", &oldIDs).Error
+	if err != nil || len(oldIDs) == 0 {
+		return err
+	}
+	return m.getDB().WithContext(ctx).Delete(&Entry{}, oldIDs).Error
+}
@@ -0,0 +1,91 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	m := &Manager{db: db}
+	require.NoError(t, m.Init(context.Background()))
+	return m
+}
+
+func TestHistoryAddAndSearch(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+
+	require.NoError(t, m.Add(ctx, Entry{Source: SourceCalculator, Expression: "1+2", Result: "3", Timestamp: 1}))
+	require.NoError(t, m.Add(ctx, Entry{Source: SourceConverter, Expression: "10 km to mi", Result: "6.213712 mi", Unit: "mi", Timestamp: 2}))
+	require.NoError(t, m.Add(ctx, Entry{Source: SourceCalculator, Expression: "2*3", Result: "6", Timestamp: 3}))
+
+	entries, err := m.Search(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "2*3", entries[0].Expression)
+	assert.Equal(t, "1+2", entries[2].Expression)
+
+	entries, err = m.Search(ctx, "mi", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, SourceConverter, entries[0].Source)
+	assert.Equal(t, "mi", entries[0].Unit)
+
+	// calculating an expression again moves it to the top
+	require.NoError(t, m.Add(ctx, Entry{Source: SourceCalculator, Expression: "1+2", Result: "3", Timestamp: 4}))
+	entries, err = m.Search(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "1+2", entries[0].Expression)
+	assert.Equal(t, 2, entries[0].UseCount)
+}
+
+func TestHistoryFavorites(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+
+	require.NoError(t, m.Add(ctx, Entry{Source: SourceCalculator, Expression: "1+1", Result: "2", Timestamp: 1}))
+	require.NoError(t, m.Add(ctx, Entry{Source: SourceCalculator, Expression: "2+2", Result: "4", Timestamp: 2}))
+	require.NoError(t, m.SetFavorite(ctx, "1+1", true))
+
+	entries, err := m.Search(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "1+1", entries[0].Expression)
+	assert.True(t, entries[0].Favorite)
+
+	// calculating it again keeps the favorite
+	require.NoError(t, m.Add(ctx, Entry{Source: SourceCalculator, Expression: "1+1", Result: "2", Timestamp: 3}))
+	entry, err := m.Get(ctx, "1+1")
+	require.NoError(t, err)
+	assert.True(t, entry.Favorite)
+
+	require.NoError(t, m.Delete(ctx, "1+1"))
+	_, err = m.Get(ctx, "1+1")
+	assert.Error(t, err)
+}
+
+func TestHistoryMaxCount(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+
+	require.NoError(t, m.Add(ctx, Entry{Source: SourceCalculator, Expression: "favorite", Result: "1", Favorite: true, Timestamp: 1}))
+	for i := 0; i < maxEntries+5; i++ {
+		require.NoError(t, m.Add(ctx, Entry{Source: SourceCalculator, Expression: fmt.Sprintf("%d+1", i), Result: fmt.Sprintf("%d", i+1), Timestamp: int64(i + 2)}))
+	}
+
+	entries, err := m.Search(ctx, "", maxEntries*2)
+	require.NoError(t, err)
+	assert.Len(t, entries, maxEntries)
+	assert.Equal(t, "favorite", entries[0].Expression)
+	_, err = m.Get(ctx, "0+1")
+	assert.Error(t, err)
+}
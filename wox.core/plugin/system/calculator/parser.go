@@ -18,6 +18,15 @@ const (
 	powNode  nodeKind = "^"
 	funcNode nodeKind = "func"
 	numNode  nodeKind = "num"
+	varNode  nodeKind = "var"
+
+	// bitwise operators of programmer expressions
+	andNode nodeKind = "&"
+	orNode  nodeKind = "|"
+	xorNode nodeKind = "xor"
+	shlNode nodeKind = "<<"
+	shrNode nodeKind = ">>"
+	notNode nodeKind = "~"
 )
 
 type node struct {
@@ -28,16 +37,26 @@ type node struct {
 	funcName string
 	args     []*node
 
+	varName string
+
 	val decimal.Decimal
 }
 
 type parser struct {
 	tokens []token
 	i      int
+
+	// env provides the user functions, calls to unknown functions are only allowed in function bodies because they are checked when called
+	env                   *environment
+	allowUnknownFunctions bool
+
+	// programmer is set when "^" means xor, format is the conversion at the end of the expression, e.g. "in hex"
+	programmer bool
+	format     *IntegerFormat
 }
 
-func newParser(tokens []token) *parser {
-	return &parser{tokens: tokens, i: 0}
+func newParser(tokens []token, env *environment) *parser {
+	return &parser{tokens: tokens, i: 0, env: env, programmer: isProgrammerExpression(tokens)}
 }
 
 func (p *parser) numberNode() (*node, error) {
@@ -49,22 +68,23 @@ func (p *parser) numberNode() (*node, error) {
 	return &node{kind: numNode, val: t.val}, nil
 }
 
-func (p *parser) constantNode(str string) (*node, error) {
-	constants := map[string]float64{
-		"e":   math.E,
-		"pi":  math.Pi,
-		"phi": math.Phi,
+var constants = map[string]float64{
+	"e":   math.E,
+	"pi":  math.Pi,
+	"phi": math.Phi,
 
-		"sqrt2":   math.Sqrt2,
-		"sqrte":   math.SqrtE,
-		"sqrtpi":  math.SqrtPi,
-		"sqrtphi": math.SqrtPhi,
+	"sqrt2":   math.Sqrt2,
+	"sqrte":   math.SqrtE,
+	"sqrtpi":  math.SqrtPi,
+	"sqrtphi": math.SqrtPhi,
 
-		"ln2":    math.Ln2,
-		"log2e":  math.Log2E,
-		"ln10":   math.Ln10,
-		"log10e": math.Log10E,
-	}
+	"ln2":    math.Ln2,
+	"log2e":  math.Log2E,
+	"ln10":   math.Ln10,
+	"log10e": math.Log10E,
+}
+
+func (p *parser) constantNode(str string) (*node, error) {
 	val, ok := constants[strings.ToLower(str)]
 	if !ok {
 		return nil, fmt.Errorf("unknown constant: %s", str)
@@ -93,15 +113,27 @@ func argumentNumber(funcName string) (int, error) {
 	}
 }
 
+// variableNode is resolved when calculating, variables and function parameters are only known then
+func (p *parser) variableNode(str string) (*node, error) {
+	p.i++
+	return &node{kind: varNode, varName: strings.ToLower(str)}, nil
+}
+
 func (p *parser) functionNode(str string) (*node, error) {
 	funcName := strings.ToLower(str)
 	num, err := argumentNumber(funcName)
 	if err != nil {
-		return nil, err
+		if userFunction, ok := p.env.getFunction(funcName); ok {
+			num, err = len(userFunction.Params), nil
+		} else if p.allowUnknownFunctions {
+			num, err = -1, nil
+		} else {
+			return nil, err
+		}
 	}
 
 	if p.consume(")") {
-		if num != 0 {
+		if num > 0 {
 			return nil, fmt.Errorf("%s should have argument(s)", funcName)
 		}
 		return &node{kind: funcNode, funcName: funcName}, nil
@@ -109,20 +141,20 @@ func (p *parser) functionNode(str string) (*node, error) {
 
 	args := []*node{}
 
-	n, err := p.add()
+	n, err := p.expr()
 	if err != nil {
 		return nil, err
 	}
 	args = append(args, n)
 
 	for p.consume(",") {
-		n, err := p.add()
+		n, err := p.expr()
 		if err != nil {
 			return nil, err
 		}
 		args = append(args, n)
 	}
-	if len(args) != num {
+	if num >= 0 && len(args) != num {
 		return nil, fmt.Errorf("%s should have %d argument(s) but has %d arguments(s)",
 			funcName, num, len(args))
 	}
@@ -139,11 +171,23 @@ func (p *parser) consume(s string) bool {
 	return true
 }
 
+func (p *parser) consumeIdent(s string) bool {
+	t := p.tokens[p.i]
+	if t.kind != identToken || !strings.EqualFold(t.str, s) {
+		return false
+	}
+	p.i++
+	return true
+}
+
 func (p *parser) parse() (*node, error) {
-	n, err := p.add()
+	n, err := p.expr()
 	if err != nil {
 		return nil, err
 	}
+	if err := p.conversion(); err != nil {
+		return nil, err
+	}
 	if p.tokens[p.i].kind != eosToken {
 		return nil, fmt.Errorf("unexpected token: %s", p.tokens[p.i].str)
 	}
@@ -159,6 +203,105 @@ func (p *parser) insert(n *node, f func() (*node, error), kind nodeKind) (*node,
 	return &node{kind: kind, left: left, right: right}, err
 }
 
+// conversion parses the integer format at the end of a programmer expression, e.g. "in hex", "as u8" or "to bin i16"
+func (p *parser) conversion() error {
+	if p.tokens[p.i].kind != identToken || !isConversionKeyword(p.tokens[p.i].str) {
+		return nil
+	}
+	p.i++
+
+	format := defaultIntegerFormat()
+	for p.tokens[p.i].kind == identToken {
+		name := strings.ToLower(p.tokens[p.i].str)
+		if base, ok := integerBases[name]; ok {
+			format.Base = base
+		} else if width, ok := integerWidths[name]; ok {
+			format.Bits, format.Signed = width.Bits, width.Signed
+		} else {
+			return fmt.Errorf("unknown integer format: %s", p.tokens[p.i].str)
+		}
+		p.i++
+	}
+	if p.tokens[p.i].kind != eosToken {
+		return fmt.Errorf("unexpected token: %s", p.tokens[p.i].str)
+	}
+	p.format = format
+	return nil
+}
+
+// expr parses the bitwise operators, they have a lower precedence than arithmetic like in C
+func (p *parser) expr() (*node, error) {
+	return p.bitOr()
+}
+
+func (p *parser) bitOr() (*node, error) {
+	n, err := p.bitXor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.consume("|") {
+		n, err = p.insert(n, p.bitXor, orNode)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) bitXor() (*node, error) {
+	n, err := p.bitAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for (p.programmer && p.consume("^")) || p.consumeIdent("xor") {
+		n, err = p.insert(n, p.bitAnd, xorNode)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) bitAnd() (*node, error) {
+	n, err := p.shift()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.consume("&") {
+		n, err = p.insert(n, p.shift, andNode)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) shift() (*node, error) {
+	n, err := p.add()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.consume("<<") {
+			n, err = p.insert(n, p.add, shlNode)
+			if err != nil {
+				return nil, err
+			}
+		} else if p.consume(">>") {
+			n, err = p.insert(n, p.add, shrNode)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return n, nil
+		}
+	}
+}
+
 func (p *parser) add() (*node, error) {
 	n, err := p.mul()
 	if err != nil {
@@ -212,7 +355,8 @@ func (p *parser) pow() (*node, error) {
 	}
 
 	// Right associative: 2^3^2 = 2^(3^2) = 2^9 = 512
-	if p.consume("^") {
+	// "^" is xor in programmer expressions, "**" is the power operator there
+	if p.consume("**") || (!p.programmer && p.consume("^")) {
 		right, err := p.pow()
 		if err != nil {
 			return nil, err
@@ -227,13 +371,19 @@ func (p *parser) unary() (*node, error) {
 		return p.primary()
 	} else if p.consume("-") {
 		return p.insert(&node{kind: numNode, val: decimal.Zero}, p.primary, subNode)
+	} else if p.consume("~") {
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: notNode, left: n}, nil
 	}
 	return p.primary()
 }
 
 func (p *parser) primary() (*node, error) {
 	if p.consume("(") {
-		n, err := p.add()
+		n, err := p.expr()
 		if err != nil {
 			return nil, err
 		}
@@ -248,7 +398,10 @@ func (p *parser) primary() (*node, error) {
 			return p.functionNode(str)
 		}
 		p.i--
-		return p.constantNode(str)
+		if _, ok := constants[strings.ToLower(str)]; ok {
+			return p.constantNode(str)
+		}
+		return p.variableNode(str)
 	}
 	return p.numberNode()
 }
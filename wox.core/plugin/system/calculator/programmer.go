@@ -0,0 +1,200 @@
+package calculator
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// maxShift limits shifts, 1 << 100000 would be a valid but useless number
+const maxShift = 1024
+
+// integerBases are the names of the bases a programmer expression can be converted to, e.g. "255 in hex"
+var integerBases = map[string]int{
+	"hex": 16,
+	"oct": 8,
+	"bin": 2,
+	"dec": 10,
+}
+
+// integerWidths are the names of the integer widths, e.g. "-1 as u8"
+var integerWidths = map[string]IntegerFormat{
+	"i8":  {Bits: 8, Signed: true},
+	"i16": {Bits: 16, Signed: true},
+	"i32": {Bits: 32, Signed: true},
+	"i64": {Bits: 64, Signed: true},
+	"u8":  {Bits: 8},
+	"u16": {Bits: 16},
+	"u32": {Bits: 32},
+	"u64": {Bits: 64},
+}
+
+// IntegerFormat is how the result of a programmer expression is displayed
+type IntegerFormat struct {
+	Base   int // 2, 8, 10 or 16
+	Bits   int // 0 means no fixed width, negative numbers are then shown as 64 bits two's complement
+	Signed bool
+}
+
+// IntegerRepresentations is a result in every base, hex, octal and binary are the two's complement bits for negative numbers
+type IntegerRepresentations struct {
+	Decimal string
+	Hex     string
+	Octal   string
+	Binary  string
+}
+
+func defaultIntegerFormat() *IntegerFormat {
+	return &IntegerFormat{Base: 10, Signed: true}
+}
+
+// Format formats the value in the base of the format
+func (f IntegerFormat) Format(val decimal.Decimal) (string, error) {
+	representations, err := f.Representations(val)
+	if err != nil {
+		return "", err
+	}
+
+	switch f.Base {
+	case 16:
+		return representations.Hex, nil
+	case 8:
+		return representations.Octal, nil
+	case 2:
+		return representations.Binary, nil
+	default:
+		return representations.Decimal, nil
+	}
+}
+
+func (f IntegerFormat) Representations(val decimal.Decimal) (IntegerRepresentations, error) {
+	if !val.IsInteger() {
+		return IntegerRepresentations{}, fmt.Errorf("%s is not an integer", val.String())
+	}
+
+	value := val.BigInt()
+	bits := f.Bits
+	if bits == 0 && value.Sign() < 0 && value.IsInt64() {
+		bits = 64
+	}
+	if bits == 0 {
+		// no width, the bits of a negative number that doesn't fit in 64 bits are shown with a sign
+		return IntegerRepresentations{
+			Decimal: value.String(),
+			Hex:     formatUnsigned(value, 16),
+			Octal:   formatUnsigned(value, 8),
+			Binary:  formatUnsigned(value, 2),
+		}, nil
+	}
+
+	// wrap the value to the width, like a fixed width integer would overflow
+	modulus := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	unsigned := new(big.Int).Mod(value, modulus)
+	signed := new(big.Int).Set(unsigned)
+	if unsigned.Bit(bits-1) == 1 {
+		signed.Sub(signed, modulus)
+	}
+
+	decimalValue := unsigned
+	if f.Signed || f.Bits == 0 {
+		decimalValue = signed
+	}
+	return IntegerRepresentations{
+		Decimal: decimalValue.String(),
+		Hex:     formatUnsigned(unsigned, 16),
+		Octal:   formatUnsigned(unsigned, 8),
+		Binary:  formatUnsigned(unsigned, 2),
+	}, nil
+}
+
+func formatUnsigned(value *big.Int, base int) string {
+	prefix := map[int]string{16: "0x", 8: "0o", 2: "0b"}[base]
+	if value.Sign() < 0 {
+		return "-" + prefix + strings.ToUpper(new(big.Int).Neg(value).Text(base))
+	}
+	return prefix + strings.ToUpper(value.Text(base))
+}
+
+// isProgrammerExpression reports whether "^" means xor in the expression, which is the case when it uses
+// a hex, octal or binary literal, another bitwise operator or an integer conversion
+func isProgrammerExpression(tokens []token) bool {
+	for i, t := range tokens {
+		if t.kind == numberToken && t.base != 0 && t.base != 10 {
+			return true
+		}
+		if t.kind == reservedToken {
+			switch t.str {
+			case "&", "|", "~", "<<", ">>":
+				return true
+			}
+		}
+		if t.kind == identToken && strings.EqualFold(t.str, "xor") {
+			return true
+		}
+		if t.kind == identToken && isConversionKeyword(t.str) && i > 0 && i+1 < len(tokens) && isIntegerFormatName(tokens[i+1].str) {
+			return true
+		}
+	}
+	return false
+}
+
+func isConversionKeyword(str string) bool {
+	switch strings.ToLower(str) {
+	case "in", "to", "as":
+		return true
+	}
+	return false
+}
+
+func isIntegerFormatName(str string) bool {
+	str = strings.ToLower(str)
+	_, isBase := integerBases[str]
+	_, isWidth := integerWidths[str]
+	return isBase || isWidth
+}
+
+// toBigInt converts an operand of a bitwise operator
+func toBigInt(val decimal.Decimal, operator nodeKind) (*big.Int, error) {
+	if !val.IsInteger() {
+		return nil, fmt.Errorf("%s needs integers, %s is not an integer", operator, val.String())
+	}
+	return val.BigInt(), nil
+}
+
+func calculateBitwise(kind nodeKind, left decimal.Decimal, right decimal.Decimal) (decimal.Decimal, error) {
+	l, err := toBigInt(left, kind)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if kind == notNode {
+		return decimal.NewFromBigInt(new(big.Int).Not(l), 0), nil
+	}
+	r, err := toBigInt(right, kind)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	result := new(big.Int)
+	switch kind {
+	case andNode:
+		result.And(l, r)
+	case orNode:
+		result.Or(l, r)
+	case xorNode:
+		result.Xor(l, r)
+	case shlNode, shrNode:
+		if r.Sign() < 0 || r.Cmp(big.NewInt(maxShift)) > 0 {
+			return decimal.Zero, fmt.Errorf("shift count must be between 0 and %d", maxShift)
+		}
+		if kind == shlNode {
+			result.Lsh(l, uint(r.Int64()))
+		} else {
+			result.Rsh(l, uint(r.Int64()))
+		}
+	default:
+		return decimal.Zero, fmt.Errorf("unknown bitwise operator %s", kind)
+	}
+	return decimal.NewFromBigInt(result, 0), nil
+}
@@ -0,0 +1,82 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgrammerExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		hasError bool
+	}{
+		{input: "0xFF", expected: "255"},
+		{input: "0b1010 + 0o17", expected: "25"},
+		{input: "0xF0 | 0x0F", expected: "255"},
+		{input: "0xFF & 0b1010", expected: "10"},
+		{input: "0b1100 ^ 0b1010", expected: "6"},
+		{input: "6 xor 3", expected: "5"},
+		{input: "1 << 4 + 1", expected: "32"},
+		{input: "256 >> 4", expected: "16"},
+		{input: "-16 >> 2", expected: "-4"},
+		{input: "~0", expected: "-1"},
+		{input: "0x10 ** 2", expected: "256"},
+		{input: "255 in hex", expected: "0xFF"},
+		{input: "10 to bin", expected: "0b1010"},
+		{input: "8 as oct", expected: "0o10"},
+		{input: "-1 in hex", expected: "0xFFFFFFFFFFFFFFFF"},
+		{input: "-1 in hex i8", expected: "0xFF"},
+		{input: "255 as i8", expected: "-1"},
+		{input: "-1 as u16", expected: "65535"},
+		{input: "0x1FF as u8 hex", expected: "0xFF"},
+		{input: "0xFF / 2", expected: "127.5"},
+		{input: "0xFF / 2 in hex", hasError: true},
+		{input: "1.5 & 1", hasError: true},
+		{input: "1 << 5000", hasError: true},
+		{input: "255 in base64", hasError: true},
+		{input: "0xZZ", hasError: true},
+	}
+
+	for _, tt := range tests {
+		line, err := NewSession().Evaluate(tt.input)
+		if tt.hasError {
+			assert.Error(t, err, tt.input)
+			continue
+		}
+		if assert.NoError(t, err, tt.input) {
+			assert.Equal(t, tt.expected, line.Result, tt.input)
+		}
+	}
+}
+
+func TestCaretIsPowerOutsideProgrammerExpressions(t *testing.T) {
+	val, err := Calculate("2^10")
+	require.NoError(t, err)
+	assert.Equal(t, "1024", val.String())
+
+	val, err = Calculate("0x2^10")
+	require.NoError(t, err)
+	assert.Equal(t, "8", val.String())
+}
+
+func TestIntegerRepresentations(t *testing.T) {
+	representations, err := defaultIntegerFormat().Representations(decimal.NewFromInt(-2))
+	require.NoError(t, err)
+	assert.Equal(t, IntegerRepresentations{
+		Decimal: "-2",
+		Hex:     "0xFFFFFFFFFFFFFFFE",
+		Octal:   "0o1777777777777777777776",
+		Binary:  "0b1111111111111111111111111111111111111111111111111111111111111110",
+	}, representations)
+
+	representations, err = IntegerFormat{Base: 16, Bits: 8}.Representations(decimal.NewFromInt(200))
+	require.NoError(t, err)
+	assert.Equal(t, IntegerRepresentations{Decimal: "200", Hex: "0xC8", Octal: "0o310", Binary: "0b11001000"}, representations)
+
+	_, err = defaultIntegerFormat().Representations(decimal.NewFromFloat(1.5))
+	assert.Error(t, err)
+}
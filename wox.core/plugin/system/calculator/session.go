@@ -0,0 +1,335 @@
+package calculator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ansVariable      = "ans"
+	maxFunctionDepth = 64
+)
+
+type userFunction struct {
+	Name   string
+	Params []string
+	Body   string
+	node   *node
+}
+
+// Definition returns the source of the function, e.g. "f(x) = x^2+1"
+func (f *userFunction) Definition() string {
+	return fmt.Sprintf("%s(%s) = %s", f.Name, strings.Join(f.Params, ", "), f.Body)
+}
+
+// environment holds the variables and user functions an expression is calculated with
+type environment struct {
+	variables     map[string]decimal.Decimal
+	functions     map[string]*userFunction
+	functionOrder []string // definition order, functions may call functions defined before them
+	depth         int
+}
+
+func newEnvironment() *environment {
+	return &environment{
+		variables: map[string]decimal.Decimal{},
+		functions: map[string]*userFunction{},
+	}
+}
+
+func (e *environment) clone() *environment {
+	c := newEnvironment()
+	for name, val := range e.variables {
+		c.variables[name] = val
+	}
+	for name, f := range e.functions {
+		c.functions[name] = f
+	}
+	c.functionOrder = append(c.functionOrder, e.functionOrder...)
+	return c
+}
+
+func (e *environment) getVariable(name string) (decimal.Decimal, bool) {
+	if e == nil {
+		return decimal.Zero, false
+	}
+	val, ok := e.variables[name]
+	return val, ok
+}
+
+func (e *environment) getFunction(name string) (*userFunction, bool) {
+	if e == nil {
+		return nil, false
+	}
+	f, ok := e.functions[name]
+	return f, ok
+}
+
+func (e *environment) setFunction(f *userFunction) {
+	if _, exists := e.functions[f.Name]; !exists {
+		e.functionOrder = append(e.functionOrder, f.Name)
+	}
+	e.functions[f.Name] = f
+}
+
+// callFunction calculates a user function, parameters shadow the variables of the session
+func (e *environment) callFunction(name string, args []decimal.Decimal) (decimal.Decimal, error) {
+	f, ok := e.getFunction(name)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("unknown function %s", name)
+	}
+	if len(args) != len(f.Params) {
+		return decimal.Zero, fmt.Errorf("%s should have %d argument(s) but has %d arguments(s)", name, len(f.Params), len(args))
+	}
+	if e.depth >= maxFunctionDepth {
+		return decimal.Zero, fmt.Errorf("too many nested calls of %s", name)
+	}
+
+	scope := e.clone()
+	scope.depth = e.depth + 1
+	for i, param := range f.Params {
+		scope.variables[param] = args[i]
+	}
+	return calculate(f.node, scope)
+}
+
+type SessionLineKind string
+
+const (
+	SessionLineKindExpression SessionLineKind = "expression"
+	SessionLineKindAssignment SessionLineKind = "assignment"
+	SessionLineKindFunction   SessionLineKind = "function"
+)
+
+// SessionLine is one evaluated statement of a session
+type SessionLine struct {
+	Kind   SessionLineKind
+	Input  string
+	Result string // the value, or the definition for functions
+	Value  decimal.Decimal
+	Format *IntegerFormat // set for programmer expressions with an integer result
+}
+
+// Session evaluates statements one after another, like lines of a scratchpad.
+// Assignments ("rate = 0.07") define variables, "ans" is the value of the previous line and "f(x) = x^2+1" defines a function.
+type Session struct {
+	env   *environment
+	lines []SessionLine
+}
+
+func NewSession() *Session {
+	return &Session{env: newEnvironment()}
+}
+
+// Clone returns a copy of the session, evaluating statements on the copy doesn't change the original
+func (s *Session) Clone() *Session {
+	return &Session{
+		env:   s.env.clone(),
+		lines: append([]SessionLine{}, s.lines...),
+	}
+}
+
+func (s *Session) Lines() []SessionLine {
+	return s.lines
+}
+
+// ClearLines forgets the lines and variables, user functions are kept
+func (s *Session) ClearLines() {
+	s.lines = nil
+	s.env.variables = map[string]decimal.Decimal{}
+}
+
+// FunctionDefinitions returns the definitions of the user functions in definition order
+func (s *Session) FunctionDefinitions() []string {
+	var definitions []string
+	for _, name := range s.env.functionOrder {
+		definitions = append(definitions, s.env.functions[name].Definition())
+	}
+	return definitions
+}
+
+// RemoveFunction removes a user function, it returns false if there is no such function
+func (s *Session) RemoveFunction(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := s.env.functions[name]; !ok {
+		return false
+	}
+	delete(s.env.functions, name)
+	for i, functionName := range s.env.functionOrder {
+		if functionName == name {
+			s.env.functionOrder = append(s.env.functionOrder[:i], s.env.functionOrder[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// EvaluateAll evaluates the statements separated by ";" or new lines, it stops at the first error
+func (s *Session) EvaluateAll(input string) ([]SessionLine, error) {
+	var lines []SessionLine
+	for _, statement := range strings.FieldsFunc(input, func(r rune) bool { return r == '\n' || r == ';' }) {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		line, err := s.Evaluate(statement)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty input")
+	}
+	return lines, nil
+}
+
+// Evaluate evaluates one statement and adds it to the session
+func (s *Session) Evaluate(statement string) (SessionLine, error) {
+	statement = strings.TrimSpace(statement)
+	tokens, err := tokenize(statement)
+	if err != nil {
+		return SessionLine{}, err
+	}
+
+	var line SessionLine
+	if name, params, body, ok := splitFunctionDefinition(statement, tokens); ok {
+		line, err = s.defineFunction(statement, name, params, body)
+	} else if isAssignment(tokens) {
+		line, err = s.assign(statement, tokens)
+	} else {
+		line, err = s.calculateLine(SessionLineKindExpression, statement, tokens)
+	}
+	if err != nil {
+		return SessionLine{}, err
+	}
+
+	if line.Kind != SessionLineKindFunction {
+		s.env.variables[ansVariable] = line.Value
+	}
+	s.lines = append(s.lines, line)
+	return line, nil
+}
+
+// calculateLine calculates an expression, integer results of programmer expressions are formatted with their conversion
+func (s *Session) calculateLine(kind SessionLineKind, statement string, tokens []token) (SessionLine, error) {
+	p := newParser(tokens, s.env)
+	n, err := p.parse()
+	if err != nil {
+		return SessionLine{}, err
+	}
+	val, err := calculate(n, s.env)
+	if err != nil {
+		return SessionLine{}, err
+	}
+
+	line := SessionLine{Kind: kind, Input: statement, Result: val.String(), Value: val}
+	format := p.format
+	if format == nil && p.programmer && val.IsInteger() {
+		format = defaultIntegerFormat()
+	}
+	if format != nil {
+		result, formatErr := format.Format(val)
+		if formatErr != nil {
+			return SessionLine{}, formatErr
+		}
+		line.Result = result
+		line.Format = format
+	}
+	return line, nil
+}
+
+func (s *Session) assign(statement string, tokens []token) (SessionLine, error) {
+	name := strings.ToLower(tokens[0].str)
+	if err := checkUserName(name); err != nil {
+		return SessionLine{}, err
+	}
+
+	line, err := s.calculateLine(SessionLineKindAssignment, statement, append([]token{}, tokens[2:]...))
+	if err != nil {
+		return SessionLine{}, err
+	}
+	s.env.variables[name] = line.Value
+	return line, nil
+}
+
+func (s *Session) defineFunction(statement string, name string, params []string, body string) (SessionLine, error) {
+	if err := checkUserName(name); err != nil {
+		return SessionLine{}, err
+	}
+	seen := map[string]bool{}
+	for _, param := range params {
+		if _, ok := constants[param]; ok {
+			return SessionLine{}, fmt.Errorf("%s is a constant and can't be a parameter", param)
+		}
+		if seen[param] {
+			return SessionLine{}, fmt.Errorf("duplicate parameter %s", param)
+		}
+		seen[param] = true
+	}
+
+	bodyTokens, err := tokenize(body)
+	if err != nil {
+		return SessionLine{}, err
+	}
+	p := newParser(bodyTokens, s.env)
+	p.allowUnknownFunctions = true
+	n, err := p.parse()
+	if err != nil {
+		return SessionLine{}, err
+	}
+	if p.format != nil {
+		return SessionLine{}, fmt.Errorf("the body of %s can't have an integer conversion", name)
+	}
+
+	f := &userFunction{Name: name, Params: params, Body: body, node: n}
+	s.env.setFunction(f)
+	return SessionLine{Kind: SessionLineKindFunction, Input: statement, Result: f.Definition()}, nil
+}
+
+// checkUserName checks a variable or function name doesn't shadow a built-in name
+func checkUserName(name string) error {
+	if name == ansVariable {
+		return fmt.Errorf("%s is the previous result and can't be assigned", name)
+	}
+	if _, ok := constants[name]; ok {
+		return fmt.Errorf("%s is a constant and can't be assigned", name)
+	}
+	if _, ok := functions[name]; ok {
+		return fmt.Errorf("%s is a built-in function and can't be redefined", name)
+	}
+	return nil
+}
+
+func isAssignment(tokens []token) bool {
+	return len(tokens) > 2 && tokens[0].kind == identToken && tokens[1].kind == reservedToken && tokens[1].str == "="
+}
+
+// splitFunctionDefinition matches "name(param, ...) = body"
+func splitFunctionDefinition(statement string, tokens []token) (name string, params []string, body string, ok bool) {
+	if len(tokens) < 5 || tokens[0].kind != identToken || tokens[1].str != "(" {
+		return "", nil, "", false
+	}
+
+	i := 2
+	for tokens[i].kind == identToken {
+		params = append(params, strings.ToLower(tokens[i].str))
+		i++
+		if tokens[i].kind == reservedToken && tokens[i].str == "," {
+			i++
+			continue
+		}
+		break
+	}
+	if tokens[i].str != ")" || tokens[i+1].kind != reservedToken || tokens[i+1].str != "=" {
+		return "", nil, "", false
+	}
+
+	// the body is everything after the first "=", which can only be the one of the definition
+	body = strings.TrimSpace(statement[strings.Index(statement, "=")+1:])
+	if body == "" {
+		return "", nil, "", false
+	}
+	return strings.ToLower(tokens[0].str), params, body, true
+}
@@ -0,0 +1,130 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionVariables(t *testing.T) {
+	session := NewSession()
+
+	line, err := session.Evaluate("rate = 0.07")
+	require.NoError(t, err)
+	assert.Equal(t, SessionLineKindAssignment, line.Kind)
+	assert.Equal(t, "0.07", line.Result)
+
+	line, err = session.Evaluate("price = 200")
+	require.NoError(t, err)
+	assert.Equal(t, "200", line.Result)
+
+	line, err = session.Evaluate("price * rate")
+	require.NoError(t, err)
+	assert.Equal(t, SessionLineKindExpression, line.Kind)
+	assert.Equal(t, "14", line.Result)
+
+	line, err = session.Evaluate("ans + price")
+	require.NoError(t, err)
+	assert.Equal(t, "214", line.Result)
+
+	_, err = session.Evaluate("unknown * 2")
+	assert.Error(t, err)
+	assert.Len(t, session.Lines(), 4)
+}
+
+func TestSessionFunctions(t *testing.T) {
+	session := NewSession()
+
+	line, err := session.Evaluate("f(x) = x^2+1")
+	require.NoError(t, err)
+	assert.Equal(t, SessionLineKindFunction, line.Kind)
+	assert.Equal(t, "f(x) = x^2+1", line.Result)
+
+	line, err = session.Evaluate("f(3)")
+	require.NoError(t, err)
+	assert.Equal(t, "10", line.Result)
+
+	_, err = session.Evaluate("hyp(a, b) = sqrt(a^2 + b^2)")
+	require.NoError(t, err)
+	line, err = session.Evaluate("hyp(3, 4) + f(1)")
+	require.NoError(t, err)
+	assert.Equal(t, "7", line.Result)
+
+	// parameters shadow variables
+	_, err = session.Evaluate("x = 100")
+	require.NoError(t, err)
+	line, err = session.Evaluate("f(2)")
+	require.NoError(t, err)
+	assert.Equal(t, "5", line.Result)
+
+	_, err = session.Evaluate("f(1, 2)")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"f(x) = x^2+1", "hyp(a, b) = sqrt(a^2 + b^2)"}, session.FunctionDefinitions())
+}
+
+func TestSessionRecursionLimit(t *testing.T) {
+	session := NewSession()
+
+	_, err := session.Evaluate("g(x) = g(x) + 1")
+	require.NoError(t, err)
+	_, err = session.Evaluate("g(1)")
+	assert.Error(t, err)
+}
+
+func TestSessionProtectedNames(t *testing.T) {
+	session := NewSession()
+
+	for _, statement := range []string{"pi = 3", "ans = 1", "sqrt = 2", "sin(x) = x", "f(pi) = pi", "f(x, x) = x"} {
+		_, err := session.Evaluate(statement)
+		assert.Error(t, err, statement)
+	}
+}
+
+func TestSessionEvaluateAll(t *testing.T) {
+	session := NewSession()
+
+	lines, err := session.EvaluateAll("a = 2; b = 3\na * b")
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "6", lines[2].Result)
+
+	_, err = session.EvaluateAll(" ; ")
+	assert.Error(t, err)
+}
+
+func TestSessionClone(t *testing.T) {
+	session := NewSession()
+	_, err := session.Evaluate("a = 1")
+	require.NoError(t, err)
+
+	scratch := session.Clone()
+	_, err = scratch.EvaluateAll("a = 2; f(x) = x")
+	require.NoError(t, err)
+
+	line, err := session.Evaluate("a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", line.Result)
+	assert.Empty(t, session.FunctionDefinitions())
+
+	// functions survive clearing the lines, so they can be restored from their definitions
+	scratch.ClearLines()
+	assert.Empty(t, scratch.Lines())
+	_, err = scratch.Evaluate("a")
+	assert.Error(t, err)
+
+	restored := NewSession()
+	for _, definition := range scratch.FunctionDefinitions() {
+		_, err = restored.Evaluate(definition)
+		require.NoError(t, err)
+	}
+	line, err = restored.Evaluate("f(5)")
+	require.NoError(t, err)
+	assert.Equal(t, "5", line.Result)
+
+	assert.True(t, restored.RemoveFunction("f"))
+	assert.False(t, restored.RemoveFunction("f"))
+	_, err = restored.Evaluate("f(5)")
+	assert.Error(t, err)
+}
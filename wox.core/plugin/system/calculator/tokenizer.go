@@ -2,6 +2,8 @@ package calculator
 
 import (
 	"errors"
+	"math/big"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
@@ -22,6 +24,7 @@ type token struct {
 	kind tokenKind
 	val  decimal.Decimal
 	str  string
+	base int // base of a number literal, 16 for "0xFF"
 }
 
 type invalidTokenError struct {
@@ -43,7 +46,10 @@ func (e *invalidTokenError) Error() string {
 	return ""
 }
 
-const operators = "+-*/^(),"
+const operators = "+-*/^(),=&|~"
+
+// twoCharOperators are checked before the single char operators, "**" is the power operator in programmer expressions
+var twoCharOperators = []string{"<<", ">>", "**"}
 
 func isOperator(char rune) bool {
 	for _, op := range operators {
@@ -89,8 +95,38 @@ func numberPrefix(chars []rune, i *int, n int) (float64, error) {
 	return val, nil
 }
 
+var literalPrefixes = map[rune]int{
+	'x': 16,
+	'o': 8,
+	'b': 2,
+}
+
+// integerLiteral reads hex, octal and binary literals like "0xFF", "0o17" or "0b1010"
+func integerLiteral(chars []rune, i *int, n int) (*big.Int, int, bool) {
+	if *i+2 >= n || chars[*i] != '0' {
+		return nil, 0, false
+	}
+	base, ok := literalPrefixes[unicode.ToLower(chars[*i+1])]
+	if !ok {
+		return nil, 0, false
+	}
+
+	start := *i + 2
+	current := start
+	for current < n && isAlNum(chars[current]) {
+		current++
+	}
+	val, ok := new(big.Int).SetString(string(chars[start:current]), base)
+	if !ok {
+		return nil, 0, false
+	}
+
+	*i = current
+	return val, base, true
+}
+
 func isAlpha(char rune) bool {
-	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || char == '_'
 }
 
 func isAlNum(char rune) bool {
@@ -120,12 +156,25 @@ func tokenize(input string) ([]token, error) {
 			continue
 		}
 
+		if i+1 < n {
+			if pair := string(chars[i : i+2]); slices.Contains(twoCharOperators, pair) {
+				tokens = append(tokens, token{kind: reservedToken, str: pair})
+				i += 2
+				continue
+			}
+		}
+
 		if isOperator(char) {
 			tokens = append(tokens, token{kind: reservedToken, str: string(char)})
 			i++
 			continue
 		}
 
+		if val, base, ok := integerLiteral(chars, &i, n); ok {
+			tokens = append(tokens, token{kind: numberToken, val: decimal.NewFromBigInt(val, 0), base: base})
+			continue
+		}
+
 		if val, err := numberPrefix(chars, &i, n); err == nil {
 			tokens = append(tokens, token{kind: numberToken, val: decimal.NewFromFloat(val)})
 			continue
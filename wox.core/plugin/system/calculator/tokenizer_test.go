@@ -39,6 +39,31 @@ func TestTokenize(t *testing.T) {
 			},
 			hasError: false,
 		},
+		{
+			input: "0xFF << 0b10",
+			expected: []token{
+				{kind: numberToken, val: decimal.NewFromInt(255)},
+				{kind: reservedToken, str: "<<"},
+				{kind: numberToken, val: decimal.NewFromInt(2)},
+				{kind: eosToken},
+			},
+			hasError: false,
+		},
+		{
+			input: "~a & b | c ** 2",
+			expected: []token{
+				{kind: reservedToken, str: "~"},
+				{kind: identToken, str: "a"},
+				{kind: reservedToken, str: "&"},
+				{kind: identToken, str: "b"},
+				{kind: reservedToken, str: "|"},
+				{kind: identToken, str: "c"},
+				{kind: reservedToken, str: "**"},
+				{kind: numberToken, val: decimal.NewFromInt(2)},
+				{kind: eosToken},
+			},
+			hasError: false,
+		},
 	}
 
 	for _, test := range tests {
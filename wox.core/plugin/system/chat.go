@@ -203,11 +203,12 @@ func (r *AIChatPlugin) GetMetadata() plugin.Metadata {
 							Tooltip: "i18n:plugin_ai_chat_mcp_server_command_tooltip",
 						},
 						{
-							Key:     "environmentVariables",
-							Label:   "i18n:plugin_ai_chat_mcp_server_environment_variables",
-							Type:    definition.PluginSettingValueTableColumnTypeTextList,
-							Width:   160,
-							Tooltip: "i18n:plugin_ai_chat_mcp_server_environment_variables_tooltip",
+							Key:      "environmentVariables",
+							Label:    "i18n:plugin_ai_chat_mcp_server_environment_variables",
+							Type:     definition.PluginSettingValueTableColumnTypeTextList,
+							Width:    160,
+							Tooltip:  "i18n:plugin_ai_chat_mcp_server_environment_variables_tooltip",
+							IsSecret: true,
 						},
 						{
 							Key:          "url",
@@ -7,19 +7,28 @@ import (
 	"image"
 	"os"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 	"wox/common"
 	"wox/plugin"
 	"wox/plugin/system"
+	"wox/plugin/system/clipboard/search"
+	"wox/plugin/system/clipboard/sensitive"
 	"wox/setting/definition"
 	"wox/util"
 	"wox/util/clipboard"
+	"wox/util/fileicon"
+	"wox/util/shell"
+	"wox/util/window"
 
 	"github.com/cdfmlr/ellipsis"
 	"github.com/disintegration/imaging"
 	"github.com/google/uuid"
+	"github.com/samber/lo"
 )
 
 var clipboardIcon = common.PluginClipboardIcon
@@ -31,6 +40,18 @@ var primaryActionSettingKey = "primary_action"
 var primaryActionValueCopy = "copy"
 var primaryActionValuePaste = "paste"
 var favoritesSettingKey = "favorites"
+var ignoredAppsSettingKey = "ignored_apps"
+var detectSensitiveContentSettingKey = "detect_sensitive_content"
+var sensitiveExpireMinutesSettingKey = "sensitive_expire_minutes"
+
+// defaultIgnoredApps are common password managers, their copies are never recorded
+var defaultIgnoredApps = []string{"1Password", "Bitwarden", "KeePassXC", "KeePass", "LastPass", "Dashlane", "Keychain Access", "Enpass"}
+
+// browsePageSize is the number of records on a page of the browse command
+var browsePageSize = 100
+
+// maxFileListCount limits the files listed in the preview and the copy path actions of a copied files record
+var maxFileListCount = 10
 
 func init() {
 	plugin.AllSystemPlugin = append(plugin.AllSystemPlugin, &ClipboardPlugin{
@@ -47,26 +68,29 @@ type ImageCacheEntry struct {
 
 // FavoriteClipboardItem represents a favorite clipboard item stored in settings
 type FavoriteClipboardItem struct {
-	ID        string  `json:"id"`
-	Type      string  `json:"type"`
-	Content   string  `json:"content"`
-	FilePath  string  `json:"filePath,omitempty"`
-	IconData  *string `json:"iconData,omitempty"`
-	Width     *int    `json:"width,omitempty"`
-	Height    *int    `json:"height,omitempty"`
-	FileSize  *int64  `json:"fileSize,omitempty"`
-	Timestamp int64   `json:"timestamp"`
-	CreatedAt int64   `json:"createdAt"`
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Content   string            `json:"content"`
+	FilePath  string            `json:"filePath,omitempty"`
+	IconData  *string           `json:"iconData,omitempty"`
+	Width     *int              `json:"width,omitempty"`
+	Height    *int              `json:"height,omitempty"`
+	FileSize  *int64            `json:"fileSize,omitempty"`
+	Formats   map[string]string `json:"formats,omitempty"`
+	SourceApp string            `json:"sourceApp,omitempty"`
+	Timestamp int64             `json:"timestamp"`
+	CreatedAt int64             `json:"createdAt"`
 }
 
 // ClipboardDBInterface defines the interface for clipboard database operations
 type ClipboardDBInterface interface {
 	Insert(ctx context.Context, record ClipboardRecord) error
+	GetFormats(ctx context.Context, ids []string) (map[string]map[string]string, error)
 	Update(ctx context.Context, record ClipboardRecord) error
 	UpdateTimestamp(ctx context.Context, id string, timestamp int64) error
 	Delete(ctx context.Context, id string) error
 	GetRecent(ctx context.Context, limit, offset int) ([]ClipboardRecord, error)
-	SearchText(ctx context.Context, searchTerm string, limit int) ([]ClipboardRecord, error)
+	Search(ctx context.Context, query search.Query, limit, offset int) ([]ClipboardRecord, error)
 	GetByID(ctx context.Context, id string) (*ClipboardRecord, error)
 	DeleteExpired(ctx context.Context, textDays, imageDays int) (int64, error)
 	EnforceMaxCount(ctx context.Context, maxCount int) (int64, error)
@@ -80,6 +104,8 @@ type ClipboardPlugin struct {
 	maxHistoryCount int
 	// Cache for generated preview and icon images to avoid regeneration
 	imageCache map[string]*ImageCacheEntry
+	// Init runs again when a backup is restored, the watcher and cleanup routine read c.db and must only start once
+	startOnce sync.Once
 }
 
 func (c *ClipboardPlugin) GetMetadata() plugin.Metadata {
@@ -107,6 +133,10 @@ func (c *ClipboardPlugin) GetMetadata() plugin.Metadata {
 				Command:     "fav",
 				Description: "List favorite clipboard history",
 			},
+			{
+				Command:     "browse",
+				Description: "Browse clipboard history by date, supports filters like type:image app:Slack after:yesterday",
+			},
 		},
 		SupportedOS: []string{
 			"Windows",
@@ -176,6 +206,50 @@ func (c *ClipboardPlugin) GetMetadata() plugin.Metadata {
 					},
 				},
 			},
+			{
+				Type: definition.PluginSettingDefinitionTypeNewLine,
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeCheckBox,
+				Value: &definition.PluginSettingValueCheckBox{
+					Key:          detectSensitiveContentSettingKey,
+					Label:        "i18n:plugin_clipboard_detect_sensitive_content",
+					Tooltip:      "i18n:plugin_clipboard_detect_sensitive_content_tooltip",
+					DefaultValue: "true",
+				},
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeNewLine,
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeTextBox,
+				Value: &definition.PluginSettingValueTextBox{
+					Key:          sensitiveExpireMinutesSettingKey,
+					Label:        "i18n:plugin_clipboard_sensitive_expire",
+					Suffix:       "i18n:plugin_clipboard_minutes",
+					Tooltip:      "i18n:plugin_clipboard_sensitive_expire_tooltip",
+					DefaultValue: "5",
+					Style: definition.PluginSettingValueStyle{
+						Width: 50,
+					},
+				},
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeTable,
+				Value: &definition.PluginSettingValueTable{
+					Key:          ignoredAppsSettingKey,
+					Title:        "i18n:plugin_clipboard_ignored_apps",
+					Tooltip:      "i18n:plugin_clipboard_ignored_apps_tooltip",
+					DefaultValue: getDefaultIgnoredAppsSetting(),
+					Columns: []definition.PluginSettingValueTableColumn{
+						{
+							Key:   "App",
+							Label: "i18n:plugin_clipboard_ignored_app_name",
+							Type:  definition.PluginSettingValueTableColumnTypeText,
+						},
+					},
+				},
+			},
 		},
 	}
 }
@@ -201,23 +275,28 @@ func (c *ClipboardPlugin) Init(ctx context.Context, initParams plugin.InitParams
 		}
 	})
 
+	// Remove the sensitive records that expired while Wox was not running
+	c.deleteExpiredRecords(ctx)
+
+	// Log initial database statistics
+	c.logDatabaseStats(ctx)
+
+	c.startOnce.Do(func() {
+		c.startWatching(ctx)
+	})
+}
+
+func (c *ClipboardPlugin) startWatching(ctx context.Context) {
 	// Start periodic cleanup routine
 	util.Go(ctx, "clipboard cleanup routine", func() {
 		c.startCleanupRoutine(ctx)
 	})
 
-	// Log initial database statistics
-	c.logDatabaseStats(ctx)
-
 	clipboard.Watch(func(data clipboard.Data) {
 		c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("clipboard data changed, type=%s", data.GetType()))
 
-		// ignore file type
-		if data.GetType() == clipboard.ClipboardTypeFile {
-			return
-		}
-
-		if data.GetType() == clipboard.ClipboardTypeText && !c.isKeepTextHistory(ctx) {
+		// copied files are paths and rich text is text, they follow the text history settings
+		if (data.GetType() == clipboard.ClipboardTypeText || data.GetType() == clipboard.ClipboardTypeRichText || data.GetType() == clipboard.ClipboardTypeFile) && !c.isKeepTextHistory(ctx) {
 			return
 		}
 		if data.GetType() == clipboard.ClipboardTypeImage && !c.isKeepImageHistory(ctx) {
@@ -225,11 +304,32 @@ func (c *ClipboardPlugin) Init(ctx context.Context, initParams plugin.InitParams
 		}
 
 		// Validate text data
-		if data.GetType() == clipboard.ClipboardTypeText {
-			textData := data.(*clipboard.TextData)
-			if len(textData.Text) == 0 || strings.TrimSpace(textData.Text) == "" {
+		if data.GetType() == clipboard.ClipboardTypeText || data.GetType() == clipboard.ClipboardTypeRichText {
+			if strings.TrimSpace(data.String()) == "" {
+				return
+			}
+		}
+		if data.GetType() == clipboard.ClipboardTypeFile && len(data.(*clipboard.FilePathData).FilePaths) == 0 {
+			return
+		}
+
+		// Skip or flag sensitive content, the active window is the app the content was copied from
+		var expiresAt *int64
+		sourceApp := window.GetActiveWindowName()
+		sensitiveResult := c.getSensitiveRules(ctx).Check(sourceApp, c.getSensitiveText(data), data.IsConcealed())
+		if sensitiveResult.Verdict == sensitive.VerdictSkip {
+			c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("clipboard content skipped, reason=%s", sensitiveResult.Reason))
+			return
+		}
+		if sensitiveResult.Verdict == sensitive.VerdictSensitive {
+			expireMinutes := c.getSensitiveExpireMinutes(ctx)
+			if expireMinutes <= 0 {
+				c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("sensitive clipboard content skipped, reason=%s", sensitiveResult.Reason))
 				return
 			}
+			expireTimestamp := util.GetSystemTimestamp() + int64(expireMinutes)*60*1000
+			expiresAt = &expireTimestamp
+			c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("sensitive clipboard content will expire in %d minutes, reason=%s", expireMinutes, sensitiveResult.Reason))
 		}
 
 		// Check for duplicate content by querying the most recent record
@@ -241,22 +341,38 @@ func (c *ClipboardPlugin) Init(ctx context.Context, initParams plugin.InitParams
 		// Create new record (always non-favorite initially)
 		record := ClipboardRecord{
 			ID:         uuid.NewString(),
-			Type:       string(data.GetType()),
+			Type:       c.getRecordType(data),
 			Timestamp:  util.GetSystemTimestamp(),
 			IsFavorite: false,
 			CreatedAt:  time.Now(),
+			SourceApp:  sourceApp,
+			ExpiresAt:  expiresAt,
 		}
 
 		// Handle different data types
-		if data.GetType() == clipboard.ClipboardTypeText {
-			textData := data.(*clipboard.TextData)
-			record.Content = textData.Text
+		if data.GetType() == clipboard.ClipboardTypeText || data.GetType() == clipboard.ClipboardTypeRichText {
+			record.Content = data.String()
+			if richTextData, ok := data.(*clipboard.RichTextData); ok {
+				record.Formats = map[string]string{
+					ClipboardFormatHTML: richTextData.HTML,
+					ClipboardFormatRTF:  richTextData.RTF,
+				}
+				if richTextData.HTML != "" {
+					record.Formats[ClipboardFormatMarkdown] = clipboard.HTMLToMarkdown(richTextData.HTML)
+				}
+			}
 
 			// Try to get active window icon for text clipboard
 			if iconImage, iconErr := system.GetActiveWindowIcon(ctx); iconErr == nil {
 				iconStr := iconImage.String()
 				record.IconData = &iconStr
 			}
+		} else if data.GetType() == clipboard.ClipboardTypeFile {
+			// Only keep the paths, the files may be moved or deleted later, so existence is checked when rendering
+			filePaths := data.(*clipboard.FilePathData).FilePaths
+			fileSize := c.getFilesSize(filePaths)
+			record.Content = strings.Join(filePaths, "\n")
+			record.FileSize = &fileSize
 		} else if data.GetType() == clipboard.ClipboardTypeImage {
 			// Save image to disk
 			imageData := data.(*clipboard.ImageData)
@@ -317,6 +433,13 @@ func (c *ClipboardPlugin) Init(ctx context.Context, initParams plugin.InitParams
 			c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("enforced max count, deleted %d old records", deletedCount))
 		}
 
+		// Delete sensitive records as soon as they expire
+		if expiresAt != nil {
+			time.AfterFunc(time.Duration(*expiresAt-util.GetSystemTimestamp())*time.Millisecond, func() {
+				c.deleteExpiredRecords(ctx)
+			})
+		}
+
 		c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("saved clipboard %s to database", data.GetType()))
 	})
 }
@@ -344,6 +467,10 @@ func (c *ClipboardPlugin) Query(ctx context.Context, query plugin.Query) []plugi
 		return results
 	}
 
+	if query.Command == "browse" {
+		return c.queryBrowse(ctx, query)
+	}
+
 	if query.Search == "" {
 		// Get favorites first from settings
 		favorites, err := c.getFavoriteItems(ctx)
@@ -361,8 +488,12 @@ func (c *ClipboardPlugin) Query(ctx context.Context, query plugin.Query) []plugi
 		if err != nil {
 			c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to get recent records: %s", err.Error()))
 		} else {
+			c.loadRecordFormats(ctx, recent)
 			for _, record := range recent {
 				// All records in database are non-favorite now
+				if c.isRecordExpired(record) {
+					continue
+				}
 				results = append(results, c.convertRecordToResult(ctx, record, query))
 			}
 		}
@@ -370,15 +501,15 @@ func (c *ClipboardPlugin) Query(ctx context.Context, query plugin.Query) []plugi
 		return results
 	}
 
-	// Search in text content
+	// Search text content and filters, e.g. "invoice type:text app:Slack"
+	searchQuery := search.Parse(query.Search, time.Now())
 	var allResults []ClipboardRecord
 
 	// Search in favorites from settings
 	favorites, err := c.getFavoriteItems(ctx)
 	if err == nil {
 		for _, favoriteItem := range favorites {
-			if favoriteItem.Type == string(clipboard.ClipboardTypeText) &&
-				strings.Contains(strings.ToLower(favoriteItem.Content), strings.ToLower(query.Search)) {
+			if searchQuery.Match(favoriteItem.Type, favoriteItem.Content, favoriteItem.SourceApp, favoriteItem.Timestamp) {
 				record := c.convertFavoriteToRecord(favoriteItem)
 				allResults = append(allResults, record)
 			}
@@ -386,20 +517,106 @@ func (c *ClipboardPlugin) Query(ctx context.Context, query plugin.Query) []plugi
 	}
 
 	// Search in database records
-	searchResults, err := c.db.SearchText(ctx, query.Search, 100)
+	searchResults, err := c.db.Search(ctx, searchQuery, 100, 0)
 	if err != nil {
 		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to search text: %s", err.Error()))
 	} else {
+		c.loadRecordFormats(ctx, searchResults)
 		allResults = append(allResults, searchResults...)
 	}
 
 	for _, record := range allResults {
+		if c.isRecordExpired(record) {
+			continue
+		}
 		results = append(results, c.convertRecordToResult(ctx, record, query))
 	}
 
 	return results
 }
 
+// queryBrowse lists a page of clipboard history grouped by the copy date, the search is applied as filters
+func (c *ClipboardPlugin) queryBrowse(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	var results []plugin.QueryResult
+
+	searchQuery := search.Parse(query.Search, time.Now())
+	records, err := c.db.Search(ctx, searchQuery, browsePageSize+1, (searchQuery.Page-1)*browsePageSize)
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to browse records: %s", err.Error()))
+		return results
+	}
+
+	// one more record than a page is queried to know whether there is a next page
+	hasNextPage := len(records) > browsePageSize
+	if hasNextPage {
+		records = records[:browsePageSize]
+	}
+	c.loadRecordFormats(ctx, records)
+
+	for _, record := range records {
+		if c.isRecordExpired(record) {
+			continue
+		}
+		result := c.convertRecordToResult(ctx, record, query)
+		result.Group, result.GroupScore = c.getBrowseGroup(record)
+		results = append(results, result)
+	}
+
+	if searchQuery.Page > 1 {
+		results = append(results, c.getBrowsePageResult(ctx, query, searchQuery, searchQuery.Page-1, "i18n:plugin_clipboard_browse_previous_page", 2))
+	}
+	if hasNextPage {
+		results = append(results, c.getBrowsePageResult(ctx, query, searchQuery, searchQuery.Page+1, "i18n:plugin_clipboard_browse_next_page", 1))
+	}
+
+	return results
+}
+
+// getBrowseGroup groups records by the copy date, newer dates first
+func (c *ClipboardPlugin) getBrowseGroup(record ClipboardRecord) (string, int64) {
+	copyTime := time.UnixMilli(record.Timestamp)
+	year, month, day := copyTime.Date()
+	dayStart := time.Date(year, month, day, 0, 0, 0, 0, copyTime.Location())
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if dayStart.Equal(today) {
+		return "i18n:plugin_clipboard_group_today", dayStart.UnixMilli()
+	}
+	if dayStart.Equal(today.AddDate(0, 0, -1)) {
+		return "i18n:plugin_clipboard_group_yesterday", dayStart.UnixMilli()
+	}
+
+	return dayStart.Format("2006-01-02 Monday"), dayStart.UnixMilli()
+}
+
+// getBrowsePageResult returns the result that changes the query to another page of the browse command, it is listed after the records
+func (c *ClipboardPlugin) getBrowsePageResult(ctx context.Context, query plugin.Query, searchQuery search.Query, page int, title string, score int64) plugin.QueryResult {
+	searchQuery.Page = page
+	pageQuery := strings.TrimSpace(fmt.Sprintf("%s %s %s", query.TriggerKeyword, query.Command, searchQuery.String())) + " "
+
+	return plugin.QueryResult{
+		Title:      title,
+		SubTitle:   fmt.Sprintf(c.api.GetTranslation(ctx, "i18n:plugin_clipboard_browse_page"), page),
+		Icon:       clipboardIcon,
+		Group:      "i18n:plugin_clipboard_group_pages",
+		GroupScore: 0,
+		Score:      score,
+		Actions: []plugin.QueryResultAction{
+			{
+				Name:                   "i18n:plugin_clipboard_browse_open_page",
+				PreventHideAfterAction: true,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					c.api.ChangeQuery(ctx, common.PlainQuery{
+						QueryType: plugin.QueryTypeInput,
+						QueryText: pageQuery,
+					})
+				},
+			},
+		},
+	}
+}
+
 // isDuplicateContent checks if the content is duplicate by comparing with the most recent record
 func (c *ClipboardPlugin) isDuplicateContent(ctx context.Context, data clipboard.Data) bool {
 	// Check most recent record from database
@@ -439,13 +656,21 @@ func (c *ClipboardPlugin) isDuplicateContent(ctx context.Context, data clipboard
 		return false
 	}
 
-	if mostRecentRecord.Type != string(data.GetType()) {
+	if mostRecentRecord.Type != c.getRecordType(data) {
 		return false
 	}
 
-	if data.GetType() == clipboard.ClipboardTypeText {
-		textData := data.(*clipboard.TextData)
-		if mostRecentRecord.Content == textData.Text {
+	if data.GetType() == clipboard.ClipboardTypeText || data.GetType() == clipboard.ClipboardTypeRichText {
+		if mostRecentRecord.Content == data.String() {
+			// Update timestamp of existing record
+			c.updateRecordTimestamp(ctx, mostRecentRecord, util.GetSystemTimestamp())
+			return true
+		}
+	}
+
+	if data.GetType() == clipboard.ClipboardTypeFile {
+		fileData := data.(*clipboard.FilePathData)
+		if mostRecentRecord.Content == strings.Join(fileData.FilePaths, "\n") {
 			// Update timestamp of existing record
 			c.updateRecordTimestamp(ctx, mostRecentRecord, util.GetSystemTimestamp())
 			return true
@@ -465,23 +690,88 @@ func (c *ClipboardPlugin) isDuplicateContent(ctx context.Context, data clipboard
 	return false
 }
 
+// getRecordType returns the record type of clipboard data, rich text is stored as a text record with alternate formats
+func (c *ClipboardPlugin) getRecordType(data clipboard.Data) string {
+	if data.GetType() == clipboard.ClipboardTypeRichText {
+		return string(clipboard.ClipboardTypeText)
+	}
+	return string(data.GetType())
+}
+
+// getRecordFormats returns the alternate formats of a text record, favorites keep them in settings
+func (c *ClipboardPlugin) getRecordFormats(ctx context.Context, record ClipboardRecord) map[string]string {
+	if record.IsFavorite || record.Formats != nil {
+		return record.Formats
+	}
+
+	formats, err := c.db.GetFormats(ctx, []string{record.ID})
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to get clipboard formats: %s", err.Error()))
+		return nil
+	}
+	return formats[record.ID]
+}
+
+// loadRecordFormats loads the alternate formats of the text records of a result page in one query
+func (c *ClipboardPlugin) loadRecordFormats(ctx context.Context, records []ClipboardRecord) {
+	var ids []string
+	for _, record := range records {
+		if record.Type == string(clipboard.ClipboardTypeText) && !record.IsFavorite && record.Formats == nil {
+			ids = append(ids, record.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	formats, err := c.db.GetFormats(ctx, ids)
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to get clipboard formats: %s", err.Error()))
+		return
+	}
+	for i := range records {
+		if recordFormats, ok := formats[records[i].ID]; ok {
+			records[i].Formats = recordFormats
+		}
+	}
+}
+
 // convertRecordToResult converts a database record to a query result
 func (c *ClipboardPlugin) convertRecordToResult(ctx context.Context, record ClipboardRecord, query plugin.Query) plugin.QueryResult {
+	var result plugin.QueryResult
 	if record.Type == string(clipboard.ClipboardTypeText) {
-		return c.convertTextRecord(ctx, record, query)
+		result = c.convertTextRecord(ctx, record, query)
 	} else if record.Type == string(clipboard.ClipboardTypeImage) {
-		return c.convertImageRecord(ctx, record, query)
+		result = c.convertImageRecord(ctx, record, query)
+	} else if record.Type == string(clipboard.ClipboardTypeFile) {
+		result = c.convertFileRecord(ctx, record, query)
+	} else {
+		return plugin.QueryResult{
+			Title: "ERR: Unknown record type",
+		}
 	}
 
-	return plugin.QueryResult{
-		Title: "ERR: Unknown record type",
+	if record.SourceApp != "" && result.Preview.PreviewProperties != nil {
+		result.Preview.PreviewProperties["i18n:plugin_clipboard_source_app"] = record.SourceApp
 	}
+	return result
 }
 
 // convertTextRecord converts a text record to a query result
 func (c *ClipboardPlugin) convertTextRecord(ctx context.Context, record ClipboardRecord, query plugin.Query) plugin.QueryResult {
 	primaryActionCode := c.api.GetSetting(ctx, primaryActionSettingKey)
 
+	// copied rich text is written back with all its formats, so the formatting is kept
+	record.Formats = c.getRecordFormats(ctx, record)
+	isRichText := record.Formats[ClipboardFormatHTML] != "" || record.Formats[ClipboardFormatRTF] != ""
+	writeContent := func() {
+		if isRichText {
+			clipboard.Write(&clipboard.RichTextData{Text: record.Content, HTML: record.Formats[ClipboardFormatHTML], RTF: record.Formats[ClipboardFormatRTF]})
+		} else {
+			clipboard.WriteText(record.Content)
+		}
+	}
+
 	actions := []plugin.QueryResultAction{
 		{
 			Name:      "Copy",
@@ -489,7 +779,7 @@ func (c *ClipboardPlugin) convertTextRecord(ctx context.Context, record Clipboar
 			IsDefault: primaryActionValueCopy == primaryActionCode,
 			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
 				c.moveRecordToTop(ctx, record.ID)
-				clipboard.WriteText(record.Content)
+				writeContent()
 			},
 		},
 	}
@@ -497,13 +787,99 @@ func (c *ClipboardPlugin) convertTextRecord(ctx context.Context, record Clipboar
 	// paste to active window
 	pasteToActiveWindowAction, pasteToActiveWindowErr := system.GetPasteToActiveWindowAction(ctx, c.api, func() {
 		c.moveRecordToTop(ctx, record.ID)
-		clipboard.WriteText(record.Content)
+		writeContent()
 	})
 	if pasteToActiveWindowErr == nil {
+		if isRichText {
+			pasteToActiveWindowAction.Name = "i18n:plugin_clipboard_paste_formatted"
+		}
 		actions = append(actions, pasteToActiveWindowAction)
 	}
+	if isRichText {
+		pastePlainTextAction, pastePlainTextErr := system.GetPasteToActiveWindowAction(ctx, c.api, func() {
+			c.moveRecordToTop(ctx, record.ID)
+			clipboard.WriteText(record.Content)
+		})
+		if pastePlainTextErr == nil {
+			pastePlainTextAction.Name = "i18n:plugin_clipboard_paste_as_plain_text"
+			pastePlainTextAction.IsDefault = false
+			pastePlainTextAction.Hotkey = "ctrl+shift+enter"
+			actions = append(actions, pastePlainTextAction)
+		}
+	}
+
+	actions = append(actions, c.getRecordManageActions(ctx, record)...)
+
+	group, groupScore := c.getResultGroup(ctx, record)
+
+	// Use stored icon data if available, otherwise use default text icon
+	icon := c.getDefaultTextIcon()
+	if record.IconData != nil && *record.IconData != "" {
+		if iconImage, err := common.ParseWoxImage(*record.IconData); err == nil {
+			icon = iconImage
+		}
+	}
+
+	preview := plugin.WoxPreview{
+		PreviewType: plugin.WoxPreviewTypeText,
+		PreviewData: record.Content,
+		PreviewProperties: map[string]string{
+			"i18n:plugin_clipboard_copy_date":       util.FormatTimestamp(record.Timestamp),
+			"i18n:plugin_clipboard_copy_characters": fmt.Sprintf("%d", len(record.Content)),
+		},
+	}
+	title := strings.TrimSpace(ellipsis.Centering(record.Content, 80))
+	if record.ExpiresAt != nil {
+		// sensitive content is masked in the list, it is still shown in the preview when selected
+		title = "🔒 " + strings.Repeat("•", min(utf8.RuneCountInString(record.Content), 24))
+		preview.PreviewProperties["i18n:plugin_clipboard_expires_at"] = util.FormatTimestamp(*record.ExpiresAt)
+	}
+	if isRichText {
+		var formats []string
+		if html := record.Formats[ClipboardFormatHTML]; html != "" {
+			formats = append(formats, "HTML")
+			preview.PreviewType = plugin.WoxPreviewTypeMarkdown
+			preview.PreviewData = record.Formats[ClipboardFormatMarkdown]
+			if preview.PreviewData == "" {
+				// copied before the preview was stored
+				preview.PreviewData = clipboard.HTMLToMarkdown(html)
+			}
+		}
+		if record.Formats[ClipboardFormatRTF] != "" {
+			formats = append(formats, "RTF")
+		}
+		preview.PreviewProperties["i18n:plugin_clipboard_formats"] = strings.Join(formats, ", ")
+	}
+
+	// show the matched parts above the content when searching
+	if query.Search != "" {
+		if snippets := search.HighlightSnippets(record.Content, search.Parse(query.Search, time.Now()).Terms, 3); snippets != "" {
+			content := preview.PreviewData
+			if preview.PreviewType == plugin.WoxPreviewTypeText {
+				content = search.CodeBlock(record.Content)
+			}
+			preview.PreviewType = plugin.WoxPreviewTypeMarkdown
+			preview.PreviewData = snippets + "\n\n---\n\n" + content
+		}
+	}
+
+	return plugin.QueryResult{
+		Title:      title,
+		Icon:       icon,
+		Group:      group,
+		GroupScore: groupScore,
+		Preview:    preview,
+
+		Score:   record.Timestamp,
+		Actions: actions,
+	}
+}
 
-	if !record.IsFavorite {
+// getRecordManageActions returns the favorite and delete actions of a record
+func (c *ClipboardPlugin) getRecordManageActions(ctx context.Context, record ClipboardRecord) []plugin.QueryResultAction {
+	var actions []plugin.QueryResultAction
+	// sensitive records expire soon, a favorite would keep the secret forever
+	if !record.IsFavorite && record.ExpiresAt == nil {
 		actions = append(actions, plugin.QueryResultAction{
 			Name:                   "Mark as favorite",
 			Icon:                   common.PinIcon,
@@ -517,7 +893,7 @@ func (c *ClipboardPlugin) convertTextRecord(ctx context.Context, record Clipboar
 				}
 			},
 		})
-	} else {
+	} else if record.IsFavorite {
 		actions = append(actions, plugin.QueryResultAction{
 			Name:                   "Cancel favorite",
 			Icon:                   common.UnpinIcon,
@@ -549,35 +925,158 @@ func (c *ClipboardPlugin) convertTextRecord(ctx context.Context, record Clipboar
 		},
 	})
 
-	group, groupScore := c.getResultGroup(ctx, record)
+	return actions
+}
 
-	// Use stored icon data if available, otherwise use default text icon
-	icon := c.getDefaultTextIcon()
-	if record.IconData != nil && *record.IconData != "" {
-		if iconImage, err := common.ParseWoxImage(*record.IconData); err == nil {
-			icon = iconImage
+// convertFileRecord converts a copied files record to a query result, files that no longer exist can't be pasted again
+func (c *ClipboardPlugin) convertFileRecord(ctx context.Context, record ClipboardRecord, query plugin.Query) plugin.QueryResult {
+	primaryActionCode := c.api.GetSetting(ctx, primaryActionSettingKey)
+
+	filePaths := strings.Split(record.Content, "\n")
+	existingPaths := lo.Filter(filePaths, func(filePath string, _ int) bool {
+		return util.IsFileExists(filePath)
+	})
+
+	var actions []plugin.QueryResultAction
+	if len(existingPaths) > 0 {
+		actions = append(actions, plugin.QueryResultAction{
+			Name:      "Copy",
+			Icon:      common.CopyIcon,
+			IsDefault: primaryActionValueCopy == primaryActionCode,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				c.moveRecordToTop(ctx, record.ID)
+				if err := clipboard.Write(&clipboard.FilePathData{FilePaths: existingPaths}); err != nil {
+					c.api.Notify(ctx, err.Error())
+				}
+			},
+		})
+
+		// paste to active window
+		pasteToActiveWindowAction, pasteToActiveWindowErr := system.GetPasteToActiveWindowAction(ctx, c.api, func() {
+			c.moveRecordToTop(ctx, record.ID)
+			if err := clipboard.Write(&clipboard.FilePathData{FilePaths: existingPaths}); err != nil {
+				c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to write files to clipboard: %s", err.Error()))
+			}
+		})
+		if pasteToActiveWindowErr == nil {
+			actions = append(actions, pasteToActiveWindowAction)
 		}
+
+		actions = append(actions, plugin.QueryResultAction{
+			Name:   "i18n:plugin_clipboard_open_containing_folder",
+			Icon:   common.OpenContainingFolderIcon,
+			Hotkey: "ctrl+enter",
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				if err := shell.OpenFileInFolder(existingPaths[0]); err != nil {
+					c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to open containing folder: %s", err.Error()))
+				}
+			},
+		})
+	}
+
+	// copy a single path, also for files that no longer exist
+	for i, filePath := range filePaths {
+		if i >= maxFileListCount {
+			break
+		}
+		name := "i18n:plugin_clipboard_copy_path"
+		if len(filePaths) > 1 {
+			name = fmt.Sprintf("%s: %s", c.api.GetTranslation(ctx, "plugin_clipboard_copy_path"), filepath.Base(filePath))
+		}
+		actions = append(actions, plugin.QueryResultAction{
+			Name: name,
+			Icon: common.CopyIcon,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				clipboard.WriteText(filePath)
+			},
+		})
+	}
+
+	actions = append(actions, c.getRecordManageActions(ctx, record)...)
+
+	group, groupScore := c.getResultGroup(ctx, record)
+
+	previewProperties := map[string]string{
+		"i18n:plugin_clipboard_copy_date":  util.FormatTimestamp(record.Timestamp),
+		"i18n:plugin_clipboard_file_count": fmt.Sprintf("%d", len(filePaths)),
+	}
+	if record.FileSize != nil {
+		previewProperties["i18n:plugin_clipboard_file_size"] = c.formatFileSize(*record.FileSize)
+	}
+
+	title := filepath.Base(filePaths[0])
+	if len(filePaths) > 1 {
+		title = fmt.Sprintf(c.api.GetTranslation(ctx, "plugin_clipboard_files_title"), len(filePaths), strings.Join(lo.Map(filePaths, func(filePath string, _ int) string {
+			return filepath.Base(filePath)
+		}), ", "))
+	}
+	if len(existingPaths) == 0 {
+		title = fmt.Sprintf("%s (%s)", title, c.api.GetTranslation(ctx, "plugin_clipboard_file_missing"))
 	}
 
 	return plugin.QueryResult{
-		Title:      strings.TrimSpace(ellipsis.Centering(record.Content, 80)),
-		Icon:       icon,
+		Title:      strings.TrimSpace(ellipsis.Ending(title, 80)),
+		Icon:       c.getFileRecordIcon(ctx, filePaths, existingPaths),
 		Group:      group,
 		GroupScore: groupScore,
 		Preview: plugin.WoxPreview{
-			PreviewType: plugin.WoxPreviewTypeText,
-			PreviewData: record.Content,
-			PreviewProperties: map[string]string{
-				"i18n:plugin_clipboard_copy_date":       util.FormatTimestamp(record.Timestamp),
-				"i18n:plugin_clipboard_copy_characters": fmt.Sprintf("%d", len(record.Content)),
-			},
+			PreviewType:       plugin.WoxPreviewTypeMarkdown,
+			PreviewData:       c.formatFileListPreview(ctx, filePaths),
+			PreviewProperties: previewProperties,
 		},
-
 		Score:   record.Timestamp,
 		Actions: actions,
 	}
 }
 
+// getFileRecordIcon returns the file type icon of a single copied file, or the generic file icon
+func (c *ClipboardPlugin) getFileRecordIcon(ctx context.Context, filePaths []string, existingPaths []string) common.WoxImage {
+	if len(filePaths) != 1 || len(existingPaths) != 1 {
+		return common.PluginFileIcon
+	}
+
+	if util.IsDirExists(existingPaths[0]) {
+		return common.FolderIcon
+	}
+	if icon, err := fileicon.GetFileTypeIconByPath(ctx, existingPaths[0]); err == nil {
+		return icon
+	}
+	return common.PluginFileIcon
+}
+
+// formatFileListPreview lists the copied files like the selection preview, marking the files that no longer exist
+func (c *ClipboardPlugin) formatFileListPreview(ctx context.Context, filePaths []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(c.api.GetTranslation(ctx, "plugin_clipboard_copied_files_count"), len(filePaths)))
+	sb.WriteString("\n\n")
+
+	for i, filePath := range filePaths {
+		if i >= maxFileListCount {
+			sb.WriteString("\n")
+			sb.WriteString(fmt.Sprintf(c.api.GetTranslation(ctx, "selection_remaining_files_not_shown"), len(filePaths)-maxFileListCount))
+			break
+		}
+		if util.IsFileExists(filePath) {
+			sb.WriteString(fmt.Sprintf("- `%s`\n", filePath))
+		} else {
+			sb.WriteString(fmt.Sprintf("- ~~`%s`~~ (%s)\n", filePath, c.api.GetTranslation(ctx, "plugin_clipboard_file_missing")))
+		}
+	}
+
+	return sb.String()
+}
+
+// getFilesSize returns the total size of the copied files, folders are not walked
+func (c *ClipboardPlugin) getFilesSize(filePaths []string) int64 {
+	var size int64
+	for _, filePath := range filePaths {
+		if info, err := os.Stat(filePath); err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
 // convertImageRecord converts an image record to a query result
 func (c *ClipboardPlugin) convertImageRecord(ctx context.Context, record ClipboardRecord, query plugin.Query) plugin.QueryResult {
 	previewWoxImage, iconWoxImage := c.generateImagePreviewAndIcon(ctx, record)
@@ -842,6 +1341,62 @@ func (c *ClipboardPlugin) getImageHistoryDays(ctx context.Context) int {
 	return 3
 }
 
+// getSensitiveRules builds the sensitive content rules from settings
+func (c *ClipboardPlugin) getSensitiveRules(ctx context.Context) sensitive.Rules {
+	rules := sensitive.Rules{
+		DetectContent: c.api.GetSetting(ctx, detectSensitiveContentSettingKey) != "false",
+	}
+
+	ignoredAppsJson := c.api.GetSetting(ctx, ignoredAppsSettingKey)
+	if ignoredAppsJson == "" {
+		rules.IgnoredApps = defaultIgnoredApps
+		return rules
+	}
+
+	var ignoredApps []struct {
+		App string
+	}
+	if err := json.Unmarshal([]byte(ignoredAppsJson), &ignoredApps); err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to parse ignored apps: %s", err.Error()))
+		rules.IgnoredApps = defaultIgnoredApps
+		return rules
+	}
+	for _, ignoredApp := range ignoredApps {
+		rules.IgnoredApps = append(rules.IgnoredApps, ignoredApp.App)
+	}
+	return rules
+}
+
+// getSensitiveText returns the text the content detectors check, images and files have none
+func (c *ClipboardPlugin) getSensitiveText(data clipboard.Data) string {
+	if data.GetType() == clipboard.ClipboardTypeText || data.GetType() == clipboard.ClipboardTypeRichText {
+		return data.String()
+	}
+	return ""
+}
+
+// getSensitiveExpireMinutes returns how long sensitive content is kept, 0 means it is never recorded
+func (c *ClipboardPlugin) getSensitiveExpireMinutes(ctx context.Context) int {
+	if minutes, err := strconv.Atoi(c.api.GetSetting(ctx, sensitiveExpireMinutesSettingKey)); err == nil {
+		return minutes
+	}
+	return 5
+}
+
+// isRecordExpired checks whether a sensitive record expired but was not deleted yet
+func (c *ClipboardPlugin) isRecordExpired(record ClipboardRecord) bool {
+	return record.ExpiresAt != nil && *record.ExpiresAt <= util.GetSystemTimestamp()
+}
+
+func getDefaultIgnoredAppsSetting() string {
+	var ignoredApps []map[string]string
+	for _, app := range defaultIgnoredApps {
+		ignoredApps = append(ignoredApps, map[string]string{"App": app})
+	}
+	ignoredAppsJson, _ := json.Marshal(ignoredApps)
+	return string(ignoredAppsJson)
+}
+
 // startCleanupRoutine starts a background routine to periodically clean up expired data
 func (c *ClipboardPlugin) startCleanupRoutine(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Minute) // Run cleanup every 30 minutes
@@ -866,15 +1421,7 @@ func (c *ClipboardPlugin) performCleanup(ctx context.Context) {
 	}
 
 	// Clean up expired database records
-	textDays := c.getTextHistoryDays(ctx)
-	imageDays := c.getImageHistoryDays(ctx)
-
-	deletedCount, err := c.db.DeleteExpired(ctx, textDays, imageDays)
-	if err != nil {
-		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to delete expired records: %s", err.Error()))
-	} else if deletedCount > 0 {
-		c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("deleted %d expired records", deletedCount))
-	}
+	c.deleteExpiredRecords(ctx)
 
 	// Clean up orphaned cache files
 	c.cleanupOrphanedCacheFiles(ctx)
@@ -888,6 +1435,19 @@ func (c *ClipboardPlugin) performCleanup(ctx context.Context) {
 	c.api.Log(ctx, plugin.LogLevelInfo, "clipboard cleanup completed")
 }
 
+// deleteExpiredRecords removes the records older than the history days and the expired sensitive records
+func (c *ClipboardPlugin) deleteExpiredRecords(ctx context.Context) {
+	textDays := c.getTextHistoryDays(ctx)
+	imageDays := c.getImageHistoryDays(ctx)
+
+	deletedCount, err := c.db.DeleteExpired(ctx, textDays, imageDays)
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to delete expired records: %s", err.Error()))
+	} else if deletedCount > 0 {
+		c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("deleted %d expired records", deletedCount))
+	}
+}
+
 // cleanupOrphanedCacheFiles removes cache files that no longer have corresponding database records
 func (c *ClipboardPlugin) cleanupOrphanedCacheFiles(ctx context.Context) {
 	cacheDir := util.GetLocation().GetImageCacheDirectory()
@@ -983,8 +1543,8 @@ func (c *ClipboardPlugin) logDatabaseStats(ctx context.Context) {
 	}
 
 	c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf(
-		"clipboard database stats - total: %d, favorites: %d, text: %d, images: %d",
-		stats["total"], stats["favorites"], stats["text"], stats["images"]))
+		"clipboard database stats - total: %d, favorites: %d, text: %d, images: %d, files: %d",
+		stats["total"], stats["favorites"], stats["text"], stats["images"], stats["files"]))
 }
 
 // formatFileSize formats file size in bytes to human readable format
@@ -1052,6 +1612,8 @@ func (c *ClipboardPlugin) addToFavorites(ctx context.Context, record ClipboardRe
 		Width:     record.Width,
 		Height:    record.Height,
 		FileSize:  record.FileSize,
+		Formats:   record.Formats,
+		SourceApp: record.SourceApp,
 		Timestamp: record.Timestamp,
 		CreatedAt: record.CreatedAt.Unix(),
 	}
@@ -1089,6 +1651,8 @@ func (c *ClipboardPlugin) convertFavoriteToRecord(item FavoriteClipboardItem) Cl
 		Width:      item.Width,
 		Height:     item.Height,
 		FileSize:   item.FileSize,
+		Formats:    item.Formats,
+		SourceApp:  item.SourceApp,
 		Timestamp:  item.Timestamp,
 		IsFavorite: true,
 		CreatedAt:  time.Unix(item.CreatedAt, 0),
@@ -1097,6 +1661,13 @@ func (c *ClipboardPlugin) convertFavoriteToRecord(item FavoriteClipboardItem) Cl
 
 // markAsFavorite moves an item from database to favorites settings
 func (c *ClipboardPlugin) markAsFavorite(ctx context.Context, record ClipboardRecord) error {
+	if record.ExpiresAt != nil {
+		return fmt.Errorf("sensitive record %s can't be marked as favorite", record.ID)
+	}
+
+	// Keep the alternate formats, deleting the record from database deletes them
+	record.Formats = c.getRecordFormats(ctx, record)
+
 	// Add to favorites settings
 	if err := c.addToFavorites(ctx, record); err != nil {
 		return fmt.Errorf("failed to add to favorites: %w", err)
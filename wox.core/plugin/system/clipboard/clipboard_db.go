@@ -7,6 +7,8 @@ import (
 	"path"
 	"strings"
 	"time"
+	"unicode/utf8"
+	"wox/plugin/system/clipboard/search"
 	"wox/util"
 	"wox/util/clipboard"
 
@@ -16,23 +18,41 @@ import (
 // ClipboardDB handles all database operations for clipboard history
 type ClipboardDB struct {
 	db *sql.DB
+	// ftsEnabled is false when sqlite is built without fts5, searches fall back to LIKE then
+	ftsEnabled bool
 }
 
 // ClipboardRecord represents a clipboard history record in the database
 type ClipboardRecord struct {
 	ID         string
 	Type       string
-	Content    string  // For text content or metadata
-	FilePath   string  // For image files
+	Content    string  // For text content, metadata, or the newline separated paths of copied files
+	FilePath   string  // For image files, the copied files are never stored here because deleting a record removes this file
 	IconData   *string // For storing icon data (base64 or file path), nullable
 	Width      *int    // For image width, nullable
 	Height     *int    // For image height, nullable
-	FileSize   *int64  // For file size in bytes, nullable
+	FileSize   *int64  // For file size in bytes (total size of copied files), nullable
 	Timestamp  int64
 	IsFavorite bool
 	CreatedAt  time.Time
+	SourceApp  string            // Name of the active window when the content was copied, empty for older records
+	ExpiresAt  *int64            // Sensitive records are deleted after this timestamp, nullable
+	Formats    map[string]string // Alternate formats of text records keyed by format, e.g. "html", stored in clipboard_formats
 }
 
+const (
+	ClipboardFormatHTML = "html"
+	ClipboardFormatRTF  = "rtf"
+	// ClipboardFormatMarkdown is the preview of the html format, it is converted once when copied instead of on every query
+	ClipboardFormatMarkdown = "markdown"
+)
+
+// recordColumns are the selected columns of a record, in the order of scanRecords
+const recordColumns = `id, type, content, file_path, icon_data, width, height, file_size, timestamp, is_favorite, created_at, COALESCE(source_app, ''), expires_at`
+
+// minFullTextTermLength is the shortest term the trigram index can match, shorter terms are matched with LIKE
+const minFullTextTermLength = 3
+
 // NewClipboardDB creates a new clipboard database instance
 func NewClipboardDB(ctx context.Context, pluginId string) (*ClipboardDB, error) {
 	dbPath := path.Join(util.GetLocation().GetPluginSettingDirectory(), pluginId+"_clipboard.db")
@@ -95,13 +115,22 @@ func (c *ClipboardDB) initTables(ctx context.Context) error {
 		file_size INTEGER,
 		timestamp INTEGER NOT NULL,
 		is_favorite BOOLEAN DEFAULT FALSE,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at INTEGER,
+		source_app TEXT
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_timestamp ON clipboard_history(timestamp DESC);
 	CREATE INDEX IF NOT EXISTS idx_favorite ON clipboard_history(is_favorite);
 	CREATE INDEX IF NOT EXISTS idx_type ON clipboard_history(type);
 	CREATE INDEX IF NOT EXISTS idx_content ON clipboard_history(content);
+
+	CREATE TABLE IF NOT EXISTS clipboard_formats (
+		record_id TEXT NOT NULL REFERENCES clipboard_history(id) ON DELETE CASCADE,
+		format TEXT NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (record_id, format)
+	);
 	`
 
 	_, err := c.db.ExecContext(ctx, createTableSQL)
@@ -115,6 +144,8 @@ func (c *ClipboardDB) initTables(ctx context.Context) error {
 		`ALTER TABLE clipboard_history ADD COLUMN width INTEGER`,
 		`ALTER TABLE clipboard_history ADD COLUMN height INTEGER`,
 		`ALTER TABLE clipboard_history ADD COLUMN file_size INTEGER`,
+		`ALTER TABLE clipboard_history ADD COLUMN expires_at INTEGER`,
+		`ALTER TABLE clipboard_history ADD COLUMN source_app TEXT`,
 	}
 
 	for _, alterSQL := range alterTableSQLs {
@@ -126,36 +157,154 @@ func (c *ClipboardDB) initTables(ctx context.Context) error {
 		}
 	}
 
+	if err := c.initFullTextIndex(ctx); err != nil {
+		util.GetLogger().Warn(ctx, fmt.Sprintf("full text search is not available, searching with LIKE: %s", err.Error()))
+	}
+
 	return nil
 }
 
-// Insert adds a new clipboard record to the database
+// initFullTextIndex creates the trigram fts5 index of text and file records, it is kept in sync by triggers.
+// The trigram tokenizer matches substrings like LIKE does, so CJK text without spaces is found as well.
+func (c *ClipboardDB) initFullTextIndex(ctx context.Context) error {
+	// The index triggers fail every insert when sqlite lacks fts5, so a database indexed by a build with fts5
+	// must drop them before it can be used by a build without it.
+	var fts5Available bool
+	if err := c.db.QueryRowContext(ctx, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5Available); err != nil {
+		return err
+	}
+	if !fts5Available {
+		dropTriggersSQL := `
+		DROP TRIGGER IF EXISTS clipboard_fts_insert;
+		DROP TRIGGER IF EXISTS clipboard_fts_delete;
+		DROP TRIGGER IF EXISTS clipboard_fts_update;
+		`
+		if _, err := c.db.ExecContext(ctx, dropTriggersSQL); err != nil {
+			return err
+		}
+		return fmt.Errorf("sqlite is built without fts5")
+	}
+
+	// the index is rebuilt when its triggers are missing, records inserted by a build without fts5 are not indexed yet
+	var triggerCount int
+	if err := c.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'clipboard_fts_%'`).Scan(&triggerCount); err != nil {
+		return err
+	}
+
+	createIndexSQL := `
+	CREATE VIRTUAL TABLE IF NOT EXISTS clipboard_fts USING fts5(record_id UNINDEXED, content, tokenize = 'trigram');
+
+	CREATE TRIGGER IF NOT EXISTS clipboard_fts_insert AFTER INSERT ON clipboard_history
+	WHEN new.type IN ('text', 'file') BEGIN
+		INSERT INTO clipboard_fts (record_id, content) VALUES (new.id, new.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS clipboard_fts_delete AFTER DELETE ON clipboard_history BEGIN
+		DELETE FROM clipboard_fts WHERE record_id = old.id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS clipboard_fts_update AFTER UPDATE OF content ON clipboard_history
+	WHEN new.type IN ('text', 'file') BEGIN
+		DELETE FROM clipboard_fts WHERE record_id = old.id;
+		INSERT INTO clipboard_fts (record_id, content) VALUES (new.id, new.content);
+	END;
+	`
+	if _, err := c.db.ExecContext(ctx, createIndexSQL); err != nil {
+		return err
+	}
+
+	if triggerCount < 3 {
+		rebuildIndexSQL := `
+		DELETE FROM clipboard_fts;
+		INSERT INTO clipboard_fts (record_id, content) SELECT id, content FROM clipboard_history WHERE type IN ('text', 'file');
+		`
+		if _, err := c.db.ExecContext(ctx, rebuildIndexSQL); err != nil {
+			return err
+		}
+	}
+
+	c.ftsEnabled = true
+	return nil
+}
+
+// Insert adds a new clipboard record and its alternate formats to the database
 func (c *ClipboardDB) Insert(ctx context.Context, record ClipboardRecord) error {
 	insertSQL := `
-	INSERT INTO clipboard_history (id, type, content, file_path, icon_data, width, height, file_size, timestamp, is_favorite, created_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO clipboard_history (id, type, content, file_path, icon_data, width, height, file_size, timestamp, is_favorite, created_at, source_app, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := c.db.ExecContext(ctx, insertSQL,
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, insertSQL,
 		record.ID, record.Type, record.Content, record.FilePath, record.IconData,
 		record.Width, record.Height, record.FileSize,
-		record.Timestamp, record.IsFavorite, record.CreatedAt)
+		record.Timestamp, record.IsFavorite, record.CreatedAt, record.SourceApp, record.ExpiresAt)
+	if err != nil {
+		return err
+	}
 
-	return err
+	for format, data := range record.Formats {
+		if data == "" {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO clipboard_formats (record_id, format, data) VALUES (?, ?, ?)`, record.ID, format, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetFormats retrieves the alternate formats of the records in one query, keyed by record id and format.
+// Records without alternate formats get an empty map.
+func (c *ClipboardDB) GetFormats(ctx context.Context, ids []string) (map[string]map[string]string, error) {
+	formats := make(map[string]map[string]string, len(ids))
+	if len(ids) == 0 {
+		return formats, nil
+	}
+
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		formats[id] = map[string]string{}
+		args = append(args, id)
+	}
+
+	querySQL := `SELECT record_id, format, data FROM clipboard_formats WHERE record_id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+	rows, err := c.db.QueryContext(ctx, querySQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, format, data string
+		if err := rows.Scan(&id, &format, &data); err != nil {
+			return nil, err
+		}
+		formats[id][format] = data
+	}
+
+	return formats, rows.Err()
 }
 
 // Update modifies an existing clipboard record
 func (c *ClipboardDB) Update(ctx context.Context, record ClipboardRecord) error {
 	updateSQL := `
 	UPDATE clipboard_history
-	SET type = ?, content = ?, file_path = ?, icon_data = ?, width = ?, height = ?, file_size = ?, timestamp = ?, is_favorite = ?
+	SET type = ?, content = ?, file_path = ?, icon_data = ?, width = ?, height = ?, file_size = ?, timestamp = ?, is_favorite = ?, source_app = ?, expires_at = ?
 	WHERE id = ?
 	`
 
 	_, err := c.db.ExecContext(ctx, updateSQL,
 		record.Type, record.Content, record.FilePath, record.IconData,
 		record.Width, record.Height, record.FileSize,
-		record.Timestamp, record.IsFavorite, record.ID)
+		record.Timestamp, record.IsFavorite, record.SourceApp, record.ExpiresAt, record.ID)
 
 	return err
 }
@@ -177,7 +326,7 @@ func (c *ClipboardDB) Delete(ctx context.Context, id string) error {
 // GetRecent retrieves recent clipboard records with pagination
 func (c *ClipboardDB) GetRecent(ctx context.Context, limit, offset int) ([]ClipboardRecord, error) {
 	querySQL := `
-	SELECT id, type, content, file_path, icon_data, width, height, file_size, timestamp, is_favorite, created_at
+	SELECT ` + recordColumns + `
 	FROM clipboard_history
 	ORDER BY timestamp DESC
 	LIMIT ? OFFSET ?
@@ -192,17 +341,64 @@ func (c *ClipboardDB) GetRecent(ctx context.Context, limit, offset int) ([]Clipb
 	return c.scanRecords(rows)
 }
 
-// SearchText searches for text content in clipboard history
-func (c *ClipboardDB) SearchText(ctx context.Context, searchTerm string, limit int) ([]ClipboardRecord, error) {
-	querySQL := `
-	SELECT id, type, content, file_path, icon_data, width, height, file_size, timestamp, is_favorite, created_at
-	FROM clipboard_history
-	WHERE type = ? AND content LIKE ?
-	ORDER BY timestamp DESC
-	LIMIT ?
-	`
+// Search searches clipboard history with the terms and filters of a query, newest first.
+// Terms only match text content and copied file paths, they use the full text index when it is available.
+func (c *ClipboardDB) Search(ctx context.Context, query search.Query, limit, offset int) ([]ClipboardRecord, error) {
+	var conditions []string
+	var args []any
+
+	if len(query.Terms) > 0 {
+		conditions = append(conditions, "type IN (?, ?)")
+		args = append(args, string(clipboard.ClipboardTypeText), string(clipboard.ClipboardTypeFile))
+	}
 
-	rows, err := c.db.QueryContext(ctx, querySQL, string(clipboard.ClipboardTypeText), "%"+searchTerm+"%", limit)
+	var matchTerms []string
+	for _, term := range query.Terms {
+		if c.ftsEnabled && utf8.RuneCountInString(term) >= minFullTextTermLength {
+			matchTerms = append(matchTerms, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+			continue
+		}
+		conditions = append(conditions, `content LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(term)+"%")
+	}
+	if len(matchTerms) > 0 {
+		conditions = append(conditions, "id IN (SELECT record_id FROM clipboard_fts WHERE clipboard_fts MATCH ?)")
+		args = append(args, strings.Join(matchTerms, " AND "))
+	}
+
+	if len(query.Types) > 0 {
+		conditions = append(conditions, "type IN (?"+strings.Repeat(", ?", len(query.Types)-1)+")")
+		for _, recordType := range query.Types {
+			args = append(args, recordType)
+		}
+	}
+
+	if len(query.Apps) > 0 {
+		var appConditions []string
+		for _, app := range query.Apps {
+			appConditions = append(appConditions, `source_app LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+escapeLike(app)+"%")
+		}
+		conditions = append(conditions, "("+strings.Join(appConditions, " OR ")+")")
+	}
+
+	if query.After > 0 {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, query.After)
+	}
+	if query.Before > 0 {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, query.Before)
+	}
+
+	querySQL := `SELECT ` + recordColumns + ` FROM clipboard_history`
+	if len(conditions) > 0 {
+		querySQL += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	querySQL += ` ORDER BY timestamp DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := c.db.QueryContext(ctx, querySQL, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -211,6 +407,11 @@ func (c *ClipboardDB) SearchText(ctx context.Context, searchTerm string, limit i
 	return c.scanRecords(rows)
 }
 
+// escapeLike escapes the wildcards of a LIKE pattern, the escape character is a backslash
+func escapeLike(str string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(str)
+}
+
 // GetByID retrieves a specific record by ID
 func (c *ClipboardDB) GetByID(ctx context.Context, id string) (*ClipboardRecord, error) {
 	querySQL := `
@@ -235,7 +436,7 @@ func (c *ClipboardDB) GetByID(ctx context.Context, id string) (*ClipboardRecord,
 	return record, nil
 }
 
-// DeleteExpired removes records older than the specified days
+// DeleteExpired removes records older than the specified days and sensitive records past their expiry, copied files are kept as long as text
 func (c *ClipboardDB) DeleteExpired(ctx context.Context, textDays, imageDays int) (int64, error) {
 	currentTime := util.GetSystemTimestamp()
 	textCutoff := currentTime - int64(textDays)*24*60*60*1000
@@ -244,14 +445,16 @@ func (c *ClipboardDB) DeleteExpired(ctx context.Context, textDays, imageDays int
 	deleteSQL := `
 	DELETE FROM clipboard_history 
 	WHERE is_favorite = FALSE AND (
+		(type IN (?, ?) AND timestamp < ?) OR
 		(type = ? AND timestamp < ?) OR
-		(type = ? AND timestamp < ?)
+		(expires_at IS NOT NULL AND expires_at <= ?)
 	)
 	`
 
 	result, err := c.db.ExecContext(ctx, deleteSQL,
-		string(clipboard.ClipboardTypeText), textCutoff,
-		string(clipboard.ClipboardTypeImage), imageCutoff)
+		string(clipboard.ClipboardTypeText), string(clipboard.ClipboardTypeFile), textCutoff,
+		string(clipboard.ClipboardTypeImage), imageCutoff,
+		currentTime)
 
 	if err != nil {
 		return 0, err
@@ -330,6 +533,14 @@ func (c *ClipboardDB) GetStats(ctx context.Context) (map[string]int, error) {
 	}
 	stats["images"] = imageCount
 
+	// File count
+	var fileCount int
+	err = c.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM clipboard_history WHERE type = ?`, string(clipboard.ClipboardTypeFile)).Scan(&fileCount)
+	if err != nil {
+		return nil, err
+	}
+	stats["files"] = fileCount
+
 	return stats, nil
 }
 
@@ -359,7 +570,7 @@ func (c *ClipboardDB) scanRecords(rows *sql.Rows) ([]ClipboardRecord, error) {
 		var record ClipboardRecord
 		err := rows.Scan(&record.ID, &record.Type, &record.Content,
 			&record.FilePath, &record.IconData, &record.Width, &record.Height, &record.FileSize,
-			&record.Timestamp, &record.IsFavorite, &record.CreatedAt)
+			&record.Timestamp, &record.IsFavorite, &record.CreatedAt, &record.SourceApp, &record.ExpiresAt)
 		if err != nil {
 			return nil, err
 		}
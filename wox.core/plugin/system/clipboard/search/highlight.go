@@ -0,0 +1,136 @@
+package search
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// snippetContext is the number of characters shown around a match
+const snippetContext = 40
+
+type matchRange struct {
+	start, end int // rune offsets, end is exclusive
+}
+
+// HighlightSnippets renders the parts of content that match the terms as markdown, matches are bold and
+// whitespace is collapsed, so every snippet is one line. It returns "" when no term matches.
+func HighlightSnippets(content string, terms []string, maxSnippets int) string {
+	runes := []rune(content)
+	ranges := findMatches(runes, terms)
+	if len(ranges) == 0 {
+		return ""
+	}
+
+	// group the matches whose contexts overlap into one snippet
+	var snippets [][]matchRange
+	for _, r := range ranges {
+		last := len(snippets) - 1
+		if last >= 0 && r.start-snippets[last][len(snippets[last])-1].end <= snippetContext*2 {
+			snippets[last] = append(snippets[last], r)
+			continue
+		}
+		if len(snippets) == maxSnippets {
+			break
+		}
+		snippets = append(snippets, []matchRange{r})
+	}
+
+	var lines []string
+	for _, snippet := range snippets {
+		start := max(snippet[0].start-snippetContext, 0)
+		end := min(snippet[len(snippet)-1].end+snippetContext, len(runes))
+
+		var sb strings.Builder
+		if start > 0 {
+			sb.WriteString("…")
+		}
+		cursor := start
+		for _, r := range snippet {
+			sb.WriteString(EscapeMarkdown(collapseWhitespace(string(runes[cursor:r.start]))))
+			sb.WriteString("**" + EscapeMarkdown(collapseWhitespace(string(runes[r.start:r.end]))) + "**")
+			cursor = r.end
+		}
+		sb.WriteString(EscapeMarkdown(collapseWhitespace(string(runes[cursor:end]))))
+		if end < len(runes) {
+			sb.WriteString("…")
+		}
+		lines = append(lines, sb.String())
+	}
+
+	return strings.Join(lines, "\n\n")
+}
+
+// findMatches finds the case-insensitive matches of the terms, overlapping matches are merged
+func findMatches(runes []rune, terms []string) []matchRange {
+	lowerRunes := make([]rune, len(runes))
+	for i, r := range runes {
+		lowerRunes[i] = unicode.ToLower(r)
+	}
+
+	var ranges []matchRange
+	for _, term := range terms {
+		termRunes := []rune(strings.ToLower(term))
+		if len(termRunes) == 0 {
+			continue
+		}
+		for i := 0; i+len(termRunes) <= len(lowerRunes); i++ {
+			if slices.Equal(lowerRunes[i:i+len(termRunes)], termRunes) {
+				ranges = append(ranges, matchRange{i, i + len(termRunes)})
+				i += len(termRunes) - 1
+			}
+		}
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start < ranges[j].start
+	})
+	var merged []matchRange
+	for _, r := range ranges {
+		if last := len(merged) - 1; last >= 0 && r.start <= merged[last].end {
+			merged[last].end = max(merged[last].end, r.end)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// EscapeMarkdown escapes the characters markdown would format, so copied text is previewed as is
+func EscapeMarkdown(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune("\\`*_{}[]<>()#+-.!|~", r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// CodeBlock wraps text in a fenced code block, the fence is longer than any backtick run in the text
+func CodeBlock(text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + "\n" + strings.TrimRight(text, "\n") + "\n" + fence
+}
+
+func collapseWhitespace(text string) string {
+	var sb strings.Builder
+	lastSpace := false
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			if !lastSpace {
+				sb.WriteRune(' ')
+			}
+			lastSpace = true
+			continue
+		}
+		sb.WriteRune(r)
+		lastSpace = false
+	}
+	return sb.String()
+}
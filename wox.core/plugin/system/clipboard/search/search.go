@@ -0,0 +1,241 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"wox/util/clipboard"
+
+	"github.com/samber/lo"
+)
+
+// Query is a parsed clipboard search, e.g. `invoice type:text app:"Google Chrome" after:yesterday`
+type Query struct {
+	Terms  []string // free text terms, a record must contain all of them
+	Types  []string // record types, e.g. "text", "image" or "file"
+	Apps   []string // source apps, a record must be copied from one of them, matched case-insensitively by substring
+	After  int64    // records copied at or after this timestamp in milliseconds, 0 means unbounded
+	Before int64    // records copied before this timestamp in milliseconds, 0 means unbounded
+	Page   int      // page of the browse mode, starts at 1
+}
+
+var typeAliases = map[string]string{
+	"text":    string(clipboard.ClipboardTypeText),
+	"txt":     string(clipboard.ClipboardTypeText),
+	"image":   string(clipboard.ClipboardTypeImage),
+	"images":  string(clipboard.ClipboardTypeImage),
+	"img":     string(clipboard.ClipboardTypeImage),
+	"picture": string(clipboard.ClipboardTypeImage),
+	"file":    string(clipboard.ClipboardTypeFile),
+	"files":   string(clipboard.ClipboardTypeFile),
+}
+
+var relativeDatePattern = regexp.MustCompile(`^(\d+)([hdwm])$`)
+
+// Parse parses a search, a filter with an invalid value is searched as text, so "after:lunch" still finds the text.
+// Dates are "today", "yesterday", "2025-03-01" or relative to now, e.g. "3d", "2w", "12h" or "1m" for a month.
+func Parse(search string, now time.Time) Query {
+	query := Query{Page: 1}
+	for _, token := range tokenize(search) {
+		key, value, found := strings.Cut(token, ":")
+		if !found || value == "" {
+			query.Terms = append(query.Terms, token)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "type":
+			recordType, ok := typeAliases[strings.ToLower(value)]
+			if !ok {
+				query.Terms = append(query.Terms, token)
+				continue
+			}
+			query.Types = append(query.Types, recordType)
+		case "app":
+			query.Apps = append(query.Apps, value)
+		case "after", "since":
+			t, err := parseDate(value, now)
+			if err != nil {
+				query.Terms = append(query.Terms, token)
+				continue
+			}
+			query.After = t.UnixMilli()
+		case "before":
+			t, err := parseDate(value, now)
+			if err != nil {
+				query.Terms = append(query.Terms, token)
+				continue
+			}
+			query.Before = t.UnixMilli()
+		case "on":
+			t, err := parseDate(value, now)
+			if err != nil {
+				query.Terms = append(query.Terms, token)
+				continue
+			}
+			day := startOfDay(t)
+			query.After = day.UnixMilli()
+			query.Before = day.AddDate(0, 0, 1).UnixMilli()
+		case "page":
+			page, err := strconv.Atoi(value)
+			if err != nil || page < 1 {
+				query.Terms = append(query.Terms, token)
+				continue
+			}
+			query.Page = page
+		default:
+			query.Terms = append(query.Terms, token)
+		}
+	}
+
+	return query
+}
+
+// HasFilters checks whether the query narrows the records by anything but text
+func (q Query) HasFilters() bool {
+	return len(q.Types) > 0 || len(q.Apps) > 0 || q.After > 0 || q.Before > 0
+}
+
+// IsEmpty checks whether the query matches all records
+func (q Query) IsEmpty() bool {
+	return len(q.Terms) == 0 && !q.HasFilters()
+}
+
+// Match checks a record against the query, the database applies the same rules in sql
+func (q Query) Match(recordType string, content string, sourceApp string, timestamp int64) bool {
+	if len(q.Types) > 0 && !lo.Contains(q.Types, recordType) {
+		return false
+	}
+	if len(q.Terms) > 0 && recordType != string(clipboard.ClipboardTypeText) && recordType != string(clipboard.ClipboardTypeFile) {
+		return false
+	}
+	if q.After > 0 && timestamp < q.After {
+		return false
+	}
+	if q.Before > 0 && timestamp >= q.Before {
+		return false
+	}
+	if len(q.Apps) > 0 {
+		matched := false
+		for _, app := range q.Apps {
+			if sourceApp != "" && strings.Contains(strings.ToLower(sourceApp), strings.ToLower(app)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	lowerContent := strings.ToLower(content)
+	for _, term := range q.Terms {
+		if !strings.Contains(lowerContent, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the query back to a search, terms first, so it can be put back in the query box with another page
+func (q Query) String() string {
+	var tokens []string
+	for _, term := range q.Terms {
+		tokens = append(tokens, quote(term))
+	}
+	for _, recordType := range q.Types {
+		tokens = append(tokens, "type:"+recordType)
+	}
+	for _, app := range q.Apps {
+		tokens = append(tokens, "app:"+quote(app))
+	}
+	if q.After > 0 {
+		tokens = append(tokens, "after:"+formatDate(q.After))
+	}
+	if q.Before > 0 {
+		tokens = append(tokens, "before:"+formatDate(q.Before))
+	}
+	if q.Page > 1 {
+		tokens = append(tokens, fmt.Sprintf("page:%d", q.Page))
+	}
+	return strings.Join(tokens, " ")
+}
+
+// tokenize splits a search by whitespace, double quotes keep whitespace in a token, e.g. `app:"Google Chrome"`
+func tokenize(search string) []string {
+	var tokens []string
+	var sb strings.Builder
+	inQuotes := false
+	for _, r := range search {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			if sb.Len() > 0 {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() > 0 {
+		tokens = append(tokens, sb.String())
+	}
+	return tokens
+}
+
+func quote(str string) string {
+	if strings.IndexFunc(str, unicode.IsSpace) >= 0 {
+		return `"` + str + `"`
+	}
+	return str
+}
+
+func parseDate(value string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(value) {
+	case "today":
+		return startOfDay(now), nil
+	case "yesterday":
+		return startOfDay(now).AddDate(0, 0, -1), nil
+	}
+
+	if matches := relativeDatePattern.FindStringSubmatch(strings.ToLower(value)); matches != nil {
+		amount, _ := strconv.Atoi(matches[1])
+		switch matches[2] {
+		case "h":
+			return now.Add(-time.Duration(amount) * time.Hour), nil
+		case "d":
+			return startOfDay(now).AddDate(0, 0, -amount), nil
+		case "w":
+			return startOfDay(now).AddDate(0, 0, -amount*7), nil
+		case "m":
+			return startOfDay(now).AddDate(0, -amount, 0), nil
+		}
+	}
+
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02", "2006/01/02"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date: %s", value)
+}
+
+// formatDate formats a timestamp the way parseDate reads it back, without the time when it is a day start
+func formatDate(timestamp int64) string {
+	t := time.UnixMilli(timestamp)
+	if t.Equal(startOfDay(t)) {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02T15:04")
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, 3, 5, 14, 30, 0, 0, time.Local)
+	today := time.Date(2025, 3, 5, 0, 0, 0, 0, time.Local).UnixMilli()
+	yesterday := time.Date(2025, 3, 4, 0, 0, 0, 0, time.Local).UnixMilli()
+
+	tests := []struct {
+		search   string
+		expected Query
+	}{
+		{"", Query{Page: 1}},
+		{"hello world", Query{Terms: []string{"hello", "world"}, Page: 1}},
+		{`"hello world"`, Query{Terms: []string{"hello world"}, Page: 1}},
+		{"type:image", Query{Types: []string{"image"}, Page: 1}},
+		{"TYPE:Files invoice", Query{Terms: []string{"invoice"}, Types: []string{"file"}, Page: 1}},
+		{"type:video", Query{Terms: []string{"type:video"}, Page: 1}},
+		{`app:Slack app:"Google Chrome"`, Query{Apps: []string{"Slack", "Google Chrome"}, Page: 1}},
+		{"after:yesterday", Query{After: yesterday, Page: 1}},
+		{"after:today before:2025-03-06", Query{After: today, Before: time.Date(2025, 3, 6, 0, 0, 0, 0, time.Local).UnixMilli(), Page: 1}},
+		{"since:2d", Query{After: time.Date(2025, 3, 3, 0, 0, 0, 0, time.Local).UnixMilli(), Page: 1}},
+		{"after:12h", Query{After: now.Add(-12 * time.Hour).UnixMilli(), Page: 1}},
+		{"on:yesterday", Query{After: yesterday, Before: today, Page: 1}},
+		{"after:lunch", Query{Terms: []string{"after:lunch"}, Page: 1}},
+		{"http://example.com", Query{Terms: []string{"http://example.com"}, Page: 1}},
+		{"page:3 type:text", Query{Types: []string{"text"}, Page: 3}},
+		{"page:0", Query{Terms: []string{"page:0"}, Page: 1}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Parse(tt.search, now), tt.search)
+	}
+}
+
+func TestQueryString(t *testing.T) {
+	now := time.Date(2025, 3, 5, 14, 30, 0, 0, time.Local)
+	for _, search := range []string{
+		`invoice type:file app:"Google Chrome" after:2025-03-04 before:2025-03-05`,
+		"after:2025-03-05T02:30 page:2",
+	} {
+		assert.Equal(t, search, Parse(search, now).String())
+	}
+	assert.Equal(t, "after:2025-03-05T02:30", Parse("after:12h", now).String())
+}
+
+func TestMatch(t *testing.T) {
+	query := Parse(`Invoice app:slack after:2025-03-01`, time.Now())
+	after := time.Date(2025, 3, 2, 0, 0, 0, 0, time.Local).UnixMilli()
+	before := time.Date(2025, 2, 2, 0, 0, 0, 0, time.Local).UnixMilli()
+
+	assert.True(t, query.Match("text", "the invoice for March", "Slack", after))
+	assert.False(t, query.Match("text", "the invoice for March", "Safari", after))
+	assert.False(t, query.Match("text", "the invoice for March", "", after))
+	assert.False(t, query.Match("text", "the invoice for March", "Slack", before))
+	assert.False(t, query.Match("text", "the receipt for March", "Slack", after))
+	assert.False(t, query.Match("image", "Image (10×10) (1 KB)", "Slack", after))
+	assert.True(t, Parse("type:image", time.Now()).Match("image", "Image (10×10) (1 KB)", "", after))
+}
+
+func TestHighlightSnippets(t *testing.T) {
+	assert.Equal(t, "", HighlightSnippets("hello world", []string{"foo"}, 3))
+	assert.Equal(t, "**Hello** **world**", HighlightSnippets("Hello world", []string{"world", "hello"}, 3))
+	assert.Equal(t, "say **hello\\_world** \\*now\\*", HighlightSnippets("say   hello_world\n*now*", []string{"hello_world"}, 3))
+	assert.Equal(t, "你好**世界**", HighlightSnippets("你好世界", []string{"世界"}, 3))
+	assert.Equal(t, "b**aaaa**b", HighlightSnippets("baaaab", []string{"aa", "aaa"}, 3))
+
+	long := "first match here " + strings.Repeat("x", 100) + " second match here " + strings.Repeat("y", 100) + " third match"
+	snippets := HighlightSnippets(long, []string{"match"}, 2)
+	assert.Equal(t, 2, len(strings.Split(snippets, "\n\n")))
+	assert.Contains(t, snippets, "first **match** here")
+	assert.Contains(t, snippets, "…")
+	assert.NotContains(t, snippets, "third")
+}
+
+func TestCodeBlock(t *testing.T) {
+	assert.Equal(t, "```\nfoo\n```", CodeBlock("foo\n"))
+	assert.Equal(t, "````\na ``` b\n````", CodeBlock("a ``` b"))
+}
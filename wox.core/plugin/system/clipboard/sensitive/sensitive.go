@@ -0,0 +1,172 @@
+package sensitive
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Verdict tells the clipboard watcher what to do with a clipboard change
+type Verdict int
+
+const (
+	VerdictRecord    Verdict = iota // record as usual
+	VerdictSkip                     // don't record at all
+	VerdictSensitive                // record, but expire it soon
+)
+
+// Reasons of the verdicts that are not a detector name
+const (
+	ReasonIgnoredApp = "ignored_app"
+	ReasonConcealed  = "concealed"
+)
+
+// Result is the verdict of a clipboard change and the rule that decided it
+type Result struct {
+	Verdict Verdict
+	Reason  string
+}
+
+// Detector detects a kind of secret in copied text
+type Detector struct {
+	Name   string
+	Detect func(text string) bool
+}
+
+var jwtPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`)
+var awsAccessKeyPattern = regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA|AGPA|AIDA|AIPA|ANPA|ANVA|AROA)[A-Z0-9]{16}\b`)
+var awsSecretKeyPattern = regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`)
+var privateKeyPattern = regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)
+var cardNumberPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// Detectors are the content detectors of common secrets
+var Detectors = []Detector{
+	{Name: "jwt", Detect: jwtPattern.MatchString},
+	{Name: "aws_key", Detect: func(text string) bool {
+		return awsAccessKeyPattern.MatchString(text) || awsSecretKeyPattern.MatchString(text)
+	}},
+	{Name: "private_key", Detect: privateKeyPattern.MatchString},
+	{Name: "credit_card", Detect: containsCardNumber},
+}
+
+// Rules decides whether a clipboard change may be recorded
+type Rules struct {
+	IgnoredApps   []string // changes made in these apps are never recorded, matched case-insensitively against the active window name
+	DetectContent bool     // whether to run the content detectors
+}
+
+// Check checks a clipboard change made in app, text is empty for images and files.
+// Concealed is the marker password managers put on the clipboard, e.g. org.nspasteboard.ConcealedType on macOS.
+func (r Rules) Check(app string, text string, concealed bool) Result {
+	app = strings.ToLower(strings.TrimSpace(app))
+	if app != "" {
+		for _, ignoredApp := range r.IgnoredApps {
+			ignoredApp = strings.ToLower(strings.TrimSpace(ignoredApp))
+			if ignoredApp != "" && strings.Contains(app, ignoredApp) {
+				return Result{Verdict: VerdictSkip, Reason: ReasonIgnoredApp}
+			}
+		}
+	}
+
+	// the marker asks clipboard managers not to record the content at all
+	if concealed {
+		return Result{Verdict: VerdictSkip, Reason: ReasonConcealed}
+	}
+
+	if r.DetectContent && strings.TrimSpace(text) != "" {
+		for _, detector := range Detectors {
+			if detector.Detect(text) {
+				return Result{Verdict: VerdictSensitive, Reason: detector.Name}
+			}
+		}
+	}
+
+	return Result{Verdict: VerdictRecord}
+}
+
+// cardNetwork is the number prefixes and lengths of a card network, e.g. Visa numbers start with 4 and have 13, 16 or 19 digits
+type cardNetwork struct {
+	prefixes  [][2]int // inclusive ranges of prefixes, all prefixes of a range have the same number of digits
+	minLength int
+	maxLength int
+	lengths   []int // the valid lengths between minLength and maxLength, all of them when empty
+}
+
+var cardNetworks = []cardNetwork{
+	{prefixes: [][2]int{{4, 4}}, minLength: 13, maxLength: 19, lengths: []int{13, 16, 19}},     // Visa
+	{prefixes: [][2]int{{51, 55}, {2221, 2720}}, minLength: 16, maxLength: 16},                 // Mastercard
+	{prefixes: [][2]int{{34, 34}, {37, 37}}, minLength: 15, maxLength: 15},                     // American Express
+	{prefixes: [][2]int{{6011, 6011}, {644, 649}, {65, 65}}, minLength: 16, maxLength: 19},     // Discover
+	{prefixes: [][2]int{{62, 62}}, minLength: 16, maxLength: 19},                               // UnionPay
+	{prefixes: [][2]int{{3528, 3589}}, minLength: 16, maxLength: 19},                           // JCB
+	{prefixes: [][2]int{{300, 305}, {36, 36}, {38, 39}}, minLength: 14, maxLength: 19},         // Diners Club
+	{prefixes: [][2]int{{50, 50}, {56, 58}, {63, 63}, {67, 67}}, minLength: 13, maxLength: 19}, // Maestro
+}
+
+// matches reports whether the digits have a prefix and a length of the network
+func (n cardNetwork) matches(digits string) bool {
+	if len(digits) < n.minLength || len(digits) > n.maxLength {
+		return false
+	}
+	if len(n.lengths) > 0 && !slices.Contains(n.lengths, len(digits)) {
+		return false
+	}
+	for _, prefixRange := range n.prefixes {
+		prefixLength := len(strconv.Itoa(prefixRange[0]))
+		prefix, err := strconv.Atoi(digits[:prefixLength])
+		if err == nil && prefix >= prefixRange[0] && prefix <= prefixRange[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// containsCardNumber finds 13 to 19 digit numbers that pass the Luhn check. Because many ids and timestamps pass the Luhn check too,
+// the number must also start with the prefix of a card network and have one of its lengths, or be grouped like a card, e.g. "1234 5678 9012 3456"
+func containsCardNumber(text string) bool {
+	for _, match := range cardNumberPattern.FindAllString(text, -1) {
+		digits := strings.NewReplacer(" ", "", "-", "").Replace(match)
+		if len(digits) < 13 || len(digits) > 19 || !isLuhnValid(digits) {
+			continue
+		}
+		if isCardGrouped(match) || slices.ContainsFunc(cardNetworks, func(network cardNetwork) bool { return network.matches(digits) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCardGrouped reports whether the number is written in groups of 4 digits, the last group may be shorter, or like American Express cards in groups of 4, 6 and 5 digits
+func isCardGrouped(number string) bool {
+	groups := strings.FieldsFunc(number, func(r rune) bool { return r == ' ' || r == '-' })
+	if len(groups) < 3 {
+		return false
+	}
+	if len(groups) == 3 && len(groups[0]) == 4 && len(groups[1]) == 6 && len(groups[2]) == 5 {
+		return true
+	}
+	for i, group := range groups {
+		if len(group) != 4 && (i != len(groups)-1 || len(group) > 4) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLuhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
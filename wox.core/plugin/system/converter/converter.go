@@ -2,16 +2,21 @@ package converter
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 	"wox/common"
 	"wox/plugin"
+	"wox/plugin/system/calculator/history"
 	"wox/plugin/system/converter/core"
 	"wox/plugin/system/converter/modules"
+	"wox/setting/definition"
 	"wox/util/clipboard"
 	"wox/util/locale"
 
 	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
 )
 
 func init() {
@@ -19,9 +24,15 @@ func init() {
 }
 
 type Converter struct {
-	api       plugin.API
-	registry  *core.ModuleRegistry
-	tokenizer *core.Tokenizer
+	api            plugin.API
+	registry       *core.ModuleRegistry
+	tokenizer      *core.Tokenizer
+	currencyModule *modules.CurrencyModule
+	historyManager *history.Manager
+}
+
+type converterContextData struct {
+	Expression string `json:"expression"`
 }
 
 func (c *Converter) GetMetadata() plugin.Metadata {
@@ -40,12 +51,36 @@ func (c *Converter) GetMetadata() plugin.Metadata {
 			"*",
 			"calculator",
 		},
-		Commands: []plugin.MetadataCommand{},
+		Commands: []plugin.MetadataCommand{
+			{
+				// listed by the calculator plugin, which shares the "calculator" trigger keyword and the history
+				Command:     "history",
+				Description: "i18n:plugin_calculator_command_history",
+			},
+		},
 		SupportedOS: []string{
 			"Windows",
 			"Macos",
 			"Linux",
 		},
+		Features: []plugin.MetadataFeature{
+			{
+				Name: plugin.MetadataFeatureMRU,
+			},
+		},
+		SettingDefinitions: definition.PluginSettingDefinitions{
+			{
+				Type: definition.PluginSettingDefinitionTypeTextBox,
+				Value: &definition.PluginSettingValueTextBox{
+					Key:     modules.LocalExchangeRatesFileSettingKey,
+					Label:   "i18n:plugin_converter_local_rates_file",
+					Tooltip: "i18n:plugin_converter_local_rates_file_tooltip",
+					Style: definition.PluginSettingValueStyle{
+						Width: 400,
+					},
+				},
+			},
+		},
 	}
 }
 
@@ -62,18 +97,57 @@ func (c *Converter) Init(ctx context.Context, initParams plugin.InitParams) {
 	currencyModule := modules.NewCurrencyModule(ctx, c.api)
 	currencyModule.StartExchangeRateSyncSchedule(ctx)
 	registry.Register(currencyModule)
+	c.currencyModule = currencyModule
 
 	cryptoModule := modules.NewCryptoModule(ctx, c.api)
 	cryptoModule.StartPriceSyncSchedule(ctx)
 	registry.Register(cryptoModule)
 
+	registry.Register(modules.NewLengthModule(ctx, c.api))
+	registry.Register(modules.NewMassModule(ctx, c.api))
+	registry.Register(modules.NewVolumeModule(ctx, c.api))
+	registry.Register(modules.NewTemperatureModule(ctx, c.api))
+	registry.Register(modules.NewAreaModule(ctx, c.api))
+	registry.Register(modules.NewSpeedModule(ctx, c.api))
+	registry.Register(modules.NewPressureModule(ctx, c.api))
+	registry.Register(modules.NewEnergyModule(ctx, c.api))
+	registry.Register(modules.NewDataSizeModule(ctx, c.api))
+	registry.Register(modules.NewAngleModule(ctx, c.api))
+
 	tokenizer := core.NewTokenizer(registry.GetTokenPatterns())
 	c.registry = registry
 	c.tokenizer = tokenizer
+
+	c.historyManager = history.GetManager()
+	if err := c.historyManager.Init(ctx); err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, err.Error())
+	}
+	c.api.OnMRURestore(ctx, c.handleMRURestore)
+}
+
+// calculateToken calculates the token with the module that tokenized it, falling back to the other modules
+func (c *Converter) calculateToken(ctx context.Context, token core.Token) (core.Result, error) {
+	if token.Module != nil {
+		if result, err := token.Module.Calculate(ctx, token); err == nil {
+			return result, nil
+		}
+	}
+
+	for _, module := range c.registry.Modules() {
+		if module == token.Module {
+			continue
+		}
+		if result, err := module.Calculate(ctx, token); err == nil {
+			return result, nil
+		}
+	}
+
+	return core.Result{}, fmt.Errorf("no module can handle token: %s", token.Str)
 }
 
 // parseExpression parses a complex expression like "1btc + 100usd"
 func (c *Converter) parseExpression(ctx context.Context, tokens []core.Token) (results []core.Result, operators []string, targetUnit core.Unit, err error) {
+	var valueTokens []core.Token
 	for i := 0; i < len(tokens); i++ {
 		token := tokens[i]
 		c.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("----- %s (%s) -----", token.Str, token.Kind.String()))
@@ -89,15 +163,8 @@ func (c *Converter) parseExpression(ctx context.Context, tokens []core.Token) (r
 		}
 
 		if token.Kind == core.ConversionToken {
-			// Try to find a module that can handle this token
-			var result core.Result
-			var err error
-			for _, module := range c.registry.Modules() {
-				result, err = module.Calculate(ctx, token)
-				if err == nil {
-					targetUnit = result.Unit
-					break
-				}
+			if result, err := c.calculateToken(ctx, token); err == nil {
+				targetUnit = result.Unit
 			}
 			if targetUnit.Name == "" {
 				c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to parse target unit from token %s", token.Str))
@@ -109,27 +176,47 @@ func (c *Converter) parseExpression(ctx context.Context, tokens []core.Token) (r
 			continue
 		}
 
-		// Try to find a module that can handle this token
-		var result core.Result
-		var err error
-		var moduleFound bool
-		for _, module := range c.registry.Modules() {
-			result, err = module.Calculate(ctx, token)
-			if err == nil {
-				moduleFound = true
-				results = append(results, result)
-				break
-			}
+		result, err := c.calculateToken(ctx, token)
+		if err != nil {
+			c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to calculate token %s: no module can handle it", token.Str))
+			return nil, nil, core.Unit{}, err
 		}
+		results = append(results, result)
+		valueTokens = append(valueTokens, token)
+	}
 
-		if !moduleFound {
-			c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to calculate token %s: no module can handle it", token.Str))
-			return nil, nil, core.Unit{}, fmt.Errorf("no module can handle token: %s", token.Str)
+	// the dimension of the expression is the one of the target unit, or of the first physical value, E.g. "1 km + 500 m"
+	dimension := targetUnit.Type
+	if !dimension.IsPhysical() {
+		if first, found := lo.Find(results, func(r core.Result) bool { return r.Unit.Type.IsPhysical() }); found {
+			dimension = first.Unit.Type
+		}
+	}
+	if !dimension.IsPhysical() {
+		if lo.SomeBy(valueTokens, func(t core.Token) bool { return t.Kind == core.NumberToken }) {
+			return nil, nil, core.Unit{}, fmt.Errorf("plain numbers are only supported to scale units, e.g. 10 km * 2")
+		}
+	}
+	if dimension.IsPhysical() {
+		for i := range results {
+			if results[i].Unit.Type == dimension || valueTokens[i].Kind == core.NumberToken {
+				continue
+			}
+
+			// values like "10 m" are tokenized as durations, read them as a unit of the expression's dimension instead, E.g. "10 m to ft"
+			if dimensionModule := c.getUnitModule(dimension); dimensionModule != nil {
+				if result, err := dimensionModule.Calculate(ctx, valueTokens[i]); err == nil {
+					results[i] = result
+					continue
+				}
+			}
+
+			return nil, nil, core.Unit{}, fmt.Errorf("can't mix %s (%s) with %s values", results[i].DisplayValue, results[i].Unit.Type, dimension)
 		}
 	}
 
-	// If we have a target unit, convert all values to that unit
-	if targetUnit.Name != "" {
+	// If we have a target unit, convert all values to that unit, physical values are converted after the calculation
+	if targetUnit.Name != "" && !targetUnit.Type.IsPhysical() {
 		for i := range results {
 			if results[i].Unit.Type == targetUnit.Type {
 				// Try all modules for conversion, not just the original module
@@ -159,6 +246,10 @@ func (c *Converter) calculateExpression(ctx context.Context, results []core.Resu
 		return core.Result{}, fmt.Errorf("invalid expression: operators count (%d) does not match results count (%d) - 1", len(operators), len(results))
 	}
 
+	if lo.SomeBy(results, func(r core.Result) bool { return r.Unit.Type.IsPhysical() }) {
+		return c.calculatePhysicalExpression(ctx, results, operators, targetUnit)
+	}
+
 	// If there are no operators and only one value, E.g. "100usd", "1btc"
 	if len(operators) == 0 && len(results) == 1 {
 		if targetUnit.Name == "" {
@@ -280,6 +371,108 @@ func (c *Converter) calculateExpression(ctx context.Context, results []core.Resu
 	return result, nil
 }
 
+// calculatePhysicalExpression calculates expressions of physical units like "1 km + 200 m in ft" or "10 km * 2".
+// The values are added up in the unit of the first value and the total is converted to the target unit once,
+// so units with an offset like temperatures are only shifted once. Values can be multiplied or divided by plain numbers.
+func (c *Converter) calculatePhysicalExpression(ctx context.Context, results []core.Result, operators []string, targetUnit core.Unit) (core.Result, error) {
+	first, _ := lo.Find(results, func(r core.Result) bool { return r.Unit.Type.IsPhysical() })
+	for _, result := range results {
+		if result.Unit.Type != first.Unit.Type && result.Unit.Type != core.UnitTypeNumber {
+			return core.Result{}, fmt.Errorf("can't mix %s (%s) and %s (%s)", first.DisplayValue, first.Unit.Type, result.DisplayValue, result.Unit.Type)
+		}
+	}
+
+	// values in the unit of the first value, plain numbers are kept as they are
+	values := make([]decimal.Decimal, len(results))
+	for i, result := range results {
+		if result.Unit.Type == core.UnitTypeNumber {
+			values[i] = result.RawValue
+			continue
+		}
+		convertedResult, err := result.Module.Convert(ctx, result, first.Unit)
+		if err != nil {
+			return core.Result{}, err
+		}
+		values[i] = convertedResult.RawValue
+	}
+
+	total := values[0]
+	isPhysical := results[0].Unit.Type.IsPhysical()
+	for i, operator := range operators {
+		nextIsPhysical := results[i+1].Unit.Type.IsPhysical()
+		switch operator {
+		case "+", "-":
+			if isPhysical != nextIsPhysical {
+				return core.Result{}, fmt.Errorf("can't add or subtract a plain number and %s values", first.Unit.Type)
+			}
+			if operator == "+" {
+				total = total.Add(values[i+1])
+			} else {
+				total = total.Sub(values[i+1])
+			}
+		case "*":
+			// multiplying two lengths gives an area, derived units are not supported
+			if isPhysical && nextIsPhysical {
+				return core.Result{}, fmt.Errorf("can't multiply two %s values, only by a plain number", first.Unit.Type)
+			}
+			total = total.Mul(values[i+1])
+			isPhysical = isPhysical || nextIsPhysical
+		case "/":
+			if nextIsPhysical {
+				return core.Result{}, fmt.Errorf("can't divide by %s values, only by a plain number", first.Unit.Type)
+			}
+			if values[i+1].IsZero() {
+				return core.Result{}, fmt.Errorf("division by zero")
+			}
+			total = total.Div(values[i+1])
+		default:
+			return core.Result{}, fmt.Errorf("operator %s is not supported for %s values", operator, first.Unit.Type)
+		}
+	}
+
+	if targetUnit.Name == "" {
+		targetUnit = first.Unit
+	}
+	return first.Module.Convert(ctx, core.Result{RawValue: total, Unit: first.Unit, Module: first.Module}, targetUnit)
+}
+
+func (c *Converter) getUnitModule(unitType core.UnitType) core.Module {
+	for _, module := range c.registry.Modules() {
+		if unitModule, ok := module.(*modules.PhysicalUnitModule); ok && unitModule.UnitType() == unitType {
+			return unitModule
+		}
+	}
+	return nil
+}
+
+// getRatesSubTitle tells the user how old the exchange rates of a currency result are, they may come from the last run when offline
+func (c *Converter) getRatesSubTitle(ctx context.Context, result core.Result) string {
+	if result.Unit.Type != core.UnitTypeCurrency || c.currencyModule == nil {
+		return ""
+	}
+
+	source, updatedAt := c.currencyModule.GetRatesInfo()
+	if updatedAt == 0 {
+		return ""
+	}
+	age := time.Since(time.UnixMilli(updatedAt))
+	return fmt.Sprintf(c.api.GetTranslation(ctx, "plugin_converter_rates_age"), source, formatRatesAge(age))
+}
+
+// formatRatesAge formats the age in the largest whole unit, E.g. "5m", "3h", "2d"
+func formatRatesAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "<1m"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
+}
+
 // GetUserDefaultCurrency returns the user's default currency based on their locale
 func GetUserDefaultCurrency() string {
 	var regionToCurrency = map[string]string{
@@ -306,16 +499,26 @@ func GetUserDefaultCurrency() string {
 }
 
 func (c *Converter) Query(ctx context.Context, query plugin.Query) []plugin.QueryResult {
-	if query.Search == "" {
+	if query.Search == "" || query.Command != "" {
 		return []plugin.QueryResult{}
 	}
 
-	tokens, err := c.tokenizer.Tokenize(ctx, query.Search)
+	result, err := c.convert(ctx, query.Search)
 	if err != nil {
-		// c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Tokenize error: %v", err))
 		return []plugin.QueryResult{}
 	}
 
+	return []plugin.QueryResult{c.getQueryResult(ctx, query.Search, result)}
+}
+
+// convert calculates a conversion or an expression with units, e.g. "10 km to mi" or "1btc + 100usd"
+func (c *Converter) convert(ctx context.Context, search string) (core.Result, error) {
+	tokens, err := c.tokenizer.Tokenize(ctx, search)
+	if err != nil {
+		// c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Tokenize error: %v", err))
+		return core.Result{}, err
+	}
+
 	c.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("Tokens: %s", strings.Join(lo.Map(tokens, func(t core.Token, _ int) string { return t.String() }), ", ")))
 
 	// Try to parse as an expression (could be a simple math expression or a mixed unit expression)
@@ -323,12 +526,12 @@ func (c *Converter) Query(ctx context.Context, query plugin.Query) []plugin.Quer
 	if err != nil {
 		c.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("Parse expression error: %v", err))
 		// For invalid expressions, return a search suggestion
-		return []plugin.QueryResult{}
+		return core.Result{}, err
 	}
 
 	if len(results) == 0 {
 		c.api.Log(ctx, plugin.LogLevelDebug, "No values parsed from expression")
-		return []plugin.QueryResult{}
+		return core.Result{}, fmt.Errorf("no values parsed from expression")
 	}
 
 	c.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("Expression parsed: values=%s, operators=%s, targetUnit=%s", strings.Join(lo.Map(results, func(v core.Result, _ int) string { return v.DisplayValue }), ", "), strings.Join(operators, ", "), targetUnit.Name))
@@ -337,29 +540,74 @@ func (c *Converter) Query(ctx context.Context, query plugin.Query) []plugin.Quer
 	result, err := c.calculateExpression(ctx, results, operators, targetUnit)
 	if err != nil {
 		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Calculation  expression error: %v", err))
-		return []plugin.QueryResult{}
+		return core.Result{}, err
 	} else {
 		c.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("Calculation result: displayValue=%s, rawValue=%s, unit=%s", result.DisplayValue, result.RawValue.String(), result.Unit.Name))
 	}
 
-	return []plugin.QueryResult{
-		{
-			Title: result.DisplayValue,
-			Icon:  common.PluginConverterIcon,
-			Actions: []plugin.QueryResultAction{
-				{
-					Name: "i18n:plugin_calculator_copy_result",
-					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-						clipboard.WriteText(result.DisplayValue)
-					},
+	return result, nil
+}
+
+func (c *Converter) getQueryResult(ctx context.Context, expression string, result core.Result) plugin.QueryResult {
+	contextDataJson, _ := json.Marshal(converterContextData{Expression: expression})
+	var preview plugin.WoxPreview
+	if result.Preview != "" {
+		preview = plugin.WoxPreview{PreviewType: plugin.WoxPreviewTypeMarkdown, PreviewData: result.Preview}
+	}
+	return plugin.QueryResult{
+		Title:       result.DisplayValue,
+		SubTitle:    c.getRatesSubTitle(ctx, result),
+		Icon:        common.PluginConverterIcon,
+		Preview:     preview,
+		ContextData: string(contextDataJson),
+		Actions: []plugin.QueryResultAction{
+			{
+				Name: "i18n:plugin_calculator_copy_result",
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					c.addHistory(ctx, expression, result, false)
+					clipboard.WriteText(result.DisplayValue)
 				},
-				{
-					Name: "i18n:plugin_calculator_add_to_favorite",
-					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-						// Handle add to favorite action
-					},
+			},
+			{
+				Name: "i18n:plugin_calculator_add_to_favorite",
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					c.addHistory(ctx, expression, result, true)
 				},
 			},
 		},
 	}
 }
+
+// addHistory adds a conversion to the history shared with the calculator plugin, which lists it
+func (c *Converter) addHistory(ctx context.Context, expression string, result core.Result, favorite bool) {
+	err := c.historyManager.Add(ctx, history.Entry{
+		Source:     history.SourceConverter,
+		Expression: expression,
+		Result:     result.DisplayValue,
+		Unit:       result.Unit.Name,
+		Favorite:   favorite,
+	})
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to add converter history: %s", err.Error()))
+	}
+}
+
+// handleMRURestore converts the expression again, rates may have changed since it was used
+func (c *Converter) handleMRURestore(mruData plugin.MRUData) (*plugin.QueryResult, error) {
+	var contextData converterContextData
+	if err := json.Unmarshal([]byte(mruData.ContextData), &contextData); err != nil {
+		return nil, fmt.Errorf("failed to parse context data: %w", err)
+	}
+
+	ctx := context.Background()
+	result, err := c.convert(ctx, contextData.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", contextData.Expression, err)
+	}
+
+	queryResult := c.getQueryResult(ctx, contextData.Expression, result)
+	if queryResult.SubTitle == "" {
+		queryResult.SubTitle = contextData.Expression
+	}
+	return &queryResult, nil
+}
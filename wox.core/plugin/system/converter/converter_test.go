@@ -0,0 +1,71 @@
+package converter
+
+import (
+	"context"
+	"testing"
+	"wox/plugin/plugintest"
+	"wox/plugin/system/converter/core"
+	"wox/plugin/system/converter/modules"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestConverter() *Converter {
+	ctx := context.Background()
+	c := &Converter{api: plugintest.NewAPI()}
+
+	registry := core.NewModuleRegistry()
+	registry.Register(modules.NewMathModule(ctx, c.api))
+	registry.Register(modules.NewTimeModule(ctx, c.api))
+	registry.Register(modules.NewLengthModule(ctx, c.api))
+	registry.Register(modules.NewTemperatureModule(ctx, c.api))
+	c.registry = registry
+	c.tokenizer = core.NewTokenizer(registry.GetTokenPatterns())
+	return c
+}
+
+func TestPhysicalExpression(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"1 km + 200 m in ft", "3937.007874 ft"},
+		{"1 km - 200 m", "0.8 km"},
+		// temperatures are added up before converting, so the offset is applied once
+		{"72 f + 10 f to c", "27.777778 °C"},
+		{"20 c + 5 c to f", "77 °F"},
+		{"72 f + 10 f", "82 °F"},
+		// scaling by a plain number
+		{"10 km * 2", "20 km"},
+		{"2 * 10 km to m", "20000 m"},
+		{"10 km / 4 to m", "2500 m"},
+		{"10 c * 2 to f", "68 °F"},
+	}
+
+	c := newTestConverter()
+	for _, tt := range tests {
+		result, err := c.convert(context.Background(), tt.query)
+		if assert.NoError(t, err, tt.query) {
+			assert.Equal(t, tt.expected, result.DisplayValue, tt.query)
+		}
+	}
+}
+
+func TestPhysicalExpressionErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"10 km * 2 km", "can't multiply two length values, only by a plain number"},
+		{"10 km / 2 km", "can't divide by length values, only by a plain number"},
+		{"10 km + 2", "can't add or subtract a plain number and length values"},
+		{"10 km / 0", "division by zero"},
+		{"1 + 2", "plain numbers are only supported to scale units, e.g. 10 km * 2"},
+	}
+
+	c := newTestConverter()
+	for _, tt := range tests {
+		_, err := c.convert(context.Background(), tt.query)
+		assert.EqualError(t, err, tt.expected, tt.query)
+	}
+}
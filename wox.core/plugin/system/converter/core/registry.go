@@ -10,12 +10,61 @@ import (
 type UnitType int
 
 const (
-	UnitTypeNumber   UnitType = iota // For pure numbers without unit
-	UnitTypeCrypto                   // For cryptocurrency units (BTC, ETH, etc.)
-	UnitTypeCurrency                 // For fiat currency units (USD, EUR, etc.)
-	UnitTypeTime                     // For time units (seconds, minutes, etc.)
+	UnitTypeNumber      UnitType = iota // For pure numbers without unit
+	UnitTypeCrypto                      // For cryptocurrency units (BTC, ETH, etc.)
+	UnitTypeCurrency                    // For fiat currency units (USD, EUR, etc.)
+	UnitTypeTime                        // For time units (seconds, minutes, etc.)
+	UnitTypeLength                      // For length units (m, km, mi, etc.)
+	UnitTypeMass                        // For mass units (g, kg, lb, etc.)
+	UnitTypeVolume                      // For volume units (L, ml, cup, etc.)
+	UnitTypeTemperature                 // For temperature units (°C, °F, K)
+	UnitTypeArea                        // For area units (m², ha, acre, etc.)
+	UnitTypeSpeed                       // For speed units (km/h, mph, knot, etc.)
+	UnitTypePressure                    // For pressure units (Pa, bar, psi, etc.)
+	UnitTypeEnergy                      // For energy units (J, kWh, kcal, etc.)
+	UnitTypeDataSize                    // For data size units (B, MB, GiB, bit, etc.)
+	UnitTypeAngle                       // For angle units (°, rad, turn, etc.)
 )
 
+// IsPhysical reports whether the unit type is a physical dimension, values of different physical dimensions can't be mixed
+func (t UnitType) IsPhysical() bool {
+	return t >= UnitTypeLength && t <= UnitTypeAngle
+}
+
+func (t UnitType) String() string {
+	switch t {
+	case UnitTypeNumber:
+		return "number"
+	case UnitTypeCrypto:
+		return "crypto"
+	case UnitTypeCurrency:
+		return "currency"
+	case UnitTypeTime:
+		return "time"
+	case UnitTypeLength:
+		return "length"
+	case UnitTypeMass:
+		return "mass"
+	case UnitTypeVolume:
+		return "volume"
+	case UnitTypeTemperature:
+		return "temperature"
+	case UnitTypeArea:
+		return "area"
+	case UnitTypeSpeed:
+		return "speed"
+	case UnitTypePressure:
+		return "pressure"
+	case UnitTypeEnergy:
+		return "energy"
+	case UnitTypeDataSize:
+		return "data size"
+	case UnitTypeAngle:
+		return "angle"
+	}
+	return "unknown"
+}
+
 type Unit struct {
 	Name string   // The name of the unit, E.g. "USD", "BTC", "ETH", "seconds", "minutes", etc.
 	Type UnitType // The type of the unit
@@ -37,6 +86,8 @@ type Result struct {
 	Unit Unit
 	// The module that this result belongs to
 	Module Module
+	// Optional markdown shown in the preview, e.g. the details of a date
+	Preview string
 }
 
 // Module interface defines methods that a calculator module must implement
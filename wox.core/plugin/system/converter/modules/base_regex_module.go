@@ -16,6 +16,7 @@ type patternHandler struct {
 	Handler     func(ctx context.Context, matches []string) (core.Result, error) // handler function for the pattern
 	Description string                                                           // description of what this pattern does
 	FullMatch   bool                                                             // whether the pattern is a full match
+	Conversion  bool                                                             // whether the pattern is a conversion target, e.g. "in EUR"
 	regexp      *regexp.Regexp                                                   // compiled regexp
 }
 
@@ -51,9 +52,13 @@ func (m *regexBaseModule) Name() string {
 func (m *regexBaseModule) TokenPatterns() []core.TokenPattern {
 	patterns := make([]core.TokenPattern, 0, len(m.patternHandlers))
 	for _, handler := range m.patternHandlers {
+		tokenKind := core.IdentToken
+		if handler.Conversion {
+			tokenKind = core.ConversionToken
+		}
 		patterns = append(patterns, core.TokenPattern{
 			Pattern:   handler.Pattern,
-			Type:      core.IdentToken,
+			Type:      tokenKind,
 			Priority:  handler.Priority,
 			FullMatch: handler.FullMatch,
 			Module:    m,
@@ -3,10 +3,14 @@ package modules
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"wox/plugin"
 	"wox/plugin/system/converter/core"
@@ -16,9 +20,41 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const (
+	// LocalExchangeRatesFileSettingKey is the setting of a user provided rates file, it is preferred over the online sources
+	LocalExchangeRatesFileSettingKey = "localExchangeRatesFile"
+)
+
+// exchangeRatesCachePath is the file the last good rates are persisted to. It is a cache of this machine,
+// in the plugin settings it would be synced to every device and exported to the dotfile on each hourly update.
+var exchangeRatesCachePath = func() string {
+	return filepath.Join(util.GetLocation().GetCacheDirectory(), "converter_exchange_rates.json")
+}
+
 type CurrencyModule struct {
 	*regexBaseModule
-	rates map[string]float64
+	rates          map[string]float64 // units of the currency per USD
+	ratesSource    string
+	ratesUpdatedAt int64
+	ratesLock      sync.RWMutex
+}
+
+// exchangeRateTable is the last good rate table, it is persisted so conversion keeps working after a restart without network
+type exchangeRateTable struct {
+	Source    string
+	UpdatedAt int64
+	Rates     map[string]float64
+}
+
+// localExchangeRatesFile is the format of the user provided rates file, rates are units of each currency per one base, e.g.
+//
+//	{"base": "EUR", "updatedAt": "2024-05-01T00:00:00Z", "rates": {"USD": 1.08, "GBP": 0.85}}
+//
+// updatedAt is optional, the modification time of the file is used instead.
+type localExchangeRatesFile struct {
+	Base      string             `json:"base"`
+	UpdatedAt string             `json:"updatedAt"`
+	Rates     map[string]float64 `json:"rates"`
 }
 
 func NewCurrencyModule(ctx context.Context, api plugin.API) *CurrencyModule {
@@ -27,72 +63,166 @@ func NewCurrencyModule(ctx context.Context, api plugin.API) *CurrencyModule {
 	}
 
 	const (
-		currencyPattern = `(?i)(usd|eur|gbp|jpy|cny|aud|cad)`
-		numberPattern   = `([0-9]+(?:\.[0-9]+)?)`
+		numberPattern = `([0-9]+(?:\.[0-9]+)?)`
+	)
+	var (
+		codePattern   = currencyCodePattern(false)
+		symbolPattern = currencySymbolPattern()
+		targetPattern = `(?:` + currencyCodePattern(true) + `|` + symbolPattern + `)`
 	)
 
 	// Initialize pattern handlers with atomic patterns
 	handlers := []*patternHandler{
 		{
-			Pattern:     numberPattern + `\s*` + currencyPattern,
+			Pattern:     numberPattern + `\s*` + codePattern,
 			Priority:    1000,
 			Description: "Handle currency amount (e.g., 10 USD)",
 			Handler:     m.handleSingleCurrency,
 		},
 		{
-			Pattern:     `in\s+` + currencyPattern,
+			Pattern:     symbolPattern + `\s*` + numberPattern,
+			Priority:    1000,
+			Description: "Handle currency amount with leading symbol (e.g., €10)",
+			Handler:     m.handleSymbolFirstCurrency,
+		},
+		{
+			Pattern:     numberPattern + `\s*` + symbolPattern,
+			Priority:    1000,
+			Description: "Handle currency amount with trailing symbol (e.g., 10€)",
+			Handler:     m.handleSingleCurrency,
+		},
+		{
+			Pattern:     `(?i:in)\s+` + targetPattern,
 			Priority:    900,
 			Description: "Handle 'in' conversion format (e.g., in EUR)",
 			Handler:     m.handleInConversion,
+			Conversion:  true,
 		},
 		{
-			Pattern:     `to\s+` + currencyPattern,
+			Pattern:     `(?i:to)\s+` + targetPattern,
 			Priority:    800,
 			Description: "Handle 'to' conversion format (e.g., to EUR)",
 			Handler:     m.handleToConversion,
+			Conversion:  true,
 		},
 		{
-			Pattern:     `=\s*\?\s*` + currencyPattern,
+			Pattern:     `=\s*\?\s*` + targetPattern,
 			Priority:    700,
 			Description: "Handle '=?' conversion format (e.g., =?EUR)",
 			Handler:     m.handleToConversion,
+			Conversion:  true,
 		},
 	}
 
 	m.regexBaseModule = NewRegexBaseModule(api, "currency", handlers)
+	m.loadPersistedRates(ctx)
 	return m
 }
 
 func (m *CurrencyModule) StartExchangeRateSyncSchedule(ctx context.Context) {
+	m.api.OnSettingChanged(ctx, func(key string, value string) {
+		if key == LocalExchangeRatesFileSettingKey {
+			util.Go(ctx, "currency_exchange_rate_sync_local_file", func() {
+				m.syncExchangeRates(ctx)
+			})
+		}
+	})
+
 	util.Go(ctx, "currency_exchange_rate_sync", func() {
-		// Try multiple data sources
-		sources := []func(context.Context) (map[string]float64, error){
-			m.parseExchangeRateFromHKAB,
-			m.parseExchangeRateFromECB,
+		m.syncExchangeRates(ctx)
+		for range time.NewTicker(1 * time.Hour).C {
+			m.syncExchangeRates(ctx)
 		}
+	})
+}
 
-		for _, source := range sources {
-			rates, err := source(ctx)
-			if err == nil && len(rates) > 0 {
-				m.rates = rates
-				util.GetLogger().Info(ctx, "Successfully updated rates from source")
-				break
-			}
-			util.GetLogger().Warn(ctx, fmt.Sprintf("Failed to update rates from source: %s", err.Error()))
+// syncExchangeRates updates the rates from the local rates file if there is one, otherwise from the first online source that works.
+// If every source fails, the persisted rates of the last sync are kept.
+func (m *CurrencyModule) syncExchangeRates(ctx context.Context) {
+	if localFile := strings.TrimSpace(m.api.GetSetting(ctx, LocalExchangeRatesFileSettingKey)); localFile != "" {
+		table, err := parseExchangeRateFromLocalFile(localFile)
+		if err == nil {
+			m.setRates(ctx, table, true)
+			util.GetLogger().Info(ctx, fmt.Sprintf("Successfully updated %d rates from local file %s", len(table.Rates), localFile))
+			return
 		}
+		util.GetLogger().Warn(ctx, fmt.Sprintf("Failed to update rates from local file: %s", err.Error()))
+	}
 
-		for range time.NewTicker(1 * time.Hour).C {
-			for _, source := range sources {
-				rates, err := source(ctx)
-				if err == nil && len(rates) > 0 {
-					m.rates = rates
-					util.GetLogger().Info(ctx, "Successfully updated rates from source")
-					break
-				}
-				util.GetLogger().Warn(ctx, fmt.Sprintf("Failed to update rates from source: %s", err.Error()))
-			}
+	sources := []struct {
+		name  string
+		fetch func(context.Context) (map[string]float64, error)
+	}{
+		{"HKAB", m.parseExchangeRateFromHKAB},
+		{"ECB", m.parseExchangeRateFromECB},
+	}
+	for _, source := range sources {
+		rates, err := source.fetch(ctx)
+		if err == nil && len(rates) > 0 {
+			m.setRates(ctx, exchangeRateTable{Source: source.name, UpdatedAt: util.GetSystemTimestamp(), Rates: rates}, true)
+			util.GetLogger().Info(ctx, fmt.Sprintf("Successfully updated rates from %s", source.name))
+			return
 		}
-	})
+		if err == nil {
+			err = fmt.Errorf("no rates found")
+		}
+		util.GetLogger().Warn(ctx, fmt.Sprintf("Failed to update rates from %s: %s", source.name, err.Error()))
+	}
+}
+
+func (m *CurrencyModule) setRates(ctx context.Context, table exchangeRateTable, persist bool) {
+	m.ratesLock.Lock()
+	m.rates = table.Rates
+	m.ratesSource = table.Source
+	m.ratesUpdatedAt = table.UpdatedAt
+	m.ratesLock.Unlock()
+
+	if !persist {
+		return
+	}
+	data, err := json.Marshal(table)
+	if err != nil {
+		util.GetLogger().Error(ctx, fmt.Sprintf("Failed to marshal exchange rates: %s", err.Error()))
+		return
+	}
+	cachePath := exchangeRatesCachePath()
+	if err := os.MkdirAll(filepath.Dir(cachePath), os.ModePerm); err != nil {
+		util.GetLogger().Error(ctx, fmt.Sprintf("Failed to create exchange rates cache directory: %s", err.Error()))
+		return
+	}
+	if err := os.WriteFile(cachePath, data, 0644); err != nil {
+		util.GetLogger().Error(ctx, fmt.Sprintf("Failed to persist exchange rates: %s", err.Error()))
+	}
+}
+
+func (m *CurrencyModule) loadPersistedRates(ctx context.Context) {
+	data, err := os.ReadFile(exchangeRatesCachePath())
+	if err != nil {
+		return
+	}
+
+	var table exchangeRateTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		util.GetLogger().Warn(ctx, fmt.Sprintf("Failed to load persisted exchange rates: %s", err.Error()))
+		return
+	}
+	if len(table.Rates) > 0 {
+		m.setRates(ctx, table, false)
+	}
+}
+
+func (m *CurrencyModule) getRate(currency string) (float64, bool) {
+	m.ratesLock.RLock()
+	defer m.ratesLock.RUnlock()
+	rate, ok := m.rates[currency]
+	return rate, ok
+}
+
+// GetRatesInfo returns the source of the current rates and when they were updated, updatedAt is 0 if there are no rates yet
+func (m *CurrencyModule) GetRatesInfo() (source string, updatedAt int64) {
+	m.ratesLock.RLock()
+	defer m.ratesLock.RUnlock()
+	return m.ratesSource, m.ratesUpdatedAt
 }
 
 func (m *CurrencyModule) Convert(ctx context.Context, value core.Result, toUnit core.Unit) (core.Result, error) {
@@ -100,17 +230,19 @@ func (m *CurrencyModule) Convert(ctx context.Context, value core.Result, toUnit
 	toCurrency := toUnit.Name
 
 	// Check if currencies are supported
-	if _, ok := m.rates[fromCurrency]; !ok {
+	fromRate, ok := m.getRate(fromCurrency)
+	if !ok {
 		return core.Result{}, fmt.Errorf("unsupported currency: %s", fromCurrency)
 	}
-	if _, ok := m.rates[toCurrency]; !ok {
+	toRate, ok := m.getRate(toCurrency)
+	if !ok {
 		return core.Result{}, fmt.Errorf("unsupported currency: %s", toCurrency)
 	}
 
 	// Convert to USD first (as base currency), then to target currency
 	amountFloat, _ := value.RawValue.Float64()
-	amountInUSD := amountFloat / m.rates[fromCurrency]
-	result := amountInUSD * m.rates[toCurrency]
+	amountInUSD := amountFloat / fromRate
+	result := amountInUSD * toRate
 	resultDecimal := decimal.NewFromFloat(result)
 
 	return core.Result{
@@ -122,7 +254,11 @@ func (m *CurrencyModule) Convert(ctx context.Context, value core.Result, toUnit
 }
 
 func (m *CurrencyModule) CanConvertTo(unit string) bool {
-	_, ok := m.rates[strings.ToUpper(unit)]
+	currency, found := lookupCurrency(unit)
+	if !found {
+		return false
+	}
+	_, ok := m.getRate(currency)
 	return ok
 }
 
@@ -134,10 +270,13 @@ func (m *CurrencyModule) handleSingleCurrency(ctx context.Context, matches []str
 		return core.Result{}, fmt.Errorf("invalid amount: %s", matches[1])
 	}
 
-	currency := strings.ToUpper(matches[2])
+	currency, found := lookupCurrency(matches[2])
+	if !found {
+		return core.Result{}, fmt.Errorf("unknown currency: %s", matches[2])
+	}
 
 	// Check if the currency is supported
-	if _, ok := m.rates[currency]; !ok {
+	if _, ok := m.getRate(currency); !ok {
 		return core.Result{}, fmt.Errorf("unsupported currency: %s", currency)
 	}
 
@@ -149,10 +288,14 @@ func (m *CurrencyModule) handleSingleCurrency(ctx context.Context, matches []str
 	}, nil
 }
 
+func (m *CurrencyModule) handleSymbolFirstCurrency(ctx context.Context, matches []string) (core.Result, error) {
+	return m.handleSingleCurrency(ctx, []string{matches[0], matches[2], matches[1]})
+}
+
 func (m *CurrencyModule) handleInConversion(ctx context.Context, matches []string) (core.Result, error) {
-	currency := strings.ToUpper(matches[1])
-	if _, ok := m.rates[currency]; !ok {
-		return core.Result{}, fmt.Errorf("unsupported currency: %s", currency)
+	currency, err := m.parseTargetCurrency(matches)
+	if err != nil {
+		return core.Result{}, err
 	}
 	return core.Result{
 		DisplayValue: fmt.Sprintf("in %s", currency),
@@ -163,9 +306,9 @@ func (m *CurrencyModule) handleInConversion(ctx context.Context, matches []strin
 }
 
 func (m *CurrencyModule) handleToConversion(ctx context.Context, matches []string) (core.Result, error) {
-	currency := strings.ToUpper(matches[1])
-	if _, ok := m.rates[currency]; !ok {
-		return core.Result{}, fmt.Errorf("unsupported currency: %s", currency)
+	currency, err := m.parseTargetCurrency(matches)
+	if err != nil {
+		return core.Result{}, err
 	}
 	return core.Result{
 		DisplayValue: fmt.Sprintf("to %s", currency),
@@ -175,25 +318,27 @@ func (m *CurrencyModule) handleToConversion(ctx context.Context, matches []strin
 	}, nil
 }
 
+// parseTargetCurrency returns the currency of a conversion, the code and the symbol are alternative groups of the pattern
+func (m *CurrencyModule) parseTargetCurrency(matches []string) (string, error) {
+	target := matches[1]
+	if target == "" && len(matches) > 2 {
+		target = matches[2]
+	}
+
+	currency, found := lookupCurrency(target)
+	if !found {
+		return "", fmt.Errorf("unknown currency: %s", target)
+	}
+	if _, ok := m.getRate(currency); !ok {
+		return "", fmt.Errorf("unsupported currency: %s", currency)
+	}
+	return currency, nil
+}
+
 func (m *CurrencyModule) formatWithCurrencySymbol(amount decimal.Decimal, currency string) string {
-	var symbol string
-	switch currency {
-	case "USD":
-		symbol = "$"
-	case "EUR":
-		symbol = "€"
-	case "GBP":
-		symbol = "£"
-	case "JPY":
-		symbol = "¥"
-	case "CNY":
-		symbol = "¥"
-	case "AUD":
-		symbol = "A$"
-	case "CAD":
-		symbol = "C$"
-	default:
-		symbol = ""
+	symbol, ok := currencySymbols[currency]
+	if !ok {
+		return fmt.Sprintf("%s %s", amount.Round(2), currency)
 	}
 
 	// Format with exactly 2 decimal places
@@ -373,25 +518,53 @@ func (m *CurrencyModule) parseExchangeRateFromECB(ctx context.Context) (rates ma
 	return rates, nil
 }
 
-func (m *CurrencyModule) TokenPatterns() []core.TokenPattern {
-	return []core.TokenPattern{
-		{
-			Pattern:   `([0-9]+(?:\.[0-9]+)?)\s*(?i)(usd|eur|gbp|jpy|cny|aud|cad)`,
-			Type:      core.IdentToken,
-			Priority:  1000,
-			FullMatch: false,
-		},
-		{
-			Pattern:   `(?i)(?:in|to)\s+(usd|eur|gbp|jpy|cny|aud|cad)`,
-			Type:      core.ConversionToken,
-			Priority:  900,
-			FullMatch: false,
-		},
-		{
-			Pattern:   `(?i)=\s*\?\s*(usd|eur|gbp|jpy|cny|aud|cad)`,
-			Type:      core.ConversionToken,
-			Priority:  800,
-			FullMatch: false,
-		},
+// parseExchangeRateFromLocalFile reads a user provided rates file and converts the rates to USD base
+func parseExchangeRateFromLocalFile(path string) (exchangeRateTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return exchangeRateTable{}, err
+	}
+
+	var file localExchangeRatesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return exchangeRateTable{}, fmt.Errorf("invalid rates file %s: %w", path, err)
+	}
+
+	base := strings.ToUpper(strings.TrimSpace(file.Base))
+	if base == "" {
+		base = "USD"
+	}
+	baseRates := map[string]float64{base: 1}
+	for currency, rate := range file.Rates {
+		if rate <= 0 {
+			return exchangeRateTable{}, fmt.Errorf("invalid rate for %s: %v", currency, rate)
+		}
+		baseRates[strings.ToUpper(currency)] = rate
+	}
+
+	usdRate, ok := baseRates["USD"]
+	if !ok {
+		return exchangeRateTable{}, fmt.Errorf("USD rate not found in rates file %s", path)
 	}
+	rates := make(map[string]float64, len(baseRates))
+	for currency, rate := range baseRates {
+		rates[currency] = rate / usdRate
+	}
+
+	var updatedAt int64
+	if file.UpdatedAt != "" {
+		t, err := time.Parse(time.RFC3339, file.UpdatedAt)
+		if err != nil {
+			return exchangeRateTable{}, fmt.Errorf("invalid updatedAt in rates file %s: %w", path, err)
+		}
+		updatedAt = t.UnixMilli()
+	} else {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return exchangeRateTable{}, err
+		}
+		updatedAt = stat.ModTime().UnixMilli()
+	}
+
+	return exchangeRateTable{Source: filepath.Base(path), UpdatedAt: updatedAt, Rates: rates}, nil
 }
@@ -0,0 +1,138 @@
+package modules
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// isoCurrencyCodes are the active ISO 4217 currency codes. Only the codes with a rate from the current source can be converted.
+var isoCurrencyCodes = []string{
+	"AED", "AFN", "ALL", "AMD", "ANG", "AOA", "ARS", "AUD", "AWG", "AZN",
+	"BAM", "BBD", "BDT", "BGN", "BHD", "BIF", "BMD", "BND", "BOB", "BRL",
+	"BSD", "BTN", "BWP", "BYN", "BZD", "CAD", "CDF", "CHF", "CLP", "CNY",
+	"COP", "CRC", "CUP", "CVE", "CZK", "DJF", "DKK", "DOP", "DZD", "EGP",
+	"ERN", "ETB", "EUR", "FJD", "FKP", "GBP", "GEL", "GHS", "GIP", "GMD",
+	"GNF", "GTQ", "GYD", "HKD", "HNL", "HTG", "HUF", "IDR", "ILS", "INR",
+	"IQD", "IRR", "ISK", "JMD", "JOD", "JPY", "KES", "KGS", "KHR", "KMF",
+	"KPW", "KRW", "KWD", "KYD", "KZT", "LAK", "LBP", "LKR", "LRD", "LSL",
+	"LYD", "MAD", "MDL", "MGA", "MKD", "MMK", "MNT", "MOP", "MRU", "MUR",
+	"MVR", "MWK", "MXN", "MYR", "MZN", "NAD", "NGN", "NIO", "NOK", "NPR",
+	"NZD", "OMR", "PAB", "PEN", "PGK", "PHP", "PKR", "PLN", "PYG", "QAR",
+	"RON", "RSD", "RUB", "RWF", "SAR", "SBD", "SCR", "SDG", "SEK", "SGD",
+	"SHP", "SLE", "SOS", "SRD", "SSP", "STN", "SVC", "SYP", "SZL", "THB",
+	"TJS", "TMT", "TND", "TOP", "TRY", "TTD", "TWD", "TZS", "UAH", "UGX",
+	"USD", "UYU", "UZS", "VES", "VND", "VUV", "WST", "XAF", "XCD", "XOF",
+	"XPF", "YER", "ZAR", "ZMW", "ZWL",
+}
+
+// ambiguousCurrencyCodes are codes that are also common words or units of other converter modules, e.g. "3 cup" is a volume.
+// They are still recognized as conversion targets ("100 usd to cup") but not as amounts.
+var ambiguousCurrencyCodes = map[string]bool{
+	"ALL": true,
+	"CUP": true,
+	"TOP": true,
+	"TRY": true,
+}
+
+// currencySymbols are the symbols shown before formatted amounts
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "¥",
+	"AUD": "A$",
+	"CAD": "C$",
+	"BRL": "R$",
+	"HKD": "HK$",
+	"NZD": "NZ$",
+	"SGD": "S$",
+	"INR": "₹",
+	"KRW": "₩",
+	"RUB": "₽",
+	"TRY": "₺",
+	"ILS": "₪",
+	"VND": "₫",
+	"PHP": "₱",
+	"UAH": "₴",
+	"NGN": "₦",
+	"THB": "฿",
+	"PLN": "zł",
+}
+
+// symbolCurrencies maps the symbols typed by the user to currency codes, ambiguous symbols map to the most common currency ("¥" is JPY)
+var symbolCurrencies = map[string]string{
+	"$":   "USD",
+	"US$": "USD",
+	"€":   "EUR",
+	"£":   "GBP",
+	"¥":   "JPY",
+	"CN¥": "CNY",
+	"RMB": "CNY",
+	"元":   "CNY",
+	"A$":  "AUD",
+	"C$":  "CAD",
+	"R$":  "BRL",
+	"HK$": "HKD",
+	"NZ$": "NZD",
+	"S$":  "SGD",
+	"₹":   "INR",
+	"₩":   "KRW",
+	"₽":   "RUB",
+	"₺":   "TRY",
+	"₪":   "ILS",
+	"₫":   "VND",
+	"₱":   "PHP",
+	"₴":   "UAH",
+	"₦":   "NGN",
+	"฿":   "THB",
+	"zł":  "PLN",
+}
+
+// lookupCurrency resolves a currency code or symbol, case-insensitively
+func lookupCurrency(codeOrSymbol string) (string, bool) {
+	codeOrSymbol = strings.TrimSpace(codeOrSymbol)
+	upper := strings.ToUpper(codeOrSymbol)
+	for _, code := range isoCurrencyCodes {
+		if code == upper {
+			return code, true
+		}
+	}
+	for symbol, code := range symbolCurrencies {
+		if strings.EqualFold(symbol, codeOrSymbol) {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// currencyCodePattern matches the currency codes as whole words, ambiguous codes are only matched when includeAmbiguous is set
+func currencyCodePattern(includeAmbiguous bool) string {
+	var codes []string
+	for _, code := range isoCurrencyCodes {
+		if !includeAmbiguous && ambiguousCurrencyCodes[code] {
+			continue
+		}
+		codes = append(codes, strings.ToLower(code))
+	}
+	return `(?i:(` + strings.Join(codes, "|") + `|rmb)\b)`
+}
+
+// currencySymbolPattern matches the currency symbols, longest first so "R$" isn't matched as "$"
+func currencySymbolPattern() string {
+	var symbols []string
+	for symbol := range symbolCurrencies {
+		if symbol == "RMB" {
+			continue // a word, it is part of the code pattern
+		}
+		symbols = append(symbols, regexp.QuoteMeta(strings.ToLower(symbol)))
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if len(symbols[i]) != len(symbols[j]) {
+			return len(symbols[i]) > len(symbols[j])
+		}
+		return symbols[i] < symbols[j]
+	})
+	return `(?i:(` + strings.Join(symbols, "|") + `))`
+}
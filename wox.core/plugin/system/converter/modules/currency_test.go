@@ -1,8 +1,18 @@
 package modules
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+	"wox/plugin/plugintest"
+	"wox/plugin/system/converter/core"
 	"wox/util"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseExchangeRateFromHKAB(t *testing.T) {
@@ -39,3 +49,112 @@ func TestParseExchangeRateFromHKAB(t *testing.T) {
 		t.Errorf("CNY rate %f is outside expected range [6.0, 8.0]", cnyRate)
 	}
 }
+
+func TestParseExchangeRateFromLocalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	content := `{"base": "eur", "updatedAt": "2024-05-01T00:00:00Z", "rates": {"USD": 1.25, "GBP": 0.8}}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	table, err := parseExchangeRateFromLocalFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "rates.json", table.Source)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC).UnixMilli(), table.UpdatedAt)
+	assert.InDelta(t, 1.0, table.Rates["USD"], 1e-9)
+	assert.InDelta(t, 0.8, table.Rates["EUR"], 1e-9)
+	assert.InDelta(t, 0.64, table.Rates["GBP"], 1e-9)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"base": "EUR", "rates": {"GBP": 0.8}}`), 0644))
+	_, err = parseExchangeRateFromLocalFile(path)
+	assert.Error(t, err, "rates without USD can't be converted to USD base")
+
+	_, err = parseExchangeRateFromLocalFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+// useTempRatesCache persists the rates of the test to a temporary file
+func useTempRatesCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "rates.json")
+	previous := exchangeRatesCachePath
+	exchangeRatesCachePath = func() string { return cachePath }
+	t.Cleanup(func() { exchangeRatesCachePath = previous })
+}
+
+func TestCurrencyRatesArePersisted(t *testing.T) {
+	ctx := context.Background()
+	useTempRatesCache(t)
+	api := plugintest.NewAPI()
+
+	m := NewCurrencyModule(ctx, api)
+	_, updatedAt := m.GetRatesInfo()
+	assert.Zero(t, updatedAt)
+
+	m.setRates(ctx, exchangeRateTable{Source: "ECB", UpdatedAt: 1700000000000, Rates: map[string]float64{"USD": 1, "EUR": 0.5}}, true)
+	assert.Empty(t, api.Settings, "the rates are a cache, they are not saved to the plugin settings")
+
+	// a new module, e.g. after a restart without network, starts with the last good rates
+	restored := NewCurrencyModule(ctx, api)
+	source, updatedAt := restored.GetRatesInfo()
+	assert.Equal(t, "ECB", source)
+	assert.Equal(t, int64(1700000000000), updatedAt)
+
+	result, err := restored.Convert(ctx, core.Result{RawValue: decimal.NewFromInt(10), Unit: core.Unit{Name: "USD", Type: core.UnitTypeCurrency}}, core.Unit{Name: "EUR", Type: core.UnitTypeCurrency})
+	require.NoError(t, err)
+	assert.Equal(t, "€5", result.DisplayValue)
+}
+
+func TestCurrencyCodesAndSymbols(t *testing.T) {
+	ctx := context.Background()
+	useTempRatesCache(t)
+	m := NewCurrencyModule(ctx, plugintest.NewAPI())
+	m.setRates(ctx, exchangeRateTable{Source: "test", Rates: map[string]float64{"USD": 1, "EUR": 0.5, "BRL": 5, "CHF": 0.9, "CNY": 7, "CUP": 24}}, false)
+
+	var patterns []core.TokenPattern
+	patterns = append(patterns, m.TokenPatterns()...)
+	tokenizer := core.NewTokenizer(patterns)
+
+	tests := []struct {
+		input    string
+		expected []string // unit of each value or conversion token
+	}{
+		{"10 usd", []string{"USD"}},
+		{"10 CHF in eur", []string{"CHF", "EUR"}},
+		{"€10", []string{"EUR"}},
+		{"10€ to $", []string{"EUR", "USD"}},
+		{"R$ 50 in usd", []string{"BRL", "USD"}},
+		{"100 rmb =? eur", []string{"CNY", "EUR"}},
+		{"100 usd to cup", []string{"USD", "CUP"}},
+	}
+
+	for _, tt := range tests {
+		tokens, err := tokenizer.Tokenize(ctx, tt.input)
+		require.NoError(t, err, tt.input)
+		require.Len(t, tokens, len(tt.expected)+1, tt.input)
+
+		for i, expected := range tt.expected {
+			result, err := tokens[i].Module.Calculate(ctx, tokens[i])
+			require.NoError(t, err, tt.input)
+			assert.Equal(t, expected, result.Unit.Name, tt.input)
+		}
+	}
+
+	// codes without a rate from the source can't be converted
+	tokens, err := tokenizer.Tokenize(ctx, "10 sek")
+	require.NoError(t, err)
+	_, err = tokens[0].Module.Calculate(ctx, tokens[0])
+	assert.Error(t, err)
+
+	// "3 cup" is a volume, not Cuban pesos
+	_, err = tokenizer.Tokenize(ctx, "3 cup")
+	assert.Error(t, err)
+}
+
+func TestCurrencyCodesDoNotShadowUnits(t *testing.T) {
+	for _, module := range newTestUnitModules() {
+		for alias := range module.dimension.exactAliases {
+			code, found := lookupCurrency(alias)
+			if found && !ambiguousCurrencyCodes[code] {
+				t.Errorf("%s unit alias %q is also the currency %s", module.Name(), alias, code)
+			}
+		}
+	}
+}
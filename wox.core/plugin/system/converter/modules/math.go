@@ -43,6 +43,12 @@ func NewMathModule(ctx context.Context, api plugin.API) *MathModule {
 			Description: "Handle percentage of number (e.g., 12% of 321)",
 			Handler:     m.handlePercentageOfNumber,
 		},
+		{
+			Pattern:     `^([0-9]+(?:\.[0-9]+)?)$`,
+			Priority:    0,
+			Description: "Handle plain number, only used to scale units (e.g., 10 km * 2)",
+			Handler:     m.handleNumber,
+		},
 	}
 
 	m.regexBaseModule = NewRegexBaseModule(api, "math", handlers)
@@ -154,6 +160,20 @@ func (m *MathModule) handlePercentageOfNumber(ctx context.Context, matches []str
 	}, nil
 }
 
+func (m *MathModule) handleNumber(ctx context.Context, matches []string) (core.Result, error) {
+	amount, err := decimal.NewFromString(matches[1])
+	if err != nil {
+		return core.Result{}, fmt.Errorf("invalid number: %s", matches[1])
+	}
+
+	return core.Result{
+		DisplayValue: amount.String(),
+		RawValue:     amount,
+		Unit:         core.Unit{Name: "number", Type: core.UnitTypeNumber},
+		Module:       m,
+	}, nil
+}
+
 func (m *MathModule) TokenPatterns() []core.TokenPattern {
 	return []core.TokenPattern{
 		{
@@ -184,5 +204,13 @@ func (m *MathModule) TokenPatterns() []core.TokenPattern {
 			FullMatch: false,
 			Module:    m,
 		},
+		{
+			// lowest priority so that numbers with a unit are tokenized by the unit modules
+			Pattern:   `[0-9]+(?:\.[0-9]+)?`,
+			Type:      core.NumberToken,
+			Priority:  0,
+			FullMatch: false,
+			Module:    m,
+		},
 	}
 }
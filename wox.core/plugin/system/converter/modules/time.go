@@ -143,10 +143,11 @@ var timeZoneAliases = map[string]string{
 
 type TimeModule struct {
 	*regexBaseModule
+	now func() time.Time // current time, replaced in tests
 }
 
 func NewTimeModule(ctx context.Context, api plugin.API) *TimeModule {
-	m := &TimeModule{}
+	m := &TimeModule{now: time.Now}
 
 	const (
 		weekdayNames = `(monday|tuesday|wednesday|thursday|friday|saturday|sunday)`
@@ -156,8 +157,9 @@ func NewTimeModule(ctx context.Context, api plugin.API) *TimeModule {
 		simpleTimePattern = `(\d+)\s*(ms|s|m|h|d|w|y)`
 	)
 
-	// Initialize pattern handlers
-	handlers := []*patternHandler{
+	// Initialize pattern handlers, the full match handlers of dates and the planner come first because they are the most specific
+	handlers := append(m.dateHandlers(), m.plannerHandler())
+	handlers = append(handlers, []*patternHandler{
 		{
 			Pattern:     `time\s+in\s+([a-zA-Z\s/]+)`,
 			Priority:    1000,
@@ -188,7 +190,7 @@ func NewTimeModule(ctx context.Context, api plugin.API) *TimeModule {
 			Description: "Convert simple time units (e.g., 1s, 1ms, 1h, 2d, 5w, 8m, 7y)",
 			Handler:     m.handleSimpleTimeUnit,
 		},
-	}
+	}...)
 
 	m.regexBaseModule = NewRegexBaseModule(api, "time", handlers)
 	return m
@@ -250,18 +252,11 @@ func (m *TimeModule) Convert(ctx context.Context, result core.Result, toUnit cor
 }
 
 func (m *TimeModule) handleTimeInLocation(ctx context.Context, matches []string) (core.Result, error) {
-	location := strings.ToLower(strings.TrimSpace(matches[1]))
-
-	// Try to find the timezone alias
-	if tzName, ok := timeZoneAliases[location]; ok {
-		location = tzName
-	}
-
-	// Load the location
-	loc, err := time.LoadLocation(location)
+	loc, _, err := resolveLocation(matches[1])
 	if err != nil {
-		return core.Result{}, fmt.Errorf("unknown location: %s", location)
+		return core.Result{}, err
 	}
+	location := loc.String()
 
 	// Get current time in location
 	now := time.Now().In(loc)
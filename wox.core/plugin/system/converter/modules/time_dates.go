@@ -0,0 +1,414 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"wox/plugin/system/converter/core"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// datePattern matches "now", "today", "tomorrow", "yesterday" and ISO dates with an optional time, e.g. "2025-03-01 14:30"
+	datePattern = `(now|today|tomorrow|yesterday|\d{4}[-/]\d{1,2}[-/]\d{1,2}(?:[t ]\d{1,2}:\d{2}(?::\d{2})?)?)`
+	// dateUnitPattern matches the units of date arithmetic, longer names first so "months" isn't read as "m"
+	dateUnitPattern = `(?:business\s+days?|workdays?|years?|yrs?|y|months?|mo|weeks?|wks?|w|days?|d|hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s)`
+	// dateTermsPattern matches the terms added to a date, e.g. "+ 45 business days" or "- 3h20m"
+	dateTermsPattern = `((?:\s*[+-]\s*(?:\d+\s*` + dateUnitPattern + `\s*)+)+)`
+	// epochUnitPattern matches the names of unix timestamps
+	epochUnitPattern = `(unix|epoch|timestamp|unix\s*ms|epoch\s*ms|unix-ms|epoch-ms|milliseconds)`
+)
+
+var dateTermRegexp = regexp.MustCompile(`^\s*([+-])?\s*(\d+)\s*(` + dateUnitPattern + `)`)
+
+// dateHandlers are the full match handlers of date arithmetic, they compute the whole expression because
+// dates can't be mixed with the values of the other modules
+func (m *TimeModule) dateHandlers() []*patternHandler {
+	return []*patternHandler{
+		{
+			Pattern:     `(?i)^` + datePattern + `\s*-\s*` + datePattern + `$`,
+			Priority:    1100,
+			Description: "Difference between two dates",
+			Handler:     m.handleDateDifference,
+			FullMatch:   true,
+		},
+		{
+			Pattern:     `(?i)^(?:(?:days?|time)\s+)?(?:between|from)\s+` + datePattern + `\s+(?:and|to)\s+` + datePattern + `$`,
+			Priority:    1100,
+			Description: "Difference between two dates",
+			Handler:     m.handleDateDifferenceBetween,
+			FullMatch:   true,
+		},
+		{
+			Pattern:     `(?i)^` + datePattern + dateTermsPattern + `$`,
+			Priority:    1100,
+			Description: "Add or subtract durations to a date",
+			Handler:     m.handleDateArithmetic,
+			FullMatch:   true,
+		},
+		{
+			Pattern:     `(?i)^(?:iso\s+)?week(?:\s+(?:of|number|no))?(?:\s+` + datePattern + `)?$`,
+			Priority:    1100,
+			Description: "ISO week number of a date",
+			Handler:     m.handleISOWeek,
+			FullMatch:   true,
+		},
+		{
+			Pattern:     `(?i)^(\d{4})-?w(\d{1,2})$`,
+			Priority:    1100,
+			Description: "Dates of an ISO week",
+			Handler:     m.handleISOWeekDates,
+			FullMatch:   true,
+		},
+		{
+			Pattern:     `(?i)^(?:(?:unix|epoch|timestamp)\s+|@)(-?\d{1,16})$`,
+			Priority:    1100,
+			Description: "Date of a unix timestamp in seconds or milliseconds",
+			Handler:     m.handleFromEpoch,
+			FullMatch:   true,
+		},
+		{
+			Pattern:     `(?i)^` + datePattern + `\s+(?:to|in)\s+` + epochUnitPattern + `$`,
+			Priority:    1100,
+			Description: "Unix timestamp of a date",
+			Handler:     m.handleToEpoch,
+			FullMatch:   true,
+		},
+	}
+}
+
+// parseDate parses the dates matched by datePattern in the local timezone, hasTime reports whether it has a time of day
+func (m *TimeModule) parseDate(str string) (t time.Time, hasTime bool, err error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	now := m.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch str {
+	case "now":
+		return now, true, nil
+	case "today":
+		return today, false, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), false, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), false, nil
+	}
+
+	str = strings.ReplaceAll(strings.ReplaceAll(str, "/", "-"), "t", " ")
+	for _, layout := range []string{"2006-1-2 15:04:05", "2006-1-2 15:04", "2006-1-2"} {
+		if t, err := time.ParseInLocation(layout, str, now.Location()); err == nil {
+			return t, layout != "2006-1-2", nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("unsupported date format: %s", str)
+}
+
+// addDateTerms adds terms like "+ 45 business days - 3h20m" to a date, hasTime reports whether a term has a time of day unit
+func addDateTerms(t time.Time, terms string) (result time.Time, hasTime bool, err error) {
+	sign := 1
+	for strings.TrimSpace(terms) != "" {
+		matches := dateTermRegexp.FindStringSubmatch(terms)
+		if matches == nil {
+			return time.Time{}, false, fmt.Errorf("invalid date term: %s", terms)
+		}
+		terms = terms[len(matches[0]):]
+
+		// the sign applies to the following terms too, e.g. "- 3h20m"
+		if matches[1] == "-" {
+			sign = -1
+		} else if matches[1] == "+" {
+			sign = 1
+		}
+		value, err := strconv.Atoi(matches[2])
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid number: %s", matches[2])
+		}
+		value *= sign
+
+		unit := strings.Join(strings.Fields(matches[3]), " ")
+		switch {
+		case strings.HasPrefix(unit, "business") || strings.HasPrefix(unit, "workday"):
+			t = addBusinessDays(t, value)
+		case strings.HasPrefix(unit, "y"):
+			t = addMonths(t, value*12)
+		case strings.HasPrefix(unit, "mo"):
+			t = addMonths(t, value)
+		case strings.HasPrefix(unit, "w"):
+			t = t.AddDate(0, 0, value*7)
+		case strings.HasPrefix(unit, "d"):
+			t = t.AddDate(0, 0, value)
+		case strings.HasPrefix(unit, "h"):
+			t, hasTime = t.Add(time.Duration(value)*time.Hour), true
+		case strings.HasPrefix(unit, "m"):
+			t, hasTime = t.Add(time.Duration(value)*time.Minute), true
+		default:
+			t, hasTime = t.Add(time.Duration(value)*time.Second), true
+		}
+	}
+	return t, hasTime, nil
+}
+
+// addMonths adds months and clamps the day to the end of the month, e.g. Jan 31 + 1 month is Feb 28
+func addMonths(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location()).AddDate(0, months, 0)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+// addBusinessDays adds days skipping saturdays and sundays
+func addBusinessDays(t time.Time, days int) time.Time {
+	step := 1
+	if days < 0 {
+		step, days = -1, -days
+	}
+	for days > 0 {
+		t = t.AddDate(0, 0, step)
+		if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+			days--
+		}
+	}
+	return t
+}
+
+// countBusinessDays counts the weekdays from start (excluded) to end (included)
+func countBusinessDays(start time.Time, end time.Time) int {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, start.Location())
+	count := 0
+	for d := start.AddDate(0, 0, 1); !d.After(end); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			count++
+		}
+	}
+	return count
+}
+
+func formatDate(t time.Time, hasTime bool) string {
+	if hasTime {
+		return fmt.Sprintf("%s (%s)", t.Format("2006-01-02 15:04"), t.Weekday().String())
+	}
+	return fmt.Sprintf("%s (%s)", t.Format("2006-01-02"), t.Weekday().String())
+}
+
+// renderDatePreview shows the details of a date in the preview
+func renderDatePreview(t time.Time) string {
+	year, week := t.ISOWeek()
+	return renderPreviewTable([]string{"", ""}, [][]string{
+		{"Date", t.Format("2006-01-02")},
+		{"Time", t.Format("15:04:05 MST")},
+		{"Weekday", t.Weekday().String()},
+		{"ISO week", fmt.Sprintf("%d-W%02d", year, week)},
+		{"Day of year", strconv.Itoa(t.YearDay())},
+		{"UTC", t.UTC().Format(time.RFC3339)},
+		{"Unix", strconv.FormatInt(t.Unix(), 10)},
+		{"Unix ms", strconv.FormatInt(t.UnixMilli(), 10)},
+	})
+}
+
+func renderPreviewTable(header []string, rows [][]string) string {
+	var sb strings.Builder
+	sb.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat("---|", len(header)) + "\n")
+	for _, row := range rows {
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (m *TimeModule) dateResult(t time.Time, hasTime bool) core.Result {
+	return core.Result{
+		DisplayValue: formatDate(t, hasTime),
+		RawValue:     decimal.NewFromInt(t.Unix()),
+		Unit:         core.Unit{Name: "date", Type: core.UnitTypeTime},
+		Module:       m,
+		Preview:      renderDatePreview(t),
+	}
+}
+
+func (m *TimeModule) handleDateArithmetic(ctx context.Context, matches []string) (core.Result, error) {
+	t, hasTime, err := m.parseDate(matches[1])
+	if err != nil {
+		return core.Result{}, err
+	}
+	t, termsHaveTime, err := addDateTerms(t, matches[2])
+	if err != nil {
+		return core.Result{}, err
+	}
+	return m.dateResult(t, hasTime || termsHaveTime), nil
+}
+
+func (m *TimeModule) handleDateDifference(ctx context.Context, matches []string) (core.Result, error) {
+	return m.dateDifference(matches[2], matches[1])
+}
+
+func (m *TimeModule) handleDateDifferenceBetween(ctx context.Context, matches []string) (core.Result, error) {
+	return m.dateDifference(matches[1], matches[2])
+}
+
+// dateDifference shows the time from start to end in mixed units, e.g. "1 year 2 months 3 days"
+func (m *TimeModule) dateDifference(startStr string, endStr string) (core.Result, error) {
+	start, startHasTime, err := m.parseDate(startStr)
+	if err != nil {
+		return core.Result{}, err
+	}
+	end, endHasTime, err := m.parseDate(endStr)
+	if err != nil {
+		return core.Result{}, err
+	}
+	hasTime := startHasTime || endHasTime
+
+	sign := ""
+	if end.Before(start) {
+		sign = "-"
+		start, end = end, start
+	}
+
+	// count whole calendar years, months and days first, so "2025-01-31 - 2024-12-31" is one month
+	years, months, days := 0, 0, 0
+	for !addMonths(start, (years+1)*12).After(end) {
+		years++
+	}
+	for !addMonths(start, years*12+months+1).After(end) {
+		months++
+	}
+	cursor := addMonths(start, years*12+months)
+	for !cursor.AddDate(0, 0, days+1).After(end) {
+		days++
+	}
+	remaining := end.Sub(cursor.AddDate(0, 0, days))
+
+	var parts []string
+	addPart := func(value int, unit string) {
+		if value == 0 {
+			return
+		}
+		if value != 1 {
+			unit += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", value, unit))
+	}
+	addPart(years, "year")
+	addPart(months, "month")
+	addPart(days, "day")
+	if hasTime {
+		addPart(int(remaining.Hours()), "hour")
+		addPart(int(remaining.Minutes())%60, "minute")
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "0 days")
+	}
+
+	total := end.Sub(start)
+	totalDays := int(end.Sub(start).Hours() / 24)
+	if !hasTime {
+		// dates without time may be apart by 23 or 25 hours around daylight saving changes
+		totalDays = int(time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC).Sub(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	}
+
+	preview := renderPreviewTable([]string{"", ""}, [][]string{
+		{"Days", sign + strconv.Itoa(totalDays)},
+		{"Weeks", fmt.Sprintf("%s%d weeks %d days", sign, totalDays/7, totalDays%7)},
+		{"Business days", sign + strconv.Itoa(countBusinessDays(start, end))},
+		{"Hours", sign + strconv.FormatInt(int64(total.Hours()), 10)},
+		{"Minutes", sign + strconv.FormatInt(int64(total.Minutes()), 10)},
+	})
+
+	seconds := int64(total.Seconds())
+	if sign != "" {
+		seconds = -seconds
+	}
+	return core.Result{
+		DisplayValue: sign + strings.Join(parts, " "),
+		RawValue:     decimal.NewFromInt(seconds),
+		Unit:         core.Unit{Name: "seconds", Type: core.UnitTypeTime},
+		Module:       m,
+		Preview:      preview,
+	}, nil
+}
+
+func (m *TimeModule) handleISOWeek(ctx context.Context, matches []string) (core.Result, error) {
+	t := m.now()
+	if matches[1] != "" {
+		var err error
+		if t, _, err = m.parseDate(matches[1]); err != nil {
+			return core.Result{}, err
+		}
+	}
+
+	year, week := t.ISOWeek()
+	return core.Result{
+		DisplayValue: fmt.Sprintf("Week %d (%d-W%02d)", week, year, week),
+		RawValue:     decimal.NewFromInt(int64(week)),
+		Unit:         core.Unit{Name: "week", Type: core.UnitTypeTime},
+		Module:       m,
+		Preview:      renderDatePreview(t),
+	}, nil
+}
+
+func (m *TimeModule) handleISOWeekDates(ctx context.Context, matches []string) (core.Result, error) {
+	year, _ := strconv.Atoi(matches[1])
+	week, _ := strconv.Atoi(matches[2])
+
+	if week < 1 || week > 53 {
+		return core.Result{}, fmt.Errorf("invalid week: %d", week)
+	}
+
+	// the first ISO week is the one with January 4th
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, m.now().Location())
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*7)
+	if actualYear, actualWeek := monday.ISOWeek(); actualYear != year || actualWeek != week {
+		return core.Result{}, fmt.Errorf("%d has no week %d", year, week)
+	}
+
+	sunday := monday.AddDate(0, 0, 6)
+	return core.Result{
+		DisplayValue: fmt.Sprintf("%s – %s", monday.Format("2006-01-02"), sunday.Format("2006-01-02")),
+		RawValue:     decimal.NewFromInt(monday.Unix()),
+		Unit:         core.Unit{Name: "date", Type: core.UnitTypeTime},
+		Module:       m,
+		Preview:      renderDatePreview(monday),
+	}, nil
+}
+
+func (m *TimeModule) handleFromEpoch(ctx context.Context, matches []string) (core.Result, error) {
+	value, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return core.Result{}, fmt.Errorf("invalid timestamp: %s", matches[1])
+	}
+
+	// timestamps with more than 11 digits are in milliseconds, seconds would be after the year 5000
+	var t time.Time
+	if len(strings.TrimPrefix(matches[1], "-")) > 11 {
+		t = time.UnixMilli(value)
+	} else {
+		t = time.Unix(value, 0)
+	}
+	t = t.In(m.now().Location())
+
+	result := m.dateResult(t, true)
+	result.DisplayValue = fmt.Sprintf("%s (%s)", t.Format("2006-01-02 15:04:05"), t.Weekday().String())
+	return result, nil
+}
+
+func (m *TimeModule) handleToEpoch(ctx context.Context, matches []string) (core.Result, error) {
+	t, _, err := m.parseDate(matches[1])
+	if err != nil {
+		return core.Result{}, err
+	}
+
+	value := t.Unix()
+	if unit := strings.ToLower(matches[2]); strings.Contains(unit, "ms") || unit == "milliseconds" {
+		value = t.UnixMilli()
+	}
+	return core.Result{
+		DisplayValue: strconv.FormatInt(value, 10),
+		RawValue:     decimal.NewFromInt(value),
+		Unit:         core.UnitUTCTimestamp,
+		Module:       m,
+		Preview:      renderDatePreview(t),
+	}, nil
+}
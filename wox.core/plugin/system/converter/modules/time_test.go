@@ -0,0 +1,100 @@
+package modules
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+	"wox/plugin/plugintest"
+	"wox/plugin/system/converter/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTimeModule(t *testing.T) *TimeModule {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no local tzdata")
+	}
+	m := NewTimeModule(context.Background(), plugintest.NewAPI())
+	m.now = func() time.Time {
+		return time.Date(2025, time.March, 5, 14, 30, 0, 0, loc) // a wednesday
+	}
+	return m
+}
+
+func TestDateArithmetic(t *testing.T) {
+	m := newTestTimeModule(t)
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"2025-03-01 + 45 business days", "2025-05-02 (Friday)"},
+		{"2025-03-07 + 1 business day", "2025-03-10 (Monday)"},
+		{"2025-01-31 + 1 month", "2025-02-28 (Friday)"},
+		{"2024-02-29 + 1 year", "2025-02-28 (Friday)"},
+		{"2025-03-01 + 2 weeks - 1 day", "2025-03-14 (Friday)"},
+		{"now - 3h20m", "2025-03-05 11:10 (Wednesday)"},
+		{"today + 1d 12h", "2025-03-06 12:00 (Thursday)"},
+		{"2025-03-01 14:00 + 90 min", "2025-03-01 15:30 (Saturday)"},
+		{"2025-12-25 - 2025-03-05", "9 months 20 days"},
+		{"2025-03-05 - 2026-04-06", "-1 year 1 month 1 day"},
+		{"between 2025-03-01 and 2025-03-01", "0 days"},
+		{"from 2025-03-01 08:00 to 2025-03-02 10:30", "1 day 2 hours 30 minutes"},
+		{"week", "Week 10 (2025-W10)"},
+		{"week of 2024-12-30", "Week 1 (2025-W01)"},
+		{"2025-W09", "2025-02-24 – 2025-03-02"},
+		{"2020-w53", "2020-12-28 – 2021-01-03"},
+		{"unix 1741181400", "2025-03-05 14:30:00 (Wednesday)"},
+		{"@1741181400000", "2025-03-05 14:30:00 (Wednesday)"},
+		{"2025-03-05 14:30 to unix", "1741181400"},
+		{"2025-03-05 14:30 to epoch ms", "1741181400000"},
+	}
+
+	for _, tt := range tests {
+		result, err := m.Calculate(context.Background(), core.Token{Kind: core.IdentToken, Str: tt.query})
+		if assert.NoError(t, err, tt.query) {
+			assert.Equal(t, tt.expected, result.DisplayValue, tt.query)
+		}
+	}
+
+	for _, query := range []string{"2025-W54", "2021-W53", "unix abc"} {
+		_, err := m.Calculate(context.Background(), core.Token{Kind: core.IdentToken, Str: query})
+		assert.Error(t, err, query)
+	}
+}
+
+func TestDateDifferencePreview(t *testing.T) {
+	m := newTestTimeModule(t)
+	result, err := m.Calculate(context.Background(), core.Token{Kind: core.IdentToken, Str: "2025-03-10 - 2025-03-01"})
+	require.NoError(t, err)
+	assert.Equal(t, "9 days", result.DisplayValue)
+	assert.Equal(t, int64(9*24*3600), result.RawValue.IntPart())
+	assert.Contains(t, result.Preview, "| Weeks | 1 weeks 2 days |")
+	assert.Contains(t, result.Preview, "| Business days | 6 |")
+}
+
+func TestMeetingPlanner(t *testing.T) {
+	m := newTestTimeModule(t)
+	result, err := m.Calculate(context.Background(), core.Token{Kind: core.IdentToken, Str: "3pm PST in Berlin, Tokyo"})
+	require.NoError(t, err)
+	assert.Equal(t, "Berlin 12:00 AM (+1), Tokyo 8:00 AM (+1)", result.DisplayValue)
+	assert.Contains(t, result.Preview, "| PST | 3:00 PM | Wed 2025-03-05 | UTC-08:00 |")
+	assert.Contains(t, result.Preview, "| Tokyo | 8:00 AM (+1) | Thu 2025-03-06 | UTC+09:00 |")
+
+	result, err = m.Calculate(context.Background(), core.Token{Kind: core.IdentToken, Str: "2025-07-01 9:30 new york to london"})
+	require.NoError(t, err)
+	assert.Equal(t, "London 2:30 PM", result.DisplayValue)
+
+	_, err = m.Calculate(context.Background(), core.Token{Kind: core.IdentToken, Str: "3pm atlantis in berlin"})
+	assert.Error(t, err)
+}
+
+func TestPlannerDoesNotMatchConversions(t *testing.T) {
+	re := regexp.MustCompile(newTestTimeModule(t).plannerHandler().Pattern)
+	for _, query := range []string{"10 usd in eur", "5 km to mi", "100 btc in usd"} {
+		assert.False(t, re.MatchString(query), query)
+	}
+}
@@ -0,0 +1,245 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"wox/plugin/system/converter/core"
+
+	"github.com/shopspring/decimal"
+)
+
+// timeZoneAbbreviations maps common abbreviations to a zone observing them, the zone's daylight saving rules apply,
+// so "3pm PST" in July is 3pm in Los Angeles
+var timeZoneAbbreviations = map[string]string{
+	"utc":  "UTC",
+	"gmt":  "UTC",
+	"pst":  "America/Los_Angeles",
+	"pdt":  "America/Los_Angeles",
+	"pt":   "America/Los_Angeles",
+	"mst":  "America/Denver",
+	"mdt":  "America/Denver",
+	"mt":   "America/Denver",
+	"cst":  "America/Chicago",
+	"cdt":  "America/Chicago",
+	"ct":   "America/Chicago",
+	"est":  "America/New_York",
+	"edt":  "America/New_York",
+	"et":   "America/New_York",
+	"bst":  "Europe/London",
+	"wet":  "Europe/Lisbon",
+	"cet":  "Europe/Paris",
+	"cest": "Europe/Paris",
+	"eet":  "Europe/Athens",
+	"eest": "Europe/Athens",
+	"msk":  "Europe/Moscow",
+	"ist":  "Asia/Kolkata",
+	"sgt":  "Asia/Singapore",
+	"hkt":  "Asia/Hong_Kong",
+	"jst":  "Asia/Tokyo",
+	"kst":  "Asia/Seoul",
+	"aest": "Australia/Sydney",
+	"aedt": "Australia/Sydney",
+	"awst": "Australia/Perth",
+	"nzst": "Pacific/Auckland",
+	"nzdt": "Pacific/Auckland",
+}
+
+// zoneInfoDirs are the directories Go reads the local tzdata from, see time.LoadLocation
+var zoneInfoDirs = []string{
+	os.Getenv("ZONEINFO"),
+	"/usr/share/zoneinfo/",
+	"/usr/share/lib/zoneinfo/",
+	"/usr/lib/locale/TZ/",
+	"/etc/zoneinfo/",
+}
+
+var zoneNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9_+\-]*(/[A-Z][A-Za-z0-9_+\-]*)*$`)
+
+var zoneIndexOnce sync.Once
+var zoneIndex map[string]string // lower case zone names and cities to zone names, e.g. "buenos aires" => "America/Argentina/Buenos_Aires"
+
+// getZoneIndex indexes the zones of the local tzdata, it is empty when the system has none, e.g. on Windows
+func getZoneIndex() map[string]string {
+	zoneIndexOnce.Do(func() {
+		zoneIndex = map[string]string{}
+		for _, dir := range zoneInfoDirs {
+			if dir == "" {
+				continue
+			}
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				continue
+			}
+
+			_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return nil
+				}
+				name, relErr := filepath.Rel(dir, path)
+				if relErr != nil {
+					return nil
+				}
+				name = filepath.ToSlash(name)
+				if !zoneNamePattern.MatchString(name) || strings.HasPrefix(name, "posix/") || strings.HasPrefix(name, "right/") {
+					return nil
+				}
+
+				zoneIndex[strings.ToLower(name)] = name
+				city := strings.ToLower(strings.ReplaceAll(name[strings.LastIndex(name, "/")+1:], "_", " "))
+				if _, exists := zoneIndex[city]; !exists && strings.Contains(name, "/") {
+					zoneIndex[city] = name
+				}
+				return nil
+			})
+		}
+	})
+	return zoneIndex
+}
+
+// resolveLocation finds the zone of a city alias, an abbreviation, a city of the local tzdata or a zone name, the name is the one to display
+func resolveLocation(location string) (loc *time.Location, name string, err error) {
+	location = strings.Join(strings.Fields(strings.ToLower(location)), " ")
+	if location == "" {
+		return nil, "", fmt.Errorf("empty location")
+	}
+	if location == "local" || location == "here" {
+		return time.Local, "Local", nil
+	}
+
+	zoneName := ""
+	displayName := titleCase(location)
+	if tzName, ok := timeZoneAliases[location]; ok {
+		zoneName = tzName
+	} else if tzName, ok := timeZoneAbbreviations[location]; ok {
+		zoneName, displayName = tzName, strings.ToUpper(location)
+	} else if tzName, ok := getZoneIndex()[location]; ok {
+		zoneName = tzName
+		displayName = strings.ReplaceAll(tzName[strings.LastIndex(tzName, "/")+1:], "_", " ")
+	} else {
+		zoneName = zoneNameCase(location)
+		displayName = zoneName
+	}
+
+	loc, err = time.LoadLocation(zoneName)
+	if err != nil {
+		return nil, "", fmt.Errorf("unknown location: %s", location)
+	}
+	return loc, displayName, nil
+}
+
+// zoneNameCase capitalizes the words of a zone name, zone names are case sensitive, e.g. "america/new_york" => "America/New_York"
+func zoneNameCase(str string) string {
+	chars := []rune(strings.ReplaceAll(str, " ", "_"))
+	for i := range chars {
+		if i == 0 || strings.ContainsRune("/_-", chars[i-1]) {
+			chars[i] = unicode.ToUpper(chars[i])
+		}
+	}
+	return string(chars)
+}
+
+func titleCase(str string) string {
+	words := strings.Fields(str)
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// plannerHandler converts a time in a zone to several zones, e.g. "3pm PST in Berlin, Tokyo".
+// The time must have am/pm or minutes, "10 usd in eur" is a currency conversion.
+func (m *TimeModule) plannerHandler() *patternHandler {
+	return &patternHandler{
+		Pattern:     `(?i)^(?:(\d{4}-\d{1,2}-\d{1,2})\s+)?(now|[0-9]{1,2}:[0-9]{2}\s*(?:am|pm)?|[0-9]{1,2}\s*(?:am|pm))\s+([a-z][a-z_/\- ]*?)\s+(?:in|to)\s+([a-z][a-z_/\- ]*(?:\s*,\s*[a-z][a-z_/\- ]*)*)$`,
+		Priority:    1050,
+		Description: "Convert a time in a location to several locations",
+		Handler:     m.handleMeetingPlanner,
+		FullMatch:   true,
+	}
+}
+
+func (m *TimeModule) handleMeetingPlanner(ctx context.Context, matches []string) (core.Result, error) {
+	sourceLoc, sourceName, err := resolveLocation(matches[3])
+	if err != nil {
+		return core.Result{}, err
+	}
+
+	// the day defaults to today in the source location
+	now := m.now().In(sourceLoc)
+	year, month, day := now.Date()
+	if matches[1] != "" {
+		date, parseErr := time.ParseInLocation("2006-1-2", matches[1], sourceLoc)
+		if parseErr != nil {
+			return core.Result{}, fmt.Errorf("invalid date: %s", matches[1])
+		}
+		year, month, day = date.Date()
+	}
+
+	sourceTime := now
+	if strings.ToLower(matches[2]) != "now" {
+		t, parseErr := m.parseTime(ctx, matches[2])
+		if parseErr != nil {
+			return core.Result{}, parseErr
+		}
+		sourceTime = time.Date(year, month, day, t.Hour(), t.Minute(), 0, 0, sourceLoc)
+	}
+
+	type plannerRow struct {
+		name string
+		t    time.Time
+	}
+	rows := []plannerRow{{sourceName, sourceTime}}
+	var targets []string
+	for _, target := range strings.Split(matches[4], ",") {
+		loc, name, resolveErr := resolveLocation(target)
+		if resolveErr != nil {
+			return core.Result{}, resolveErr
+		}
+		targetTime := sourceTime.In(loc)
+		rows = append(rows, plannerRow{name, targetTime})
+		targets = append(targets, fmt.Sprintf("%s %s%s", name, m.formatTimeForDisplay(targetTime), formatDayOffset(sourceTime, targetTime)))
+	}
+	hasLocal := false
+	for _, row := range rows {
+		hasLocal = hasLocal || row.t.Location() == time.Local
+	}
+	if !hasLocal {
+		rows = append(rows, plannerRow{"Local", sourceTime.In(time.Local)})
+	}
+
+	var tableRows [][]string
+	for _, row := range rows {
+		tableRows = append(tableRows, []string{
+			row.name,
+			m.formatTimeForDisplay(row.t) + formatDayOffset(sourceTime, row.t),
+			row.t.Format("Mon 2006-01-02"),
+			"UTC" + row.t.Format("-07:00"),
+		})
+	}
+
+	return core.Result{
+		DisplayValue: strings.Join(targets, ", "),
+		RawValue:     decimal.NewFromInt(sourceTime.Unix()),
+		Unit:         core.Unit{Name: "planner", Type: core.UnitTypeTime},
+		Module:       m,
+		Preview:      renderPreviewTable([]string{"Location", "Time", "Date", "Offset"}, tableRows),
+	}, nil
+}
+
+// formatDayOffset shows the day difference of a converted time, e.g. " (+1)" when it is the next day
+func formatDayOffset(source time.Time, target time.Time) string {
+	sourceDay := time.Date(source.Year(), source.Month(), source.Day(), 0, 0, 0, 0, time.UTC)
+	targetDay := time.Date(target.Year(), target.Month(), target.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(targetDay.Sub(sourceDay).Hours() / 24)
+	if days == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%+d)", days)
+}
@@ -0,0 +1,312 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"wox/plugin"
+	"wox/plugin/system/converter/core"
+
+	"github.com/shopspring/decimal"
+)
+
+// physicalUnit is a unit of a physical dimension.
+// A value v is converted to the base unit of its dimension by (v + Offset) * Factor, only temperatures have an offset.
+type physicalUnit struct {
+	Symbol string // canonical symbol, used as display name and as core.Unit.Name
+	Factor unitFactor
+	Offset decimal.Decimal
+}
+
+// unitFactor is kept as a fraction, so factors like 5/9 (°F) convert back and forth without rounding errors
+type unitFactor struct {
+	Numerator   decimal.Decimal
+	Denominator decimal.Decimal
+}
+
+type siPrefix struct {
+	Symbol   string
+	Name     string
+	Exponent int32
+}
+
+// siPrefixes are ordered by how common they are, so for case-insensitive input "mm" means millimeter instead of megameter
+var siPrefixes = []siPrefix{
+	{"k", "kilo", 3},
+	{"c", "centi", -2},
+	{"m", "milli", -3},
+	{"µ", "micro", -6},
+	{"u", "micro", -6},
+	{"n", "nano", -9},
+	{"d", "deci", -1},
+	{"h", "hecto", 2},
+	{"da", "deca", 1},
+	{"M", "mega", 6},
+	{"G", "giga", 9},
+	{"T", "tera", 12},
+	{"p", "pico", -12},
+	{"P", "peta", 15},
+	{"E", "exa", 18},
+}
+
+// unitDimension holds the units of one physical dimension and resolves the aliases typed by the user
+type unitDimension struct {
+	name          string
+	unitType      core.UnitType
+	units         map[string]*physicalUnit // by symbol
+	exactAliases  map[string]*physicalUnit
+	foldedAliases map[string]*physicalUnit // lower-cased, the first registered alias wins
+}
+
+func newUnitDimension(name string, unitType core.UnitType) *unitDimension {
+	return &unitDimension{
+		name:          name,
+		unitType:      unitType,
+		units:         map[string]*physicalUnit{},
+		exactAliases:  map[string]*physicalUnit{},
+		foldedAliases: map[string]*physicalUnit{},
+	}
+}
+
+// add registers a unit, factor is the number of base units in one unit
+func (d *unitDimension) add(symbol string, factor unitFactor, aliases ...string) {
+	d.addWithOffset(symbol, factor, decimal.Zero, aliases...)
+}
+
+func (d *unitDimension) addWithOffset(symbol string, factor unitFactor, offset decimal.Decimal, aliases ...string) {
+	unit, exists := d.units[symbol]
+	if !exists {
+		unit = &physicalUnit{Symbol: symbol, Factor: factor, Offset: offset}
+		d.units[symbol] = unit
+	}
+
+	for _, alias := range append([]string{symbol}, aliases...) {
+		if _, taken := d.exactAliases[alias]; !taken {
+			d.exactAliases[alias] = unit
+		}
+		if _, taken := d.foldedAliases[strings.ToLower(alias)]; !taken {
+			d.foldedAliases[strings.ToLower(alias)] = unit
+		}
+	}
+}
+
+// addSIPrefixed registers the unit with every SI prefix whose exponent is within [minExponent, maxExponent],
+// e.g. "m" with the names "meter" and "metre" also gives km, kilometer, kilometres, mm, millimeter...
+func (d *unitDimension) addSIPrefixed(symbol string, factor unitFactor, minExponent int32, maxExponent int32, names ...string) {
+	var aliases []string
+	for _, name := range names {
+		aliases = append(aliases, name, name+"s")
+	}
+	d.add(symbol, factor, aliases...)
+
+	for _, prefix := range siPrefixes {
+		if prefix.Exponent < minExponent || prefix.Exponent > maxExponent {
+			continue
+		}
+		var prefixedAliases []string
+		for _, name := range names {
+			prefixedAliases = append(prefixedAliases, prefix.Name+name, prefix.Name+name+"s")
+		}
+		prefixedSymbol := prefix.Symbol + symbol
+		if prefix.Symbol == "u" {
+			// "u" is the ASCII spelling of "µ", display the real symbol
+			prefixedAliases = append(prefixedAliases, prefixedSymbol)
+			prefixedSymbol = "µ" + symbol
+		}
+		prefixedFactor := unitFactor{Numerator: decimal.New(1, prefix.Exponent).Mul(factor.Numerator), Denominator: factor.Denominator}
+		d.add(prefixedSymbol, prefixedFactor, prefixedAliases...)
+	}
+}
+
+// lookup prefers an exact match, so "Mb" (megabit) and "MB" (megabyte) are different units, and falls back to case-insensitive matching
+func (d *unitDimension) lookup(alias string) (*physicalUnit, bool) {
+	alias = strings.TrimSpace(alias)
+	if unit, ok := d.exactAliases[alias]; ok {
+		return unit, true
+	}
+	unit, ok := d.foldedAliases[strings.ToLower(alias)]
+	return unit, ok
+}
+
+// aliasPattern returns a regex group matching every alias, longest first so "mi" isn't matched as "m".
+// Aliases ending with a letter or digit need a word boundary, symbols like "°" or "²" can't be the prefix of a longer word.
+func (d *unitDimension) aliasPattern(excluded map[string]bool) string {
+	var wordAliases, symbolAliases []string
+	for alias := range d.exactAliases {
+		if excluded[alias] {
+			continue
+		}
+		last := alias[len(alias)-1]
+		if (last >= 'a' && last <= 'z') || (last >= 'A' && last <= 'Z') || (last >= '0' && last <= '9') {
+			wordAliases = append(wordAliases, regexp.QuoteMeta(alias))
+		} else {
+			symbolAliases = append(symbolAliases, regexp.QuoteMeta(alias))
+		}
+	}
+
+	byLength := func(aliases []string) {
+		sort.Slice(aliases, func(i, j int) bool {
+			if len(aliases[i]) != len(aliases[j]) {
+				return len(aliases[i]) > len(aliases[j])
+			}
+			return aliases[i] < aliases[j]
+		})
+	}
+	byLength(wordAliases)
+	byLength(symbolAliases)
+
+	var groups []string
+	if len(wordAliases) > 0 {
+		groups = append(groups, `(?:`+strings.Join(wordAliases, "|")+`)\b`)
+	}
+	if len(symbolAliases) > 0 {
+		groups = append(groups, `(?:`+strings.Join(symbolAliases, "|")+`)`)
+	}
+	return `(?i:(` + strings.Join(groups, "|") + `))`
+}
+
+// convert converts the value between two units of this dimension, going through the base unit.
+// Division is done once at the end, it is the only step that rounds.
+func (d *unitDimension) convert(value decimal.Decimal, from *physicalUnit, to *physicalUnit) decimal.Decimal {
+	numerator := value.Add(from.Offset).Mul(from.Factor.Numerator).Mul(to.Factor.Denominator)
+	denominator := from.Factor.Denominator.Mul(to.Factor.Numerator)
+	return numerator.Div(denominator).Sub(to.Offset)
+}
+
+// PhysicalUnitModule converts between the units of one physical dimension, e.g. "10 km in miles" or "72 f to c"
+type PhysicalUnitModule struct {
+	api               plugin.API
+	dimension         *unitDimension
+	priority          int
+	valueRegexp       *regexp.Regexp
+	conversionRegexp  *regexp.Regexp
+	valuePattern      string
+	conversionPattern string
+}
+
+// newPhysicalUnitModule creates the module for a dimension. Priority decides which dimension tokenizes first when aliases
+// share a prefix, e.g. speed (km/h) must come before length (km), temperature (°C) before angle (°).
+func newPhysicalUnitModule(api plugin.API, dimension *unitDimension, priority int) *PhysicalUnitModule {
+	// single letter time units (1m, 2h, 5d) are tokenized by the time module, "10 m to ft" is recalculated by the converter once the target is known
+	excluded := map[string]bool{}
+	for alias := range timeUnits {
+		excluded[alias] = true
+	}
+
+	const numberPattern = `(-?[0-9]+(?:\.[0-9]+)?)`
+	m := &PhysicalUnitModule{
+		api:               api,
+		dimension:         dimension,
+		priority:          priority,
+		valuePattern:      numberPattern + `\s*` + dimension.aliasPattern(excluded),
+		conversionPattern: `(?i:(?:into|in|to|as)\s+|=\s*\?\s*)` + dimension.aliasPattern(nil),
+	}
+	m.valueRegexp = regexp.MustCompile(`^` + numberPattern + `\s*` + dimension.aliasPattern(nil) + `$`)
+	m.conversionRegexp = regexp.MustCompile(`^` + m.conversionPattern + `$`)
+	return m
+}
+
+func (m *PhysicalUnitModule) Name() string {
+	return m.dimension.name
+}
+
+// UnitType returns the dimension this module converts
+func (m *PhysicalUnitModule) UnitType() core.UnitType {
+	return m.dimension.unitType
+}
+
+func (m *PhysicalUnitModule) TokenPatterns() []core.TokenPattern {
+	return []core.TokenPattern{
+		{
+			Pattern:  m.valuePattern,
+			Type:     core.IdentToken,
+			Priority: m.priority,
+			Module:   m,
+		},
+		{
+			Pattern:  m.conversionPattern,
+			Type:     core.ConversionToken,
+			Priority: m.priority - 100,
+			Module:   m,
+		},
+	}
+}
+
+func (m *PhysicalUnitModule) Calculate(ctx context.Context, token core.Token) (core.Result, error) {
+	input := strings.TrimSpace(token.Str)
+
+	if token.Kind == core.ConversionToken {
+		matches := m.conversionRegexp.FindStringSubmatch(input)
+		if len(matches) == 0 {
+			return core.Result{}, fmt.Errorf("unsupported %s conversion: %s", m.dimension.name, input)
+		}
+		unit, ok := m.dimension.lookup(matches[1])
+		if !ok {
+			return core.Result{}, fmt.Errorf("unsupported %s unit: %s", m.dimension.name, matches[1])
+		}
+		return core.Result{
+			DisplayValue: fmt.Sprintf("to %s", unit.Symbol),
+			RawValue:     decimal.Zero,
+			Unit:         core.Unit{Name: unit.Symbol, Type: m.dimension.unitType},
+			Module:       m,
+		}, nil
+	}
+
+	matches := m.valueRegexp.FindStringSubmatch(input)
+	if len(matches) == 0 {
+		return core.Result{}, fmt.Errorf("unsupported %s value: %s", m.dimension.name, input)
+	}
+	amount, err := decimal.NewFromString(matches[1])
+	if err != nil {
+		return core.Result{}, fmt.Errorf("invalid amount: %s", matches[1])
+	}
+	unit, ok := m.dimension.lookup(matches[2])
+	if !ok {
+		return core.Result{}, fmt.Errorf("unsupported %s unit: %s", m.dimension.name, matches[2])
+	}
+
+	return core.Result{
+		DisplayValue: formatPhysicalValue(amount, unit.Symbol),
+		RawValue:     amount,
+		Unit:         core.Unit{Name: unit.Symbol, Type: m.dimension.unitType},
+		Module:       m,
+	}, nil
+}
+
+func (m *PhysicalUnitModule) Convert(ctx context.Context, value core.Result, toUnit core.Unit) (core.Result, error) {
+	if value.Unit.Type != m.dimension.unitType || toUnit.Type != m.dimension.unitType {
+		return core.Result{}, fmt.Errorf("%s module can't convert %s to %s", m.dimension.name, value.Unit.Type, toUnit.Type)
+	}
+
+	from, ok := m.dimension.units[value.Unit.Name]
+	if !ok {
+		return core.Result{}, fmt.Errorf("unsupported %s unit: %s", m.dimension.name, value.Unit.Name)
+	}
+	to, ok := m.dimension.units[toUnit.Name]
+	if !ok {
+		return core.Result{}, fmt.Errorf("unsupported %s unit: %s", m.dimension.name, toUnit.Name)
+	}
+
+	converted := m.dimension.convert(value.RawValue, from, to)
+	return core.Result{
+		DisplayValue: formatPhysicalValue(converted, to.Symbol),
+		RawValue:     converted,
+		Unit:         toUnit,
+		Module:       m,
+	}, nil
+}
+
+// formatPhysicalValue shows up to 6 decimals, very small or very large values use scientific notation
+func formatPhysicalValue(value decimal.Decimal, symbol string) string {
+	abs := value.Abs()
+	var formatted string
+	if abs.IsZero() || (abs.GreaterThanOrEqual(decimal.New(1, -4)) && abs.LessThan(decimal.New(1, 15))) {
+		formatted = value.Round(6).String()
+	} else {
+		formatted = strconv.FormatFloat(value.InexactFloat64(), 'g', 7, 64)
+	}
+	return formatted + " " + symbol
+}
@@ -0,0 +1,196 @@
+package modules
+
+import (
+	"context"
+	"strings"
+	"wox/plugin"
+	"wox/plugin/system/converter/core"
+
+	"github.com/shopspring/decimal"
+)
+
+// Token priorities of the physical unit modules. They are above the time module, so "5 in to cm" isn't read as a time in a location,
+// and below currency and crypto. Dimensions whose aliases extend aliases of another dimension must tokenize first.
+const (
+	unitPriorityTemperature = 970 // °C before °
+	unitPriorityCompound    = 965 // km/h before km, mmHg before mm
+	unitPriorityAreaVolume  = 960 // cm², cm³ before cm
+	unitPriorityDefault     = 950
+	unitPriorityAngle       = 945
+)
+
+func factor(value string) unitFactor {
+	return unitFactor{Numerator: decimal.RequireFromString(value), Denominator: decimal.NewFromInt(1)}
+}
+
+func ratio(numerator string, denominator string) unitFactor {
+	return unitFactor{Numerator: decimal.RequireFromString(numerator), Denominator: decimal.RequireFromString(denominator)}
+}
+
+// NewLengthModule converts lengths, the base unit is meter
+func NewLengthModule(ctx context.Context, api plugin.API) *PhysicalUnitModule {
+	dim := newUnitDimension("length", core.UnitTypeLength)
+	dim.addSIPrefixed("m", factor("1"), -12, 3, "meter", "metre")
+	dim.add("in", factor("0.0254"), "inch", "inches")
+	dim.add("ft", factor("0.3048"), "foot", "feet")
+	dim.add("yd", factor("0.9144"), "yard", "yards")
+	dim.add("mi", factor("1609.344"), "mile", "miles")
+	dim.add("nmi", factor("1852"), "nautical mile", "nautical miles")
+	dim.add("au", factor("149597870700"), "astronomical unit", "astronomical units")
+	dim.add("ly", factor("9460730472580800"), "light year", "light years", "lightyear", "lightyears")
+	return newPhysicalUnitModule(api, dim, unitPriorityDefault)
+}
+
+// NewMassModule converts masses, the base unit is gram
+func NewMassModule(ctx context.Context, api plugin.API) *PhysicalUnitModule {
+	dim := newUnitDimension("mass", core.UnitTypeMass)
+	dim.addSIPrefixed("g", factor("1"), -9, 3, "gram", "gramme")
+	dim.add("t", factor("1000000"), "tonne", "tonnes", "metric ton", "metric tons")
+	dim.add("lb", factor("453.59237"), "lbs", "pound", "pounds")
+	dim.add("oz", factor("28.349523125"), "ounce", "ounces")
+	dim.add("stone", factor("6350.29318"), "stones") // "st" would make "1st" a mass
+	dim.add("ton", factor("907184.74"), "tons", "short ton", "short tons")
+	dim.add("ct", factor("0.2"), "carat", "carats")
+	return newPhysicalUnitModule(api, dim, unitPriorityDefault)
+}
+
+// NewVolumeModule converts volumes, the base unit is liter. Cups, pints and gallons are US customary units.
+func NewVolumeModule(ctx context.Context, api plugin.API) *PhysicalUnitModule {
+	dim := newUnitDimension("volume", core.UnitTypeVolume)
+	dim.addSIPrefixed("L", factor("1"), -6, 3, "liter", "litre")
+	dim.add("m³", factor("1000"), "m3", "cubic meter", "cubic meters", "cubic metre", "cubic metres")
+	dim.add("cm³", factor("0.001"), "cm3", "cc", "cubic centimeter", "cubic centimeters")
+	dim.add("ft³", factor("28.316846592"), "ft3", "cu ft", "cubic foot", "cubic feet")
+	dim.add("in³", factor("0.016387064"), "in3", "cu in", "cubic inch", "cubic inches")
+	dim.add("gal", factor("3.785411784"), "gallon", "gallons", "us gal")
+	dim.add("imp gal", factor("4.54609"), "imperial gallon", "imperial gallons")
+	dim.add("qt", factor("0.946352946"), "quart", "quarts")
+	dim.add("pt", factor("0.473176473"), "pint", "pints")
+	dim.add("cup", factor("0.2365882365"), "cups")
+	dim.add("fl oz", factor("0.0295735295625"), "floz", "fluid ounce", "fluid ounces")
+	dim.add("tbsp", factor("0.01478676478125"), "tablespoon", "tablespoons")
+	dim.add("tsp", factor("0.00492892159375"), "teaspoon", "teaspoons")
+	return newPhysicalUnitModule(api, dim, unitPriorityAreaVolume)
+}
+
+// NewTemperatureModule converts temperatures, the base unit is kelvin. Conversions apply the offset of each scale.
+func NewTemperatureModule(ctx context.Context, api plugin.API) *PhysicalUnitModule {
+	dim := newUnitDimension("temperature", core.UnitTypeTemperature)
+	dim.add("K", factor("1"), "kelvin", "kelvins")
+	dim.addWithOffset("°C", factor("1"), decimal.RequireFromString("273.15"), "c", "°c", "celsius", "degree celsius", "degrees celsius", "centigrade")
+	dim.addWithOffset("°F", ratio("5", "9"), decimal.RequireFromString("459.67"), "f", "°f", "fahrenheit", "degree fahrenheit", "degrees fahrenheit")
+	dim.add("°R", ratio("5", "9"), "°r", "rankine")
+	return newPhysicalUnitModule(api, dim, unitPriorityTemperature)
+}
+
+// NewAreaModule converts areas, the base unit is square meter
+func NewAreaModule(ctx context.Context, api plugin.API) *PhysicalUnitModule {
+	dim := newUnitDimension("area", core.UnitTypeArea)
+	dim.add("m²", factor("1"), "m2", "sqm", "sq m", "square meter", "square meters", "square metre", "square metres")
+	dim.add("km²", factor("1000000"), "km2", "sq km", "square kilometer", "square kilometers", "square kilometre", "square kilometres")
+	dim.add("cm²", factor("0.0001"), "cm2", "sq cm", "square centimeter", "square centimeters")
+	dim.add("mm²", factor("0.000001"), "mm2", "sq mm", "square millimeter", "square millimeters")
+	dim.add("ha", factor("10000"), "hectare", "hectares")
+	dim.add("ac", factor("4046.8564224"), "acre", "acres")
+	dim.add("ft²", factor("0.09290304"), "ft2", "sqft", "sq ft", "square foot", "square feet")
+	dim.add("in²", factor("0.00064516"), "in2", "sq in", "square inch", "square inches")
+	dim.add("yd²", factor("0.83612736"), "yd2", "sq yd", "square yard", "square yards")
+	dim.add("mi²", factor("2589988.110336"), "mi2", "sq mi", "square mile", "square miles")
+	return newPhysicalUnitModule(api, dim, unitPriorityAreaVolume)
+}
+
+// NewSpeedModule converts speeds, the base unit is meter per second
+func NewSpeedModule(ctx context.Context, api plugin.API) *PhysicalUnitModule {
+	dim := newUnitDimension("speed", core.UnitTypeSpeed)
+	dim.add("m/s", factor("1"), "mps", "meters per second", "metres per second")
+	dim.add("km/h", ratio("1000", "3600"), "kmh", "kph", "kmph", "kilometers per hour", "kilometres per hour")
+	dim.add("mph", factor("0.44704"), "mi/h", "miles per hour")
+	dim.add("kn", ratio("1852", "3600"), "kt", "knot", "knots")
+	dim.add("ft/s", factor("0.3048"), "fps", "feet per second")
+	return newPhysicalUnitModule(api, dim, unitPriorityCompound)
+}
+
+// NewPressureModule converts pressures, the base unit is pascal
+func NewPressureModule(ctx context.Context, api plugin.API) *PhysicalUnitModule {
+	dim := newUnitDimension("pressure", core.UnitTypePressure)
+	dim.addSIPrefixed("Pa", factor("1"), 2, 9, "pascal")
+	dim.add("bar", factor("100000"), "bars")
+	dim.add("mbar", factor("100"), "millibar", "millibars")
+	dim.add("atm", factor("101325"), "atmosphere", "atmospheres")
+	dim.add("psi", factor("6894.757293168"), "pounds per square inch")
+	dim.add("mmHg", factor("133.322387415"), "millimeter of mercury", "millimeters of mercury")
+	dim.add("inHg", factor("3386.389"), "inch of mercury", "inches of mercury")
+	dim.add("Torr", ratio("101325", "760"), "torr")
+	return newPhysicalUnitModule(api, dim, unitPriorityCompound)
+}
+
+// NewEnergyModule converts energies, the base unit is joule
+func NewEnergyModule(ctx context.Context, api plugin.API) *PhysicalUnitModule {
+	dim := newUnitDimension("energy", core.UnitTypeEnergy)
+	dim.addSIPrefixed("J", factor("1"), -3, 12, "joule")
+	dim.addSIPrefixed("Wh", factor("3600"), 3, 12, "watt hour", "watthour")
+	dim.add("cal", factor("4.184"), "calorie", "calories")
+	dim.add("kcal", factor("4184"), "Cal", "kilocalorie", "kilocalories")
+	dim.add("eV", factor("0.0000000000000000001602176634"), "electronvolt", "electronvolts")
+	dim.add("BTU", factor("1055.05585262"), "btu", "btus")
+	dim.add("thm", factor("105480400"), "therm", "therms")
+	return newPhysicalUnitModule(api, dim, unitPriorityDefault)
+}
+
+// NewDataSizeModule converts data sizes, the base unit is byte.
+// SI prefixes are powers of 1000 (1 MB = 1000000 B), IEC prefixes are powers of 1024 (1 MiB = 1048576 B).
+// Case matters where it is the only difference: "Mb" and "Kb" are bits while "MB", "mb" and "kb" are bytes.
+func NewDataSizeModule(ctx context.Context, api plugin.API) *PhysicalUnitModule {
+	dim := newUnitDimension("data size", core.UnitTypeDataSize)
+
+	type dataPrefix struct {
+		siSymbol  string
+		siName    string
+		iecSymbol string
+		iecName   string
+		power     int32
+	}
+	prefixes := []dataPrefix{
+		{"k", "kilo", "Ki", "kibi", 1},
+		{"M", "mega", "Mi", "mebi", 2},
+		{"G", "giga", "Gi", "gibi", 3},
+		{"T", "tera", "Ti", "tebi", 4},
+		{"P", "peta", "Pi", "pebi", 5},
+		{"E", "exa", "Ei", "exbi", 6},
+	}
+
+	// bytes are registered before bits, so case-insensitive input like "mb" resolves to megabyte
+	dim.add("B", factor("1"), "byte", "bytes")
+	for _, prefix := range prefixes {
+		si := unitFactor{Numerator: decimal.New(1, 3*prefix.power), Denominator: decimal.NewFromInt(1)}
+		iec := unitFactor{Numerator: decimal.NewFromInt(1024).Pow(decimal.NewFromInt32(prefix.power)), Denominator: decimal.NewFromInt(1)}
+		dim.add(prefix.siSymbol+"B", si, prefix.siName+"byte", prefix.siName+"bytes")
+		dim.add(prefix.iecSymbol+"B", iec, prefix.iecName+"byte", prefix.iecName+"bytes")
+	}
+
+	dim.add("kB", factor("1000"), "KB")
+
+	dim.add("bit", ratio("1", "8"), "bits", "b")
+	for _, prefix := range prefixes {
+		si := unitFactor{Numerator: decimal.New(1, 3*prefix.power), Denominator: decimal.NewFromInt(8)}
+		iec := unitFactor{Numerator: decimal.NewFromInt(1024).Pow(decimal.NewFromInt32(prefix.power)), Denominator: decimal.NewFromInt(8)}
+		// the short bit symbols are upper case ("Kb", "Mb"), lower case input like "kb" or "mb" means bytes
+		dim.add(prefix.siSymbol+"bit", si, prefix.siName+"bit", prefix.siName+"bits", strings.ToUpper(prefix.siSymbol)+"b")
+		dim.add(prefix.iecSymbol+"bit", iec, prefix.iecName+"bit", prefix.iecName+"bits", prefix.iecSymbol+"b")
+	}
+
+	return newPhysicalUnitModule(api, dim, unitPriorityDefault)
+}
+
+// NewAngleModule converts angles, the base unit is degree
+func NewAngleModule(ctx context.Context, api plugin.API) *PhysicalUnitModule {
+	dim := newUnitDimension("angle", core.UnitTypeAngle)
+	dim.add("°", factor("1"), "deg", "degree", "degrees")
+	dim.add("rad", ratio("180", "3.14159265358979323846264338327950288"), "radian", "radians")
+	dim.add("mrad", ratio("0.18", "3.14159265358979323846264338327950288"), "milliradian", "milliradians")
+	dim.add("grad", factor("0.9"), "gon", "gradian", "gradians")
+	dim.add("turn", factor("360"), "turns", "rev", "revolution", "revolutions")
+	dim.add("arcmin", ratio("1", "60"), "arcminute", "arcminutes")
+	dim.add("arcsec", ratio("1", "3600"), "arcsecond", "arcseconds")
+	return newPhysicalUnitModule(api, dim, unitPriorityAngle)
+}
@@ -0,0 +1,190 @@
+package modules
+
+import (
+	"context"
+	"testing"
+	"wox/plugin/system/converter/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestUnitModules() []*PhysicalUnitModule {
+	ctx := context.Background()
+	return []*PhysicalUnitModule{
+		NewLengthModule(ctx, nil),
+		NewMassModule(ctx, nil),
+		NewVolumeModule(ctx, nil),
+		NewTemperatureModule(ctx, nil),
+		NewAreaModule(ctx, nil),
+		NewSpeedModule(ctx, nil),
+		NewPressureModule(ctx, nil),
+		NewEnergyModule(ctx, nil),
+		NewDataSizeModule(ctx, nil),
+		NewAngleModule(ctx, nil),
+	}
+}
+
+// convertUnits tokenizes the query like the converter plugin does and converts the single value to the target
+func convertUnits(t *testing.T, query string) (core.Result, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	var patterns []core.TokenPattern
+	for _, module := range newTestUnitModules() {
+		patterns = append(patterns, module.TokenPatterns()...)
+	}
+	tokens, err := core.NewTokenizer(patterns).Tokenize(ctx, query)
+	if err != nil {
+		return core.Result{}, err
+	}
+	require.Len(t, tokens, 3, query) // value, conversion, eos
+
+	value, err := tokens[0].Module.Calculate(ctx, tokens[0])
+	if err != nil {
+		return core.Result{}, err
+	}
+	target, err := tokens[1].Module.Calculate(ctx, tokens[1])
+	if err != nil {
+		return core.Result{}, err
+	}
+	return value.Module.Convert(ctx, value, target.Unit)
+}
+
+func TestPhysicalUnitConversion(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		// length
+		{"10 km in miles", "6.213712 mi"},
+		{"1 mile to km", "1.609344 km"},
+		{"5 in to cm", "12.7 cm"},
+		{"6 feet to meters", "1.8288 m"},
+		{"1500mm to m", "1.5 m"},
+		{"3 nautical miles in km", "5.556 km"},
+		{"1 ly to km", "9460730472580.8 km"},
+		// mass
+		{"1 kg to lb", "2.204623 lb"},
+		{"16 oz in g", "453.59237 g"},
+		{"2.5 t to kg", "2500 kg"},
+		{"500 mg to g", "0.5 g"},
+		// volume
+		{"3 cups in ml", "709.76471 mL"},
+		{"1 gal to L", "3.785412 L"},
+		{"1 m3 to L", "1000 L"},
+		{"2 tbsp to tsp", "6 tsp"},
+		// temperature, with offsets
+		{"72 f to c", "22.222222 °C"},
+		{"100 °C to °F", "212 °F"},
+		{"-40 c to f", "-40 °F"},
+		{"0 kelvin to celsius", "-273.15 °C"},
+		{"491.67 °R to °C", "0 °C"},
+		// area
+		{"1 acre to m²", "4046.856422 m²"},
+		{"2 ha in km2", "0.02 km²"},
+		{"100 sq ft to m2", "9.290304 m²"},
+		// speed
+		{"100 km/h to mph", "62.137119 mph"},
+		{"10 m/s in km/h", "36 km/h"},
+		{"20 knots to km/h", "37.04 km/h"},
+		// pressure
+		{"1 atm to kPa", "101.325 kPa"},
+		{"30 psi to bar", "2.068427 bar"},
+		{"760 mmHg to atm", "1 atm"},
+		// energy
+		{"1 kWh to MJ", "3.6 MJ"},
+		{"2000 kcal to kJ", "8368 kJ"},
+		{"1 BTU to J", "1055.055853 J"},
+		// data size, SI vs IEC
+		{"5 GiB to MB", "5368.70912 MB"},
+		{"1 GB to MiB", "953.674316 MiB"},
+		{"100 Mb to MB", "12.5 MB"},
+		{"100 mb to MB", "100 MB"},
+		{"1 KiB in bits", "8192 bit"},
+		{"1 TB to GB", "1000 GB"},
+		// angle
+		{"180 deg to rad", "3.141593 rad"},
+		{"1 turn to degrees", "360 °"},
+		{"100 grad to °", "90 °"},
+		{"90 degrees to arcmin", "5400 arcmin"},
+	}
+
+	for _, tt := range tests {
+		result, err := convertUnits(t, tt.query)
+		if assert.NoError(t, err, tt.query) {
+			assert.Equal(t, tt.expected, result.DisplayValue, tt.query)
+		}
+	}
+}
+
+func TestPhysicalUnitIncompatibleDimensions(t *testing.T) {
+	ctx := context.Background()
+	length := NewLengthModule(ctx, nil)
+	mass := NewMassModule(ctx, nil)
+
+	value, err := length.Calculate(ctx, core.Token{Kind: core.IdentToken, Str: "10 km"})
+	require.NoError(t, err)
+	_, err = length.Convert(ctx, value, core.Unit{Name: "kg", Type: core.UnitTypeMass})
+	assert.Error(t, err)
+	_, err = mass.Convert(ctx, value, core.Unit{Name: "kg", Type: core.UnitTypeMass})
+	assert.Error(t, err)
+}
+
+func TestPhysicalUnitTokenPriority(t *testing.T) {
+	tests := []struct {
+		input    string
+		unitType core.UnitType
+		unit     string
+	}{
+		{"100 km/h", core.UnitTypeSpeed, "km/h"},
+		{"100 km", core.UnitTypeLength, "km"},
+		{"20 °C", core.UnitTypeTemperature, "°C"},
+		{"20 °", core.UnitTypeAngle, "°"},
+		{"5 cm²", core.UnitTypeArea, "cm²"},
+		{"5 cm3", core.UnitTypeVolume, "cm³"},
+		{"5 cm", core.UnitTypeLength, "cm"},
+		{"30 inHg", core.UnitTypePressure, "inHg"},
+		{"3 miles", core.UnitTypeLength, "mi"},
+		{"1 Mb", core.UnitTypeDataSize, "Mbit"},
+		{"1 MB", core.UnitTypeDataSize, "MB"},
+		{"1 kb", core.UnitTypeDataSize, "kB"},
+		{"5 µm", core.UnitTypeLength, "µm"},
+		{"5 um", core.UnitTypeLength, "µm"},
+	}
+
+	var patterns []core.TokenPattern
+	for _, module := range newTestUnitModules() {
+		patterns = append(patterns, module.TokenPatterns()...)
+	}
+	tokenizer := core.NewTokenizer(patterns)
+
+	for _, tt := range tests {
+		tokens, err := tokenizer.Tokenize(context.Background(), tt.input)
+		require.NoError(t, err, tt.input)
+		require.Len(t, tokens, 2, tt.input)
+
+		result, err := tokens[0].Module.Calculate(context.Background(), tokens[0])
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.unitType, result.Unit.Type, tt.input)
+		assert.Equal(t, tt.unit, result.Unit.Name, tt.input)
+	}
+}
+
+func TestPhysicalUnitSingleLetterTimeUnits(t *testing.T) {
+	ctx := context.Background()
+	length := NewLengthModule(ctx, nil)
+
+	// "10m" is tokenized by the time module
+	for _, pattern := range length.TokenPatterns() {
+		if pattern.Type == core.IdentToken {
+			assert.NotRegexp(t, "^"+pattern.Pattern+"$", "10m")
+			assert.Regexp(t, "^"+pattern.Pattern+"$", "10 km")
+		}
+	}
+
+	// but it is still a length once the converter knows the target is a length
+	result, err := length.Calculate(ctx, core.Token{Kind: core.IdentToken, Str: "10m"})
+	require.NoError(t, err)
+	assert.Equal(t, "m", result.Unit.Name)
+}
@@ -0,0 +1,163 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"wox/common"
+	"wox/plugin"
+	"wox/setting/definition"
+	"wox/util/clipboard"
+	"wox/util/desktop"
+)
+
+const (
+	mimeAppsIndexTTL   = time.Minute
+	maxOpenWithActions = 8
+)
+
+// linuxContextMenu builds the file context menu from the XDG MIME associations.
+// Linux has no context menu API that works on every desktop, so the menu is rendered as result actions instead.
+type linuxContextMenu struct {
+	mu           sync.Mutex
+	index        *desktop.MimeAppsIndex
+	loadedAt     time.Time
+	applications map[string][]*desktop.DesktopEntry // by extension, or by path for files without extension
+}
+
+// getApplications returns the applications that open the file. The menu is built for every result of every query,
+// so the applications are cached by extension and only files without extension are sniffed.
+// The associations are reloaded at most once per minute, loading scans every desktop entry.
+func (m *linuxContextMenu) getApplications(filePath string, isDir bool) []*desktop.DesktopEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.index == nil || time.Since(m.loadedAt) > mimeAppsIndexTTL {
+		m.index = desktop.LoadMimeAppsIndex()
+		m.loadedAt = time.Now()
+		m.applications = map[string][]*desktop.DesktopEntry{}
+	}
+
+	cacheKey := strings.ToLower(filepath.Ext(filePath))
+	if isDir {
+		cacheKey = "inode/directory"
+	} else if cacheKey == "" {
+		cacheKey = filePath
+	}
+	if applications, ok := m.applications[cacheKey]; ok {
+		return applications
+	}
+
+	mimeType := "inode/directory"
+	if !isDir {
+		mimeType = desktop.GetMimeType(filePath)
+	}
+	applications := m.index.GetApplications(mimeType)
+	m.applications[cacheKey] = applications
+	return applications
+}
+
+func (c *Plugin) getLinuxContextMenuActions(ctx context.Context, filePath string, isDir bool) []plugin.QueryResultAction {
+	var actions []plugin.QueryResultAction
+
+	applications := c.contextMenu.getApplications(filePath, isDir)
+	for i, application := range applications {
+		if i >= maxOpenWithActions {
+			break
+		}
+		app := application
+		actions = append(actions, plugin.QueryResultAction{
+			Name: fmt.Sprintf(c.api.GetTranslation(ctx, "plugin_file_open_with"), app.Name),
+			Icon: common.OpenIcon,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				if err := app.Launch(filePath); err != nil {
+					c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to open %s with %s: %s", filePath, app.Id, err.Error()))
+					c.api.Notify(ctx, err.Error())
+				}
+			},
+		})
+	}
+
+	actions = append(actions,
+		plugin.QueryResultAction{
+			Name: "i18n:plugin_file_copy_path",
+			Icon: common.CopyIcon,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				clipboard.WriteText(filePath)
+			},
+		},
+		plugin.QueryResultAction{
+			Name: "i18n:plugin_file_show_in_file_manager",
+			Icon: common.OpenContainingFolderIcon,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				if err := desktop.ShowItemsInFileManager([]string{filePath}); err != nil {
+					c.api.Log(ctx, plugin.LogLevelError, err.Error())
+					c.api.Notify(ctx, err.Error())
+				}
+			},
+		},
+		plugin.QueryResultAction{
+			Name:                   "i18n:plugin_file_rename",
+			Icon:                   common.TextIcon,
+			Type:                   plugin.QueryResultActionTypeForm,
+			PreventHideAfterAction: true,
+			Form: definition.PluginSettingDefinitions{
+				{
+					Type: definition.PluginSettingDefinitionTypeTextBox,
+					Value: &definition.PluginSettingValueTextBox{
+						Key:          "name",
+						Label:        "i18n:plugin_file_rename_new_name",
+						DefaultValue: filepath.Base(filePath),
+					},
+				},
+			},
+			OnSubmit: func(ctx context.Context, actionContext plugin.FormActionContext) {
+				if _, err := renameFile(filePath, actionContext.Values["name"]); err != nil {
+					c.api.Log(ctx, plugin.LogLevelError, err.Error())
+					c.api.Notify(ctx, err.Error())
+					return
+				}
+				c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: true})
+			},
+		},
+		plugin.QueryResultAction{
+			Name:                   "i18n:plugin_file_move_to_trash",
+			Icon:                   common.TrashIcon,
+			PreventHideAfterAction: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				if _, err := desktop.MoveToTrash(filePath); err != nil {
+					c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to move %s to trash: %s", filePath, err.Error()))
+					c.api.Notify(ctx, err.Error())
+					return
+				}
+				c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: false})
+			},
+		},
+	)
+
+	return actions
+}
+
+// renameFile renames the file inside its directory, it never overwrites an existing file
+func renameFile(filePath string, newName string) (string, error) {
+	newName = strings.TrimSpace(newName)
+	if newName == "" || newName == "." || newName == ".." || strings.ContainsRune(newName, '/') || strings.ContainsRune(newName, filepath.Separator) {
+		return "", fmt.Errorf("invalid file name: %q", newName)
+	}
+
+	newPath := filepath.Join(filepath.Dir(filePath), newName)
+	if newPath == filePath {
+		return filePath, nil
+	}
+	if _, err := os.Lstat(newPath); err == nil {
+		return "", fmt.Errorf("%s already exists", newPath)
+	}
+	if err := os.Rename(filePath, newPath); err != nil {
+		return "", fmt.Errorf("failed to rename %s: %w", filePath, err)
+	}
+	return newPath, nil
+}
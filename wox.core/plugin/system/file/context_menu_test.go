@@ -0,0 +1,32 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameFile(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "draft.txt")
+	require.NoError(t, os.WriteFile(original, []byte("draft"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "taken.txt"), []byte("taken"), 0644))
+
+	for _, invalidName := range []string{"", "  ", ".", "..", "sub/dir.txt"} {
+		_, err := renameFile(original, invalidName)
+		assert.Error(t, err, invalidName)
+	}
+
+	_, err := renameFile(original, "taken.txt")
+	assert.Error(t, err)
+	assert.FileExists(t, original)
+
+	newPath, err := renameFile(original, " final.txt ")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "final.txt"), newPath)
+	assert.NoFileExists(t, original)
+	assert.FileExists(t, newPath)
+}
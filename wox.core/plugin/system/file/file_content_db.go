@@ -0,0 +1,349 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+	"wox/util"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// binarySniffLength is the number of leading bytes checked for NUL bytes, same heuristic as git and grep
+const binarySniffLength = 8000
+
+// minContentTermLength is the shortest phrase the trigram index can match, shorter phrases are matched with LIKE
+const minContentTermLength = 3
+
+var errContentDBClosed = errors.New("file content database is closed")
+
+// fileContentDB stores the text of files under the content search roots.
+// It implements fileIndexStore so the crawler and watcher of fileIndexer keep it up to date.
+type fileContentDB struct {
+	// mu guards db, a reload closes the database while the crawler or a query may still use it
+	mu          sync.RWMutex
+	db          *sql.DB
+	maxFileSize int64
+	ftsEnabled  bool // false when sqlite is built without fts5, searches fall back to LIKE then
+}
+
+// contentMatch is a file whose content contains the search phrase
+type contentMatch struct {
+	Path    string
+	ModTime int64
+	Lines   []contentMatchLine
+}
+
+type contentMatchLine struct {
+	LineNumber int // 1 based
+	Text       string
+}
+
+func newFileContentDB(ctx context.Context, dbPath string, maxFileSize int64) (*fileContentDB, error) {
+	dsn := dbPath + "?" +
+		"_journal_mode=WAL&" + // The index is a cache, favour write throughput while crawling
+		"_synchronous=NORMAL&" +
+		"_busy_timeout=5000" // Set busy timeout to 5 seconds
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file content database: %w", err)
+	}
+
+	db.SetMaxOpenConns(4)
+	db.SetMaxIdleConns(2)
+	db.SetConnMaxLifetime(time.Hour)
+
+	contentDB := &fileContentDB{db: db, maxFileSize: maxFileSize}
+	if err := contentDB.initTables(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize file content tables: %w", err)
+	}
+
+	util.GetLogger().Info(ctx, fmt.Sprintf("file content database initialized at %s", dbPath))
+	return contentDB, nil
+}
+
+func (f *fileContentDB) initTables(ctx context.Context) error {
+	// the content is a cache of the files, a table without the id column is dropped and filled again by the next crawl
+	var idColumnCount int
+	if err := f.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info('file_content') WHERE name = 'id'`).Scan(&idColumnCount); err != nil {
+		return err
+	}
+	if idColumnCount == 0 {
+		if _, err := f.db.ExecContext(ctx, `DROP TABLE IF EXISTS file_content`); err != nil {
+			return err
+		}
+	}
+
+	// id keeps the rowids stable, the full text index refers to the rows by rowid
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS file_content (
+		id INTEGER PRIMARY KEY,
+		path TEXT NOT NULL UNIQUE,
+		size INTEGER DEFAULT 0,
+		mod_time INTEGER DEFAULT 0,
+		content TEXT,
+		indexed_at INTEGER DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_file_content_indexed_at ON file_content(indexed_at);
+	`
+
+	if _, err := f.db.ExecContext(ctx, createTableSQL); err != nil {
+		return err
+	}
+
+	if err := f.initFullTextIndex(ctx); err != nil {
+		util.GetLogger().Warn(ctx, fmt.Sprintf("file content full text search is not available, searching with LIKE: %s", err.Error()))
+	}
+	return nil
+}
+
+// initFullTextIndex creates the trigram fts5 index of the file contents, it is kept in sync by triggers.
+// The index reads the text from file_content instead of storing a second copy of it.
+func (f *fileContentDB) initFullTextIndex(ctx context.Context) error {
+	// the triggers fail every write when sqlite lacks fts5, drop them if a build with fts5 created them
+	var fts5Available bool
+	if err := f.db.QueryRowContext(ctx, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5Available); err != nil {
+		return err
+	}
+	if !fts5Available {
+		dropTriggersSQL := `
+		DROP TRIGGER IF EXISTS file_content_fts_insert;
+		DROP TRIGGER IF EXISTS file_content_fts_delete;
+		DROP TRIGGER IF EXISTS file_content_fts_update;
+		`
+		if _, err := f.db.ExecContext(ctx, dropTriggersSQL); err != nil {
+			return err
+		}
+		return fmt.Errorf("sqlite is built without fts5")
+	}
+
+	// the index is rebuilt when its triggers are missing, files written by a build without fts5 are not indexed yet
+	var triggerCount int
+	if err := f.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'file_content_fts_%'`).Scan(&triggerCount); err != nil {
+		return err
+	}
+
+	createIndexSQL := `
+	CREATE VIRTUAL TABLE IF NOT EXISTS file_content_fts USING fts5(content, content = 'file_content', content_rowid = 'id', tokenize = 'trigram');
+
+	CREATE TRIGGER IF NOT EXISTS file_content_fts_insert AFTER INSERT ON file_content BEGIN
+		INSERT INTO file_content_fts (rowid, content) VALUES (new.id, new.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS file_content_fts_delete AFTER DELETE ON file_content BEGIN
+		INSERT INTO file_content_fts (file_content_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS file_content_fts_update AFTER UPDATE OF content ON file_content BEGIN
+		INSERT INTO file_content_fts (file_content_fts, rowid, content) VALUES ('delete', old.id, old.content);
+		INSERT INTO file_content_fts (rowid, content) VALUES (new.id, new.content);
+	END;
+	`
+	if _, err := f.db.ExecContext(ctx, createIndexSQL); err != nil {
+		return err
+	}
+
+	if triggerCount < 3 {
+		if _, err := f.db.ExecContext(ctx, `INSERT INTO file_content_fts (file_content_fts) VALUES ('rebuild')`); err != nil {
+			return err
+		}
+	}
+
+	f.ftsEnabled = true
+	return nil
+}
+
+// Upsert reads the content of changed text files, unchanged files only get their generation bumped
+func (f *fileContentDB) Upsert(ctx context.Context, entries []fileIndexEntry) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.db == nil {
+		return errContentDBClosed
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+
+		if f.maxFileSize > 0 && entry.Size > f.maxFileSize {
+			if err := f.deletePath(ctx, entry.Path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var storedModTime int64
+		err := f.db.QueryRowContext(ctx, `SELECT mod_time FROM file_content WHERE path = ?`, entry.Path).Scan(&storedModTime)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil && storedModTime == entry.ModTime {
+			if _, err := f.db.ExecContext(ctx, `UPDATE file_content SET indexed_at = ? WHERE path = ?`, entry.IndexedAt, entry.Path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		content, ok := readTextFile(entry.Path)
+		if !ok {
+			// binary or unreadable, make sure an older text version doesn't linger
+			if err := f.deletePath(ctx, entry.Path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		_, err = f.db.ExecContext(ctx, `
+		INSERT INTO file_content (path, size, mod_time, content, indexed_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(path) DO UPDATE SET size = excluded.size, mod_time = excluded.mod_time,
+			content = excluded.content, indexed_at = excluded.indexed_at
+		`, entry.Path, entry.Size, entry.ModTime, content, entry.IndexedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeletePath removes the path and, if it is a directory, everything below it
+func (f *fileContentDB) DeletePath(ctx context.Context, path string) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.db == nil {
+		return errContentDBClosed
+	}
+
+	return f.deletePath(ctx, path)
+}
+
+func (f *fileContentDB) deletePath(ctx context.Context, path string) error {
+	prefix := strings.TrimSuffix(path, string(filepath.Separator)) + string(filepath.Separator)
+	_, err := f.db.ExecContext(ctx, `DELETE FROM file_content WHERE path = ? OR substr(path, 1, length(?)) = ?`, path, prefix, prefix)
+	return err
+}
+
+// DeleteStale removes files that were not seen by the crawl with the given generation, files below the kept directories are kept
+func (f *fileContentDB) DeleteStale(ctx context.Context, generation int64, keptDirectories []string) (int64, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.db == nil {
+		return 0, errContentDBClosed
+	}
+
+	condition, args := staleCondition(generation, keptDirectories)
+	result, err := f.db.ExecContext(ctx, `DELETE FROM file_content WHERE `+condition, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Search returns files containing the phrase (case-insensitive) with the matching lines, most recently modified first
+func (f *fileContentDB) Search(ctx context.Context, phrase string, limit int, maxLinesPerFile int) ([]contentMatch, error) {
+	if strings.TrimSpace(phrase) == "" {
+		return []contentMatch{}, nil
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.db == nil {
+		return nil, errContentDBClosed
+	}
+
+	querySQL := `
+	SELECT path, mod_time, content
+	FROM file_content
+	WHERE content LIKE ? ESCAPE '\'
+	ORDER BY mod_time DESC
+	LIMIT ?
+	`
+	args := []any{"%" + escapeLike(phrase) + "%", limit}
+	if f.ftsEnabled && utf8.RuneCountInString(phrase) >= minContentTermLength {
+		querySQL = `
+		SELECT path, mod_time, content
+		FROM file_content
+		WHERE id IN (SELECT rowid FROM file_content_fts WHERE file_content_fts MATCH ?)
+		ORDER BY mod_time DESC
+		LIMIT ?
+		`
+		args = []any{`"` + strings.ReplaceAll(phrase, `"`, `""`) + `"`, limit}
+	}
+
+	rows, err := f.db.QueryContext(ctx, querySQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []contentMatch
+	for rows.Next() {
+		var match contentMatch
+		var content string
+		if err := rows.Scan(&match.Path, &match.ModTime, &content); err != nil {
+			return nil, err
+		}
+		match.Lines = findMatchingLines(content, phrase, maxLinesPerFile)
+		// LIKE is only case-insensitive for ASCII and a phrase may span lines, skip rows without a matching line
+		if len(match.Lines) == 0 {
+			continue
+		}
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
+
+// Close closes the database connection, it waits for running writes and searches
+func (f *fileContentDB) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.db == nil {
+		return nil
+	}
+	err := f.db.Close()
+	f.db = nil
+	return err
+}
+
+// readTextFile returns the file content if it looks like UTF-8 text
+func readTextFile(path string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	if bytes.IndexByte(data[:min(len(data), binarySniffLength)], 0) >= 0 {
+		return "", false
+	}
+	if !utf8.Valid(data) {
+		return "", false
+	}
+	return string(data), true
+}
+
+func findMatchingLines(content string, phrase string, maxLines int) []contentMatchLine {
+	lowerPhrase := strings.ToLower(phrase)
+	var lines []contentMatchLine
+	for i, line := range strings.Split(content, "\n") {
+		if strings.Contains(strings.ToLower(line), lowerPhrase) {
+			lines = append(lines, contentMatchLine{LineNumber: i + 1, Text: strings.TrimRight(line, "\r")})
+			if maxLines > 0 && len(lines) >= maxLines {
+				break
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,262 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"wox/common"
+	"wox/plugin"
+	"wox/util"
+	"wox/util/shell"
+
+	"github.com/cdfmlr/ellipsis"
+)
+
+const (
+	contentSearchEnabledSettingKey = "ContentSearchEnabled"
+	contentRootsSettingKey         = "ContentRoots"
+	contentMaxFileSizeSettingKey   = "ContentMaxFileSizeKb"
+	contentEditorSettingKey        = "ContentEditorCommand"
+
+	defaultContentMaxFileSizeKb = 1024
+	contentMaxSearchResults     = 50
+	contentMaxPreviewLines      = 30
+)
+
+// contentSearcher searches inside text files under the content roots, the index is shared by all platforms
+type contentSearcher struct {
+	api        plugin.API
+	reloadLock sync.Mutex // serializes reloads, each setting change triggers one
+	mu         sync.RWMutex
+	db         *fileContentDB
+	indexer    *fileIndexer
+}
+
+func (c *contentSearcher) Init(ctx context.Context, api plugin.API) {
+	c.api = api
+
+	c.reload(ctx)
+	api.OnSettingChanged(ctx, func(key string, value string) {
+		if key == contentSearchEnabledSettingKey || key == contentRootsSettingKey || key == contentMaxFileSizeSettingKey ||
+			key == indexExcludesSettingKey || key == indexHiddenFilesSettingKey {
+			c.reload(util.NewTraceContext())
+		}
+	})
+	api.OnUnload(ctx, func() {
+		c.stop()
+	})
+}
+
+func (c *contentSearcher) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.indexer != nil {
+		c.indexer.Stop()
+		c.indexer = nil
+	}
+	if c.db != nil {
+		c.db.Close()
+		c.db = nil
+	}
+}
+
+// reload restarts the content index with the current settings, or stops it if content search is disabled
+func (c *contentSearcher) reload(ctx context.Context) {
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+
+	c.stop()
+
+	if c.api.GetSetting(ctx, contentSearchEnabledSettingKey) != "true" {
+		return
+	}
+
+	maxFileSizeKb, parseErr := strconv.ParseInt(c.api.GetSetting(ctx, contentMaxFileSizeSettingKey), 10, 64)
+	if parseErr != nil || maxFileSizeKb <= 0 {
+		maxFileSizeKb = defaultContentMaxFileSizeKb
+	}
+
+	dbPath := path.Join(util.GetLocation().GetPluginSettingDirectory(), pluginId+"_file_content.db")
+	db, err := newFileContentDB(ctx, dbPath, maxFileSizeKb*1024)
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to initialize file content index: %s", err.Error()))
+		return
+	}
+
+	var roots []struct {
+		Path string
+	}
+	rootsJson := c.api.GetSetting(ctx, contentRootsSettingKey)
+	if rootsJson != "" {
+		if unmarshalErr := json.Unmarshal([]byte(rootsJson), &roots); unmarshalErr != nil {
+			c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to parse content roots: %s", unmarshalErr.Error()))
+		}
+	}
+
+	options := fileIndexOptions{
+		IncludeHidden: c.api.GetSetting(ctx, indexHiddenFilesSettingKey) == "true",
+		Excludes:      strings.Split(c.api.GetSetting(ctx, indexExcludesSettingKey), "\n"),
+	}
+	for _, root := range roots {
+		if root.Path != "" {
+			options.Roots = append(options.Roots, root.Path)
+		}
+	}
+
+	c.mu.Lock()
+	c.db = db
+	c.indexer = newFileIndexer(c.api, db)
+	c.indexer.Start(ctx, options)
+	c.mu.Unlock()
+}
+
+func (c *contentSearcher) Query(ctx context.Context, pattern SearchPattern) []plugin.QueryResult {
+	// keep the read lock while searching, a reload closes the database
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	db := c.db
+
+	if db == nil {
+		return []plugin.QueryResult{
+			{
+				Title:    "i18n:plugin_file_content_search_disabled",
+				SubTitle: "i18n:plugin_file_content_search_disabled_subtitle",
+				Icon:     fileIcon,
+			},
+		}
+	}
+
+	matches, err := db.Search(ctx, pattern.Content, contentMaxSearchResults*4, contentMaxPreviewLines)
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to search file content: %s", err.Error()))
+		c.api.Notify(ctx, err.Error())
+		return []plugin.QueryResult{}
+	}
+
+	var results []plugin.QueryResult
+	for _, match := range matches {
+		searchResult := SearchResult{
+			Name:    filepath.Base(match.Path),
+			Path:    match.Path,
+			ModTime: time.UnixMilli(match.ModTime),
+		}
+		// name and filters such as ext: or in: can be combined with content:
+		if pattern.Name != "" && !strings.Contains(strings.ToLower(searchResult.Name), strings.ToLower(pattern.Name)) {
+			continue
+		}
+		if pattern.HasFilters() && !pattern.Match(&searchResult) {
+			continue
+		}
+
+		results = append(results, c.buildResult(ctx, match))
+		if len(results) >= contentMaxSearchResults {
+			break
+		}
+	}
+
+	return results
+}
+
+func (c *contentSearcher) buildResult(ctx context.Context, match contentMatch) plugin.QueryResult {
+	firstLine := match.Lines[0].LineNumber
+	filePath := match.Path
+
+	return plugin.QueryResult{
+		Title:    filepath.Base(filePath),
+		SubTitle: fmt.Sprintf("%s:%d", filePath, firstLine),
+		Icon:     fileIcon,
+		Preview: plugin.WoxPreview{
+			PreviewType: plugin.WoxPreviewTypeMarkdown,
+			PreviewData: formatContentMatchPreview(match),
+			PreviewProperties: map[string]string{
+				"i18n:plugin_file_content_matches": strconv.Itoa(len(match.Lines)),
+				"i18n:plugin_file_content_path":    filePath,
+			},
+		},
+		Actions: []plugin.QueryResultAction{
+			{
+				Name: "i18n:plugin_file_content_open_at_line",
+				Icon: common.PreviewIcon,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					if err := c.openInEditor(ctx, filePath, firstLine); err != nil {
+						c.api.Log(ctx, plugin.LogLevelError, err.Error())
+						c.api.Notify(ctx, err.Error())
+					}
+				},
+			},
+			{
+				Name: "i18n:plugin_file_open",
+				Icon: common.PreviewIcon,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					shell.Open(filePath)
+				},
+			},
+			{
+				Name: "i18n:plugin_file_open_containing_folder",
+				Icon: common.OpenContainingFolderIcon,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					shell.OpenFileInFolder(filePath)
+				},
+				Hotkey: "ctrl+enter",
+			},
+		},
+	}
+}
+
+// openInEditor runs the configured editor command, {file} and {line} are replaced. Without a command the file is opened by the system.
+func (c *contentSearcher) openInEditor(ctx context.Context, filePath string, line int) error {
+	command := strings.TrimSpace(c.api.GetSetting(ctx, contentEditorSettingKey))
+	if command == "" {
+		return shell.Open(filePath)
+	}
+
+	args := buildEditorArgs(command, filePath, line)
+	if len(args) == 0 {
+		return shell.Open(filePath)
+	}
+
+	c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("open %s at line %d with %s", filePath, line, strings.Join(args, " ")))
+	_, err := shell.Run(args[0], args[1:]...)
+	if err != nil {
+		return fmt.Errorf("failed to run editor command: %w", err)
+	}
+	return nil
+}
+
+// buildEditorArgs splits the editor command like a shell would and fills in the placeholders.
+// If the command has no {file} placeholder, the file path is appended.
+func buildEditorArgs(command string, filePath string, line int) []string {
+	hasFilePlaceholder := strings.Contains(command, "{file}")
+
+	var args []string
+	for _, token := range splitQueryTokens(command) {
+		arg := strings.ReplaceAll(token.value, "{file}", filePath)
+		arg = strings.ReplaceAll(arg, "{line}", strconv.Itoa(line))
+		args = append(args, arg)
+	}
+	if len(args) > 0 && !hasFilePlaceholder {
+		args = append(args, filePath)
+	}
+	return args
+}
+
+func formatContentMatchPreview(match contentMatch) string {
+	width := len(strconv.Itoa(match.Lines[len(match.Lines)-1].LineNumber))
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**%s**\n\n", filepath.Base(match.Path)))
+	sb.WriteString("```\n")
+	for _, line := range match.Lines {
+		text := ellipsis.Ending(strings.ReplaceAll(line.Text, "```", "` ` `"), 300)
+		sb.WriteString(fmt.Sprintf("%*d │ %s\n", width, line.LineNumber, text))
+	}
+	sb.WriteString("```\n")
+	return sb.String()
+}
@@ -0,0 +1,93 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileContentIndex(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\n// TODO fix this\nfunc main() {}\n// todo FIX later\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "image.bin"), []byte("TODO fix\x00\x01\x02"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "big.txt"), []byte(strings.Repeat("TODO fix\n", 200)), 0644))
+
+	ctx := context.Background()
+	db, err := newFileContentDB(ctx, filepath.Join(t.TempDir(), "content.db"), 1024)
+	require.NoError(t, err)
+	defer db.Close()
+
+	indexer := &fileIndexer{store: db}
+	config := newFileIndexConfig(fileIndexOptions{}, time.Now().UnixMilli())
+	_, err = indexer.indexDirectory(ctx, config, root, root, nil)
+	require.NoError(t, err)
+
+	matches, err := db.Search(ctx, "TODO fix", 10, 10)
+	require.NoError(t, err)
+	require.Len(t, matches, 1, "binary and oversized files are skipped")
+	assert.Equal(t, filepath.Join(root, "main.go"), matches[0].Path)
+	assert.Equal(t, []contentMatchLine{
+		{LineNumber: 3, Text: "// TODO fix this"},
+		{LineNumber: 5, Text: "// todo FIX later"},
+	}, matches[0].Lines)
+
+	preview := formatContentMatchPreview(matches[0])
+	assert.Contains(t, preview, "3 │ // TODO fix this")
+
+	// unchanged files are not read again, a new generation only bumps indexed_at
+	config = newFileIndexConfig(fileIndexOptions{}, config.generation+1)
+	_, err = indexer.indexDirectory(ctx, config, root, root, nil)
+	require.NoError(t, err)
+	deleted, err := db.DeleteStale(ctx, config.generation, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+}
+
+func TestFileContentIndexFollowsChanges(t *testing.T) {
+	root := t.TempDir()
+	filePath := filepath.Join(root, "notes.md")
+	require.NoError(t, os.WriteFile(filePath, []byte("first draft\n"), 0644))
+
+	ctx := context.Background()
+	db, err := newFileContentDB(ctx, filepath.Join(t.TempDir(), "content.db"), 1024)
+	require.NoError(t, err)
+
+	indexer := &fileIndexer{store: db}
+	config := newFileIndexConfig(fileIndexOptions{}, time.Now().UnixMilli())
+	_, err = indexer.indexDirectory(ctx, config, root, root, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filePath, []byte("final version\n"), 0644))
+	require.NoError(t, os.Chtimes(filePath, time.Now(), time.Now().Add(time.Minute)))
+	config = newFileIndexConfig(fileIndexOptions{}, config.generation+1)
+	_, err = indexer.indexDirectory(ctx, config, root, root, nil)
+	require.NoError(t, err)
+
+	matches, err := db.Search(ctx, "draft", 10, 10)
+	require.NoError(t, err)
+	assert.Empty(t, matches, "the old content is removed from the index")
+	matches, err = db.Search(ctx, "final", 10, 10)
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	require.NoError(t, db.DeletePath(ctx, root))
+	matches, err = db.Search(ctx, "final", 10, 10)
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	require.NoError(t, db.Close())
+	_, err = db.Search(ctx, "final", 10, 10)
+	assert.ErrorIs(t, err, errContentDBClosed)
+}
+
+func TestBuildEditorArgs(t *testing.T) {
+	assert.Equal(t, []string{"code", "--goto", "/tmp/a b.go:12"}, buildEditorArgs("code --goto {file}:{line}", "/tmp/a b.go", 12))
+	assert.Equal(t, []string{"subl", "/tmp/a.go"}, buildEditorArgs("subl", "/tmp/a.go", 3))
+	assert.Equal(t, []string{"/opt/my editor/bin", "+7", "/tmp/a.go"}, buildEditorArgs(`"/opt/my editor/bin" +{line} {file}`, "/tmp/a.go", 7))
+}
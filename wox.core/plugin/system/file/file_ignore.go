@@ -0,0 +1,156 @@
+package file
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is a single .gitignore-style exclude pattern
+type ignoreRule struct {
+	regex    *regexp.Regexp
+	negate   bool // pattern starts with "!", re-include paths excluded by previous rules
+	dirOnly  bool // pattern ends with "/", only match directories
+	anchored bool // pattern contains a slash, match against the path relative to the root instead of the base name
+}
+
+// ignoreMatcher evaluates .gitignore-style patterns, the last matching rule wins
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+func newIgnoreMatcher(patterns []string) *ignoreMatcher {
+	matcher := &ignoreMatcher{}
+	for _, pattern := range patterns {
+		rule, ok := parseIgnoreRule(pattern)
+		if ok {
+			matcher.rules = append(matcher.rules, rule)
+		}
+	}
+	return matcher
+}
+
+func parseIgnoreRule(pattern string) (ignoreRule, bool) {
+	pattern = strings.TrimRight(pattern, " \t\r")
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{}
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, "\\!") || strings.HasPrefix(pattern, "\\#") {
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if strings.Contains(pattern, "/") {
+		rule.anchored = true
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+	if pattern == "" {
+		return ignoreRule{}, false
+	}
+
+	regex, err := regexp.Compile("^" + globToRegex(pattern) + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.regex = regex
+	return rule, true
+}
+
+// globToRegex converts a gitignore glob into a regular expression, "**" matches across directories
+func globToRegex(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				// "**/" matches zero or more directories, "/**" matches everything inside
+				if i+2 < len(pattern) && pattern[i+2] == '/' {
+					sb.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					sb.WriteString(".*")
+					i++
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				sb.WriteString("\\[")
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// Match reports whether the given path (relative to the index root, slash separated) should be ignored
+func (m *ignoreMatcher) Match(relPath string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+
+	relPath = filepath.ToSlash(relPath)
+	baseName := relPath
+	if idx := strings.LastIndex(relPath, "/"); idx >= 0 {
+		baseName = relPath[idx+1:]
+	}
+
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		target := baseName
+		if rule.anchored {
+			target = relPath
+		}
+		if rule.regex.MatchString(target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// MatchAny reports whether the path or any of its parent directories (relative to the root) is ignored.
+// Used for watcher events, where the parents haven't been checked by the crawler.
+func (m *ignoreMatcher) MatchAny(relPath string, isDir bool, includeHidden bool) bool {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for i := range parts {
+		if parts[i] == "" || parts[i] == "." {
+			continue
+		}
+		if !includeHidden && strings.HasPrefix(parts[i], ".") {
+			return true
+		}
+		isLast := i == len(parts)-1
+		if m.Match(strings.Join(parts[:i+1], "/"), !isLast || isDir) {
+			return true
+		}
+	}
+	return false
+}
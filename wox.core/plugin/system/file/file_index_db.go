@@ -0,0 +1,253 @@
+package file
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"wox/util"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// fileIndexDB persists the native file index so it survives restarts
+type fileIndexDB struct {
+	db *sql.DB
+}
+
+// fileIndexEntry represents a single indexed file or directory
+type fileIndexEntry struct {
+	Path      string
+	Name      string
+	IsDir     bool
+	Size      int64
+	ModTime   int64 // unix milliseconds
+	IndexedAt int64 // crawl generation, used to sweep entries that no longer exist
+}
+
+func newFileIndexDB(ctx context.Context, dbPath string) (*fileIndexDB, error) {
+	dsn := dbPath + "?" +
+		"_journal_mode=WAL&" + // The index is a cache, favour write throughput while crawling
+		"_synchronous=NORMAL&" +
+		"_busy_timeout=5000" // Set busy timeout to 5 seconds
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file index database: %w", err)
+	}
+
+	db.SetMaxOpenConns(4)
+	db.SetMaxIdleConns(2)
+	db.SetConnMaxLifetime(time.Hour)
+
+	indexDB := &fileIndexDB{db: db}
+	if err := indexDB.initTables(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize file index tables: %w", err)
+	}
+
+	util.GetLogger().Info(ctx, fmt.Sprintf("file index database initialized at %s", dbPath))
+	return indexDB, nil
+}
+
+func (f *fileIndexDB) initTables(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS file_index (
+		path TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		is_dir BOOLEAN DEFAULT FALSE,
+		size INTEGER DEFAULT 0,
+		mod_time INTEGER DEFAULT 0,
+		indexed_at INTEGER DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_file_index_name ON file_index(name COLLATE NOCASE);
+	CREATE INDEX IF NOT EXISTS idx_file_index_indexed_at ON file_index(indexed_at);
+	`
+
+	_, err := f.db.ExecContext(ctx, createTableSQL)
+	return err
+}
+
+// Upsert inserts or updates entries in a single transaction
+func (f *fileIndexDB) Upsert(ctx context.Context, entries []fileIndexEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+	INSERT INTO file_index (path, name, is_dir, size, mod_time, indexed_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT(path) DO UPDATE SET name = excluded.name, is_dir = excluded.is_dir, size = excluded.size,
+		mod_time = excluded.mod_time, indexed_at = excluded.indexed_at
+	`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, entry := range entries {
+		if _, err := stmt.ExecContext(ctx, entry.Path, entry.Name, entry.IsDir, entry.Size, entry.ModTime, entry.IndexedAt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeletePath removes the path and, if it is a directory, everything below it
+func (f *fileIndexDB) DeletePath(ctx context.Context, path string) error {
+	prefix := strings.TrimSuffix(path, string(filepath.Separator)) + string(filepath.Separator)
+	_, err := f.db.ExecContext(ctx, `DELETE FROM file_index WHERE path = ? OR substr(path, 1, length(?)) = ?`, path, prefix, prefix)
+	return err
+}
+
+// DeleteStale removes entries that were not seen by the crawl with the given generation,
+// entries below the kept directories (e.g. roots that failed to index) are kept
+func (f *fileIndexDB) DeleteStale(ctx context.Context, generation int64, keptDirectories []string) (int64, error) {
+	condition, args := staleCondition(generation, keptDirectories)
+	result, err := f.db.ExecContext(ctx, `DELETE FROM file_index WHERE `+condition, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Search returns entries matching the name terms and every filter of the pattern.
+// Exact name matches come first, then prefix and contains matches, newest first within each, so the limit never cuts better matches.
+// Glob and regex can't be expressed in SQL, they are applied while reading the rows, so the limit counts matching entries only.
+func (f *fileIndexDB) Search(ctx context.Context, pattern SearchPattern, limit int) ([]fileIndexEntry, error) {
+	var conditions []string
+	var args []any
+	for _, term := range strings.Fields(pattern.Name) {
+		conditions = append(conditions, `name LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(term)+"%")
+	}
+	if len(pattern.Extensions) > 0 {
+		var extConditions []string
+		for _, ext := range pattern.Extensions {
+			extConditions = append(extConditions, `name LIKE ? ESCAPE '\'`)
+			args = append(args, "%."+escapeLike(ext))
+		}
+		conditions = append(conditions, "("+strings.Join(extConditions, " OR ")+")")
+	}
+	if pattern.InDirectory != "" {
+		prefix := strings.TrimSuffix(pattern.InDirectory, string(filepath.Separator)) + string(filepath.Separator)
+		conditions = append(conditions, `substr(path, 1, length(?)) = ?`)
+		args = append(args, prefix, prefix)
+	}
+	switch pattern.Type {
+	case FileTypeDir:
+		conditions = append(conditions, `is_dir = TRUE`)
+	case FileTypeFile:
+		conditions = append(conditions, `is_dir = FALSE`)
+	}
+	if !pattern.ModifiedAfter.IsZero() {
+		conditions = append(conditions, `mod_time >= ?`)
+		args = append(args, pattern.ModifiedAfter.UnixMilli())
+	}
+	if !pattern.ModifiedBefore.IsZero() {
+		conditions = append(conditions, `mod_time < ?`)
+		args = append(args, pattern.ModifiedBefore.UnixMilli())
+	}
+	if pattern.MinSize >= 0 {
+		conditions = append(conditions, `size >= ? AND is_dir = FALSE`)
+		args = append(args, pattern.MinSize)
+	}
+	if pattern.MaxSize >= 0 {
+		conditions = append(conditions, `size <= ? AND is_dir = FALSE`)
+		args = append(args, pattern.MaxSize)
+	}
+	if len(conditions) == 0 && pattern.Glob == "" && pattern.Regex == nil {
+		return []fileIndexEntry{}, nil
+	}
+	if len(conditions) == 0 {
+		// glob or regex only, they are matched while reading the rows
+		conditions = append(conditions, "1 = 1")
+	}
+	filterRows := pattern.Glob != "" || pattern.Regex != nil
+
+	orderBy := "mod_time DESC"
+	if name := strings.TrimSpace(pattern.Name); name != "" {
+		orderBy = `CASE
+		WHEN name = ? COLLATE NOCASE THEN 0
+		WHEN name LIKE ? ESCAPE '\' THEN 1
+		WHEN name LIKE ? ESCAPE '\' THEN 2
+		ELSE 3
+	END, mod_time DESC`
+		args = append(args, name, escapeLike(name)+"%", "%"+escapeLike(name)+"%")
+	}
+	limitSQL := ""
+	if !filterRows {
+		limitSQL = "LIMIT ?"
+		args = append(args, limit)
+	}
+
+	querySQL := `
+	SELECT path, name, is_dir, size, mod_time, indexed_at
+	FROM file_index
+	WHERE ` + strings.Join(conditions, " AND ") + `
+	ORDER BY ` + orderBy + `
+	` + limitSQL + `
+	`
+
+	rows, err := f.db.QueryContext(ctx, querySQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []fileIndexEntry
+	for rows.Next() && len(entries) < limit {
+		var entry fileIndexEntry
+		if err := rows.Scan(&entry.Path, &entry.Name, &entry.IsDir, &entry.Size, &entry.ModTime, &entry.IndexedAt); err != nil {
+			return nil, err
+		}
+		if filterRows && !pattern.MatchName(entry.Name) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Count returns the number of indexed entries
+func (f *fileIndexDB) Count(ctx context.Context) (int, error) {
+	var count int
+	err := f.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM file_index`).Scan(&count)
+	return count, err
+}
+
+// Close closes the database connection
+func (f *fileIndexDB) Close() error {
+	if f.db != nil {
+		return f.db.Close()
+	}
+	return nil
+}
+
+// staleCondition matches the entries older than the generation that are not below one of the kept directories
+func staleCondition(generation int64, keptDirectories []string) (string, []any) {
+	conditions := []string{`indexed_at < ?`}
+	args := []any{generation}
+	for _, directory := range keptDirectories {
+		prefix := strings.TrimSuffix(directory, string(filepath.Separator)) + string(filepath.Separator)
+		conditions = append(conditions, `path != ? AND substr(path, 1, length(?)) != ?`)
+		args = append(args, directory, prefix, prefix)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func escapeLike(term string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(term)
+}
@@ -0,0 +1,429 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"wox/plugin"
+	"wox/util"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	indexRootsSettingKey       = "IndexRoots"
+	indexExcludesSettingKey    = "IndexExcludes"
+	indexHiddenFilesSettingKey = "IndexHiddenFiles"
+
+	indexBatchSize        = 500
+	indexMaxSearchResults = 100
+	// fetch more candidates than we return so ranking can pick the best matches
+	indexSearchCandidates = 2000
+)
+
+var defaultIndexExcludes = strings.Join([]string{
+	"node_modules/",
+	"__pycache__/",
+	".cache/",
+	"*.tmp",
+	"*.swp",
+}, "\n")
+
+// fileIndexOptions controls which directories are crawled and what is skipped
+type fileIndexOptions struct {
+	Roots         []string
+	Excludes      []string // .gitignore-style patterns
+	IncludeHidden bool
+}
+
+// fileIndexConfig is what a crawl and its watchers index, it is never changed after creation.
+// Start creates a new one for every crawl, so the watcher goroutines of a crawl never see the options of the next one.
+type fileIndexConfig struct {
+	options    fileIndexOptions
+	ignore     *ignoreMatcher
+	generation int64 // the IndexedAt of the entries of the crawl
+}
+
+func newFileIndexConfig(options fileIndexOptions, generation int64) *fileIndexConfig {
+	return &fileIndexConfig{options: options, ignore: newIgnoreMatcher(options.Excludes), generation: generation}
+}
+
+// fileIndexStore receives the entries found by the crawler and the watcher
+type fileIndexStore interface {
+	Upsert(ctx context.Context, entries []fileIndexEntry) error
+	DeletePath(ctx context.Context, path string) error
+	DeleteStale(ctx context.Context, generation int64, keptDirectories []string) (int64, error)
+}
+
+// fileIndexer crawls the configured roots into a SQLite index and keeps it live with fsnotify
+type fileIndexer struct {
+	api   plugin.API
+	store fileIndexStore
+
+	mu            sync.Mutex
+	cancel        context.CancelFunc
+	watchers      map[string]*fsnotify.Watcher // index root -> watcher of the root and its sub directories
+	watchDisabled bool                         // set when the inotify watch limit is reached
+
+	isIndexing atomic.Bool
+}
+
+func newFileIndexer(api plugin.API, store fileIndexStore) *fileIndexer {
+	return &fileIndexer{api: api, store: store}
+}
+
+// Start stops any running crawl or watcher and indexes the roots again with the given options
+func (f *fileIndexer) Start(ctx context.Context, options fileIndexOptions) {
+	f.Stop()
+
+	f.mu.Lock()
+	indexCtx, cancel := context.WithCancel(util.NewTraceContext())
+	f.cancel = cancel
+	f.watchers = map[string]*fsnotify.Watcher{}
+	f.watchDisabled = false
+	f.mu.Unlock()
+
+	config := newFileIndexConfig(options, time.Now().UnixMilli())
+	util.Go(ctx, "index files", func() {
+		f.crawl(indexCtx, config)
+	})
+}
+
+// Stop cancels the current crawl and closes all directory watchers
+func (f *fileIndexer) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cancel != nil {
+		f.cancel()
+		f.cancel = nil
+	}
+	for _, watcher := range f.watchers {
+		watcher.Close()
+	}
+	f.watchers = nil
+}
+
+func (f *fileIndexer) IsIndexing() bool {
+	return f.isIndexing.Load()
+}
+
+func (f *fileIndexer) crawl(ctx context.Context, config *fileIndexConfig) {
+	f.isIndexing.Store(true)
+	defer f.isIndexing.Store(false)
+
+	start := util.GetSystemTimestamp()
+	total := 0
+	// the entries of roots that failed to index (e.g. permission denied or an unmounted drive) are kept until the next crawl
+	var failedRoots []string
+	for _, root := range config.options.Roots {
+		if ctx.Err() != nil {
+			return
+		}
+
+		watcher, watchErr := util.WatchDirectoryChanges(ctx, root, func(event fsnotify.Event) {
+			f.handleEvent(ctx, config, root, event)
+		})
+		if watchErr != nil {
+			f.api.Log(ctx, plugin.LogLevelWarning, fmt.Sprintf("failed to watch index root %s: %s", root, watchErr.Error()))
+		} else {
+			f.mu.Lock()
+			if ctx.Err() != nil {
+				// stopped while the watcher was created
+				f.mu.Unlock()
+				watcher.Close()
+				return
+			}
+			f.watchers[root] = watcher
+			f.mu.Unlock()
+		}
+
+		count, err := f.indexDirectory(ctx, config, root, root, watcher)
+		if err != nil {
+			f.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to index %s: %s", root, err.Error()))
+			failedRoots = append(failedRoots, root)
+			continue
+		}
+		total += count
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	deleted, err := f.store.DeleteStale(ctx, config.generation, failedRoots)
+	if err != nil {
+		f.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to delete stale index entries: %s", err.Error()))
+	}
+
+	f.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("indexed %d files in %d roots, removed %d stale entries, cost %d ms", total, len(config.options.Roots), deleted, util.GetSystemTimestamp()-start))
+}
+
+// indexDirectory walks the directory, writes entries in batches and adds every visited directory to the watcher
+func (f *fileIndexer) indexDirectory(ctx context.Context, config *fileIndexConfig, root string, directory string, watcher *fsnotify.Watcher) (int, error) {
+	var batch []fileIndexEntry
+	count := 0
+
+	walkErr := filepath.WalkDir(directory, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// the directory itself can't be read, e.g. it was removed or its drive is not mounted
+			if path == directory {
+				return err
+			}
+			// permission denied etc, skip this entry but keep walking
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+
+		relPath, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			return nil
+		}
+		if config.shouldSkip(relPath, d.Name(), d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, infoErr := d.Info()
+		if infoErr != nil {
+			return nil
+		}
+
+		batch = append(batch, config.newEntry(path, info))
+		count++
+		if len(batch) >= indexBatchSize {
+			if upsertErr := f.store.Upsert(ctx, batch); upsertErr != nil {
+				return upsertErr
+			}
+			batch = batch[:0]
+		}
+
+		if d.IsDir() && watcher != nil {
+			f.addWatch(ctx, watcher, path)
+		}
+		return nil
+	})
+	if walkErr != nil && !errors.Is(walkErr, context.Canceled) {
+		return count, walkErr
+	}
+
+	if err := f.store.Upsert(ctx, batch); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+func (c *fileIndexConfig) shouldSkip(relPath string, name string, isDir bool) bool {
+	if !c.options.IncludeHidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+	return c.ignore.Match(relPath, isDir)
+}
+
+func (c *fileIndexConfig) newEntry(path string, info os.FileInfo) fileIndexEntry {
+	return fileIndexEntry{
+		Path:      path,
+		Name:      info.Name(),
+		IsDir:     info.IsDir(),
+		Size:      info.Size(),
+		ModTime:   info.ModTime().UnixMilli(),
+		IndexedAt: c.generation,
+	}
+}
+
+func (f *fileIndexer) addWatch(ctx context.Context, watcher *fsnotify.Watcher, path string) {
+	f.mu.Lock()
+	disabled := f.watchDisabled
+	f.mu.Unlock()
+	if disabled {
+		return
+	}
+
+	if err := watcher.Add(path); err != nil {
+		if errors.Is(err, syscall.ENOSPC) {
+			// inotify watch limit reached, keep the index but stop watching new directories
+			f.mu.Lock()
+			f.watchDisabled = true
+			f.mu.Unlock()
+			f.api.Log(ctx, plugin.LogLevelWarning, "inotify watch limit reached, increase fs.inotify.max_user_watches to keep the whole file index live")
+			return
+		}
+		f.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("failed to watch %s: %s", path, err.Error()))
+	}
+}
+
+func (f *fileIndexer) handleEvent(ctx context.Context, config *fileIndexConfig, root string, event fsnotify.Event) {
+	relPath, err := filepath.Rel(root, event.Name)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return
+	}
+
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		if deleteErr := f.store.DeletePath(ctx, event.Name); deleteErr != nil {
+			f.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to remove %s from file index: %s", event.Name, deleteErr.Error()))
+		}
+		return
+	}
+
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+		return
+	}
+
+	info, statErr := os.Lstat(event.Name)
+	if statErr != nil {
+		return
+	}
+	if config.ignore.MatchAny(relPath, info.IsDir(), config.options.IncludeHidden) {
+		return
+	}
+
+	if upsertErr := f.store.Upsert(ctx, []fileIndexEntry{config.newEntry(event.Name, info)}); upsertErr != nil {
+		f.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to update %s in file index: %s", event.Name, upsertErr.Error()))
+		return
+	}
+
+	// a new directory may already contain files (e.g. moved in or extracted), index it and watch it
+	if event.Has(fsnotify.Create) && info.IsDir() {
+		f.mu.Lock()
+		watcher := f.watchers[root]
+		f.mu.Unlock()
+
+		if watcher != nil {
+			f.addWatch(ctx, watcher, event.Name)
+		}
+		if _, indexErr := f.indexDirectory(ctx, config, root, event.Name, watcher); indexErr != nil {
+			f.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to index new directory %s: %s", event.Name, indexErr.Error()))
+		}
+	}
+}
+
+// searchFileIndex queries the name index and ranks the candidates by name match quality and recency
+func searchFileIndex(ctx context.Context, db *fileIndexDB, pattern SearchPattern) ([]SearchResult, error) {
+	if pattern.IsEmpty() {
+		return []SearchResult{}, nil
+	}
+
+	entries, err := db.Search(ctx, pattern, indexSearchCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, entry := range rankIndexEntries(entries, pattern.Name, time.Now()) {
+		results = append(results, SearchResult{
+			Name:    entry.Name,
+			Path:    entry.Path,
+			IsDir:   entry.IsDir,
+			Size:    entry.Size,
+			ModTime: time.UnixMilli(entry.ModTime),
+		})
+		if len(results) >= indexMaxSearchResults {
+			break
+		}
+	}
+	return results, nil
+}
+
+// rankIndexEntries sorts entries by how well the file name matches the query, newer files win ties
+func rankIndexEntries(entries []fileIndexEntry, query string, now time.Time) []fileIndexEntry {
+	type scoredEntry struct {
+		entry fileIndexEntry
+		score int
+	}
+
+	scored := make([]scoredEntry, 0, len(entries))
+	for _, entry := range entries {
+		scored = append(scored, scoredEntry{
+			entry: entry,
+			score: nameMatchScore(entry.Name, query) + recencyScore(entry.ModTime, now),
+		})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		if scored[i].entry.ModTime != scored[j].entry.ModTime {
+			return scored[i].entry.ModTime > scored[j].entry.ModTime
+		}
+		return len(scored[i].entry.Path) < len(scored[j].entry.Path)
+	})
+
+	result := make([]fileIndexEntry, 0, len(scored))
+	for _, s := range scored {
+		result = append(result, s.entry)
+	}
+	return result
+}
+
+func nameMatchScore(name string, query string) int {
+	lowerName := strings.ToLower(name)
+	lowerQuery := strings.ToLower(strings.TrimSpace(query))
+	nameWithoutExt := strings.TrimSuffix(lowerName, strings.ToLower(filepath.Ext(name)))
+
+	switch {
+	case lowerName == lowerQuery:
+		return 100
+	case nameWithoutExt == lowerQuery:
+		return 90
+	case strings.HasPrefix(lowerName, lowerQuery):
+		return 70
+	case isWordBoundaryMatch(lowerName, lowerQuery):
+		return 50
+	case strings.Contains(lowerName, lowerQuery):
+		return 30
+	default:
+		// multi-term queries where every term matches somewhere in the name
+		return 10
+	}
+}
+
+// isWordBoundaryMatch reports whether the query starts right after a separator such as "-", "_", "." or space
+func isWordBoundaryMatch(name string, query string) bool {
+	for idx := strings.Index(name, query); idx >= 0; {
+		if idx > 0 && strings.ContainsRune(" -_.()[]", rune(name[idx-1])) {
+			return true
+		}
+		next := strings.Index(name[idx+1:], query)
+		if next < 0 {
+			break
+		}
+		idx = idx + 1 + next
+	}
+	return false
+}
+
+func recencyScore(modTime int64, now time.Time) int {
+	age := now.Sub(time.UnixMilli(modTime))
+	switch {
+	case age < 24*time.Hour:
+		return 20
+	case age < 7*24*time.Hour:
+		return 12
+	case age < 30*24*time.Hour:
+		return 6
+	case age < 365*24*time.Hour:
+		return 2
+	default:
+		return 0
+	}
+}
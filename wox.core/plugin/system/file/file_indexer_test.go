@@ -0,0 +1,189 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"wox/plugin/plugintest"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	matcher := newIgnoreMatcher([]string{
+		"# comment",
+		"node_modules/",
+		"*.log",
+		"!important.log",
+		"/build",
+		"docs/**/draft",
+	})
+
+	assert.True(t, matcher.Match("node_modules", true))
+	assert.True(t, matcher.Match("web/node_modules", true))
+	assert.False(t, matcher.Match("node_modules", false), "dir-only pattern must not match files")
+
+	assert.True(t, matcher.Match("debug.log", false))
+	assert.True(t, matcher.Match("logs/debug.log", false))
+	assert.False(t, matcher.Match("important.log", false), "negated pattern re-includes the file")
+
+	assert.True(t, matcher.Match("build", true))
+	assert.False(t, matcher.Match("src/build", true), "anchored pattern only matches at the root")
+
+	assert.True(t, matcher.Match("docs/draft", true))
+	assert.True(t, matcher.Match("docs/a/b/draft", true))
+	assert.False(t, matcher.Match("src/draft", true))
+
+	assert.True(t, matcher.MatchAny("web/node_modules/react/index.js", false, true))
+	assert.True(t, matcher.MatchAny("src/.git/config", false, false), "hidden parent is skipped")
+	assert.False(t, matcher.MatchAny("src/.git/config", false, true))
+}
+
+func TestRankIndexEntries(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-400 * 24 * time.Hour).UnixMilli()
+	recent := now.Add(-10 * 24 * time.Hour).UnixMilli()
+
+	entries := []fileIndexEntry{
+		{Path: "/a/my-report-final.pdf", Name: "my-report-final.pdf", ModTime: old},
+		{Path: "/a/unreported.txt", Name: "unreported.txt", ModTime: recent},
+		{Path: "/a/report.pdf", Name: "report.pdf", ModTime: old},
+		{Path: "/a/report", Name: "report", ModTime: old},
+		{Path: "/a/reports.md", Name: "reports.md", ModTime: old},
+	}
+
+	ranked := rankIndexEntries(entries, "report", now)
+	names := make([]string, 0, len(ranked))
+	for _, entry := range ranked {
+		names = append(names, entry.Name)
+	}
+
+	assert.Equal(t, []string{"report", "report.pdf", "reports.md", "my-report-final.pdf", "unreported.txt"}, names)
+}
+
+func TestFileIndexerCrawlAndSearch(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(rel string) {
+		full := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte("x"), 0644))
+	}
+	writeFile("notes/todo.md")
+	writeFile("notes/.secret-todo.md")
+	writeFile("node_modules/todo/index.js")
+	writeFile("project/todo-list.txt")
+
+	ctx := context.Background()
+	db, err := newFileIndexDB(ctx, filepath.Join(t.TempDir(), "index.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	indexer := &fileIndexer{store: db}
+	config := newFileIndexConfig(fileIndexOptions{Excludes: []string{"node_modules/"}}, time.Now().UnixMilli())
+	count, err := indexer.indexDirectory(ctx, config, root, root, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count) // notes, notes/todo.md, project, project/todo-list.txt
+
+	results, err := searchFileIndex(ctx, db, SearchPattern{Name: "todo", MinSize: -1, MaxSize: -1})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "todo.md", results[0].Name)
+	assert.Equal(t, "todo-list.txt", results[1].Name)
+
+	results, err = searchFileIndex(ctx, db, SearchPattern{Extensions: []string{"txt"}, Type: FileTypeFile, MinSize: -1, MaxSize: -1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "todo-list.txt", results[0].Name)
+
+	require.NoError(t, db.DeletePath(ctx, filepath.Join(root, "notes")))
+	results, err = searchFileIndex(ctx, db, SearchPattern{Name: "todo", MinSize: -1, MaxSize: -1})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestFileIndexSearchRanksBeforeLimit(t *testing.T) {
+	ctx := context.Background()
+	db, err := newFileIndexDB(ctx, filepath.Join(t.TempDir(), "index.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	entries := []fileIndexEntry{
+		{Path: "/a/Report", Name: "Report", ModTime: now.Add(-400 * 24 * time.Hour).UnixMilli()},
+		{Path: "/a/report.pdf", Name: "report.pdf", ModTime: now.Add(-300 * 24 * time.Hour).UnixMilli()},
+	}
+	for i := range 5 {
+		name := fmt.Sprintf("my-report-%d.txt", i)
+		entries = append(entries, fileIndexEntry{Path: "/b/" + name, Name: name, ModTime: now.Add(-time.Duration(i) * time.Hour).UnixMilli()})
+	}
+	require.NoError(t, db.Upsert(ctx, entries))
+
+	found, err := db.Search(ctx, SearchPattern{Name: "report", MinSize: -1, MaxSize: -1}, 3)
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	assert.Equal(t, "Report", found[0].Name, "older exact and prefix matches are not cut by newer contains matches")
+	assert.Equal(t, "report.pdf", found[1].Name)
+	assert.Equal(t, "my-report-0.txt", found[2].Name)
+}
+
+func TestFileIndexSearchFiltersBeforeLimit(t *testing.T) {
+	ctx := context.Background()
+	db, err := newFileIndexDB(ctx, filepath.Join(t.TempDir(), "index.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	var entries []fileIndexEntry
+	for i := range 10 {
+		name := fmt.Sprintf("notes-%d.txt", i)
+		entries = append(entries, fileIndexEntry{Path: "/a/" + name, Name: name, ModTime: now.Add(-time.Duration(i) * time.Hour).UnixMilli()})
+	}
+	entries = append(entries, fileIndexEntry{Path: "/a/old.md", Name: "old.md", ModTime: now.Add(-400 * 24 * time.Hour).UnixMilli()})
+	require.NoError(t, db.Upsert(ctx, entries))
+
+	pattern, err := ParseSearchPattern("*.md", now)
+	require.NoError(t, err)
+	found, err := db.Search(ctx, pattern, 3)
+	require.NoError(t, err)
+	require.Len(t, found, 1, "the oldest entry is the only glob match and must not be cut by the limit")
+	assert.Equal(t, "old.md", found[0].Name)
+
+	pattern, err = ParseSearchPattern(`/notes-[0-9]\.txt/`, now)
+	require.NoError(t, err)
+	found, err = db.Search(ctx, pattern, 3)
+	require.NoError(t, err)
+	assert.Len(t, found, 3)
+}
+
+func TestFileIndexerKeepsEntriesOfFailedRoots(t *testing.T) {
+	ctx := context.Background()
+	db, err := newFileIndexDB(ctx, filepath.Join(t.TempDir(), "index.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	root := t.TempDir()
+	unmounted := filepath.Join(t.TempDir(), "unmounted")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "kept.txt"), []byte("x"), 0644))
+	require.NoError(t, db.Upsert(ctx, []fileIndexEntry{
+		{Path: filepath.Join(root, "removed.txt"), Name: "removed.txt", IndexedAt: 1},
+		{Path: filepath.Join(unmounted, "backup.zip"), Name: "backup.zip", IndexedAt: 1},
+	}))
+
+	indexer := newFileIndexer(plugintest.NewAPI(), db)
+	indexer.watchers = map[string]*fsnotify.Watcher{}
+	indexer.crawl(ctx, newFileIndexConfig(fileIndexOptions{Roots: []string{root, unmounted}}, time.Now().UnixMilli()))
+	indexer.Stop()
+
+	results, err := searchFileIndex(ctx, db, SearchPattern{Extensions: []string{"txt", "zip"}, MinSize: -1, MaxSize: -1})
+	require.NoError(t, err)
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.Name)
+	}
+	assert.ElementsMatch(t, []string{"kept.txt", "backup.zip"}, names, "stale entries are removed, except those of a root that can't be read")
+}
@@ -0,0 +1,376 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseSearchPattern parses the query language of the file plugin. Supported filters:
+//
+//	ext:pdf,docx        file extensions
+//	in:~/work           only search inside the directory
+//	type:dir/file       only directories or only files
+//	modified:<7d        modified within the last 7 days (m, h, d, w, mo, y), ">7d" means older than 7 days
+//	modified:>2024-01-01 modified after the date, "<" means before, no operator means on that day
+//	size:>10mb          size in b, kb, mb, gb, tb (1024 based)
+//	*.go, report-??     glob on the file name
+//	/^IMG_\d+/          regex on the file name (or regex:pattern)
+//	content:"TODO fix"  search inside text files, requires content search to be enabled
+//
+// Everything else is treated as the file name, use quotes to keep spaces or to search for a literal "ext:".
+func ParseSearchPattern(query string, now time.Time) (SearchPattern, error) {
+	pattern := SearchPattern{MinSize: -1, MaxSize: -1}
+
+	var nameParts []string
+	for _, token := range splitQueryTokens(query) {
+		if token.quoted {
+			nameParts = append(nameParts, token.value)
+			continue
+		}
+
+		key, value, hasKey := strings.Cut(token.value, ":")
+		if hasKey && value != "" {
+			handled, err := pattern.applyFilter(strings.ToLower(key), value, now)
+			if err != nil {
+				return SearchPattern{}, err
+			}
+			if handled {
+				continue
+			}
+		}
+
+		if len(token.value) > 2 && strings.HasPrefix(token.value, "/") && strings.HasSuffix(token.value, "/") {
+			if err := pattern.setRegex(token.value[1 : len(token.value)-1]); err != nil {
+				return SearchPattern{}, err
+			}
+			continue
+		}
+
+		if strings.ContainsAny(token.value, "*?") {
+			if _, err := filepath.Match(token.value, ""); err != nil {
+				return SearchPattern{}, fmt.Errorf("invalid glob %q: %w", token.value, err)
+			}
+			pattern.Glob = token.value
+			continue
+		}
+
+		nameParts = append(nameParts, token.value)
+	}
+
+	pattern.Name = strings.Join(nameParts, " ")
+	return pattern, nil
+}
+
+func (p *SearchPattern) applyFilter(key string, value string, now time.Time) (bool, error) {
+	switch key {
+	case "ext":
+		for _, ext := range strings.Split(value, ",") {
+			ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+			if ext != "" {
+				p.Extensions = append(p.Extensions, ext)
+			}
+		}
+	case "in":
+		p.InDirectory = expandHomeDirectory(value)
+	case "type":
+		switch strings.ToLower(value) {
+		case "dir", "folder", "directory":
+			p.Type = FileTypeDir
+		case "file":
+			p.Type = FileTypeFile
+		default:
+			return false, fmt.Errorf("invalid type %q, expected dir or file", value)
+		}
+	case "modified":
+		return true, p.setModified(value, now)
+	case "size":
+		return true, p.setSize(value)
+	case "regex":
+		return true, p.setRegex(value)
+	case "content":
+		p.Content = value
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (p *SearchPattern) setRegex(expr string) error {
+	regex, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return fmt.Errorf("invalid regex %q: %w", expr, err)
+	}
+	p.Regex = regex
+	return nil
+}
+
+func (p *SearchPattern) setModified(value string, now time.Time) error {
+	op, operand := splitComparison(value)
+
+	if date, err := time.ParseInLocation("2006-01-02", operand, now.Location()); err == nil {
+		switch op {
+		case ">", ">=":
+			p.ModifiedAfter = date
+		case "<", "<=":
+			p.ModifiedBefore = date
+		default:
+			p.ModifiedAfter = date
+			p.ModifiedBefore = date.AddDate(0, 0, 1)
+		}
+		return nil
+	}
+
+	duration, err := parseAge(operand)
+	if err != nil {
+		return fmt.Errorf("invalid modified filter %q: %w", value, err)
+	}
+	// for ages the operator compares the age, so "<7d" means modified after now-7d
+	if op == ">" || op == ">=" {
+		p.ModifiedBefore = now.Add(-duration)
+	} else {
+		p.ModifiedAfter = now.Add(-duration)
+	}
+	return nil
+}
+
+func (p *SearchPattern) setSize(value string) error {
+	op, operand := splitComparison(value)
+	size, err := parseSize(operand)
+	if err != nil {
+		return fmt.Errorf("invalid size filter %q: %w", value, err)
+	}
+
+	switch op {
+	case ">", ">=":
+		p.MinSize = size
+	case "<", "<=":
+		p.MaxSize = size
+	default:
+		p.MinSize = size
+		p.MaxSize = size
+	}
+	return nil
+}
+
+// HasFilters reports whether any filter besides the name is set
+func (p *SearchPattern) HasFilters() bool {
+	return p.Glob != "" || p.Regex != nil || len(p.Extensions) > 0 || p.InDirectory != "" || p.Type != FileTypeAny ||
+		!p.ModifiedAfter.IsZero() || !p.ModifiedBefore.IsZero() || p.MinSize >= 0 || p.MaxSize >= 0
+}
+
+// IsEmpty reports whether the pattern would match everything
+func (p *SearchPattern) IsEmpty() bool {
+	return strings.TrimSpace(p.Name) == "" && strings.TrimSpace(p.Content) == "" && !p.HasFilters()
+}
+
+// MatchName applies the glob and regex filters to a file name
+func (p *SearchPattern) MatchName(name string) bool {
+	if p.Glob != "" {
+		if matched, _ := filepath.Match(strings.ToLower(p.Glob), strings.ToLower(name)); !matched {
+			return false
+		}
+	}
+	return p.Regex == nil || p.Regex.MatchString(name)
+}
+
+// Match applies every filter except Name to the result, stats the file if the backend didn't provide file info
+func (p *SearchPattern) Match(result *SearchResult) bool {
+	name := result.Name
+	if name == "" {
+		name = filepath.Base(result.Path)
+	}
+	if !p.MatchName(name) {
+		return false
+	}
+	if len(p.Extensions) > 0 {
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+		found := false
+		for _, e := range p.Extensions {
+			if e == ext {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if p.InDirectory != "" {
+		rel, err := filepath.Rel(p.InDirectory, result.Path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return false
+		}
+	}
+
+	needStat := p.Type != FileTypeAny || !p.ModifiedAfter.IsZero() || !p.ModifiedBefore.IsZero() || p.MinSize >= 0 || p.MaxSize >= 0
+	if !needStat {
+		return true
+	}
+	if result.ModTime.IsZero() {
+		info, err := os.Stat(result.Path)
+		if err != nil {
+			return false
+		}
+		result.IsDir = info.IsDir()
+		result.Size = info.Size()
+		result.ModTime = info.ModTime()
+	}
+
+	if p.Type == FileTypeDir && !result.IsDir {
+		return false
+	}
+	if p.Type == FileTypeFile && result.IsDir {
+		return false
+	}
+	if !p.ModifiedAfter.IsZero() && result.ModTime.Before(p.ModifiedAfter) {
+		return false
+	}
+	if !p.ModifiedBefore.IsZero() && !result.ModTime.Before(p.ModifiedBefore) {
+		return false
+	}
+	if (p.MinSize >= 0 || p.MaxSize >= 0) && result.IsDir {
+		return false
+	}
+	if p.MinSize >= 0 && result.Size < p.MinSize {
+		return false
+	}
+	if p.MaxSize >= 0 && result.Size > p.MaxSize {
+		return false
+	}
+
+	return true
+}
+
+// filterSearchResults applies the filters the backend couldn't express natively
+func filterSearchResults(pattern SearchPattern, results []SearchResult) []SearchResult {
+	if !pattern.HasFilters() {
+		return results
+	}
+
+	filtered := make([]SearchResult, 0, len(results))
+	for i := range results {
+		if pattern.Match(&results[i]) {
+			filtered = append(filtered, results[i])
+		}
+	}
+	return filtered
+}
+
+type queryToken struct {
+	value  string
+	quoted bool
+}
+
+// splitQueryTokens splits by whitespace, double quotes keep spaces inside a token (also for values like in:"~/my work")
+func splitQueryTokens(query string) []queryToken {
+	var tokens []queryToken
+	var current strings.Builder
+	inQuotes := false
+	quoted := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, queryToken{value: current.String(), quoted: quoted})
+		}
+		current.Reset()
+		quoted = false
+	}
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			if inQuotes {
+				inQuotes = false
+			} else {
+				inQuotes = true
+				// a quote at the start of a token makes the whole token literal
+				if current.Len() == 0 {
+					quoted = true
+				}
+			}
+		case !inQuotes && (r == ' ' || r == '\t'):
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+func splitComparison(value string) (string, string) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, op) {
+			operand := strings.TrimSpace(value[len(op):])
+			if op == "=" {
+				op = ""
+			}
+			return op, operand
+		}
+	}
+	return "", strings.TrimSpace(value)
+}
+
+var agePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(m|min|h|d|w|mo|y)$`)
+
+func parseAge(value string) (time.Duration, error) {
+	matches := agePattern.FindStringSubmatch(strings.ToLower(value))
+	if matches == nil {
+		return 0, fmt.Errorf("expected a date (2006-01-02) or an age like 30m, 12h, 7d, 2w, 6mo, 1y")
+	}
+
+	amount, _ := strconv.ParseFloat(matches[1], 64)
+	unit := map[string]time.Duration{
+		"m":   time.Minute,
+		"min": time.Minute,
+		"h":   time.Hour,
+		"d":   24 * time.Hour,
+		"w":   7 * 24 * time.Hour,
+		"mo":  30 * 24 * time.Hour,
+		"y":   365 * 24 * time.Hour,
+	}[matches[2]]
+	return time.Duration(amount * float64(unit)), nil
+}
+
+var sizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(b|k|kb|m|mb|g|gb|t|tb)?$`)
+
+func parseSize(value string) (int64, error) {
+	matches := sizePattern.FindStringSubmatch(strings.ToLower(value))
+	if matches == nil {
+		return 0, fmt.Errorf("expected a size like 500kb, 10mb or 1gb")
+	}
+
+	amount, _ := strconv.ParseFloat(matches[1], 64)
+	multiplier := map[string]float64{
+		"":   1,
+		"b":  1,
+		"k":  1 << 10,
+		"kb": 1 << 10,
+		"m":  1 << 20,
+		"mb": 1 << 20,
+		"g":  1 << 30,
+		"gb": 1 << 30,
+		"t":  1 << 40,
+		"tb": 1 << 40,
+	}[matches[2]]
+	return int64(amount * multiplier), nil
+}
+
+func expandHomeDirectory(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~\\") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(homeDir, path[1:])
+		}
+	}
+	if absPath, err := filepath.Abs(path); err == nil {
+		return absPath
+	}
+	return path
+}
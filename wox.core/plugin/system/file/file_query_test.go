@@ -0,0 +1,112 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchPattern(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.Local)
+	home, _ := os.UserHomeDir()
+
+	tests := []struct {
+		query  string
+		expect func(t *testing.T, p SearchPattern)
+	}{
+		{"report", func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, "report", p.Name)
+			assert.False(t, p.HasFilters())
+		}},
+		{"annual report ext:PDF,.docx", func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, "annual report", p.Name)
+			assert.Equal(t, []string{"pdf", "docx"}, p.Extensions)
+		}},
+		{"in:~/work type:dir", func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, filepath.Join(home, "work"), p.InDirectory)
+			assert.Equal(t, FileTypeDir, p.Type)
+			assert.Equal(t, "", p.Name)
+		}},
+		{"modified:<7d", func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, now.Add(-7*24*time.Hour), p.ModifiedAfter)
+			assert.True(t, p.ModifiedBefore.IsZero())
+		}},
+		{"modified:>2h", func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, now.Add(-2*time.Hour), p.ModifiedBefore)
+		}},
+		{"modified:2025-01-31", func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local), p.ModifiedAfter)
+			assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.Local), p.ModifiedBefore)
+		}},
+		{"size:>10mb", func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, int64(10*1024*1024), p.MinSize)
+			assert.Equal(t, int64(-1), p.MaxSize)
+		}},
+		{"size:<1.5kb", func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, int64(1536), p.MaxSize)
+		}},
+		{"IMG_*.jpg", func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, "IMG_*.jpg", p.Glob)
+			assert.Equal(t, "", p.Name)
+		}},
+		{`/^img_\d+\.jpe?g$/`, func(t *testing.T, p SearchPattern) {
+			require.NotNil(t, p.Regex)
+			assert.True(t, p.Regex.MatchString("IMG_0042.JPG"))
+		}},
+		{`"ext:literal" notes`, func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, "ext:literal notes", p.Name)
+			assert.Empty(t, p.Extensions)
+		}},
+		{`in:"~/my work" todo`, func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, filepath.Join(home, "my work"), p.InDirectory)
+			assert.Equal(t, "todo", p.Name)
+		}},
+		{"C:\\Users\\me", func(t *testing.T, p SearchPattern) {
+			assert.Equal(t, "C:\\Users\\me", p.Name)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			pattern, err := ParseSearchPattern(tt.query, now)
+			require.NoError(t, err)
+			tt.expect(t, pattern)
+		})
+	}
+}
+
+func TestParseSearchPatternErrors(t *testing.T) {
+	for _, query := range []string{"type:socket", "modified:<7x", "size:>lots", "regex:(unclosed"} {
+		_, err := ParseSearchPattern(query, time.Now())
+		assert.Error(t, err, query)
+	}
+}
+
+func TestSearchPatternMatch(t *testing.T) {
+	now := time.Now()
+	pattern, err := ParseSearchPattern("in:/home/me/work ext:pdf modified:<7d size:>1kb", now)
+	require.NoError(t, err)
+
+	match := func(result SearchResult) bool {
+		return pattern.Match(&result)
+	}
+	recent := now.Add(-time.Hour)
+
+	assert.True(t, match(SearchResult{Name: "a.pdf", Path: "/home/me/work/a.pdf", Size: 4096, ModTime: recent}))
+	assert.False(t, match(SearchResult{Name: "a.pdf", Path: "/home/me/other/a.pdf", Size: 4096, ModTime: recent}))
+	assert.False(t, match(SearchResult{Name: "a.txt", Path: "/home/me/work/a.txt", Size: 4096, ModTime: recent}))
+	assert.False(t, match(SearchResult{Name: "a.pdf", Path: "/home/me/work/a.pdf", Size: 10, ModTime: recent}))
+	assert.False(t, match(SearchResult{Name: "a.pdf", Path: "/home/me/work/a.pdf", Size: 4096, ModTime: now.Add(-30 * 24 * time.Hour)}))
+
+	// results without file info are stat-ed
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fresh.pdf")
+	require.NoError(t, os.WriteFile(path, make([]byte, 2048), 0644))
+	statPattern, err := ParseSearchPattern("ext:pdf size:>1kb type:file", now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(filterSearchResults(statPattern, []SearchResult{{Name: "fresh.pdf", Path: path}, {Name: "missing.pdf", Path: filepath.Join(dir, "missing.pdf")}})))
+}
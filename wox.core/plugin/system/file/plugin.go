@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"os"
+	"strconv"
+	"time"
 	"wox/common"
 	"wox/plugin"
 	"wox/setting/definition"
+	"wox/setting/validator"
+	"wox/util"
 	"wox/util/fileicon"
 	"wox/util/nativecontextmenu"
 	"wox/util/shell"
@@ -15,6 +19,7 @@ import (
 )
 
 var fileIcon = common.PluginFileIcon
+var pluginId = "979d6363-025a-4f51-88d3-0b04e9dc56bf"
 var EverythingNotRunningError = errors.New("Everything is not running")
 
 func init() {
@@ -22,12 +27,14 @@ func init() {
 }
 
 type Plugin struct {
-	api plugin.API
+	api         plugin.API
+	content     contentSearcher
+	contextMenu linuxContextMenu
 }
 
 func (c *Plugin) GetMetadata() plugin.Metadata {
 	return plugin.Metadata{
-		Id:            "979d6363-025a-4f51-88d3-0b04e9dc56bf",
+		Id:            pluginId,
 		Name:          "files",
 		Author:        "Wox Launcher",
 		Website:       "https://github.com/Wox-launcher/Wox",
@@ -45,7 +52,105 @@ func (c *Plugin) GetMetadata() plugin.Metadata {
 			"Macos",
 			"Linux",
 		},
-		SettingDefinitions: definition.PluginSettingDefinitions{},
+		SettingDefinitions: definition.PluginSettingDefinitions{
+			{
+				Type:                definition.PluginSettingDefinitionTypeTable,
+				DisabledInPlatforms: []util.Platform{util.PlatformWindows, util.PlatformMacOS},
+				Value: &definition.PluginSettingValueTable{
+					Key:     indexRootsSettingKey,
+					Title:   "i18n:plugin_file_index_roots",
+					Tooltip: "i18n:plugin_file_index_roots_tooltip",
+					Columns: []definition.PluginSettingValueTableColumn{
+						{
+							Key:   "Path",
+							Label: "i18n:plugin_file_index_root_path",
+							Type:  definition.PluginSettingValueTableColumnTypeDirPath,
+						},
+					},
+				},
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeTextBox,
+				Value: &definition.PluginSettingValueTextBox{
+					Key:          indexExcludesSettingKey,
+					Label:        "i18n:plugin_file_index_excludes",
+					Tooltip:      "i18n:plugin_file_index_excludes_tooltip",
+					DefaultValue: defaultIndexExcludes,
+					MaxLines:     6,
+					Style: definition.PluginSettingValueStyle{
+						Width: 400,
+					},
+				},
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeCheckBox,
+				Value: &definition.PluginSettingValueCheckBox{
+					Key:          indexHiddenFilesSettingKey,
+					Label:        "i18n:plugin_file_index_hidden_files",
+					DefaultValue: "false",
+				},
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeHead,
+				Value: &definition.PluginSettingValueHead{
+					Content: "i18n:plugin_file_content_search",
+				},
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeCheckBox,
+				Value: &definition.PluginSettingValueCheckBox{
+					Key:          contentSearchEnabledSettingKey,
+					Label:        "i18n:plugin_file_content_search_enabled",
+					Tooltip:      "i18n:plugin_file_content_search_enabled_tooltip",
+					DefaultValue: "false",
+				},
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeTable,
+				Value: &definition.PluginSettingValueTable{
+					Key:   contentRootsSettingKey,
+					Title: "i18n:plugin_file_content_roots",
+					Columns: []definition.PluginSettingValueTableColumn{
+						{
+							Key:   "Path",
+							Label: "i18n:plugin_file_index_root_path",
+							Type:  definition.PluginSettingValueTableColumnTypeDirPath,
+						},
+					},
+				},
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeTextBox,
+				Value: &definition.PluginSettingValueTextBox{
+					Key:          contentMaxFileSizeSettingKey,
+					Label:        "i18n:plugin_file_content_max_file_size",
+					Suffix:       "KB",
+					DefaultValue: strconv.Itoa(defaultContentMaxFileSizeKb),
+					Validators: []validator.PluginSettingValidator{
+						{
+							Type: validator.PluginSettingValidatorTypeIsNumber,
+							Value: &validator.PluginSettingValidatorIsNumber{
+								IsInteger: true,
+							},
+						},
+					},
+					Style: definition.PluginSettingValueStyle{
+						Width: 80,
+					},
+				},
+			},
+			{
+				Type: definition.PluginSettingDefinitionTypeTextBox,
+				Value: &definition.PluginSettingValueTextBox{
+					Key:     contentEditorSettingKey,
+					Label:   "i18n:plugin_file_content_editor",
+					Tooltip: "i18n:plugin_file_content_editor_tooltip",
+					Style: definition.PluginSettingValueStyle{
+						Width: 300,
+					},
+				},
+			},
+		},
 		Features: []plugin.MetadataFeature{
 			{
 				Name: plugin.MetadataFeatureDebounce,
@@ -60,10 +165,12 @@ func (c *Plugin) GetMetadata() plugin.Metadata {
 func (c *Plugin) Init(ctx context.Context, initParams plugin.InitParams) {
 	c.api = initParams.API
 
-	initErr := searcher.Init(ctx)
+	initErr := searcher.Init(ctx, c.api)
 	if initErr != nil {
 		c.api.Log(ctx, plugin.LogLevelError, initErr.Error())
 	}
+
+	c.content.Init(ctx, c.api)
 }
 
 func (c *Plugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryResult {
@@ -72,8 +179,25 @@ func (c *Plugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryRe
 		return []plugin.QueryResult{}
 	}
 
+	pattern, parseErr := ParseSearchPattern(query.Search, time.Now())
+	if parseErr != nil {
+		return []plugin.QueryResult{
+			{
+				Title:    "i18n:plugin_file_invalid_query",
+				SubTitle: parseErr.Error(),
+				Icon:     fileIcon,
+			},
+		}
+	}
+	if pattern.IsEmpty() {
+		return []plugin.QueryResult{}
+	}
+	if pattern.Content != "" {
+		return c.content.Query(ctx, pattern)
+	}
+
 	// search for the query
-	results, err := searcher.Search(SearchPattern{Name: query.Search})
+	results, err := searcher.Search(pattern)
 	if err != nil {
 		if err == EverythingNotRunningError {
 			return []plugin.QueryResult{
@@ -98,9 +222,14 @@ func (c *Plugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryRe
 		return []plugin.QueryResult{}
 	}
 
+	// apply the filters the backend can't express natively
+	results = filterSearchResults(pattern, results)
+
 	return lo.Map(results, func(item SearchResult, _ int) plugin.QueryResult {
 		icon := fileIcon
+		isDir := item.IsDir
 		if info, err := os.Stat(item.Path); err == nil {
+			isDir = info.IsDir()
 			if info.IsDir() {
 				icon = common.FolderIcon
 			} else {
@@ -112,41 +241,47 @@ func (c *Plugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryRe
 			}
 		}
 
+		actions := []plugin.QueryResultAction{
+			{
+				Name: "i18n:plugin_file_open",
+				Icon: common.PreviewIcon,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					shell.Open(item.Path)
+				},
+			},
+			{
+				Name: "i18n:plugin_file_open_containing_folder",
+				Icon: common.OpenContainingFolderIcon,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					shell.OpenFileInFolder(item.Path)
+				},
+				Hotkey: "ctrl+enter",
+			},
+		}
+		if util.IsLinux() {
+			actions = append(actions, c.getLinuxContextMenuActions(ctx, item.Path, isDir)...)
+		} else {
+			actions = append(actions, plugin.QueryResultAction{
+				Name: "i18n:plugin_file_show_context_menu",
+				Icon: common.PluginMenusIcon,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					c.api.Log(ctx, plugin.LogLevelInfo, "Showing context menu for: "+item.Path)
+					err := nativecontextmenu.ShowContextMenu(item.Path)
+					if err != nil {
+						c.api.Log(ctx, plugin.LogLevelError, err.Error())
+						c.api.Notify(ctx, err.Error())
+					}
+				},
+				Hotkey:                 "ctrl+m",
+				PreventHideAfterAction: true,
+			})
+		}
+
 		return plugin.QueryResult{
 			Title:    item.Name,
 			SubTitle: item.Path,
 			Icon:     icon,
-			Actions: []plugin.QueryResultAction{
-				{
-					Name: "i18n:plugin_file_open",
-					Icon: common.PreviewIcon,
-					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-						shell.Open(item.Path)
-					},
-				},
-				{
-					Name: "i18n:plugin_file_open_containing_folder",
-					Icon: common.OpenContainingFolderIcon,
-					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-						shell.OpenFileInFolder(item.Path)
-					},
-					Hotkey: "ctrl+enter",
-				},
-				{
-					Name: "i18n:plugin_file_show_context_menu",
-					Icon: common.PluginMenusIcon,
-					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-						c.api.Log(ctx, plugin.LogLevelInfo, "Showing context menu for: "+item.Path)
-						err := nativecontextmenu.ShowContextMenu(item.Path)
-						if err != nil {
-							c.api.Log(ctx, plugin.LogLevelError, err.Error())
-							c.api.Notify(ctx, err.Error())
-						}
-					},
-					Hotkey:                 "ctrl+m",
-					PreventHideAfterAction: true,
-				},
-			},
+			Actions:  actions,
 		}
 	})
 }
@@ -1,17 +1,45 @@
 package file
 
-import "context"
+import (
+	"context"
+	"regexp"
+	"time"
+	"wox/plugin"
+)
 
+type FileType string
+
+const (
+	FileTypeAny  FileType = ""
+	FileTypeFile FileType = "file"
+	FileTypeDir  FileType = "dir"
+)
+
+// SearchPattern is parsed from the user query, see ParseSearchPattern for the syntax.
+// Backends translate as much as they can into their native query, the rest is filtered after the search by Match.
 type SearchPattern struct {
-	Name string // The name of the file or directory.
+	Name           string         // The name of the file or directory.
+	Glob           string         // Glob matched against the file name, e.g. report-*.pdf
+	Regex          *regexp.Regexp // Regex matched against the file name, e.g. /^IMG_\d+\.jpe?g$/
+	Extensions     []string       // Lower-case extensions without leading dot, e.g. ext:pdf,docx
+	InDirectory    string         // Only return results under this absolute directory, e.g. in:~/work
+	Type           FileType       // Only return files or only directories, e.g. type:dir
+	ModifiedAfter  time.Time      // e.g. modified:<7d or modified:>2024-01-01
+	ModifiedBefore time.Time      // e.g. modified:>30d or modified:<2024-01-01
+	MinSize        int64          // In bytes, -1 means no limit. e.g. size:>10mb
+	MaxSize        int64          // In bytes, -1 means no limit. e.g. size:<1kb
+	Content        string         // Text inside the file, searched by the content index instead of the Searcher. e.g. content:"TODO fix"
 }
 
 type SearchResult struct {
-	Name string
-	Path string
+	Name    string
+	Path    string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time // zero if the backend doesn't provide file info, Match will stat the file instead
 }
 
 type Searcher interface {
-	Init(ctx context.Context) error
+	Init(ctx context.Context, api plugin.API) error
 	Search(pattern SearchPattern) ([]SearchResult, error)
 }
@@ -5,39 +5,100 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
+	"time"
+	"wox/plugin"
 	"wox/util/shell"
 )
 
 var searcher Searcher = &MacSearcher{}
 
+const maxSpotlightResults = 100
+
 type MacSearcher struct {
 }
 
-func (m *MacSearcher) Init(ctx context.Context) error {
+func (m *MacSearcher) Init(ctx context.Context, api plugin.API) error {
 	return nil
 }
 
 func (m *MacSearcher) Search(pattern SearchPattern) ([]SearchResult, error) {
-	// if the search pattern is too short, return empty result
-	if len(pattern.Name) <= 3 {
+	// if the search pattern is too short and has no filter, return empty result
+	if len(pattern.Name) <= 3 && !pattern.HasFilters() {
 		return []SearchResult{}, nil
 	}
 
-	// use mdfind to search files
-	cmd := fmt.Sprintf("mdfind \"kMDItemDisplayName=='%s'\" | head -n 20", pattern.Name)
-	output, err := shell.RunOutput("bash", "-c", cmd)
+	args := []string{}
+	if pattern.InDirectory != "" {
+		args = append(args, "-onlyin", pattern.InDirectory)
+	}
+	args = append(args, buildSpotlightQuery(pattern))
+	output, err := shell.RunOutput("mdfind", args...)
 	if err != nil {
 		return nil, err
 	}
 
-	//read output line by line
+	//read output line by line, regex can't be expressed in spotlight queries so it is applied before the result limit,
+	//exact time filters are applied by the plugin after the search
 	var results []SearchResult
 	for _, line := range bytes.Split(output, []byte("\n")) {
 		if len(line) > 0 {
 			path := string(line)
 			fileName := filepath.Base(path)
+			if !pattern.MatchName(fileName) {
+				continue
+			}
 			results = append(results, SearchResult{Name: fileName, Path: path})
+			if len(results) >= maxSpotlightResults {
+				break
+			}
 		}
 	}
 	return results, nil
 }
+
+// buildSpotlightQuery translates the pattern into a Spotlight metadata query
+// See https://developer.apple.com/library/archive/documentation/Carbon/Conceptual/SpotlightQuery/Concepts/QueryFormat.html
+func buildSpotlightQuery(pattern SearchPattern) string {
+	var conditions []string
+	if pattern.Name != "" {
+		conditions = append(conditions, fmt.Sprintf("kMDItemDisplayName == '%s'", escapeSpotlightValue(pattern.Name)))
+	}
+	if pattern.Glob != "" {
+		conditions = append(conditions, fmt.Sprintf("kMDItemFSName == '%s'cd", escapeSpotlightValue(pattern.Glob)))
+	}
+	if len(pattern.Extensions) > 0 {
+		var extConditions []string
+		for _, ext := range pattern.Extensions {
+			extConditions = append(extConditions, fmt.Sprintf("kMDItemFSName == '*.%s'c", escapeSpotlightValue(ext)))
+		}
+		conditions = append(conditions, "("+strings.Join(extConditions, " || ")+")")
+	}
+	switch pattern.Type {
+	case FileTypeDir:
+		conditions = append(conditions, "kMDItemContentType == 'public.folder'")
+	case FileTypeFile:
+		conditions = append(conditions, "kMDItemContentType != 'public.folder'")
+	}
+	if !pattern.ModifiedAfter.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("kMDItemFSContentChangeDate >= $time.iso(%s)", pattern.ModifiedAfter.UTC().Format(time.RFC3339)))
+	}
+	if !pattern.ModifiedBefore.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("kMDItemFSContentChangeDate < $time.iso(%s)", pattern.ModifiedBefore.UTC().Format(time.RFC3339)))
+	}
+	if pattern.MinSize >= 0 {
+		conditions = append(conditions, fmt.Sprintf("kMDItemFSSize >= %d", pattern.MinSize))
+	}
+	if pattern.MaxSize >= 0 {
+		conditions = append(conditions, fmt.Sprintf("kMDItemFSSize <= %d", pattern.MaxSize))
+	}
+	if len(conditions) == 0 {
+		// regex only, let spotlight return every file name and filter afterwards
+		conditions = append(conditions, "kMDItemFSName == '*'")
+	}
+	return strings.Join(conditions, " && ")
+}
+
+func escapeSpotlightValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
@@ -3,11 +3,16 @@ package file
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
+	"wox/plugin"
+	"wox/util"
 )
 
 type LocateOptions struct {
@@ -61,23 +66,112 @@ func LocateWithOptions(query string, opts LocateOptions) ([]string, error) {
 
 var searcher Searcher = &LinuxSearcher{}
 
+// LinuxSearcher searches the built-in file index, and falls back to locate while the index is unavailable
 type LinuxSearcher struct {
+	api     plugin.API
+	db      *fileIndexDB
+	indexer *fileIndexer
 }
 
-func (m *LinuxSearcher) Init(ctx context.Context) error {
+func (m *LinuxSearcher) Init(ctx context.Context, api plugin.API) error {
+	m.api = api
+
+	dbPath := path.Join(util.GetLocation().GetPluginSettingDirectory(), pluginId+"_file_index.db")
+	db, err := newFileIndexDB(ctx, dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize file index, fallback to locate: %w", err)
+	}
+
+	m.db = db
+	m.indexer = newFileIndexer(api, db)
+	m.indexer.Start(ctx, m.getIndexOptions(ctx))
+
+	api.OnSettingChanged(ctx, func(key string, value string) {
+		if key == indexRootsSettingKey || key == indexExcludesSettingKey || key == indexHiddenFilesSettingKey {
+			m.indexer.Start(util.NewTraceContext(), m.getIndexOptions(util.NewTraceContext()))
+		}
+	})
+	api.OnUnload(ctx, func() {
+		m.indexer.Stop()
+		db.Close()
+	})
+
 	return nil
 }
 
+func (m *LinuxSearcher) getIndexOptions(ctx context.Context) fileIndexOptions {
+	options := fileIndexOptions{
+		IncludeHidden: m.api.GetSetting(ctx, indexHiddenFilesSettingKey) == "true",
+	}
+
+	var roots []struct {
+		Path string
+	}
+	rootsJson := m.api.GetSetting(ctx, indexRootsSettingKey)
+	if rootsJson != "" {
+		if unmarshalErr := json.Unmarshal([]byte(rootsJson), &roots); unmarshalErr != nil {
+			m.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to parse index roots: %s", unmarshalErr.Error()))
+		}
+	}
+	for _, root := range roots {
+		if root.Path != "" {
+			options.Roots = append(options.Roots, root.Path)
+		}
+	}
+	if len(options.Roots) == 0 {
+		if homeDir, homeErr := os.UserHomeDir(); homeErr == nil {
+			options.Roots = append(options.Roots, homeDir)
+		}
+	}
+
+	options.Excludes = strings.Split(m.api.GetSetting(ctx, indexExcludesSettingKey), "\n")
+	return options
+}
+
 func (m *LinuxSearcher) Search(pattern SearchPattern) ([]SearchResult, error) {
+	if m.indexer == nil {
+		return m.searchByLocate(pattern)
+	}
+
+	ctx := util.NewTraceContext()
+	results, err := searchFileIndex(ctx, m.db, pattern)
+	if err != nil {
+		m.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to search file index, fallback to locate: %s", err.Error()))
+		return m.searchByLocate(pattern)
+	}
+
+	// the first crawl may take a while, use locate until the index is complete
+	if len(results) == 0 && m.indexer.IsIndexing() {
+		locateResults, locateErr := m.searchByLocate(pattern)
+		if locateErr != nil {
+			m.api.Log(ctx, plugin.LogLevelDebug, fmt.Sprintf("locate fallback failed: %s", locateErr.Error()))
+			return results, nil
+		}
+		return locateResults, nil
+	}
+
+	return results, nil
+}
+
+func (m *LinuxSearcher) searchByLocate(pattern SearchPattern) ([]SearchResult, error) {
+	// locate understands globs natively, the other filters are applied by the plugin after the search
+	query := pattern.Name
+	if query == "" {
+		query = pattern.Glob
+	}
+	if query == "" {
+		return []SearchResult{}, nil
+	}
+
 	options :=
 		LocateOptions{
-			CaseInsensitive: false,
+			CaseInsensitive: true,
 			MaxResults:      256,
-			Timeout:         200000000,
+			Timeout:         500 * time.Millisecond,
 		}
-	results, err := LocateWithOptions(pattern.Name, options)
+	results, err := LocateWithOptions(query, options)
 	if err != nil {
-		return []SearchResult{}, nil
+		return []SearchResult{}, err
 	}
 
 	var searchResults []SearchResult
@@ -2,10 +2,13 @@ package file
 
 import (
 	"context"
+	"fmt"
 	"path"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"unsafe"
+	"wox/plugin"
 	"wox/util"
 )
 
@@ -29,7 +32,7 @@ var searcher Searcher = &WindowsSearcher{}
 type WindowsSearcher struct {
 }
 
-func (m *WindowsSearcher) Init(ctx context.Context) error {
+func (m *WindowsSearcher) Init(ctx context.Context, api plugin.API) error {
 	dllPath := path.Join(util.GetLocation().GetOthersDirectory(), "Everything64.dll")
 	initEverythingDLL(dllPath)
 	return nil
@@ -43,17 +46,60 @@ func (m *WindowsSearcher) Search(pattern SearchPattern) ([]SearchResult, error)
 	}
 
 	var results []SearchResult
-	Walk(pattern.Name, 100, func(path string, info FileInfo, err error) error {
+	Walk(buildEverythingQuery(pattern), 100, func(path string, info FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
 		results = append(results, SearchResult{
-			Name: filepath.Base(path),
-			Path: path,
+			Name:    filepath.Base(path),
+			Path:    path,
+			IsDir:   info.IsDir(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
 		})
 
 		return nil
 	})
 	return results, nil
 }
+
+// buildEverythingQuery translates the pattern into Everything search syntax
+// See https://www.voidtools.com/support/everything/searching/
+func buildEverythingQuery(pattern SearchPattern) string {
+	var parts []string
+	if pattern.InDirectory != "" {
+		parts = append(parts, fmt.Sprintf("\"%s\\\"", strings.TrimRight(pattern.InDirectory, "\\")))
+	}
+	if pattern.Name != "" {
+		parts = append(parts, pattern.Name)
+	}
+	if pattern.Glob != "" {
+		parts = append(parts, pattern.Glob)
+	}
+	if pattern.Regex != nil {
+		parts = append(parts, "regex:"+strings.TrimPrefix(pattern.Regex.String(), "(?i)"))
+	}
+	if len(pattern.Extensions) > 0 {
+		parts = append(parts, "ext:"+strings.Join(pattern.Extensions, ";"))
+	}
+	switch pattern.Type {
+	case FileTypeDir:
+		parts = append(parts, "folder:")
+	case FileTypeFile:
+		parts = append(parts, "file:")
+	}
+	if !pattern.ModifiedAfter.IsZero() {
+		parts = append(parts, "dm:>="+pattern.ModifiedAfter.Format("2006-01-02"))
+	}
+	if !pattern.ModifiedBefore.IsZero() {
+		parts = append(parts, "dm:<="+pattern.ModifiedBefore.Format("2006-01-02"))
+	}
+	if pattern.MinSize >= 0 {
+		parts = append(parts, fmt.Sprintf("size:>=%d", pattern.MinSize))
+	}
+	if pattern.MaxSize >= 0 {
+		parts = append(parts, fmt.Sprintf("size:<=%d", pattern.MaxSize))
+	}
+	return strings.Join(parts, " ")
+}
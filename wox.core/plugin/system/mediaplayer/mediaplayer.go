@@ -3,6 +3,7 @@ package mediaplayer
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 	"wox/common"
 	"wox/plugin"
@@ -24,6 +25,9 @@ type MediaPlayerPlugin struct {
 
 	// Track results that need periodic refresh
 	trackedResults *util.HashMap[string, bool] // resultId -> true
+
+	// Init runs again when a backup is restored, the refresh timer must only start once
+	refreshOnce sync.Once
 }
 
 type mediaContextData struct {
@@ -66,12 +70,14 @@ func (m *MediaPlayerPlugin) Init(ctx context.Context, initParams plugin.InitPara
 	m.trackedResults = util.NewHashMap[string, bool]()
 
 	// Start global refresh timer
-	util.Go(ctx, "refresh media player", func() {
-		ticker := time.NewTicker(time.Second)
-		defer ticker.Stop()
-		for range ticker.C {
-			m.refreshMediaPlayer(util.NewTraceContext())
-		}
+	m.refreshOnce.Do(func() {
+		util.Go(ctx, "refresh media player", func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for range ticker.C {
+				m.refreshMediaPlayer(util.NewTraceContext())
+			}
+		})
 	})
 }
 
@@ -0,0 +1,109 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"wox/common"
+	"wox/i18n"
+	"wox/plugin"
+	"wox/setting"
+)
+
+var profileIcon = common.SettingIcon
+
+func init() {
+	plugin.AllSystemPlugin = append(plugin.AllSystemPlugin, &ProfilePlugin{})
+}
+
+type ProfilePlugin struct {
+	api plugin.API
+}
+
+func (c *ProfilePlugin) GetMetadata() plugin.Metadata {
+	return plugin.Metadata{
+		Id:            "db1691ff-ee21-4d9c-9af9-3c17979b9510",
+		Name:          "Setting profiles",
+		Author:        "Wox Launcher",
+		Website:       "https://github.com/Wox-launcher/Wox",
+		Version:       "1.0.0",
+		MinWoxVersion: "2.0.0",
+		Runtime:       "Go",
+		Description:   "Switch between setting profiles, e.g. work, home or presentation",
+		Icon:          profileIcon.String(),
+		Entry:         "",
+		TriggerKeywords: []string{
+			"profile",
+		},
+		Commands: []plugin.MetadataCommand{},
+		SupportedOS: []string{
+			"Windows",
+			"Macos",
+			"Linux",
+		},
+	}
+}
+
+func (c *ProfilePlugin) Init(ctx context.Context, initParams plugin.InitParams) {
+	c.api = initParams.API
+}
+
+func (c *ProfilePlugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	settingManager := setting.GetSettingManager()
+	activeProfile := settingManager.GetActiveProfile(ctx)
+	activeText := i18n.GetI18nManager().TranslateWox(ctx, "plugin_profile_active")
+	subTitleFormat := i18n.GetI18nManager().TranslateWox(ctx, "plugin_profile_subtitle")
+
+	// the default profile is the settings without any profile
+	profiles := append([]setting.SettingProfile{{}}, settingManager.GetProfiles(ctx)...)
+
+	var results []plugin.QueryResult
+	for _, profile := range profiles {
+		title := profile.Name
+		subTitle := fmt.Sprintf(subTitleFormat, len(profile.Settings)+countPluginOverrides(profile), len(profile.Rules))
+		if profile.Name == "" {
+			title = i18n.GetI18nManager().TranslateWox(ctx, "plugin_profile_default")
+			subTitle = i18n.GetI18nManager().TranslateWox(ctx, "plugin_profile_default_subtitle")
+		}
+
+		match, score := IsStringMatchScore(ctx, title, query.Search)
+		if query.Search != "" && !match {
+			continue
+		}
+
+		result := plugin.QueryResult{
+			Title:    title,
+			SubTitle: subTitle,
+			Icon:     profileIcon,
+			Score:    score,
+			Actions: []plugin.QueryResultAction{
+				{
+					Name: "i18n:plugin_profile_switch",
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						if err := settingManager.SwitchProfile(ctx, profile.Name); err != nil {
+							c.api.Notify(ctx, err.Error())
+							return
+						}
+						c.api.Notify(ctx, fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_profile_switched"), title))
+					},
+				},
+			},
+		}
+		if profile.Name == activeProfile {
+			result.Tails = append(result.Tails, plugin.QueryResultTail{
+				Type: plugin.QueryResultTailTypeText,
+				Text: activeText,
+			})
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func countPluginOverrides(profile setting.SettingProfile) int {
+	count := 0
+	for _, settings := range profile.PluginSettings {
+		count += len(settings)
+	}
+	return count
+}
@@ -0,0 +1,140 @@
+package snippet
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// raycastPlaceholderRegex matches the placeholders of Raycast, e.g. {argument name="Name"} or {date format="yyyy-MM-dd"}
+var raycastPlaceholderRegex = regexp.MustCompile(`\{(argument|date|time|datetime|snippet)((?:\s+\w+="[^"]*")*)\s*\}`)
+var raycastAttributeRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// readSnippetExport reads the snippets exported by Alfred (.alfredsnippets) or Raycast (.json)
+func readSnippetExport(path string) ([]Snippet, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".alfredsnippets", ".zip":
+		return readAlfredSnippets(path)
+	case ".json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parseRaycastSnippets(data)
+	}
+	return nil, fmt.Errorf("unsupported snippet export %s, expected an Alfred .alfredsnippets or a Raycast .json file", filepath.Base(path))
+}
+
+// readAlfredSnippets reads an Alfred snippet collection, a zip with a json file per snippet.
+// The placeholders of Alfred, e.g. {clipboard}, {cursor} and {date:yyyy-MM-dd}, are the same as ours.
+func readAlfredSnippets(path string) ([]Snippet, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Alfred snippets: %w", err)
+	}
+	defer reader.Close()
+
+	var snippets []Snippet
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || strings.ToLower(filepath.Ext(file.Name)) != ".json" {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+
+		snippet, err := parseAlfredSnippet(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file.Name, err)
+		}
+		snippets = append(snippets, snippet)
+	}
+	return snippets, nil
+}
+
+func parseAlfredSnippet(data []byte) (Snippet, error) {
+	var alfred struct {
+		AlfredSnippet struct {
+			Name    string `json:"name"`
+			Keyword string `json:"keyword"`
+			Snippet string `json:"snippet"`
+		} `json:"alfredsnippet"`
+	}
+	if err := json.Unmarshal(data, &alfred); err != nil {
+		return Snippet{}, err
+	}
+
+	snippet := Snippet{
+		Name:    alfred.AlfredSnippet.Name,
+		Keyword: alfred.AlfredSnippet.Keyword,
+		Text:    alfred.AlfredSnippet.Snippet,
+	}
+	if strings.TrimSpace(snippet.Name) == "" {
+		snippet.Name = snippet.Keyword
+	}
+	return snippet, nil
+}
+
+// parseRaycastSnippets parses the json array exported by Raycast and converts its placeholders to ours
+func parseRaycastSnippets(data []byte) ([]Snippet, error) {
+	var raycast []struct {
+		Name    string `json:"name"`
+		Keyword string `json:"keyword"`
+		Text    string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &raycast); err != nil {
+		return nil, fmt.Errorf("failed to parse Raycast snippets: %w", err)
+	}
+
+	var snippets []Snippet
+	for _, item := range raycast {
+		snippet := Snippet{
+			Name:    item.Name,
+			Keyword: item.Keyword,
+			Text:    convertRaycastPlaceholders(item.Text),
+		}
+		if strings.TrimSpace(snippet.Name) == "" {
+			snippet.Name = snippet.Keyword
+		}
+		snippets = append(snippets, snippet)
+	}
+	return snippets, nil
+}
+
+func convertRaycastPlaceholders(text string) string {
+	return raycastPlaceholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+		match := raycastPlaceholderRegex.FindStringSubmatch(placeholder)
+		attributes := map[string]string{}
+		for _, attribute := range raycastAttributeRegex.FindAllStringSubmatch(match[2], -1) {
+			attributes[attribute[1]] = attribute[2]
+		}
+
+		switch match[1] {
+		case "argument":
+			name := attributes["name"]
+			if name == "" {
+				name = "Argument"
+			}
+			return fmt.Sprintf("{input:%s}", name)
+		case "snippet":
+			return fmt.Sprintf("{snippet:%s}", attributes["name"])
+		default:
+			if format := attributes["format"]; format != "" {
+				return fmt.Sprintf("{%s:%s}", match[1], format)
+			}
+			return fmt.Sprintf("{%s}", match[1])
+		}
+	})
+}
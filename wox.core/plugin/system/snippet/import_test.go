@@ -0,0 +1,96 @@
+package snippet
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	m := &Manager{db: db}
+	require.NoError(t, m.Init(context.Background()))
+	return m
+}
+
+func TestReadAlfredSnippets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Work.alfredsnippets")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	writer := zip.NewWriter(file)
+	files := map[string]string{
+		"info.plist":          `<plist version="1.0"><dict></dict></plist>`,
+		"Address [1A2B].json": `{"alfredsnippet":{"snippet":"1 Main Street","uid":"1A2B","name":"Address","keyword":"addr"}}`,
+		"Date [3C4D].json":    `{"alfredsnippet":{"snippet":"{date:yyyy-MM-dd}{cursor}","uid":"3C4D","name":"","keyword":"today"}}`,
+	}
+	for name, content := range files {
+		w, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, file.Close())
+
+	snippets, err := readSnippetExport(path)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []Snippet{
+		{Name: "Address", Keyword: "addr", Text: "1 Main Street"},
+		{Name: "today", Keyword: "today", Text: "{date:yyyy-MM-dd}{cursor}"},
+	}, snippets)
+}
+
+func TestParseRaycastSnippets(t *testing.T) {
+	snippets, err := parseRaycastSnippets([]byte(`[
+		{"name": "Meeting", "text": "Hi {argument name=\"Name\" default=\"there\"}, see you {date format=\"EEEE\"} {time}. {snippet name=\"Signature\"}", "keyword": "meet"},
+		{"name": "Clipboard", "text": "> {clipboard}{cursor}"}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, []Snippet{
+		{Name: "Meeting", Keyword: "meet", Text: "Hi {input:Name}, see you {date:EEEE} {time}. {snippet:Signature}"},
+		{Name: "Clipboard", Text: "> {clipboard}{cursor}"},
+	}, snippets)
+
+	_, err = readSnippetExport("snippets.txt")
+	assert.ErrorContains(t, err, "unsupported snippet export")
+}
+
+func TestManagerImport(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+
+	existing := &Snippet{Name: "Address", Keyword: "addr", Text: "old street"}
+	require.NoError(t, m.Save(ctx, existing))
+	require.NoError(t, m.MarkUsed(ctx, existing.ID))
+
+	require.NoError(t, m.Import(ctx, []Snippet{
+		{Name: " Address ", Keyword: "address", Text: "1 Main Street"},
+		{Name: "Email", Keyword: "em", Text: "ada@example.com"},
+		{Name: "", Text: "skipped"},
+	}))
+
+	snippets, err := m.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, snippets, 2)
+	// the imported snippet keeps its usage, so it stays on top
+	assert.Equal(t, existing.ID, snippets[0].ID)
+	assert.Equal(t, "address", snippets[0].Keyword)
+	assert.Equal(t, "1 Main Street", snippets[0].Text)
+	assert.Equal(t, 1, snippets[0].UseCount)
+	assert.Equal(t, "Email", snippets[1].Name)
+
+	assert.Error(t, m.Save(ctx, &Snippet{Name: "Email", Text: "duplicate"}))
+	require.NoError(t, m.Delete(ctx, existing.ID))
+	snippets, err = m.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, snippets, 1)
+}
@@ -0,0 +1,401 @@
+package snippet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"wox/common"
+	"wox/plugin"
+	"wox/plugin/system"
+	"wox/setting/definition"
+	"wox/setting/validator"
+	"wox/util"
+	"wox/util/clipboard"
+	"wox/util/keyboard"
+)
+
+var snippetIcon = common.TextIcon
+
+const importCommand = "import"
+
+// cursorMoveDelay waits until the snippet is pasted before the cursor is moved to {cursor}, the paste itself is delayed by 150ms
+const cursorMoveDelay = 400 * time.Millisecond
+
+func init() {
+	plugin.AllSystemPlugin = append(plugin.AllSystemPlugin, &SnippetPlugin{})
+}
+
+type SnippetPlugin struct {
+	api     plugin.API
+	manager *Manager
+}
+
+func (c *SnippetPlugin) GetMetadata() plugin.Metadata {
+	return plugin.Metadata{
+		Id:            "4c0f3ab6-5a1e-4f0b-9d57-2c8e61b7d3f9",
+		Name:          "Snippets",
+		Author:        "Wox Launcher",
+		Website:       "https://github.com/Wox-launcher/Wox",
+		Version:       "1.0.0",
+		MinWoxVersion: "2.0.0",
+		Runtime:       "Go",
+		Description:   "Save text snippets with placeholders and paste them into the active app",
+		Icon:          snippetIcon.String(),
+		Entry:         "",
+		TriggerKeywords: []string{
+			"*",
+			"snippet",
+		},
+		Commands: []plugin.MetadataCommand{
+			{
+				Command:     importCommand,
+				Description: "i18n:plugin_snippet_command_import",
+			},
+		},
+		SupportedOS: []string{
+			"Windows",
+			"Macos",
+			"Linux",
+		},
+	}
+}
+
+func (c *SnippetPlugin) Init(ctx context.Context, initParams plugin.InitParams) {
+	c.api = initParams.API
+	c.manager = GetManager()
+	if err := c.manager.Init(ctx); err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, err.Error())
+	}
+}
+
+func (c *SnippetPlugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	if query.Command == importCommand {
+		return c.getImportResults(ctx, query.Search)
+	}
+
+	// without the trigger keyword only a snippet of which the keyword is typed is shown
+	isGlobal := query.TriggerKeyword == ""
+	if isGlobal && strings.TrimSpace(query.Search) == "" {
+		return []plugin.QueryResult{}
+	}
+
+	snippets, err := c.manager.List(ctx)
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to list snippets: %s", err.Error()))
+		return []plugin.QueryResult{}
+	}
+
+	lookup := newSnippetLookup(snippets)
+	var results []plugin.QueryResult
+	for _, snippet := range snippets {
+		matched, score := c.matchSnippet(ctx, snippet, query.Search, isGlobal)
+		if !matched {
+			continue
+		}
+		results = append(results, c.getSnippetResult(ctx, snippet, lookup, score))
+	}
+
+	if !isGlobal {
+		results = append(results, c.getAddResult(ctx, query.Search))
+	}
+	return results
+}
+
+// matchSnippet matches the keyword exactly, and the name or the text when the trigger keyword is typed
+func (c *SnippetPlugin) matchSnippet(ctx context.Context, snippet Snippet, search string, isGlobal bool) (bool, int64) {
+	search = strings.TrimSpace(search)
+	if snippet.Keyword != "" && strings.EqualFold(snippet.Keyword, search) {
+		return true, 1000
+	}
+	if isGlobal {
+		return false, 0
+	}
+	if search == "" {
+		return true, 0
+	}
+
+	if match, score := system.IsStringMatchScore(ctx, snippet.Name, search); match {
+		return true, score
+	}
+	if snippet.Keyword != "" {
+		if match, score := system.IsStringMatchScore(ctx, snippet.Keyword, search); match {
+			return true, score
+		}
+	}
+	return strings.Contains(strings.ToLower(snippet.Text), strings.ToLower(search)), 0
+}
+
+func (c *SnippetPlugin) getSnippetResult(ctx context.Context, snippet Snippet, lookup func(ref string) (string, bool), score int64) plugin.QueryResult {
+	subTitle, _, _ := strings.Cut(strings.TrimSpace(snippet.Text), "\n")
+	result := plugin.QueryResult{
+		Title:    snippet.Name,
+		SubTitle: subTitle,
+		Icon:     snippetIcon,
+		Score:    score,
+		Preview: plugin.WoxPreview{
+			PreviewType: plugin.WoxPreviewTypeText,
+			PreviewData: snippet.Text,
+		},
+	}
+	if snippet.Keyword != "" {
+		result.Tails = plugin.NewQueryResultTailTexts(snippet.Keyword)
+	}
+
+	// a snippet that can't be expanded, e.g. nested in itself, can only be edited or deleted
+	if _, _, err := expandSnippet(snippet.Text, expandEnv{Now: time.Now(), Lookup: lookup}); err != nil {
+		result.SubTitle = err.Error()
+		result.Actions = c.getManageActions(ctx, snippet)
+		return result
+	}
+
+	inputs := getSnippetInputs(snippet.Text, lookup)
+	var inputValues map[string]string
+	pasteAction, pasteErr := system.GetPasteToActiveWindowAction(ctx, c.api, func() {
+		c.writeSnippet(ctx, snippet, inputValues, lookup, true)
+	})
+	if pasteErr == nil {
+		result.Actions = append(result.Actions, c.withInputs(pasteAction, inputs, &inputValues))
+	}
+	result.Actions = append(result.Actions, c.withInputs(plugin.QueryResultAction{
+		Name: "i18n:plugin_snippet_copy",
+		Icon: common.CopyIcon,
+		Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+			c.writeSnippet(ctx, snippet, inputValues, lookup, false)
+		},
+	}, inputs, &inputValues))
+	result.Actions = append(result.Actions, c.getManageActions(ctx, snippet)...)
+	return result
+}
+
+// withInputs turns the action into a form that asks for the {input:Name} placeholders first, the values are stored in inputValues before the action runs
+func (c *SnippetPlugin) withInputs(action plugin.QueryResultAction, inputs []string, inputValues *map[string]string) plugin.QueryResultAction {
+	if len(inputs) == 0 {
+		return action
+	}
+
+	var form definition.PluginSettingDefinitions
+	for _, input := range inputs {
+		form = append(form, definition.PluginSettingDefinitionItem{
+			Type: definition.PluginSettingDefinitionTypeTextBox,
+			Value: &definition.PluginSettingValueTextBox{
+				Key:   input,
+				Label: input,
+			},
+		})
+	}
+
+	run := action.Action
+	action.Type = plugin.QueryResultActionTypeForm
+	action.Form = form
+	action.Action = nil
+	action.OnSubmit = func(ctx context.Context, actionContext plugin.FormActionContext) {
+		*inputValues = actionContext.Values
+		run(ctx, actionContext.ActionContext)
+	}
+	return action
+}
+
+// writeSnippet expands the snippet to the clipboard, moveCursor moves the cursor to {cursor} once the snippet is pasted
+func (c *SnippetPlugin) writeSnippet(ctx context.Context, snippet Snippet, inputValues map[string]string, lookup func(ref string) (string, bool), moveCursor bool) {
+	text, cursorOffset, err := expandSnippet(snippet.Text, expandEnv{
+		Now:       time.Now(),
+		Clipboard: readClipboardText,
+		Inputs:    inputValues,
+		Lookup:    lookup,
+	})
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to expand snippet %s: %s", snippet.Name, err.Error()))
+		c.api.Notify(ctx, err.Error())
+		return
+	}
+
+	if err := clipboard.WriteText(text); err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to write snippet to clipboard: %s", err.Error()))
+		return
+	}
+	if err := c.manager.MarkUsed(ctx, snippet.ID); err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to update snippet usage: %s", err.Error()))
+	}
+
+	if moveCursor && cursorOffset > 0 {
+		util.Go(ctx, "snippet move cursor", func() {
+			time.Sleep(cursorMoveDelay)
+			if err := keyboard.SimulateLeftArrow(cursorOffset); err != nil {
+				c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to move cursor: %s", err.Error()))
+			}
+		})
+	}
+}
+
+func (c *SnippetPlugin) getManageActions(ctx context.Context, snippet Snippet) []plugin.QueryResultAction {
+	return []plugin.QueryResultAction{
+		{
+			Name:                   "i18n:plugin_snippet_edit",
+			Icon:                   common.TextIcon,
+			Type:                   plugin.QueryResultActionTypeForm,
+			PreventHideAfterAction: true,
+			Form:                   getSnippetForm(snippet),
+			OnSubmit: func(ctx context.Context, actionContext plugin.FormActionContext) {
+				c.saveSnippet(ctx, snippet.ID, actionContext.Values)
+			},
+		},
+		{
+			Name:                   "i18n:plugin_snippet_delete",
+			Icon:                   common.TrashIcon,
+			PreventHideAfterAction: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				if err := c.manager.Delete(ctx, snippet.ID); err != nil {
+					c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to delete snippet: %s", err.Error()))
+				}
+				c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: true})
+			},
+		},
+	}
+}
+
+func (c *SnippetPlugin) getAddResult(ctx context.Context, search string) plugin.QueryResult {
+	return plugin.QueryResult{
+		Title:    "i18n:plugin_snippet_add",
+		SubTitle: "i18n:plugin_snippet_add_subtitle",
+		Icon:     snippetIcon,
+		Actions: []plugin.QueryResultAction{
+			{
+				Name:                   "i18n:plugin_snippet_add",
+				Type:                   plugin.QueryResultActionTypeForm,
+				PreventHideAfterAction: true,
+				Form:                   getSnippetForm(Snippet{Name: strings.TrimSpace(search)}),
+				OnSubmit: func(ctx context.Context, actionContext plugin.FormActionContext) {
+					c.saveSnippet(ctx, 0, actionContext.Values)
+				},
+			},
+		},
+	}
+}
+
+func getSnippetForm(snippet Snippet) definition.PluginSettingDefinitions {
+	notEmpty := []validator.PluginSettingValidator{
+		{
+			Type:  validator.PluginSettingValidatorTypeNotEmpty,
+			Value: &validator.PluginSettingValidatorNotEmpty{},
+		},
+	}
+	return definition.PluginSettingDefinitions{
+		{
+			Type: definition.PluginSettingDefinitionTypeTextBox,
+			Value: &definition.PluginSettingValueTextBox{
+				Key:          "name",
+				Label:        "i18n:plugin_snippet_name",
+				DefaultValue: snippet.Name,
+				Validators:   notEmpty,
+			},
+		},
+		{
+			Type: definition.PluginSettingDefinitionTypeTextBox,
+			Value: &definition.PluginSettingValueTextBox{
+				Key:          "keyword",
+				Label:        "i18n:plugin_snippet_keyword",
+				DefaultValue: snippet.Keyword,
+				Tooltip:      "i18n:plugin_snippet_keyword_tooltip",
+			},
+		},
+		{
+			Type: definition.PluginSettingDefinitionTypeTextBox,
+			Value: &definition.PluginSettingValueTextBox{
+				Key:          "text",
+				Label:        "i18n:plugin_snippet_text",
+				DefaultValue: snippet.Text,
+				Tooltip:      "i18n:plugin_snippet_text_tooltip",
+				MaxLines:     8,
+				Validators:   notEmpty,
+			},
+		},
+	}
+}
+
+func (c *SnippetPlugin) saveSnippet(ctx context.Context, id uint, values map[string]string) {
+	snippet := &Snippet{
+		ID:      id,
+		Name:    values["name"],
+		Keyword: values["keyword"],
+		Text:    values["text"],
+	}
+	if err := c.manager.Save(ctx, snippet); err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to save snippet: %s", err.Error()))
+		c.api.Notify(ctx, err.Error())
+		return
+	}
+	c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: true})
+}
+
+func (c *SnippetPlugin) getImportResults(ctx context.Context, search string) []plugin.QueryResult {
+	path := strings.Trim(strings.TrimSpace(search), `"'`)
+	if path == "" {
+		return []plugin.QueryResult{
+			{
+				Title: "i18n:plugin_snippet_import_hint",
+				Icon:  snippetIcon,
+			},
+		}
+	}
+	if strings.HasPrefix(path, "~") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(homeDir, path[1:])
+		}
+	}
+
+	return []plugin.QueryResult{
+		{
+			Title:    fmt.Sprintf(c.api.GetTranslation(ctx, "plugin_snippet_import"), filepath.Base(path)),
+			SubTitle: path,
+			Icon:     snippetIcon,
+			Actions: []plugin.QueryResultAction{
+				{
+					Name: "i18n:plugin_snippet_import_action",
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						snippets, err := readSnippetExport(path)
+						if err == nil {
+							err = c.manager.Import(ctx, snippets)
+						}
+						if err != nil {
+							c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to import snippets: %s", err.Error()))
+							c.api.Notify(ctx, err.Error())
+							return
+						}
+						c.api.Notify(ctx, fmt.Sprintf(c.api.GetTranslation(ctx, "plugin_snippet_imported"), len(snippets)))
+					},
+				},
+			},
+		},
+	}
+}
+
+// newSnippetLookup finds the text of a nested snippet by keyword first and then by name
+func newSnippetLookup(snippets []Snippet) func(ref string) (string, bool) {
+	return func(ref string) (string, bool) {
+		for _, snippet := range snippets {
+			if snippet.Keyword != "" && snippet.Keyword == ref {
+				return snippet.Text, true
+			}
+		}
+		for _, snippet := range snippets {
+			if snippet.Name == ref {
+				return snippet.Text, true
+			}
+		}
+		return "", false
+	}
+}
+
+func readClipboardText() string {
+	data, err := clipboard.Read()
+	if err != nil || data == nil {
+		return ""
+	}
+	if data.GetType() == clipboard.ClipboardTypeText || data.GetType() == clipboard.ClipboardTypeRichText {
+		return data.String()
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package snippet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"wox/database"
+	"wox/util"
+
+	"gorm.io/gorm"
+)
+
+// Snippet is a named text with placeholders, see expandSnippet
+type Snippet struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	Name      string `gorm:"not null;uniqueIndex"`
+	Keyword   string `gorm:"index"` // optional abbreviation the snippet is searched by, e.g. "addr"
+	Text      string `gorm:"type:text;not null"`
+	UseCount  int    `gorm:"default:0"`
+	LastUsed  int64  `gorm:"index"` // in milliseconds
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Snippet) TableName() string {
+	return "snippets"
+}
+
+// Manager manages the snippets in database
+type Manager struct {
+	db *gorm.DB // nil uses the wox database, which is reopened when a backup is restored
+}
+
+var managerInstance *Manager
+var managerOnce sync.Once
+
+func GetManager() *Manager {
+	managerOnce.Do(func() {
+		managerInstance = &Manager{}
+	})
+	return managerInstance
+}
+
+func (m *Manager) getDB() *gorm.DB {
+	if m.db != nil {
+		return m.db
+	}
+	return database.GetDB()
+}
+
+// Init initializes the snippet table
+func (m *Manager) Init(ctx context.Context) error {
+	err := m.getDB().AutoMigrate(&Snippet{})
+	if err != nil {
+		return fmt.Errorf("failed to migrate snippet table: %w", err)
+	}
+	return nil
+}
+
+// List returns all snippets, most used first
+func (m *Manager) List(ctx context.Context) ([]Snippet, error) {
+	var snippets []Snippet
+	err := m.getDB().WithContext(ctx).
+		Order("use_count DESC").
+		Order("last_used DESC").
+		Order("name").
+		Find(&snippets).Error
+	return snippets, err
+}
+
+// Get retrieves a snippet by id
+func (m *Manager) Get(ctx context.Context, id uint) (*Snippet, error) {
+	var snippet Snippet
+	err := m.getDB().WithContext(ctx).Where("id = ?", id).First(&snippet).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snippet, nil
+}
+
+// Save creates a snippet, or updates it when it has an id
+func (m *Manager) Save(ctx context.Context, snippet *Snippet) error {
+	snippet.Name = strings.TrimSpace(snippet.Name)
+	snippet.Keyword = strings.TrimSpace(snippet.Keyword)
+	if snippet.Name == "" {
+		return errors.New("snippet name is empty")
+	}
+
+	if snippet.ID == 0 {
+		return m.getDB().WithContext(ctx).Create(snippet).Error
+	}
+	return m.getDB().WithContext(ctx).Model(&Snippet{}).
+		Where("id = ?", snippet.ID).
+		Updates(map[string]interface{}{
+			"name":    snippet.Name,
+			"keyword": snippet.Keyword,
+			"text":    snippet.Text,
+		}).Error
+}
+
+// Import saves the snippets in one transaction, a snippet with the name of an existing snippet replaces its keyword and text
+func (m *Manager) Import(ctx context.Context, snippets []Snippet) error {
+	return m.getDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, snippet := range snippets {
+			snippet.Name = strings.TrimSpace(snippet.Name)
+			snippet.Keyword = strings.TrimSpace(snippet.Keyword)
+			if snippet.Name == "" {
+				continue
+			}
+
+			var existing Snippet
+			err := tx.Where("name = ?", snippet.Name).First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Create(&Snippet{Name: snippet.Name, Keyword: snippet.Keyword, Text: snippet.Text}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&Snippet{}).Where("id = ?", existing.ID).Updates(map[string]interface{}{
+				"keyword": snippet.Keyword,
+				"text":    snippet.Text,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarkUsed moves a snippet up in the list
+func (m *Manager) MarkUsed(ctx context.Context, id uint) error {
+	return m.getDB().WithContext(ctx).Model(&Snippet{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"use_count": gorm.Expr("use_count + 1"),
+			"last_used": util.GetSystemTimestamp(),
+		}).Error
+}
+
+// Delete deletes a snippet by id
+func (m *Manager) Delete(ctx context.Context, id uint) error {
+	return m.getDB().WithContext(ctx).Delete(&Snippet{}, "id = ?", id).Error
+}
@@ -0,0 +1,243 @@
+package snippet
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxNestingDepth limits how deep snippets can be nested in each other
+const maxNestingDepth = 8
+
+// cursorMarker marks the {cursor} placeholder while the snippet is expanded, it is removed from the expanded text
+const cursorMarker = "\x00"
+
+// placeholderRegex matches {date}, {date:YYYY-MM-DD}, {time}, {datetime}, {clipboard}, {cursor}, {input:Name} and {snippet:Name}.
+// Braces that are not a placeholder are left as they are, so a snippet can contain code.
+var placeholderRegex = regexp.MustCompile(`\{(date|time|datetime|clipboard|cursor|input|snippet)(?::([^{}]*))?\}`)
+
+var defaultDateFormats = map[string]string{
+	"date":     "YYYY-MM-DD",
+	"time":     "HH:mm",
+	"datetime": "YYYY-MM-DD HH:mm",
+}
+
+// namedDateFormats are the date styles of Alfred, e.g. {date:long}
+var namedDateFormats = map[string]string{
+	"short":  "YYYY-MM-DD",
+	"medium": "MMM D, YYYY",
+	"long":   "MMMM D, YYYY",
+	"full":   "dddd, MMMM D, YYYY",
+}
+
+// dateTokens maps the date format tokens to go layouts. Both moment (YYYY-MM-DD) and ICU (yyyy-MM-dd) tokens are supported.
+var dateTokens = map[string]string{
+	"YYYY": "2006", "yyyy": "2006", "YY": "06", "yy": "06",
+	"MMMM": "January", "MMM": "Jan", "MM": "01", "M": "1",
+	"dddd": "Monday", "EEEE": "Monday", "ddd": "Mon", "EEE": "Mon",
+	"DD": "02", "dd": "02", "D": "2", "d": "2",
+	"HH": "15", "hh": "03", "h": "3",
+	"mm": "04", "m": "4", "ss": "05", "s": "5",
+	"A": "PM", "a": "PM",
+}
+
+// expandEnv is what the placeholders are expanded with
+type expandEnv struct {
+	Now       time.Time
+	Clipboard func() string                   // read only when the snippet uses {clipboard}
+	Inputs    map[string]string               // values of the {input:Name} placeholders
+	Lookup    func(ref string) (string, bool) // text of a nested snippet by keyword or name
+}
+
+// expandSnippet replaces the placeholders of a snippet. It returns the text and the number of characters between {cursor} and the end, or -1 without {cursor}.
+func expandSnippet(text string, env expandEnv) (string, int, error) {
+	expanded, err := expandText(text, env, nil)
+	if err != nil {
+		return "", -1, err
+	}
+
+	cursorOffset := -1
+	if index := strings.Index(expanded, cursorMarker); index >= 0 {
+		expanded = expanded[:index] + strings.ReplaceAll(expanded[index:], cursorMarker, "")
+		cursorOffset = utf8.RuneCountInString(expanded[index:])
+	}
+	return expanded, cursorOffset, nil
+}
+
+func expandText(text string, env expandEnv, nested []string) (string, error) {
+	var expandErr error
+	expanded := placeholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if expandErr != nil {
+			return ""
+		}
+
+		match := placeholderRegex.FindStringSubmatch(placeholder)
+		name, arg := match[1], strings.TrimSpace(match[2])
+		switch name {
+		case "date", "time", "datetime":
+			if arg == "" {
+				arg = defaultDateFormats[name]
+			}
+			return formatDate(env.Now, arg)
+		case "clipboard":
+			if env.Clipboard == nil {
+				return ""
+			}
+			return env.Clipboard()
+		case "cursor":
+			return cursorMarker
+		case "input":
+			return env.Inputs[arg]
+		case "snippet":
+			nestedText, err := lookupNested(arg, env, nested)
+			if err != nil {
+				expandErr = err
+				return ""
+			}
+			nestedExpanded, err := expandText(nestedText, env, append(nested, arg))
+			if err != nil {
+				expandErr = err
+				return ""
+			}
+			return nestedExpanded
+		}
+		return placeholder
+	})
+	return expanded, expandErr
+}
+
+func lookupNested(ref string, env expandEnv, nested []string) (string, error) {
+	for _, name := range nested {
+		if name == ref {
+			return "", fmt.Errorf("snippet %s is nested in itself", ref)
+		}
+	}
+	if len(nested) >= maxNestingDepth {
+		return "", fmt.Errorf("snippets are nested more than %d levels deep", maxNestingDepth)
+	}
+	if env.Lookup == nil {
+		return "", fmt.Errorf("snippet %s not found", ref)
+	}
+	text, found := env.Lookup(ref)
+	if !found {
+		return "", fmt.Errorf("snippet %s not found", ref)
+	}
+	return text, nil
+}
+
+// getSnippetInputs returns the names of the {input:Name} placeholders of a snippet and its nested snippets, in order of appearance
+func getSnippetInputs(text string, lookup func(ref string) (string, bool)) []string {
+	var inputs []string
+	var collect func(text string, nested []string)
+	collect = func(text string, nested []string) {
+		for _, match := range placeholderRegex.FindAllStringSubmatch(text, -1) {
+			name, arg := match[1], strings.TrimSpace(match[2])
+			switch name {
+			case "input":
+				if arg != "" && !slices.Contains(inputs, arg) {
+					inputs = append(inputs, arg)
+				}
+			case "snippet":
+				// invalid nesting is reported when the snippet is expanded
+				if _, err := lookupNested(arg, expandEnv{Lookup: lookup}, nested); err == nil {
+					nestedText, _ := lookup(arg)
+					collect(nestedText, append(nested, arg))
+				}
+			}
+		}
+	}
+	collect(text, nil)
+	return inputs
+}
+
+// formatDate formats a date with moment or ICU tokens, e.g. "YYYY/MM/DD" gives "2025/01/31".
+// Text can be quoted as 'at' (ICU) or [at] (moment), a word that is not made of tokens only is kept as it is.
+func formatDate(t time.Time, format string) string {
+	if named, ok := namedDateFormats[format]; ok {
+		format = named
+	}
+
+	var sb strings.Builder
+	for len(format) > 0 {
+		switch {
+		case format[0] == '\'':
+			literal, rest := splitICUQuoted(format[1:])
+			sb.WriteString(literal)
+			format = rest
+		case format[0] == '[':
+			literal, rest := splitQuoted(format[1:], "]")
+			sb.WriteString(literal)
+			format = rest
+		case isASCIILetter(format[0]):
+			end := 1
+			for end < len(format) && isASCIILetter(format[end]) {
+				end++
+			}
+			sb.WriteString(formatDateWord(t, format[:end]))
+			format = format[end:]
+		default:
+			r, size := utf8.DecodeRuneInString(format)
+			sb.WriteRune(r)
+			format = format[size:]
+		}
+	}
+	return sb.String()
+}
+
+// formatDateWord formats a word made of runs of the same letter, e.g. "YYYYMMDD". Words with a run that is not a token, e.g. "at", are kept.
+func formatDateWord(t time.Time, word string) string {
+	var sb strings.Builder
+	for len(word) > 0 {
+		end := 1
+		for end < len(word) && word[end] == word[0] {
+			end++
+		}
+		layout, ok := dateTokens[word[:end]]
+		if !ok {
+			return word
+		}
+		sb.WriteString(t.Format(layout))
+		word = word[end:]
+	}
+	return sb.String()
+}
+
+// splitICUQuoted returns the text before the closing quote and the text after it, two quotes in a row are a quote
+func splitICUQuoted(format string) (string, string) {
+	if strings.HasPrefix(format, "'") {
+		return "'", format[1:]
+	}
+
+	var sb strings.Builder
+	for len(format) > 0 {
+		index := strings.Index(format, "'")
+		if index < 0 {
+			break
+		}
+		sb.WriteString(format[:index])
+		if !strings.HasPrefix(format[index+1:], "'") {
+			return sb.String(), format[index+1:]
+		}
+		sb.WriteByte('\'')
+		format = format[index+2:]
+	}
+	// an unclosed quote runs to the end
+	sb.WriteString(format)
+	return sb.String(), ""
+}
+
+// splitQuoted returns the text before the closing quote and the text after it, an unclosed quote runs to the end
+func splitQuoted(format string, quote string) (string, string) {
+	index := strings.Index(format, quote)
+	if index < 0 {
+		return format, ""
+	}
+	return format[:index], format[index+len(quote):]
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,68 @@
+package snippet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandSnippet(t *testing.T) {
+	now := time.Date(2025, 1, 31, 14, 5, 9, 0, time.UTC)
+	snippets := []Snippet{
+		{Name: "Signature", Keyword: "sig", Text: "Best regards,\n{input:Sender}"},
+		{Name: "Loop A", Text: "{snippet:Loop B}"},
+		{Name: "Loop B", Text: "{snippet:Loop A}"},
+	}
+	env := expandEnv{
+		Now:       now,
+		Clipboard: func() string { return "copied" },
+		Inputs:    map[string]string{"Name": "Ada", "Sender": "Bob"},
+		Lookup:    newSnippetLookup(snippets),
+	}
+
+	tests := []struct {
+		text   string
+		want   string
+		cursor int
+	}{
+		{"Today is {date}", "Today is 2025-01-31", -1},
+		{"{date:YYYY/MM/DD HH:mm:ss}", "2025/01/31 14:05:09", -1},
+		{"{date:yyyy-MM-dd} {time} {datetime}", "2025-01-31 14:05 2025-01-31 14:05", -1},
+		{"{date:long}", "January 31, 2025", -1},
+		{"{date:dddd 'at' h:mm a}", "Friday at 2:05 PM", -1},
+		{"{date:dddd [at] HH:mm}", "Friday at 14:05", -1},
+		{"{date:YYYYMMDD} Sent at {time}", "20250131 Sent at 14:05", -1},
+		{"{date:[Week] ddd}", "Week Fri", -1},
+		{"{date:h 'o''clock'}", "2 o'clock", -1},
+		{"Pasted: {clipboard}", "Pasted: copied", -1},
+		{"Hi {input:Name}, {cursor}thanks!", "Hi Ada, thanks!", 7},
+		{"Hi 👋{cursor}", "Hi 👋", 0},
+		{"Dear {input:Name},\n\n{snippet:sig}", "Dear Ada,\n\nBest regards,\nBob", -1},
+		{"{snippet:Signature}", "Best regards,\nBob", -1},
+		{"func main() { fmt.Println({unknown}) }", "func main() { fmt.Println({unknown}) }", -1},
+	}
+	for _, tt := range tests {
+		got, cursor, err := expandSnippet(tt.text, env)
+		require.NoError(t, err, tt.text)
+		assert.Equal(t, tt.want, got, tt.text)
+		assert.Equal(t, tt.cursor, cursor, tt.text)
+	}
+
+	_, _, err := expandSnippet("{snippet:Loop A}", env)
+	assert.ErrorContains(t, err, "nested in itself")
+	_, _, err = expandSnippet("{snippet:missing}", env)
+	assert.ErrorContains(t, err, "snippet missing not found")
+}
+
+func TestGetSnippetInputs(t *testing.T) {
+	lookup := newSnippetLookup([]Snippet{
+		{Name: "Greeting", Keyword: "hi", Text: "Hello {input:Name} from {input:Company}"},
+		{Name: "Self", Text: "{snippet:Self}{input:Ignored}"},
+	})
+
+	assert.Equal(t, []string{"Name", "Company", "Topic"}, getSnippetInputs("{snippet:hi}, about {input:Topic} {input:Name}", lookup))
+	assert.Equal(t, []string{"Ignored"}, getSnippetInputs("{snippet:Self}", lookup))
+	assert.Empty(t, getSnippetInputs("{date} {clipboard}", lookup))
+}
@@ -13,6 +13,7 @@ import (
 	"wox/plugin"
 	"wox/ui"
 	"wox/util"
+	"wox/util/desktop"
 	"wox/util/shell"
 )
 
@@ -33,6 +34,7 @@ type SysCommand struct {
 	SubTitle               string
 	Icon                   common.WoxImage
 	PreventHideAfterAction bool
+	RequireConfirmation    bool // destructive commands ask to press enter again before running
 	Action                 func(ctx context.Context, actionContext plugin.ActionContext)
 }
 
@@ -83,12 +85,16 @@ func (r *SysPlugin) Init(ctx context.Context, initParams plugin.InitParams) {
 				if util.IsWindows() {
 					shell.Run("rundll32.exe", "user32.dll,LockWorkStation")
 				}
+				if util.IsLinux() {
+					r.runLinuxCommand(ctx, "lock screen", desktop.LockScreen)
+				}
 			},
 		},
 		{
-			ID:    "empty_trash",
-			Title: "i18n:plugin_sys_empty_trash",
-			Icon:  common.TrashIcon,
+			ID:                  "empty_trash",
+			Title:               "i18n:plugin_sys_empty_trash",
+			Icon:                common.TrashIcon,
+			RequireConfirmation: util.IsLinux(), // Linux empties the trash of every mounted drive, so it asks first
 			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
 				if util.IsMacOS() {
 					shell.Run("osascript", "-e", "tell application \"Finder\" to empty trash")
@@ -96,6 +102,15 @@ func (r *SysPlugin) Init(ctx context.Context, initParams plugin.InitParams) {
 				if util.IsWindows() {
 					shell.Run("powershell.exe", "-Command", "Clear-RecycleBin -Force")
 				}
+				if util.IsLinux() {
+					removed, err := desktop.EmptyAllTrash()
+					if err != nil {
+						r.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to empty trash: %s", err.Error()))
+						r.api.Notify(ctx, "i18n:plugin_sys_empty_trash_failed")
+						return
+					}
+					r.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("emptied trash, %d items removed", removed))
+				}
 			},
 		},
 		{
@@ -130,6 +145,62 @@ func (r *SysPlugin) Init(ctx context.Context, initParams plugin.InitParams) {
 		},
 	}
 
+	// power and session commands of Linux desktops, they go through logind
+	if util.IsLinux() {
+		r.commands = append(r.commands, SysCommand{
+			ID:    "sleep",
+			Title: "i18n:plugin_sys_sleep",
+			Icon:  common.NewWoxImageEmoji("💤"),
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				r.runLinuxCommand(ctx, "suspend", desktop.Suspend)
+			},
+		}, SysCommand{
+			ID:    "hibernate",
+			Title: "i18n:plugin_sys_hibernate",
+			Icon:  common.NewWoxImageEmoji("❄️"),
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				r.runLinuxCommand(ctx, "hibernate", desktop.Hibernate)
+			},
+		}, SysCommand{
+			ID:                  "restart",
+			Title:               "i18n:plugin_sys_restart",
+			Icon:                common.NewWoxImageEmoji("🔄"),
+			RequireConfirmation: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				r.runLinuxCommand(ctx, "reboot", desktop.Reboot)
+			},
+		}, SysCommand{
+			ID:                  "shutdown",
+			Title:               "i18n:plugin_sys_shutdown",
+			Icon:                common.NewWoxImageEmoji("🔌"),
+			RequireConfirmation: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				r.runLinuxCommand(ctx, "power off", desktop.PowerOff)
+			},
+		}, SysCommand{
+			ID:                  "log_out",
+			Title:               "i18n:plugin_sys_log_out",
+			Icon:                common.NewWoxImageEmoji("🚪"),
+			RequireConfirmation: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				r.runLinuxCommand(ctx, "log out", desktop.Logout)
+			},
+		}, SysCommand{
+			ID:    "toggle_do_not_disturb",
+			Title: "i18n:plugin_sys_toggle_do_not_disturb",
+			Icon:  common.NewWoxImageEmoji("🔕"),
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				enabled, err := desktop.ToggleDoNotDisturb()
+				if err != nil {
+					r.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to toggle do not disturb: %s", err.Error()))
+					r.api.Notify(ctx, "i18n:plugin_sys_do_not_disturb_not_supported")
+					return
+				}
+				r.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("do not disturb enabled: %t", enabled))
+			},
+		})
+	}
+
 	if util.IsDev() {
 		r.commands = append(r.commands, SysCommand{
 			ID:    "cpu_profiling",
@@ -233,14 +304,7 @@ func (r *SysPlugin) Query(ctx context.Context, query plugin.Query) (results []pl
 				Score:       titleScore,
 				Icon:        command.Icon,
 				ContextData: string(contextDataJson),
-				Actions: []plugin.QueryResultAction{
-					{
-						Name:                   "i18n:plugin_sys_execute",
-						Icon:                   common.ExecuteRunIcon,
-						Action:                 command.Action,
-						PreventHideAfterAction: command.PreventHideAfterAction,
-					},
-				},
+				Actions:     r.getCommandActions(command),
 			})
 		}
 
@@ -306,14 +370,75 @@ func (r *SysPlugin) handleMRURestore(mruData plugin.MRUData) (*plugin.QueryResul
 		SubTitle:    foundCommand.SubTitle,
 		Icon:        mruData.Icon,
 		ContextData: mruData.ContextData,
-		Actions: []plugin.QueryResultAction{
+		Actions:     r.getCommandActions(*foundCommand),
+	}
+
+	return result, nil
+}
+
+func (r *SysPlugin) getCommandActions(command SysCommand) []plugin.QueryResultAction {
+	if !command.RequireConfirmation {
+		return []plugin.QueryResultAction{
 			{
 				Name:                   "i18n:plugin_sys_execute",
-				Action:                 foundCommand.Action,
-				PreventHideAfterAction: foundCommand.PreventHideAfterAction,
+				Icon:                   common.ExecuteRunIcon,
+				Action:                 command.Action,
+				PreventHideAfterAction: command.PreventHideAfterAction,
+			},
+		}
+	}
+
+	return []plugin.QueryResultAction{
+		{
+			Name:                   "i18n:plugin_sys_execute",
+			Icon:                   common.ExecuteRunIcon,
+			PreventHideAfterAction: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				r.askForConfirmation(ctx, actionContext.ResultId, command)
 			},
 		},
 	}
+}
+
+// askForConfirmation turns the result into a confirm prompt, so destructive commands need a second enter
+func (r *SysPlugin) askForConfirmation(ctx context.Context, resultId string, command SysCommand) {
+	updatable := r.api.GetUpdatableResult(ctx, resultId)
+	if updatable == nil {
+		return
+	}
 
-	return result, nil
+	subTitle := "i18n:plugin_sys_confirm_subtitle"
+	actions := []plugin.QueryResultAction{
+		{
+			Name:                   "i18n:plugin_sys_confirm",
+			Icon:                   common.CorrectIcon,
+			Action:                 command.Action,
+			PreventHideAfterAction: command.PreventHideAfterAction,
+		},
+		{
+			Name:                   "i18n:plugin_sys_cancel",
+			Icon:                   common.ErrorIcon,
+			PreventHideAfterAction: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				if restore := r.api.GetUpdatableResult(ctx, actionContext.ResultId); restore != nil {
+					originalSubTitle := command.SubTitle
+					originalActions := r.getCommandActions(command)
+					restore.SubTitle = &originalSubTitle
+					restore.Actions = &originalActions
+					r.api.UpdateResult(ctx, *restore)
+				}
+			},
+		},
+	}
+	updatable.SubTitle = &subTitle
+	updatable.Actions = &actions
+	r.api.UpdateResult(ctx, *updatable)
+}
+
+// runLinuxCommand runs a desktop session command and tells the user if the desktop doesn't support it
+func (r *SysPlugin) runLinuxCommand(ctx context.Context, name string, command func() error) {
+	if err := command(); err != nil {
+		r.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to %s: %s", name, err.Error()))
+		r.api.Notify(ctx, fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_sys_command_failed"), err.Error()))
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sync"
 	"wox/common"
 	"wox/i18n"
 	"wox/plugin"
@@ -27,6 +28,9 @@ func init() {
 
 type ThemePlugin struct {
 	api plugin.API
+
+	editLock  sync.Mutex
+	editDraft *common.Theme // the theme being edited by the edit command, nil when nothing is being edited
 }
 
 func (c *ThemePlugin) GetMetadata() plugin.Metadata {
@@ -49,6 +53,10 @@ func (c *ThemePlugin) GetMetadata() plugin.Metadata {
 				Command:     "ai",
 				Description: "i18n:plugin_theme_ai_command_description",
 			},
+			{
+				Command:     "edit",
+				Description: "i18n:plugin_theme_edit_command_description",
+			},
 			{
 				Command:     "restore",
 				Description: "i18n:plugin_theme_restore_command_description",
@@ -88,6 +96,9 @@ func (c *ThemePlugin) Query(ctx context.Context, query plugin.Query) []plugin.Qu
 	if query.Command == "restore" {
 		return c.queryRestore(ctx, query)
 	}
+	if query.Command == "edit" {
+		return c.queryEdit(ctx, query)
+	}
 
 	uiManager := plugin.GetPluginManager().GetUI()
 	installedThemes := uiManager.GetAllThemes(ctx)
@@ -100,6 +111,8 @@ func (c *ThemePlugin) Query(ctx context.Context, query plugin.Query) []plugin.Qu
 	installThemeText := i18n.GetI18nManager().TranslateWox(ctx, "plugin_theme_install_theme")
 	systemTagText := i18n.GetI18nManager().TranslateWox(ctx, "ui_setting_theme_system_tag")
 	openThemeFolderText := i18n.GetI18nManager().TranslateWox(ctx, "plugin_theme_open_containing_folder")
+	updatesGroup := i18n.GetI18nManager().TranslateWox(ctx, "plugin_theme_group_updates")
+	storeThemes := ui.GetStoreManager().GetThemes()
 
 	results := lo.FilterMap(installedThemes, func(theme common.Theme, _ int) (plugin.QueryResult, bool) {
 		match, _ := IsStringMatchScore(ctx, theme.ThemeName, query.Search)
@@ -146,7 +159,13 @@ func (c *ThemePlugin) Query(ctx context.Context, query plugin.Query) []plugin.Qu
 				})
 			}
 			currentThemeId := setting.GetSettingManager().GetWoxSetting(ctx).ThemeId.Get()
-			if currentThemeId == theme.ThemeId {
+			storeTheme, inStore := lo.Find(storeThemes, func(t common.Theme) bool { return t.ThemeId == theme.ThemeId })
+			if inStore && ui.GetStoreManager().IsUpgradable(ctx, storeTheme) {
+				result.SubTitle = fmt.Sprintf("%s → %s", theme.Version, storeTheme.Version)
+				result.Group = updatesGroup
+				result.GroupScore = 200
+				result.Actions = append([]plugin.QueryResultAction{c.createUpgradeAction(storeTheme)}, result.Actions...)
+			} else if currentThemeId == theme.ThemeId {
 				result.Group = currentGroup
 				result.GroupScore = 100
 			} else {
@@ -161,7 +180,6 @@ func (c *ThemePlugin) Query(ctx context.Context, query plugin.Query) []plugin.Qu
 	})
 
 	// Add store themes
-	storeThemes := ui.GetStoreManager().GetThemes()
 	installedThemeIds := lo.Map(installedThemes, func(t common.Theme, _ int) string { return t.ThemeId })
 
 	storeResults := lo.FilterMap(storeThemes, func(theme common.Theme, _ int) (plugin.QueryResult, bool) {
@@ -187,7 +205,10 @@ func (c *ThemePlugin) Query(ctx context.Context, query plugin.Query) []plugin.Qu
 						Name:                   installThemeText,
 						PreventHideAfterAction: true,
 						Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-							uiManager.InstallTheme(ctx, theme)
+							if installErr := uiManager.InstallTheme(ctx, theme); installErr != nil {
+								c.api.Log(ctx, plugin.LogLevelError, installErr.Error())
+								c.api.Notify(ctx, installErr.Error())
+							}
 						},
 					},
 				},
@@ -200,6 +221,22 @@ func (c *ThemePlugin) Query(ctx context.Context, query plugin.Query) []plugin.Qu
 	return append(results, storeResults...)
 }
 
+func (c *ThemePlugin) createUpgradeAction(storeTheme common.Theme) plugin.QueryResultAction {
+	return plugin.QueryResultAction{
+		Name:                   "i18n:plugin_theme_upgrade_theme",
+		Icon:                   common.UpdateIcon,
+		PreventHideAfterAction: true,
+		Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+			if upgradeErr := ui.GetStoreManager().Upgrade(ctx, storeTheme); upgradeErr != nil {
+				c.api.Log(ctx, plugin.LogLevelError, upgradeErr.Error())
+				c.api.Notify(ctx, upgradeErr.Error())
+				return
+			}
+			c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: true})
+		},
+	}
+}
+
 func (c *ThemePlugin) queryAI(ctx context.Context, query plugin.Query) []plugin.QueryResult {
 	modelStr := c.api.GetSetting(ctx, "model")
 	if modelStr == "" {
@@ -356,7 +393,10 @@ Please directly output the JSON configuration, do not add any other content.
 									theme.ThemeUrl = "https://www.github.com/wox-launcher/wox"
 									theme.Version = "1.0.0"
 									theme.IsSystem = false
-									plugin.GetPluginManager().GetUI().InstallTheme(ctx, theme)
+									if installErr := plugin.GetPluginManager().GetUI().InstallTheme(ctx, theme); installErr != nil {
+										c.api.Log(ctx, plugin.LogLevelError, installErr.Error())
+										c.api.Notify(ctx, installErr.Error())
+									}
 								})
 
 							case common.ChatStreamStatusError:
@@ -0,0 +1,254 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"wox/common"
+	"wox/plugin"
+	"wox/setting/definition"
+	"wox/setting/validator"
+	"wox/ui"
+	"wox/util/clipboard"
+
+	"github.com/google/uuid"
+	"github.com/tidwall/pretty"
+)
+
+// themeEditorDraftId is the id of the theme being edited, the draft is not installed so changing to it only previews it
+const themeEditorDraftId = "wox-theme-editor-draft"
+
+// themeEditorAreas groups the style fields of a theme by the part of the launcher they style, a field belongs to the first area it has a prefix of
+var themeEditorAreas = []struct {
+	name     string
+	prefixes []string
+}{
+	{name: "i18n:plugin_theme_edit_area_app", prefixes: []string{"App"}},
+	{name: "i18n:plugin_theme_edit_area_query_box", prefixes: []string{"QueryBox"}},
+	{name: "i18n:plugin_theme_edit_area_results", prefixes: []string{"ResultContainer", "ResultItem"}},
+	{name: "i18n:plugin_theme_edit_area_actions", prefixes: []string{"ActionContainer", "ActionItem", "ActionQueryBox"}},
+	{name: "i18n:plugin_theme_edit_area_preview", prefixes: []string{"Preview"}},
+	{name: "i18n:plugin_theme_edit_area_toolbar", prefixes: []string{"Toolbar"}},
+}
+
+func getThemeEditorAreaFields(prefixes []string) []string {
+	var fields []string
+	for _, name := range common.ThemeStyleFieldNames() {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				fields = append(fields, name)
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// getEditDraft returns the theme being edited, a new draft is started when there is none or the current theme changed since
+func (c *ThemePlugin) getEditDraft(ctx context.Context) common.Theme {
+	c.editLock.Lock()
+	defer c.editLock.Unlock()
+
+	currentTheme := ui.GetUIManager().GetCurrentTheme(ctx)
+	if c.editDraft == nil || c.editDraft.Extends != currentTheme.ThemeId {
+		draftName := fmt.Sprintf(c.api.GetTranslation(ctx, "plugin_theme_edit_draft_name"), currentTheme.ThemeName)
+		draft := common.NewExtendingTheme(themeEditorDraftId, draftName, currentTheme.ThemeId)
+		c.editDraft = &draft
+	}
+	return c.editDraft.Clone()
+}
+
+func (c *ThemePlugin) setEditDraft(draft *common.Theme) {
+	c.editLock.Lock()
+	defer c.editLock.Unlock()
+	c.editDraft = draft
+}
+
+func (c *ThemePlugin) queryEdit(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	draft := c.getEditDraft(ctx)
+	if draft.Extends == "" {
+		return []plugin.QueryResult{
+			{
+				Title: c.api.GetTranslation(ctx, "plugin_theme_edit_no_current_theme"),
+				Icon:  themeIcon,
+			},
+		}
+	}
+
+	resolved, resolveErr := ui.GetUIManager().ResolveTheme(draft)
+	if resolveErr != nil {
+		return []plugin.QueryResult{
+			{
+				Title:    c.api.GetTranslation(ctx, "plugin_theme_edit_invalid"),
+				SubTitle: resolveErr.Error(),
+				Icon:     themeIcon,
+			},
+		}
+	}
+
+	var results []plugin.QueryResult
+	for _, area := range themeEditorAreas {
+		areaName := c.api.GetTranslation(ctx, area.name)
+		if match, _ := IsStringMatchScore(ctx, areaName, query.Search); !match {
+			continue
+		}
+
+		fields := getThemeEditorAreaFields(area.prefixes)
+		results = append(results, plugin.QueryResult{
+			Title:    areaName,
+			SubTitle: strings.Join(fields, ", "),
+			Icon:     themeIcon,
+			Actions: []plugin.QueryResultAction{
+				{
+					Name:                   "i18n:plugin_theme_edit_action",
+					Type:                   plugin.QueryResultActionTypeForm,
+					PreventHideAfterAction: true,
+					Form:                   getThemeEditorForm(fields, resolved),
+					OnSubmit: func(ctx context.Context, actionContext plugin.FormActionContext) {
+						c.applyEdit(ctx, fields, resolved, actionContext.Values)
+					},
+				},
+			},
+		})
+	}
+
+	return append(results, c.getEditDraftResults(ctx, draft)...)
+}
+
+func getThemeEditorForm(fields []string, resolved common.Theme) definition.PluginSettingDefinitions {
+	var form definition.PluginSettingDefinitions
+	for _, field := range fields {
+		tooltip := "i18n:plugin_theme_edit_size_tooltip"
+		if common.IsThemeColorField(field) {
+			tooltip = "i18n:plugin_theme_edit_color_tooltip"
+		}
+		form = append(form, definition.PluginSettingDefinitionItem{
+			Type: definition.PluginSettingDefinitionTypeTextBox,
+			Value: &definition.PluginSettingValueTextBox{
+				Key:          field,
+				Label:        field,
+				DefaultValue: resolved.GetStyleField(field),
+				Tooltip:      tooltip,
+			},
+		})
+	}
+	return form
+}
+
+// applyEdit sets the changed fields on the draft and previews it, the draft is left as it was when a field is invalid
+func (c *ThemePlugin) applyEdit(ctx context.Context, fields []string, resolved common.Theme, values map[string]string) {
+	draft := c.getEditDraft(ctx)
+	for _, field := range fields {
+		value, ok := values[field]
+		if !ok || strings.TrimSpace(value) == resolved.GetStyleField(field) {
+			continue
+		}
+		if err := draft.SetStyleField(field, value); err != nil {
+			c.api.Notify(ctx, err.Error())
+			return
+		}
+	}
+
+	if err := ui.GetUIManager().ValidateTheme(draft); err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("invalid theme edit: %s", err.Error()))
+		c.api.Notify(ctx, err.Error())
+		return
+	}
+
+	c.setEditDraft(&draft)
+	plugin.GetPluginManager().GetUI().ChangeTheme(ctx, draft)
+	c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: true})
+}
+
+func (c *ThemePlugin) getEditDraftResults(ctx context.Context, draft common.Theme) []plugin.QueryResult {
+	draftJson, err := draft.SourceJSON()
+	if err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to export theme draft: %s", err.Error()))
+		return nil
+	}
+	draftJson = pretty.Pretty(draftJson)
+
+	return []plugin.QueryResult{
+		{
+			Title:    "i18n:plugin_theme_edit_save",
+			SubTitle: "i18n:plugin_theme_edit_save_subtitle",
+			Icon:     themeIcon,
+			Actions: []plugin.QueryResultAction{
+				{
+					Name:                   "i18n:plugin_theme_edit_save",
+					Type:                   plugin.QueryResultActionTypeForm,
+					PreventHideAfterAction: true,
+					Form: definition.PluginSettingDefinitions{
+						{
+							Type: definition.PluginSettingDefinitionTypeTextBox,
+							Value: &definition.PluginSettingValueTextBox{
+								Key:          "name",
+								Label:        "i18n:plugin_theme_edit_save_name",
+								DefaultValue: draft.ThemeName,
+								Validators: []validator.PluginSettingValidator{
+									{
+										Type:  validator.PluginSettingValidatorTypeNotEmpty,
+										Value: &validator.PluginSettingValidatorNotEmpty{},
+									},
+								},
+							},
+						},
+					},
+					OnSubmit: func(ctx context.Context, actionContext plugin.FormActionContext) {
+						c.saveEditDraft(ctx, strings.TrimSpace(actionContext.Values["name"]))
+					},
+				},
+			},
+		},
+		{
+			Title:    "i18n:plugin_theme_edit_export",
+			SubTitle: "i18n:plugin_theme_edit_export_subtitle",
+			Icon:     themeIcon,
+			Preview:  plugin.WoxPreview{PreviewType: plugin.WoxPreviewTypeMarkdown, PreviewData: fmt.Sprintf("```json\n%s\n```", string(draftJson))},
+			Actions: []plugin.QueryResultAction{
+				{
+					Name: "i18n:plugin_theme_edit_export",
+					Icon: common.CopyIcon,
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						if err := clipboard.WriteText(string(draftJson)); err != nil {
+							c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to copy theme draft: %s", err.Error()))
+						}
+					},
+				},
+			},
+		},
+		{
+			Title:    "i18n:plugin_theme_edit_revert",
+			SubTitle: "i18n:plugin_theme_edit_revert_subtitle",
+			Icon:     themeIcon,
+			Actions: []plugin.QueryResultAction{
+				{
+					Name:                   "i18n:plugin_theme_edit_revert",
+					PreventHideAfterAction: true,
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						c.setEditDraft(nil)
+						plugin.GetPluginManager().GetUI().ChangeTheme(ctx, ui.GetUIManager().GetThemeById(draft.Extends))
+						c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: true})
+					},
+				},
+			},
+		},
+	}
+}
+
+// saveEditDraft installs the draft as a new theme that extends the edited theme, the new theme becomes the current theme
+func (c *ThemePlugin) saveEditDraft(ctx context.Context, name string) {
+	theme := c.getEditDraft(ctx)
+	theme.ThemeId = uuid.NewString()
+	theme.ThemeName = name
+	theme.Version = "1.0.0"
+
+	if err := plugin.GetPluginManager().GetUI().InstallTheme(ctx, theme); err != nil {
+		c.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("failed to save theme %s: %s", name, err.Error()))
+		c.api.Notify(ctx, err.Error())
+		return
+	}
+
+	c.setEditDraft(nil)
+	c.api.RefreshQuery(ctx, plugin.RefreshQueryParam{PreserveSelectedIndex: true})
+}
@@ -15,7 +15,6 @@ import (
 	"wox/util"
 	"wox/util/shell"
 
-	"github.com/Masterminds/semver/v3"
 	"github.com/fsnotify/fsnotify"
 	"github.com/google/uuid"
 	cp "github.com/otiai10/copy"
@@ -586,16 +585,10 @@ func (w *WPMPlugin) installCommand(ctx context.Context, query plugin.Query) []pl
 	for _, pluginManifest := range pluginManifests {
 		// build tails to indicate installation/upgrade status
 		var tails []plugin.QueryResultTail
-		if inst, ok := lo.Find(installed, func(it *plugin.Instance) bool { return it.Metadata.Id == pluginManifest.Id }); ok {
-			// plugin is installed, check if upgrade is available
-			// best-effort semver comparison; fall back to show installed if parse fails
-			upgrade := false
-			if vInstalled, err1 := semver.NewVersion(inst.Metadata.Version); err1 == nil {
-				if vStore, err2 := semver.NewVersion(pluginManifest.Version); err2 == nil {
-					upgrade = vStore.GreaterThan(vInstalled)
-				}
-			}
-			if upgrade {
+		installedFlag := lo.ContainsBy(installed, func(it *plugin.Instance) bool { return it.Metadata.Id == pluginManifest.Id })
+		upgradeFlag := installedFlag && plugin.GetStoreManager().IsUpgradable(ctx, pluginManifest)
+		if installedFlag {
+			if upgradeFlag {
 				// show an upgrade icon
 				tails = append(tails, plugin.QueryResultTail{Type: plugin.QueryResultTailTypeImage, Image: common.NewWoxImageEmoji("\u2b06\ufe0f")})
 			} else {
@@ -606,16 +599,7 @@ func (w *WPMPlugin) installCommand(ctx context.Context, query plugin.Query) []pl
 
 		// decide actions based on install/upgrade status
 		var actions []plugin.QueryResultAction
-		installedFlag := lo.ContainsBy(installed, func(it *plugin.Instance) bool { return it.Metadata.Id == pluginManifest.Id })
 		if installedFlag {
-			upgradeFlag := false
-			if inst, ok := lo.Find(installed, func(it *plugin.Instance) bool { return it.Metadata.Id == pluginManifest.Id }); ok {
-				if vInstalled, err1 := semver.NewVersion(inst.Metadata.Version); err1 == nil {
-					if vStore, err2 := semver.NewVersion(pluginManifest.Version); err2 == nil {
-						upgradeFlag = vStore.GreaterThan(vInstalled)
-					}
-				}
-			}
 			if upgradeFlag {
 				// show Upgrade action
 				actions = make([]plugin.QueryResultAction, 0, 2)
@@ -727,12 +711,20 @@ func (w *WPMPlugin) installCommand(ctx context.Context, query plugin.Query) []pl
 			icon = wpmIcon
 		}
 
+		// installed plugins with a newer version in the store are listed first
+		group, groupScore := "i18n:plugin_wpm_group_store", int64(0)
+		if upgradeFlag {
+			group, groupScore = "i18n:plugin_wpm_group_updates", 100
+		}
+
 		results = append(results, plugin.QueryResult{
-			Id:       uuid.NewString(),
-			Title:    pluginManifest.Name,
-			SubTitle: pluginManifest.Description,
-			Icon:     icon,
-			Tails:    tails,
+			Id:         uuid.NewString(),
+			Title:      pluginManifest.Name,
+			SubTitle:   pluginManifest.Description,
+			Icon:       icon,
+			Tails:      tails,
+			Group:      group,
+			GroupScore: groupScore,
 			Preview: plugin.WoxPreview{
 				PreviewType: plugin.WoxPreviewTypePluginDetail,
 				PreviewData: string(pluginDetailJSON),
@@ -0,0 +1,587 @@
+package setting
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// A backup is a zip archive with a manifest and the files of the user data directory under "data/".
+// The manifest lists the sha256 of every file, so a backup is verified before anything is restored.
+// Encrypted backups compress each file and seal it in chunks with AES-256-GCM, the key is derived from a passphrase.
+// Their file list is sealed as well and the files are stored under their index, so paths, sizes and hashes stay private.
+const (
+	backupFileExtension   = ".woxbackup"
+	backupManifestName    = "manifest.json"
+	backupDataPrefix      = "data/"
+	backupFormatVersion   = 2 // 2 seals the file list of encrypted backups
+	backupKeyIterations   = 600000
+	backupKeyCheckText    = "wox backup"
+	backupEncryptChunkLen = 64 * 1024
+)
+
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+var ErrBackupPassphraseRequired = errors.New("backup is encrypted, a passphrase is required")
+var ErrBackupWrongPassphrase = errors.New("wrong backup passphrase")
+
+type backupManifest struct {
+	Version     int
+	Backup      Backup
+	Salt        string // base64 salt of the passphrase key, empty when not encrypted
+	KeyCheck    string // base64 sealed backupKeyCheckText, tells a wrong passphrase from a corrupted backup
+	SealedFiles string // base64 sealed json of backupFileList in encrypted backups, Files and Databases are empty then
+	backupFileList
+}
+
+type backupFileList struct {
+	Files     []backupManifestFile
+	Databases []string // paths of the files that are consistent sqlite snapshots
+}
+
+type backupManifestFile struct {
+	Path   string // slash separated path relative to the user data directory
+	Entry  string `json:",omitempty"` // zip entry of the file in encrypted backups, backupDataPrefix + Path otherwise
+	Size   int64
+	SHA256 string
+}
+
+func (f backupManifestFile) entryName() string {
+	if f.Entry != "" {
+		return f.Entry
+	}
+	return backupDataPrefix + f.Path
+}
+
+// writeBackupArchive packs sourceDir into archivePath, sqlite databases are snapshotted with VACUUM INTO,
+// so databases that are open and written meanwhile are still consistent. The archive is encrypted when passphrase is not empty.
+func writeBackupArchive(ctx context.Context, sourceDir string, archivePath string, backup Backup, passphrase string) (err error) {
+	snapshotDir, err := os.MkdirTemp(filepath.Dir(archivePath), "snapshot_")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	defer os.RemoveAll(snapshotDir)
+
+	manifest := backupManifest{Version: backupFormatVersion, Backup: backup}
+	var fileList backupFileList
+	var aead cipher.AEAD
+	if passphrase != "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		aead, err = newBackupCipher(passphrase, salt)
+		if err != nil {
+			return err
+		}
+		keyCheck, err := sealBackupKeyCheck(aead)
+		if err != nil {
+			return err
+		}
+		manifest.Salt = base64.StdEncoding.EncodeToString(salt)
+		manifest.KeyCheck = keyCheck
+		manifest.Backup.Encrypted = true
+	}
+
+	tempArchivePath := archivePath + ".tmp"
+	archiveFile, err := os.Create(tempArchivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer func() {
+		archiveFile.Close()
+		if err != nil {
+			os.Remove(tempArchivePath)
+		}
+	}()
+
+	zipWriter := zip.NewWriter(archiveFile)
+	walkErr := filepath.WalkDir(sourceDir, func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		// the backups themselves may be in the user data directory
+		if entry.IsDir() && filePath == filepath.Dir(archivePath) {
+			return filepath.SkipDir
+		}
+		if entry.IsDir() || !entry.Type().IsRegular() {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// journals are part of the database snapshots
+		name := entry.Name()
+		if strings.HasSuffix(name, "-journal") || strings.HasSuffix(name, "-wal") || strings.HasSuffix(name, "-shm") {
+			return nil
+		}
+
+		relPath, relErr := filepath.Rel(sourceDir, filePath)
+		if relErr != nil {
+			return relErr
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		readPath := filePath
+		if isSQLiteDatabase(filePath) {
+			readPath = filepath.Join(snapshotDir, fmt.Sprintf("%d.db", len(fileList.Databases)))
+			if snapshotErr := snapshotSQLiteDatabase(ctx, filePath, readPath); snapshotErr != nil {
+				return fmt.Errorf("failed to snapshot database %s: %w", relPath, snapshotErr)
+			}
+			fileList.Databases = append(fileList.Databases, relPath)
+		}
+
+		manifestFile := backupManifestFile{Path: relPath}
+		if aead != nil {
+			manifestFile.Entry = fmt.Sprintf("%s%d", backupDataPrefix, len(fileList.Files))
+		}
+		if addErr := addBackupFile(zipWriter, readPath, &manifestFile, aead); addErr != nil {
+			return fmt.Errorf("failed to add %s to backup: %w", relPath, addErr)
+		}
+		fileList.Files = append(fileList.Files, manifestFile)
+		return nil
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+
+	if aead == nil {
+		manifest.backupFileList = fileList
+	} else {
+		fileListData, marshalErr := json.Marshal(fileList)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		if manifest.SealedFiles, err = sealBackupData(aead, fileListData); err != nil {
+			return err
+		}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifestWriter, err := zipWriter.Create(backupManifestName)
+	if err != nil {
+		return err
+	}
+	if _, err = manifestWriter.Write(manifestData); err != nil {
+		return err
+	}
+	if err = zipWriter.Close(); err != nil {
+		return err
+	}
+	if err = archiveFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempArchivePath, archivePath)
+}
+
+// addBackupFile writes a file to the archive and fills the size and hash of its manifest entry, the hash is of the original content
+func addBackupFile(zipWriter *zip.Writer, filePath string, manifestFile *backupManifestFile, aead cipher.AEAD) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := &zip.FileHeader{Name: manifestFile.entryName(), Method: zip.Deflate}
+	if aead != nil {
+		// encrypted data doesn't compress, it is compressed before sealing
+		header.Method = zip.Store
+	}
+	entryWriter, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	hasher := sha256.New()
+	var size int64
+	if aead == nil {
+		size, err = io.Copy(io.MultiWriter(entryWriter, hasher), file)
+		if err != nil {
+			return err
+		}
+	} else {
+		sealer := newBackupSealWriter(entryWriter, aead, manifestFile.Path)
+		compressor, _ := flate.NewWriter(sealer, flate.DefaultCompression)
+		size, err = io.Copy(io.MultiWriter(compressor, hasher), file)
+		if err != nil {
+			return err
+		}
+		if err = compressor.Close(); err != nil {
+			return err
+		}
+		if err = sealer.Close(); err != nil {
+			return err
+		}
+	}
+
+	manifestFile.Size = size
+	manifestFile.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	return nil
+}
+
+// readBackupManifest reads the manifest of a backup archive without reading its files
+func readBackupManifest(archivePath string) (backupManifest, error) {
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return backupManifest{}, err
+	}
+	defer zipReader.Close()
+
+	return readBackupManifestFromZip(&zipReader.Reader)
+}
+
+func readBackupManifestFromZip(zipReader *zip.Reader) (backupManifest, error) {
+	manifestFile, err := zipReader.Open(backupManifestName)
+	if err != nil {
+		return backupManifest{}, fmt.Errorf("backup manifest not found: %w", err)
+	}
+	defer manifestFile.Close()
+
+	var manifest backupManifest
+	if err := json.NewDecoder(manifestFile).Decode(&manifest); err != nil {
+		return backupManifest{}, fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	if manifest.Version > backupFormatVersion {
+		return backupManifest{}, fmt.Errorf("backup version %d is newer than supported version %d", manifest.Version, backupFormatVersion)
+	}
+	return manifest, nil
+}
+
+// extractBackupArchive extracts and verifies every file of the manifest into targetDir, targetDir is left incomplete on error
+func extractBackupArchive(ctx context.Context, archivePath string, targetDir string, passphrase string) error {
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer zipReader.Close()
+
+	manifest, err := readBackupManifestFromZip(&zipReader.Reader)
+	if err != nil {
+		return err
+	}
+
+	var aead cipher.AEAD
+	if manifest.Salt != "" {
+		if passphrase == "" {
+			return ErrBackupPassphraseRequired
+		}
+		salt, decodeErr := base64.StdEncoding.DecodeString(manifest.Salt)
+		if decodeErr != nil {
+			return fmt.Errorf("invalid backup salt: %w", decodeErr)
+		}
+		aead, err = newBackupCipher(passphrase, salt)
+		if err != nil {
+			return err
+		}
+		if !openBackupKeyCheck(aead, manifest.KeyCheck) {
+			return ErrBackupWrongPassphrase
+		}
+		if manifest.SealedFiles != "" {
+			fileListData, openErr := openBackupData(aead, manifest.SealedFiles)
+			if openErr != nil {
+				return fmt.Errorf("backup is corrupted, failed to decrypt the file list: %w", openErr)
+			}
+			if unmarshalErr := json.Unmarshal(fileListData, &manifest.backupFileList); unmarshalErr != nil {
+				return fmt.Errorf("backup is corrupted, invalid file list: %w", unmarshalErr)
+			}
+		}
+	}
+
+	entries := make(map[string]*zip.File, len(zipReader.File))
+	for _, file := range zipReader.File {
+		entries[file.Name] = file
+	}
+
+	for _, manifestFile := range manifest.Files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// reject paths that escape the target directory
+		cleanPath := path.Clean(manifestFile.Path)
+		if !fs.ValidPath(cleanPath) || cleanPath != manifestFile.Path {
+			return fmt.Errorf("invalid file path in backup: %s", manifestFile.Path)
+		}
+		entry, ok := entries[manifestFile.entryName()]
+		if !ok {
+			return fmt.Errorf("backup is corrupted, missing file: %s", manifestFile.Path)
+		}
+
+		if err := extractBackupFile(entry, filepath.Join(targetDir, filepath.FromSlash(cleanPath)), manifestFile, aead); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractBackupFile(entry *zip.File, targetPath string, manifestFile backupManifestFile, aead cipher.AEAD) error {
+	if err := os.MkdirAll(filepath.Dir(targetPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	entryReader, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s from backup: %w", manifestFile.Path, err)
+	}
+	defer entryReader.Close()
+
+	var reader io.Reader = entryReader
+	if aead != nil {
+		decompressor := flate.NewReader(newBackupOpenReader(entryReader, aead, manifestFile.Path))
+		defer decompressor.Close()
+		reader = decompressor
+	}
+
+	targetFile, err := os.Create(targetPath)
+	if err != nil {
+		return err
+	}
+	defer targetFile.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(targetFile, hasher), reader)
+	if err != nil {
+		return fmt.Errorf("backup is corrupted, failed to read %s: %w", manifestFile.Path, err)
+	}
+	if size != manifestFile.Size || hex.EncodeToString(hasher.Sum(nil)) != manifestFile.SHA256 {
+		return fmt.Errorf("backup is corrupted, checksum mismatch: %s", manifestFile.Path)
+	}
+
+	return targetFile.Close()
+}
+
+func isSQLiteDatabase(filePath string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false
+	}
+	return bytes.Equal(header, sqliteHeader)
+}
+
+// snapshotSQLiteDatabase copies a database with VACUUM INTO, which reads in a transaction, so writers of the open database don't tear the copy
+func snapshotSQLiteDatabase(ctx context.Context, dbPath string, snapshotPath string) error {
+	db, err := sql.Open("sqlite3", dbPath+"?_busy_timeout=5000")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, "VACUUM INTO ?", snapshotPath)
+	return err
+}
+
+func newBackupCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, backupKeyIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealBackupKeyCheck(aead cipher.AEAD) (string, error) {
+	return sealBackupData(aead, []byte(backupKeyCheckText))
+}
+
+func openBackupKeyCheck(aead cipher.AEAD, keyCheck string) bool {
+	plain, err := openBackupData(aead, keyCheck)
+	return err == nil && string(plain) == backupKeyCheckText
+}
+
+// sealBackupData seals a small value of the manifest with a random nonce, the result is base64 of nonce and sealed data
+func sealBackupData(aead cipher.AEAD, plain []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, nil)), nil
+}
+
+func openBackupData(aead cipher.AEAD, sealed string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("sealed data is too short")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+}
+
+// backupSealWriter seals the data in chunks, each chunk is written as
+// [4 byte length][1 byte final flag][sealed chunk], the nonce is a random prefix followed by the chunk index.
+// The file path, chunk index and final flag are authenticated, so chunks can't be moved, reordered or truncated.
+type backupSealWriter struct {
+	writer  io.Writer
+	aead    cipher.AEAD
+	path    string
+	prefix  []byte
+	index   uint64
+	buffer  []byte
+	started bool
+}
+
+func newBackupSealWriter(writer io.Writer, aead cipher.AEAD, path string) *backupSealWriter {
+	return &backupSealWriter{writer: writer, aead: aead, path: path}
+}
+
+func (w *backupSealWriter) Write(data []byte) (int, error) {
+	w.buffer = append(w.buffer, data...)
+	for len(w.buffer) > backupEncryptChunkLen {
+		if err := w.writeChunk(w.buffer[:backupEncryptChunkLen], false); err != nil {
+			return 0, err
+		}
+		w.buffer = w.buffer[backupEncryptChunkLen:]
+	}
+	return len(data), nil
+}
+
+// Close writes the final chunk, it is written even when empty, so a truncated file is detected
+func (w *backupSealWriter) Close() error {
+	return w.writeChunk(w.buffer, true)
+}
+
+func (w *backupSealWriter) writeChunk(chunk []byte, final bool) error {
+	if !w.started {
+		w.prefix = make([]byte, w.aead.NonceSize()-8)
+		if _, err := rand.Read(w.prefix); err != nil {
+			return err
+		}
+		if _, err := w.writer.Write(w.prefix); err != nil {
+			return err
+		}
+		w.started = true
+	}
+
+	sealed := w.aead.Seal(nil, backupChunkNonce(w.prefix, w.index), chunk, backupChunkAdditionalData(w.path, w.index, final))
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header, uint32(len(sealed)))
+	if final {
+		header[4] = 1
+	}
+	if _, err := w.writer.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.writer.Write(sealed); err != nil {
+		return err
+	}
+	w.index++
+	return nil
+}
+
+// backupOpenReader reads the chunks written by backupSealWriter
+type backupOpenReader struct {
+	reader io.Reader
+	aead   cipher.AEAD
+	path   string
+	prefix []byte
+	index  uint64
+	buffer []byte
+	final  bool
+}
+
+func newBackupOpenReader(reader io.Reader, aead cipher.AEAD, path string) *backupOpenReader {
+	return &backupOpenReader{reader: reader, aead: aead, path: path}
+}
+
+func (r *backupOpenReader) Read(data []byte) (int, error) {
+	for len(r.buffer) == 0 {
+		if r.final {
+			return 0, io.EOF
+		}
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(data, r.buffer)
+	r.buffer = r.buffer[n:]
+	return n, nil
+}
+
+func (r *backupOpenReader) readChunk() error {
+	if r.prefix == nil {
+		r.prefix = make([]byte, r.aead.NonceSize()-8)
+		if _, err := io.ReadFull(r.reader, r.prefix); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		return unexpectedEOF(err)
+	}
+	sealedLen := binary.BigEndian.Uint32(header)
+	if sealedLen > backupEncryptChunkLen+uint32(r.aead.Overhead()) {
+		return fmt.Errorf("invalid chunk length %d", sealedLen)
+	}
+	sealed := make([]byte, sealedLen)
+	if _, err := io.ReadFull(r.reader, sealed); err != nil {
+		return unexpectedEOF(err)
+	}
+
+	final := header[4] == 1
+	plain, err := r.aead.Open(nil, backupChunkNonce(r.prefix, r.index), sealed, backupChunkAdditionalData(r.path, r.index, final))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt chunk %d: %w", r.index, err)
+	}
+	r.buffer = plain
+	r.final = final
+	r.index++
+	return nil
+}
+
+func backupChunkNonce(prefix []byte, index uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, prefix...), index)
+}
+
+func backupChunkAdditionalData(path string, index uint64, final bool) []byte {
+	data := binary.BigEndian.AppendUint64([]byte(path), index)
+	if final {
+		return append(data, 1)
+	}
+	return append(data, 0)
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package setting
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createBackupSource(t *testing.T) (string, *sql.DB) {
+	sourceDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "settings"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "settings", "wox.json"), []byte(`{"theme":"dark"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "large.txt"), make([]byte, backupEncryptChunkLen*3+7), 0644))
+
+	// the database stays open while backing up, like wox.db
+	db, err := sql.Open("sqlite3", filepath.Join(sourceDir, "wox.db"))
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE kv (key TEXT PRIMARY KEY, value TEXT); INSERT INTO kv VALUES ('hello', 'world')`)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return sourceDir, db
+}
+
+func TestBackupArchiveRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for _, passphrase := range []string{"", "correct horse"} {
+		sourceDir, _ := createBackupSource(t)
+		archivePath := filepath.Join(t.TempDir(), "1"+backupFileExtension)
+		require.NoError(t, writeBackupArchive(ctx, sourceDir, archivePath, Backup{Id: "id", Name: "1", Type: BackupTypeManual}, passphrase))
+
+		manifest, err := readBackupManifest(archivePath)
+		require.NoError(t, err)
+		assert.Equal(t, "id", manifest.Backup.Id)
+		assert.Equal(t, passphrase != "", manifest.Backup.Encrypted)
+		if passphrase == "" {
+			assert.Equal(t, []string{"wox.db"}, manifest.Databases)
+			assert.Len(t, manifest.Files, 3)
+		} else {
+			assert.Empty(t, manifest.Files, "the file list of encrypted backups is sealed")
+			assert.NotEmpty(t, manifest.SealedFiles)
+		}
+
+		targetDir := filepath.Join(t.TempDir(), "restore")
+		require.NoError(t, extractBackupArchive(ctx, archivePath, targetDir, passphrase))
+
+		settingData, err := os.ReadFile(filepath.Join(targetDir, "settings", "wox.json"))
+		require.NoError(t, err)
+		assert.Equal(t, `{"theme":"dark"}`, string(settingData))
+		largeInfo, err := os.Stat(filepath.Join(targetDir, "large.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(backupEncryptChunkLen*3+7), largeInfo.Size())
+
+		restoredDB, err := sql.Open("sqlite3", filepath.Join(targetDir, "wox.db"))
+		require.NoError(t, err)
+		var value string
+		require.NoError(t, restoredDB.QueryRow(`SELECT value FROM kv WHERE key = 'hello'`).Scan(&value))
+		assert.Equal(t, "world", value)
+		restoredDB.Close()
+	}
+}
+
+func TestBackupArchivePassphrase(t *testing.T) {
+	ctx := context.Background()
+	sourceDir, _ := createBackupSource(t)
+	archivePath := filepath.Join(t.TempDir(), "1"+backupFileExtension)
+	require.NoError(t, writeBackupArchive(ctx, sourceDir, archivePath, Backup{Id: "id"}, "secret"))
+
+	assert.ErrorIs(t, extractBackupArchive(ctx, archivePath, t.TempDir(), ""), ErrBackupPassphraseRequired)
+	assert.ErrorIs(t, extractBackupArchive(ctx, archivePath, t.TempDir(), "wrong"), ErrBackupWrongPassphrase)
+
+	// nothing readable is left in the archive, neither the contents nor the names, sizes and hashes of the files
+	zipReader, err := zip.OpenReader(archivePath)
+	require.NoError(t, err)
+	defer zipReader.Close()
+	for _, file := range zipReader.File {
+		assert.NotContains(t, file.Name, "wox.json")
+		reader, openErr := file.Open()
+		require.NoError(t, openErr)
+		data, readErr := io.ReadAll(reader)
+		require.NoError(t, readErr)
+		reader.Close()
+		assert.NotContains(t, string(data), "theme")
+		assert.NotContains(t, string(data), "wox.json")
+	}
+}
+
+func TestBackupArchiveDetectsCorruption(t *testing.T) {
+	ctx := context.Background()
+	for _, passphrase := range []string{"", "secret"} {
+		sourceDir, _ := createBackupSource(t)
+		archivePath := filepath.Join(t.TempDir(), "1"+backupFileExtension)
+		require.NoError(t, writeBackupArchive(ctx, sourceDir, archivePath, Backup{Id: "id"}, passphrase))
+
+		// rewrite the archive with a changed file and the original manifest
+		tamperedPath := filepath.Join(t.TempDir(), "tampered"+backupFileExtension)
+		zipReader, err := zip.OpenReader(archivePath)
+		require.NoError(t, err)
+		tamperedFile, err := os.Create(tamperedPath)
+		require.NoError(t, err)
+		zipWriter := zip.NewWriter(tamperedFile)
+		for _, file := range zipReader.File {
+			reader, openErr := file.Open()
+			require.NoError(t, openErr)
+			data, readErr := io.ReadAll(reader)
+			require.NoError(t, readErr)
+			reader.Close()
+			// the names of encrypted files are hidden, change every file
+			if strings.HasPrefix(file.Name, backupDataPrefix) && len(data) > 0 {
+				data[len(data)-1] ^= 0xff
+			}
+			writer, createErr := zipWriter.CreateHeader(&zip.FileHeader{Name: file.Name, Method: file.Method})
+			require.NoError(t, createErr)
+			_, writeErr := writer.Write(data)
+			require.NoError(t, writeErr)
+		}
+		require.NoError(t, zipWriter.Close())
+		require.NoError(t, tamperedFile.Close())
+		zipReader.Close()
+
+		err = extractBackupArchive(ctx, tamperedPath, t.TempDir(), passphrase)
+		assert.ErrorContains(t, err, "backup is corrupted")
+	}
+}
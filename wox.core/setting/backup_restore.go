@@ -9,6 +9,7 @@ import (
 	"slices"
 	"strings"
 	"time"
+	"wox/database"
 	"wox/util"
 
 	"github.com/google/uuid"
@@ -25,10 +26,12 @@ const (
 
 type Backup struct {
 	Id        string
-	Name      string // backup folder name
+	Name      string // backup file name without extension
 	Timestamp int64
 	Type      BackupType
 	Path      string // backup file path
+	Encrypted bool
+	Legacy    bool // backup folder of older versions, it has no checksums
 }
 
 func (m *Manager) StartAutoBackup(ctx context.Context) {
@@ -54,47 +57,30 @@ func (m *Manager) StartAutoBackup(ctx context.Context) {
 	})
 }
 
+// Backup packs the user data directory into a verifiable archive, it is encrypted when a backup passphrase is set
 func (m *Manager) Backup(ctx context.Context, backupType BackupType) error {
 	logger.Info(ctx, fmt.Sprintf("backing up data: %s", backupType))
 
 	ts := util.GetSystemTimestamp()
 	backupName := fmt.Sprintf("%d", ts)
-	backupPath := path.Join(util.GetLocation().GetBackupDirectory(), backupName)
+	backupPath := path.Join(util.GetLocation().GetBackupDirectory(), backupName+backupFileExtension)
 	logger.Info(ctx, fmt.Sprintf("backup path: %s", backupPath))
 
-	err := cp.Copy(util.GetLocation().GetUserDataDirectory(), backupPath)
-	if err != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to backup data: %s", err.Error()))
-		return err
-	}
-
 	backup := Backup{
 		Id:        uuid.New().String(),
 		Name:      backupName,
 		Timestamp: ts,
 		Type:      backupType,
 	}
-	marshal, marshalErr := json.Marshal(backup)
-	if marshalErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to marshal backup data: %s", marshalErr.Error()))
-		// remove backup data
-		rmErr := os.RemoveAll(backupPath)
-		if rmErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to remove backup data: %s", rmErr.Error()))
-		}
-		return marshalErr
+	passphrase := ""
+	if woxSetting := m.GetWoxSetting(ctx); woxSetting != nil {
+		passphrase = woxSetting.BackupPassphrase.Get()
 	}
 
-	backupInfoPath := path.Join(backupPath, "backup.json")
-	writeErr := os.WriteFile(backupInfoPath, marshal, 0644)
-	if writeErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to write backup info: %s", writeErr.Error()))
-		// remove backup data
-		rmErr := os.RemoveAll(backupPath)
-		if rmErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to remove backup data: %s", rmErr.Error()))
-		}
-		return writeErr
+	err := writeBackupArchive(ctx, util.GetLocation().GetUserDataDirectory(), backupPath, backup, passphrase)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to backup data: %s", err.Error()))
+		return err
 	}
 
 	logger.Info(ctx, "backup data saved successfully")
@@ -106,7 +92,9 @@ func (m *Manager) Backup(ctx context.Context, backupType BackupType) error {
 	return nil
 }
 
-func (m *Manager) Restore(ctx context.Context, backupId string) error {
+// Restore verifies a backup and replaces the user data directory with it.
+// The passphrase of an encrypted backup defaults to the current backup passphrase.
+func (m *Manager) Restore(ctx context.Context, backupId string, passphrase string) error {
 	logger.Info(ctx, fmt.Sprintf("restoring backup data: %s", backupId))
 	backups, getErr := m.FindAllBackups(ctx)
 	if getErr != nil {
@@ -114,65 +102,115 @@ func (m *Manager) Restore(ctx context.Context, backupId string) error {
 		return getErr
 	}
 
-	var backupName string
-	for _, backup := range backups {
-		if backup.Id == backupId {
-			backupName = backup.Name
-			break
-		}
-	}
-	if backupName == "" {
+	backupIndex := slices.IndexFunc(backups, func(backup Backup) bool {
+		return backup.Id == backupId
+	})
+	if backupIndex == -1 {
 		logger.Error(ctx, fmt.Sprintf("backup not found: %s", backupId))
 		return fmt.Errorf("backup not found: %s", backupId)
 	}
+	backup := backups[backupIndex]
 
-	// backup current data to temp directory
-	tempBackupName := fmt.Sprintf("temp_%d", util.GetSystemTimestamp())
-	tempBackupPath := path.Join(util.GetLocation().GetWoxDataDirectory(), tempBackupName)
-	cpErr := cp.Copy(util.GetLocation().GetUserDataDirectory(), tempBackupPath)
-	if cpErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to backup current data to temp directory: %s", cpErr.Error()))
-		return cpErr
+	if passphrase == "" {
+		if woxSetting := m.GetWoxSetting(ctx); woxSetting != nil {
+			passphrase = woxSetting.BackupPassphrase.Get()
+		}
 	}
 
-	// first remove current data
-	rmErr := os.Remove(util.GetLocation().GetUserDataDirectory())
-	if rmErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to remove user data directory: %s", rmErr.Error()))
-		return rmErr
+	// extract next to the user data directory, so the directories can be swapped by renaming
+	userDataDirectory := util.GetLocation().GetUserDataDirectory()
+	ts := util.GetSystemTimestamp()
+	restorePath := path.Join(path.Dir(userDataDirectory), fmt.Sprintf(".wox_restore_%d", ts))
+	previousPath := path.Join(path.Dir(userDataDirectory), fmt.Sprintf(".wox_previous_%d", ts))
+
+	var extractErr error
+	if backup.Legacy {
+		logger.Warn(ctx, "restoring a legacy backup, it has no checksums to verify")
+		extractErr = cp.Copy(backup.Path, restorePath)
+		if extractErr == nil {
+			extractErr = os.Remove(path.Join(restorePath, "backup.json"))
+		}
+	} else {
+		extractErr = extractBackupArchive(ctx, backup.Path, restorePath, passphrase)
 	}
+	if extractErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to extract backup: %s", extractErr.Error()))
+		if rmErr := os.RemoveAll(restorePath); rmErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to remove extracted backup: %s", rmErr.Error()))
+		}
+		return extractErr
+	}
+
+	m.restoreLock.Lock()
+	defer m.restoreLock.Unlock()
 
-	backupPath := path.Join(util.GetLocation().GetBackupDirectory(), backupName)
-	cpErr = cp.Copy(backupPath, util.GetLocation().GetUserDataDirectory())
-	if cpErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to restore backup data to user data directory: %s", cpErr.Error()))
-		return cpErr
+	// nothing may use the user data directory while it is swapped
+	if quiesceErr := m.quiesce(ctx); quiesceErr != nil {
+		logger.Error(ctx, quiesceErr.Error())
+		os.RemoveAll(restorePath)
+		return quiesceErr
+	}
+	if closeErr := database.Close(); closeErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to close database: %s", closeErr.Error()))
+		m.reload(ctx, false)
+		os.RemoveAll(restorePath)
+		return closeErr
 	}
 
-	// remove backup info if exists
-	backupInfoPath := path.Join(backupPath, "backup.json")
-	if _, statErr := os.Stat(backupInfoPath); !os.IsNotExist(statErr) {
-		rmErr = os.Remove(backupInfoPath)
-		if rmErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to remove backup info: %s", rmErr.Error()))
-			return rmErr
+	if renameErr := os.Rename(userDataDirectory, previousPath); renameErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to move current user data directory: %s", renameErr.Error()))
+		m.reopen(ctx, false)
+		os.RemoveAll(restorePath)
+		return renameErr
+	}
+	if renameErr := os.Rename(restorePath, userDataDirectory); renameErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to move restored data to user data directory: %s", renameErr.Error()))
+		if rollbackErr := os.Rename(previousPath, userDataDirectory); rollbackErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to roll back user data directory, previous data is kept at %s: %s", previousPath, rollbackErr.Error()))
 		}
+		m.reopen(ctx, false)
+		os.RemoveAll(restorePath)
+		return renameErr
+	}
+
+	if reopenErr := m.reopen(ctx, true); reopenErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to load restored data, rolling back: %s", reopenErr.Error()))
+		m.rollbackRestore(ctx, previousPath, restorePath)
+		return fmt.Errorf("failed to load restored data, previous data is kept: %w", reopenErr)
 	}
 
-	// remove temp backup data
-	rmErr = os.RemoveAll(tempBackupPath)
-	if rmErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to remove temp backup data: %s", rmErr.Error()))
-		return rmErr
+	// remove previous data
+	if rmErr := os.RemoveAll(previousPath); rmErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to remove previous user data: %s", rmErr.Error()))
 	}
 
 	logger.Info(ctx, "backup data restored successfully")
+	return nil
+}
 
-	//TODO: reload data / plugins
+// rollbackRestore puts the previous user data directory back after the restored data failed to load
+func (m *Manager) rollbackRestore(ctx context.Context, previousPath string, failedPath string) {
+	userDataDirectory := util.GetLocation().GetUserDataDirectory()
 
-	return nil
+	if quiesceErr := m.quiesce(ctx); quiesceErr != nil {
+		logger.Error(ctx, quiesceErr.Error())
+	}
+	if closeErr := database.Close(); closeErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to close database: %s", closeErr.Error()))
+	}
+
+	if renameErr := os.Rename(userDataDirectory, failedPath); renameErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to move restored data away, previous data is kept at %s: %s", previousPath, renameErr.Error()))
+	} else if renameErr := os.Rename(previousPath, userDataDirectory); renameErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to roll back user data directory, previous data is kept at %s: %s", previousPath, renameErr.Error()))
+	} else {
+		os.RemoveAll(failedPath)
+	}
+
+	m.reopen(ctx, false)
 }
 
+// FindAllBackups lists the backup archives, and the backup folders of older versions
 func (m *Manager) FindAllBackups(ctx context.Context) ([]Backup, error) {
 	var backupList []Backup = make([]Backup, 0)
 
@@ -184,7 +222,21 @@ func (m *Manager) FindAllBackups(ctx context.Context) ([]Backup, error) {
 	}
 
 	for _, entry := range backupDirEntries {
-		if strings.HasPrefix(entry.Name(), "temp_") {
+		if strings.HasPrefix(entry.Name(), "temp_") || strings.HasPrefix(entry.Name(), "snapshot_") {
+			continue
+		}
+
+		if !entry.IsDir() {
+			if !strings.HasSuffix(entry.Name(), backupFileExtension) {
+				continue
+			}
+			manifest, readErr := readBackupManifest(path.Join(backupDir, entry.Name()))
+			if readErr != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to read backup manifest of %s: %s", entry.Name(), readErr.Error()))
+				continue
+			}
+			manifest.Backup.Path = path.Join(backupDir, entry.Name())
+			backupList = append(backupList, manifest.Backup)
 			continue
 		}
 
@@ -204,6 +256,7 @@ func (m *Manager) FindAllBackups(ctx context.Context) ([]Backup, error) {
 		}
 
 		backupInfo.Path = path.Join(backupDir, entry.Name())
+		backupInfo.Legacy = true
 		backupList = append(backupList, backupInfo)
 	}
 
@@ -234,8 +287,7 @@ func (m *Manager) cleanBackups(ctx context.Context) error {
 	removedCount := 0
 	for i := 0; i < len(backups)-maxBackups; i++ {
 		backup := backups[i]
-		backupPath := path.Join(util.GetLocation().GetBackupDirectory(), backup.Name)
-		rmErr := os.RemoveAll(backupPath)
+		rmErr := os.RemoveAll(backup.Path)
 		if rmErr != nil {
 			logger.Error(ctx, fmt.Sprintf("failed to remove backup: %s", rmErr.Error()))
 			continue
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
+	"wox/setting/validator"
 	"wox/util"
 
 	"github.com/tidwall/gjson"
@@ -38,6 +40,7 @@ type PluginSettingDefinitionItem struct {
 	Value               PluginSettingDefinitionValue
 	DisabledInPlatforms []util.Platform
 	IsPlatformSpecific  bool // if true, this setting may be different in different platforms
+	IsSecret            bool // if true, the value is kept in the OS keyring instead of the database. For a table, mark the secret columns instead
 }
 
 type PluginSettingValueStyle struct {
@@ -63,6 +66,8 @@ func (n *PluginSettingDefinitionItem) UnmarshalJSON(b []byte) error {
 		}
 	}
 
+	n.IsSecret = gjson.GetBytes(b, "IsSecret").Bool()
+
 	switch value.String() {
 	case "head":
 		n.Type = PluginSettingDefinitionTypeHead
@@ -130,6 +135,35 @@ func (n *PluginSettingDefinitionItem) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// Validate checks a stored value of this setting against its validators, the same way as the setting view does
+func (n *PluginSettingDefinitionItem) Validate(value string) error {
+	switch v := n.Value.(type) {
+	case *PluginSettingValueTextBox:
+		return validateValue(v.Validators, value)
+	case *PluginSettingValueSelect:
+		return validateValue(v.Validators, value)
+	case *PluginSettingValueSelectAIModel:
+		return validateValue(v.Validators, value)
+	case *PluginSettingValueCheckBox:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.New("value must be true or false")
+		}
+	case *PluginSettingValueTable:
+		return v.validateRows(value)
+	}
+
+	return nil
+}
+
+func validateValue(validators []validator.PluginSettingValidator, value string) error {
+	for _, v := range validators {
+		if err := v.Validate(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type PluginSettingDefinitions []PluginSettingDefinitionItem
 
 func (c PluginSettingDefinitions) ToMap() map[string]string {
@@ -142,6 +176,29 @@ func (c PluginSettingDefinitions) ToMap() map[string]string {
 	return m
 }
 
+// GetSecretFields returns the secrets of the settings by setting key, "" means the whole value is a secret,
+// any other field is a secret column of a table
+func (c PluginSettingDefinitions) GetSecretFields() map[string][]string {
+	fields := make(map[string][]string)
+	for _, item := range c {
+		if item.Value == nil {
+			continue
+		}
+		if item.IsSecret {
+			fields[item.Value.GetKey()] = []string{""}
+			continue
+		}
+		if table, ok := item.Value.(*PluginSettingValueTable); ok {
+			for _, column := range table.Columns {
+				if column.IsSecret {
+					fields[item.Value.GetKey()] = append(fields[item.Value.GetKey()], column.Key)
+				}
+			}
+		}
+	}
+	return fields
+}
+
 func (c PluginSettingDefinitions) GetDefaultValue(key string) (string, bool) {
 	for _, item := range c {
 		if item.Value.GetKey() == key {
@@ -2,6 +2,8 @@ package definition
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"wox/setting/validator"
 )
 
@@ -48,6 +50,7 @@ type PluginSettingValueTableColumn struct {
 	TextMaxLines  int                                // Only used when Type is PluginSettingValueTableColumnTypeText
 	HideInTable   bool                               // Hide this column in the table, but still show it in the setting dialog
 	HideInUpdate  bool                               // Hide this column in the update/add dialog, but still show it in the table
+	IsSecret      bool                               // Keep the values of this column in the OS keyring instead of the database
 }
 
 func (p *PluginSettingValueTable) GetPluginSettingType() PluginSettingDefinitionType {
@@ -74,3 +77,49 @@ func (p *PluginSettingValueTable) Translate(translator func(ctx context.Context,
 	}
 	return &copy
 }
+
+// validateRows checks every cell of the stored rows against the validators of its column
+func (p *PluginSettingValueTable) validateRows(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(value), &rows); err != nil {
+		return fmt.Errorf("value must be a list of rows: %w", err)
+	}
+
+	for i, row := range rows {
+		for _, column := range p.Columns {
+			if len(column.Validators) == 0 {
+				continue
+			}
+
+			// a text list is valid when every text is valid, an empty one is validated as empty text
+			var cellValues []string
+			switch cell := row[column.Key].(type) {
+			case nil:
+				cellValues = []string{""}
+			case string:
+				cellValues = []string{cell}
+			case []any:
+				if len(cell) == 0 {
+					cellValues = []string{""}
+				}
+				for _, item := range cell {
+					cellValues = append(cellValues, fmt.Sprint(item))
+				}
+			default:
+				cellValues = []string{fmt.Sprint(cell)}
+			}
+
+			for _, cellValue := range cellValues {
+				if err := validateValue(column.Validators, cellValue); err != nil {
+					return fmt.Errorf("row %d column %s: %w", i+1, column.Key, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,423 @@
+package setting
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"wox/database"
+
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+const dotfileVersion = 1
+
+type DotfileSecretMode = string
+
+const (
+	DotfileSecretModeInclude DotfileSecretMode = "include"
+	DotfileSecretModeRedact  DotfileSecretMode = "redact" // secrets are left out, an import keeps the current secrets
+	DotfileSecretModeEnv     DotfileSecretMode = "env"    // secrets are replaced by env var references, e.g. "${WOX_OPENAI_API_KEY}"
+)
+
+// dotfileSkippedSettingKeys are usage state rather than configuration, they are not in the dotfile besides the local only settings
+var dotfileSkippedSettingKeys = []string{"PinedResults"}
+
+var envReferencePattern = regexp.MustCompile(`^\$\{(\w+)\}$`)
+
+// Dotfile is the human editable form of the settings, the keys are the same as the stored keys
+type Dotfile struct {
+	Version  int                       `yaml:"version"`
+	Settings map[string]any            `yaml:"settings,omitempty"`
+	Plugins  map[string]map[string]any `yaml:"plugins,omitempty"` // plugin id => setting key => value
+}
+
+// DotfileChange is a setting changed by a dotfile import
+type DotfileChange struct {
+	PluginId string // empty for Wox settings
+	Key      string
+	OldValue string // empty when the setting is not saved yet, secrets are masked
+	NewValue string
+}
+
+type dotfileUpdate struct {
+	change       DotfileChange
+	settingValue anySettingValue // nil for plugin settings
+	value        any             // typed value of a Wox setting, stored value of a plugin setting
+}
+
+// exportDotfile writes the Wox settings and all saved plugin settings as yaml
+func exportDotfile(db *gorm.DB, woxSetting *WoxSetting, secretMode DotfileSecretMode) ([]byte, error) {
+	dotfile := Dotfile{Version: dotfileVersion, Settings: map[string]any{}, Plugins: map[string]map[string]any{}}
+
+	for key, settingValue := range dotfileSettingValues(woxSetting) {
+		value, err := toDotfileValue(settingValue.getAny())
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", key, err)
+		}
+		if key == "AIProviders" {
+			value = exportAIProviderSecrets(value, secretMode)
+		}
+		dotfile.Settings[key] = value
+	}
+
+	var pluginSettings []database.PluginSetting
+	if err := db.Find(&pluginSettings).Error; err != nil {
+		return nil, err
+	}
+	for _, pluginSetting := range pluginSettings {
+		if isLocalOnlyPluginSetting(pluginSetting.PluginID, pluginSetting.Key) {
+			continue
+		}
+		if _, ok := dotfile.Plugins[pluginSetting.PluginID]; !ok {
+			dotfile.Plugins[pluginSetting.PluginID] = map[string]any{}
+		}
+		dotfile.Plugins[pluginSetting.PluginID][pluginSetting.Key] = pluginSettingToDotfileValue(pluginSetting.Value)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(dotfile); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// planDotfile validates a dotfile and returns the settings it changes, settings missing in the dotfile are kept.
+// All invalid settings are reported at once, so they can be fixed in one go.
+func planDotfile(db *gorm.DB, woxSetting *WoxSetting, data []byte, validatePluginSetting func(pluginId string, key string, value string) error) ([]dotfileUpdate, error) {
+	var dotfile Dotfile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&dotfile); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse dotfile: %w", err)
+	}
+	if dotfile.Version > dotfileVersion {
+		return nil, errors.New("dotfile is written by a newer version of Wox")
+	}
+
+	var updates []dotfileUpdate
+	var errs []error
+
+	settingValues := dotfileSettingValues(woxSetting)
+	for _, key := range sortedKeys(dotfile.Settings) {
+		settingValue, ok := settingValues[key]
+		if !ok {
+			if slices.Contains(localOnlySettingKeys, key) || slices.Contains(dotfileSkippedSettingKeys, key) {
+				errs = append(errs, fmt.Errorf("setting %s can't be imported", key))
+			} else {
+				errs = append(errs, fmt.Errorf("unknown setting %s", key))
+			}
+			continue
+		}
+
+		update, err := planWoxSetting(woxSetting, settingValue, dotfile.Settings[key])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("setting %s: %w", key, err))
+			continue
+		}
+		if update != nil {
+			updates = append(updates, *update)
+		}
+	}
+
+	for _, pluginId := range sortedKeys(dotfile.Plugins) {
+		for _, key := range sortedKeys(dotfile.Plugins[pluginId]) {
+			if isLocalOnlyPluginSetting(pluginId, key) {
+				errs = append(errs, fmt.Errorf("plugin %s setting %s can't be imported", pluginId, key))
+				continue
+			}
+			update, err := planPluginSetting(db, pluginId, key, dotfile.Plugins[pluginId][key], validatePluginSetting)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("plugin %s setting %s: %w", pluginId, key, err))
+				continue
+			}
+			if update != nil {
+				updates = append(updates, *update)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return updates, nil
+}
+
+func planWoxSetting(woxSetting *WoxSetting, settingValue anySettingValue, value any) (*dotfileUpdate, error) {
+	current := settingValue.getAny()
+	currentValue, err := toDotfileValue(current)
+	if err != nil {
+		return nil, err
+	}
+
+	if settingValue.getKey() == "AIProviders" {
+		value, err = importAIProviderSecrets(value, woxSetting.AIProviders.Get())
+		if err != nil {
+			return nil, err
+		}
+	}
+	// a platform value may be given for some platforms only, the others keep their values
+	if currentFields, ok := currentValue.(map[string]any); ok {
+		if fields, ok := value.(map[string]any); ok {
+			value = lo.Assign(currentFields, fields)
+		}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	typedValue, err := settingValue.parseAny(data)
+	if err != nil {
+		return nil, err
+	}
+
+	oldValue, err := serializeValue(current)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := serializeValue(typedValue)
+	if err != nil {
+		return nil, err
+	}
+	if oldValue == newValue {
+		return nil, nil
+	}
+
+	if settingValue.getKey() == "AIProviders" {
+		oldValue = maskAIProviderApiKeys(oldValue)
+		newValue = maskAIProviderApiKeys(newValue)
+	}
+	return &dotfileUpdate{
+		change:       DotfileChange{Key: settingValue.getKey(), OldValue: oldValue, NewValue: newValue},
+		settingValue: settingValue,
+		value:        typedValue,
+	}, nil
+}
+
+func planPluginSetting(db *gorm.DB, pluginId string, key string, value any, validatePluginSetting func(pluginId string, key string, value string) error) (*dotfileUpdate, error) {
+	newValue, err := pluginSettingFromDotfileValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if validatePluginSetting != nil {
+		if err := validatePluginSetting(pluginId, key, newValue); err != nil {
+			return nil, err
+		}
+	}
+
+	var existing []database.PluginSetting
+	if err := db.Where("plugin_id = ? AND key = ?", pluginId, key).Limit(1).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	oldValue := ""
+	if len(existing) > 0 {
+		oldValue = existing[0].Value
+		if isSameStoredValue(oldValue, newValue) {
+			return nil, nil
+		}
+	}
+
+	return &dotfileUpdate{
+		change: DotfileChange{PluginId: pluginId, Key: key, OldValue: oldValue, NewValue: newValue},
+		value:  newValue,
+	}, nil
+}
+
+// applyDotfile saves the planned settings, they are recorded in the oplog like any other change
+func applyDotfile(db *gorm.DB, updates []dotfileUpdate) ([]SettingChange, error) {
+	var changes []SettingChange
+	for _, update := range updates {
+		if update.settingValue != nil {
+			if err := update.settingValue.setAny(update.value); err != nil {
+				return changes, fmt.Errorf("failed to save setting %s: %w", update.change.Key, err)
+			}
+			value, _ := serializeValue(update.value) // NewValue may be masked
+			changes = append(changes, SettingChange{Key: update.change.Key, Value: value})
+			continue
+		}
+
+		value := update.value.(string)
+		if err := NewPluginSettingStore(db, update.change.PluginId).Set(update.change.Key, value); err != nil {
+			return changes, fmt.Errorf("failed to save setting %s of plugin %s: %w", update.change.Key, update.change.PluginId, err)
+		}
+		changes = append(changes, SettingChange{PluginId: update.change.PluginId, Key: update.change.Key, Value: value})
+	}
+	return changes, nil
+}
+
+// dotfileSettingValues returns the Wox settings that are in the dotfile by key
+func dotfileSettingValues(woxSetting *WoxSetting) map[string]anySettingValue {
+	settingValues := map[string]anySettingValue{}
+	fields := reflect.ValueOf(woxSetting).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		if !field.CanInterface() || field.IsNil() {
+			continue
+		}
+		settingValue, ok := field.Interface().(anySettingValue)
+		if !ok {
+			continue
+		}
+		key := settingValue.getKey()
+		if slices.Contains(localOnlySettingKeys, key) || slices.Contains(dotfileSkippedSettingKeys, key) {
+			continue
+		}
+		settingValues[key] = settingValue
+	}
+	return settingValues
+}
+
+// toDotfileValue converts a setting value to plain maps, slices and scalars, the way it is stored
+func toDotfileValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var dotfileValue any
+	if err := json.Unmarshal(data, &dotfileValue); err != nil {
+		return nil, err
+	}
+	return dotfileValue, nil
+}
+
+// pluginSettingToDotfileValue shows a json value, e.g. the rows of a table setting, as yaml instead of a json string
+func pluginSettingToDotfileValue(value string) any {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		var dotfileValue any
+		if err := json.Unmarshal([]byte(trimmed), &dotfileValue); err == nil {
+			return dotfileValue
+		}
+	}
+	return value
+}
+
+// pluginSettingFromDotfileValue converts a dotfile value back to the stored string, e.g. true => "true"
+func pluginSettingFromDotfileValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// isSameStoredValue compares json values by content, the dotfile doesn't keep the key order of json objects
+func isSameStoredValue(a string, b string) bool {
+	if a == b {
+		return true
+	}
+
+	var aValue, bValue any
+	if json.Unmarshal([]byte(a), &aValue) != nil || json.Unmarshal([]byte(b), &bValue) != nil {
+		return false
+	}
+	return reflect.DeepEqual(aValue, bValue)
+}
+
+func exportAIProviderSecrets(value any, secretMode DotfileSecretMode) any {
+	providers, _ := value.([]any)
+	for _, item := range providers {
+		provider, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		switch secretMode {
+		case DotfileSecretModeRedact:
+			delete(provider, "ApiKey")
+		case DotfileSecretModeEnv:
+			if apiKey, _ := provider["ApiKey"].(string); apiKey != "" {
+				name, _ := provider["Name"].(string)
+				provider["ApiKey"] = "${" + aiProviderApiKeyEnv(name) + "}"
+			}
+		}
+	}
+	return value
+}
+
+// importAIProviderSecrets resolves env var references, a provider without api key keeps the api key it has now
+func importAIProviderSecrets(value any, current []AIProvider) (any, error) {
+	providers, _ := value.([]any)
+	for _, item := range providers {
+		provider, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		apiKey, hasApiKey := provider["ApiKey"]
+		if !hasApiKey {
+			name, _ := provider["Name"].(string)
+			if currentProvider, found := lo.Find(current, func(p AIProvider) bool { return string(p.Name) == name }); found {
+				provider["ApiKey"] = currentProvider.ApiKey
+			}
+			continue
+		}
+
+		if apiKeyString, ok := apiKey.(string); ok {
+			if match := envReferencePattern.FindStringSubmatch(apiKeyString); match != nil {
+				envValue, found := os.LookupEnv(match[1])
+				if !found {
+					return nil, fmt.Errorf("environment variable %s is not set", match[1])
+				}
+				provider["ApiKey"] = envValue
+			}
+		}
+	}
+	return value, nil
+}
+
+// aiProviderApiKeyEnv returns the env var name of an api key, e.g. "openai" => "WOX_OPENAI_API_KEY"
+func aiProviderApiKeyEnv(name string) string {
+	envName := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(name))
+	return "WOX_" + envName + "_API_KEY"
+}
+
+func maskAIProviderApiKeys(value string) string {
+	var providers []AIProvider
+	if err := json.Unmarshal([]byte(value), &providers); err != nil {
+		return value
+	}
+	for i := range providers {
+		if providers[i].ApiKey != "" {
+			providers[i].ApiKey = "******"
+		}
+	}
+	masked, err := json.Marshal(providers)
+	if err != nil {
+		return value
+	}
+	return string(masked)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := lo.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,123 @@
+package setting
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"wox/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newDotfileTestSetting returns the settings of a new test database, saved settings are logged with a test device id
+func newDotfileTestSetting(t *testing.T) (*gorm.DB, *WoxSetting) {
+	syncDeviceIdOnce.Do(func() {
+		syncDeviceId = "test"
+	})
+	db := newSyncTestDB(t)
+	return db, NewWoxSetting(NewWoxSettingStore(db))
+}
+
+func importDotfile(t *testing.T, db *gorm.DB, woxSetting *WoxSetting, data []byte) []DotfileChange {
+	updates, err := planDotfile(db, woxSetting, data, nil)
+	require.NoError(t, err)
+	_, err = applyDotfile(db, updates)
+	require.NoError(t, err)
+	return getDotfileChanges(updates)
+}
+
+func TestDotfileExportImport(t *testing.T) {
+	dbA, woxSettingA := newDotfileTestSetting(t)
+	woxSettingA.ThemeId.Set("dark")
+	woxSettingA.QueryShortcuts.Set([]QueryShortcut{{Shortcut: "wi", Query: "wpm install {0}"}})
+	woxSettingA.AIProviders.Set([]AIProvider{{Name: "openai", ApiKey: "sk-a"}})
+	woxSettingA.LastWindowX.Set(100)
+	require.NoError(t, NewPluginSettingStore(dbA, "websearch").Set("webSearches", `[{"Keyword":"g","Urls":["https://google.com"]}]`))
+	require.NoError(t, NewPluginSettingStore(dbA, "websearch").Set("Disabled", "true"))
+	require.NoError(t, NewPluginSettingStore(dbA, "5f815d98-27f5-488d-a756-c317ea39935b").Set("favorites", `[{"Content":"copied text"}]`))
+
+	data, err := exportDotfile(dbA, woxSettingA, DotfileSecretModeRedact)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "ThemeId: dark")
+	assert.Contains(t, string(data), "Keyword: g")
+	assert.NotContains(t, string(data), "LastWindowX")
+	assert.NotContains(t, string(data), "copied text", "local only plugin settings are not exported")
+	assert.NotContains(t, string(data), "sk-a")
+
+	dbB, woxSettingB := newDotfileTestSetting(t)
+	woxSettingB.AIProviders.Set([]AIProvider{{Name: "openai", ApiKey: "sk-b"}})
+	importDotfile(t, dbB, woxSettingB, data)
+	assert.Equal(t, "dark", woxSettingB.ThemeId.Get())
+	assert.Equal(t, woxSettingA.QueryShortcuts.Get(), woxSettingB.QueryShortcuts.Get())
+	assert.Equal(t, -1, woxSettingB.LastWindowX.Get())
+	// the redacted api key is kept
+	assert.Equal(t, []AIProvider{{Name: "openai", ApiKey: "sk-b"}}, woxSettingB.AIProviders.Get())
+	webSearches, _ := NewPluginSetting(NewPluginSettingStore(dbB, "websearch"), "websearch", nil).Get("webSearches")
+	assert.JSONEq(t, `[{"Keyword":"g","Urls":["https://google.com"]}]`, webSearches)
+	disabled, _ := NewPluginSetting(NewPluginSettingStore(dbB, "websearch"), "websearch", nil).Get("Disabled")
+	assert.Equal(t, "true", disabled)
+
+	// importing the exported settings again changes nothing
+	assert.Empty(t, importDotfile(t, dbA, woxSettingA, data))
+}
+
+func TestDotfileSecretEnv(t *testing.T) {
+	db, woxSetting := newDotfileTestSetting(t)
+	woxSetting.AIProviders.Set([]AIProvider{{Name: "openai", ApiKey: "sk-a"}})
+
+	data, err := exportDotfile(db, woxSetting, DotfileSecretModeEnv)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "${WOX_OPENAI_API_KEY}")
+
+	_, err = planDotfile(db, woxSetting, data, nil)
+	assert.ErrorContains(t, err, "WOX_OPENAI_API_KEY is not set")
+
+	t.Setenv("WOX_OPENAI_API_KEY", "sk-env")
+	changes := importDotfile(t, db, woxSetting, data)
+	assert.Equal(t, []AIProvider{{Name: "openai", ApiKey: "sk-env"}}, woxSetting.AIProviders.Get())
+	require.Len(t, changes, 1)
+	assert.NotContains(t, changes[0].OldValue+changes[0].NewValue, "sk-")
+}
+
+func TestDotfilePlatformValue(t *testing.T) {
+	db, woxSetting := newDotfileTestSetting(t)
+	woxSetting.MainHotkey.Set("ctrl+space")
+	before := woxSetting.MainHotkey.SettingValue.Get()
+
+	importDotfile(t, db, woxSetting, []byte("settings:\n  MainHotkey:\n    MacValue: cmd+k\n"))
+
+	after := woxSetting.MainHotkey.SettingValue.Get()
+	assert.Equal(t, "cmd+k", after.MacValue)
+	assert.Equal(t, before.WinValue, after.WinValue)
+	assert.Equal(t, before.LinuxValue, after.LinuxValue)
+}
+
+func TestDotfileValidation(t *testing.T) {
+	db, woxSetting := newDotfileTestSetting(t)
+	data := []byte(strings.Join([]string{
+		"settings:",
+		"  ThemeId: dark",
+		"  LangCode: xx_XX",
+		"  AppWidth: wide",
+		"  LastWindowX: 1",
+		"  NoSuchSetting: 1",
+		"plugins:",
+		"  websearch:",
+		"    webSearches: []",
+	}, "\n"))
+
+	_, err := planDotfile(db, woxSetting, data, func(pluginId string, key string, value string) error {
+		return errors.New("value can not be empty")
+	})
+	require.Error(t, err)
+	for _, message := range []string{"setting LangCode", "setting AppWidth", "setting LastWindowX can't be imported", "unknown setting NoSuchSetting", "plugin websearch setting webSearches: value can not be empty"} {
+		assert.ErrorContains(t, err, message)
+	}
+
+	// nothing is saved when the dotfile is invalid
+	var count int64
+	require.NoError(t, db.Model(&database.WoxSetting{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 	"wox/common"
 	"wox/database"
 	"wox/util"
 	"wox/util/autostart"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/samber/lo"
+	"gorm.io/gorm"
 )
 
 var managerInstance *Manager
@@ -18,8 +21,25 @@ var managerOnce sync.Once
 var logger *util.Log
 
 type Manager struct {
-	woxSetting *WoxSetting
-	mruManager *MRUManager
+	// loaded is replaced as a whole when the settings are reloaded, e.g. by a profile switch, a sync or a restore,
+	// while other goroutines keep reading it
+	loaded atomic.Pointer[loadedSettings]
+
+	migratedPluginSecrets sync.Map // plugin id -> true once the secrets of the plugin are moved to the keyring, see migrateSecrets
+
+	restoreHooks           []RestoreHook
+	restoreLock            sync.Mutex
+	settingChangeListeners []func(ctx context.Context, changes []SettingChange)
+	pluginSettingValidator func(ctx context.Context, pluginId string, key string, value string) error
+	dotfileWatcher         *fsnotify.Watcher
+	dotfileLock            sync.Mutex
+}
+
+// loadedSettings are the settings loaded from the database, see Manager.loadSettings
+type loadedSettings struct {
+	woxSetting   *WoxSetting
+	profileLayer *profileLayer
+	mruManager   *MRUManager
 }
 
 func GetSettingManager() *Manager {
@@ -31,14 +51,56 @@ func GetSettingManager() *Manager {
 			panic("database not initialized")
 		}
 
-		store := NewWoxSettingStore(db)
 		managerInstance = &Manager{}
-		managerInstance.woxSetting = NewWoxSetting(store)
-		managerInstance.mruManager = NewMRUManager(db)
+		managerInstance.migrateSecrets(db)
+		managerInstance.loadSettings(db)
 	})
 	return managerInstance
 }
 
+// migrateSecrets moves the secrets still stored in the database to the keyring, it runs once for every database opened,
+// i.e. at startup and after a restore, not on every reload of the settings. Plugin secrets are moved when a plugin setting is first loaded.
+func (m *Manager) migrateSecrets(db *gorm.DB) {
+	m.migratedPluginSecrets.Clear()
+	if migrated, err := NewWoxSettingStore(db).migrateSecrets(); err != nil {
+		logger.Error(util.NewTraceContext(), fmt.Sprintf("failed to move secrets to keyring: %s", err.Error()))
+	} else if migrated > 0 {
+		logger.Info(util.NewTraceContext(), fmt.Sprintf("moved secrets of %d settings to keyring", migrated))
+	}
+}
+
+func (m *Manager) loadSettings(db *gorm.DB) {
+	woxSetting, layer := newProfileWoxSetting(NewWoxSettingStore(db))
+	m.loaded.Store(&loadedSettings{
+		woxSetting:   woxSetting,
+		profileLayer: layer,
+		mruManager:   NewMRUManager(db),
+	})
+}
+
+// current returns the settings loaded last, they are empty before the first load
+func (m *Manager) current() *loadedSettings {
+	if loaded := m.loaded.Load(); loaded != nil {
+		return loaded
+	}
+	return &loadedSettings{}
+}
+
+// RegisterSettingChangeListener is notified of the settings changed outside of the setting view, by a sync or a dotfile import
+func (m *Manager) RegisterSettingChangeListener(listener func(ctx context.Context, changes []SettingChange)) {
+	m.restoreLock.Lock()
+	defer m.restoreLock.Unlock()
+
+	m.settingChangeListeners = append(m.settingChangeListeners, listener)
+}
+
+// notifySettingChanges must be called with restoreLock held
+func (m *Manager) notifySettingChanges(ctx context.Context, changes []SettingChange) {
+	for _, listener := range m.settingChangeListeners {
+		listener(ctx, changes)
+	}
+}
+
 func (m *Manager) Init(ctx context.Context) error {
 	m.StartAutoBackup(ctx)
 
@@ -55,7 +117,7 @@ func (m *Manager) checkAutostart(ctx context.Context) error {
 		return fmt.Errorf("failed to check autostart status: %w", err)
 	}
 
-	configAutostart := m.woxSetting.EnableAutostart.Get()
+	configAutostart := m.current().woxSetting.EnableAutostart.Get()
 	if actualAutostart != configAutostart {
 		util.GetLogger().Warn(ctx, fmt.Sprintf("Autostart setting mismatch: config %v, actual %v", configAutostart, actualAutostart))
 
@@ -63,7 +125,7 @@ func (m *Manager) checkAutostart(ctx context.Context) error {
 			util.GetLogger().Info(ctx, "Attempting to fix autostart configuration...")
 			if err := autostart.SetAutostart(ctx, true); err != nil {
 				util.GetLogger().Error(ctx, fmt.Sprintf("Failed to fix autostart: %s", err.Error()))
-				m.woxSetting.EnableAutostart.Set(false)
+				m.current().woxSetting.EnableAutostart.Set(false)
 			} else {
 				util.GetLogger().Info(ctx, "Autostart configuration fixed successfully")
 			}
@@ -71,7 +133,7 @@ func (m *Manager) checkAutostart(ctx context.Context) error {
 			// This case is less common, but we can ensure it's disabled if config says so.
 			if err := autostart.SetAutostart(ctx, false); err != nil {
 				util.GetLogger().Error(ctx, fmt.Sprintf("Failed to disable autostart: %s", err.Error()))
-				m.woxSetting.EnableAutostart.Set(true) // Revert setting if action fails
+				m.current().woxSetting.EnableAutostart.Set(true) // Revert setting if action fails
 			}
 		}
 	}
@@ -79,11 +141,11 @@ func (m *Manager) checkAutostart(ctx context.Context) error {
 }
 
 func (m *Manager) GetWoxSetting(ctx context.Context) *WoxSetting {
-	return m.woxSetting
+	return m.current().woxSetting
 }
 
 func (m *Manager) GetLatestQueryHistory(ctx context.Context, limit int) []QueryHistory {
-	histories := m.woxSetting.QueryHistories.Get()
+	histories := m.current().woxSetting.QueryHistories.Get()
 
 	// Sort by timestamp descending and limit results
 	var result []QueryHistory
@@ -96,9 +158,19 @@ func (m *Manager) GetLatestQueryHistory(ctx context.Context, limit int) []QueryH
 	return result
 }
 
-func (m *Manager) LoadPluginSetting(ctx context.Context, pluginId string, defaultSettings map[string]string) (*PluginSetting, error) {
+// LoadPluginSetting loads the setting of a plugin, secretFields are the secrets of the plugin settings which are kept in the OS keyring
+func (m *Manager) LoadPluginSetting(ctx context.Context, pluginId string, defaultSettings map[string]string, secretFields map[string][]string) (*PluginSetting, error) {
+	pluginSecretFields.Store(pluginId, secretFields)
 	pluginSettingStore := NewPluginSettingStore(database.GetDB(), pluginId)
-	pluginSetting := NewPluginSetting(pluginSettingStore, defaultSettings)
+	if _, done := m.migratedPluginSecrets.LoadOrStore(pluginId, true); !done {
+		if migrated, err := pluginSettingStore.migrateSecrets(); err != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to move secrets of plugin %s to keyring: %s", pluginId, err.Error()))
+		} else if migrated > 0 {
+			logger.Info(ctx, fmt.Sprintf("moved secrets of %d settings of plugin %s to keyring", migrated, pluginId))
+		}
+	}
+
+	pluginSetting := NewPluginSetting(newProfileSettingStore(pluginSettingStore, pluginId, m.current().profileLayer), pluginId, defaultSettings)
 	return pluginSetting, nil
 }
 
@@ -109,7 +181,7 @@ func (m *Manager) AddActionedResult(ctx context.Context, pluginId string, result
 		Query:     query,
 	}
 
-	actionedResults := m.woxSetting.ActionedResults.Get()
+	actionedResults := m.current().woxSetting.ActionedResults.Get()
 	if v, ok := actionedResults.Load(resultHash); ok {
 		v = append(v, actionedResult)
 		if len(v) > 100 {
@@ -119,32 +191,32 @@ func (m *Manager) AddActionedResult(ctx context.Context, pluginId string, result
 	} else {
 		actionedResults.Store(resultHash, []ActionedResult{actionedResult})
 	}
-	m.woxSetting.ActionedResults.Set(actionedResults)
+	m.current().woxSetting.ActionedResults.Set(actionedResults)
 }
 
 func (m *Manager) PinResult(ctx context.Context, pluginId string, resultTitle string, resultSubTitle string) {
 	util.GetLogger().Info(ctx, fmt.Sprintf("pin result: %s, %s", resultTitle, resultSubTitle))
 	resultHash := NewResultHash(pluginId, resultTitle, resultSubTitle)
-	results := m.woxSetting.PinedResults.Get()
+	results := m.current().woxSetting.PinedResults.Get()
 	results.Store(resultHash, true)
-	m.woxSetting.PinedResults.Set(results)
+	m.current().woxSetting.PinedResults.Set(results)
 }
 
 func (m *Manager) IsPinedResult(ctx context.Context, pluginId string, resultTitle string, resultSubTitle string) bool {
 	resultHash := NewResultHash(pluginId, resultTitle, resultSubTitle)
-	return m.woxSetting.PinedResults.Get().Exist(resultHash)
+	return m.current().woxSetting.PinedResults.Get().Exist(resultHash)
 }
 
 func (m *Manager) UnpinResult(ctx context.Context, pluginId string, resultTitle string, resultSubTitle string) {
 	util.GetLogger().Info(ctx, fmt.Sprintf("unpin result: %s, %s", resultTitle, resultSubTitle))
 	resultHash := NewResultHash(pluginId, resultTitle, resultSubTitle)
-	results := m.woxSetting.PinedResults.Get()
+	results := m.current().woxSetting.PinedResults.Get()
 	results.Delete(resultHash)
-	m.woxSetting.PinedResults.Set(results)
+	m.current().woxSetting.PinedResults.Set(results)
 }
 
 func (m *Manager) AddQueryHistory(ctx context.Context, query common.PlainQuery) {
-	histories := m.woxSetting.QueryHistories.Get()
+	histories := m.current().woxSetting.QueryHistories.Get()
 	newHistory := QueryHistory{
 		Query:     query,
 		Timestamp: util.GetSystemTimestamp(),
@@ -163,25 +235,25 @@ func (m *Manager) AddQueryHistory(ctx context.Context, query common.PlainQuery)
 		histories = histories[len(histories)-1000:]
 	}
 
-	m.woxSetting.QueryHistories.Set(histories)
+	m.current().woxSetting.QueryHistories.Set(histories)
 }
 
 // MRU related methods
 
 func (m *Manager) AddMRUItem(ctx context.Context, item MRUItem) error {
-	return m.mruManager.AddMRUItem(ctx, item)
+	return m.current().mruManager.AddMRUItem(ctx, item)
 }
 
 func (m *Manager) GetMRUItems(ctx context.Context, limit int) ([]MRUItem, error) {
-	return m.mruManager.GetMRUItems(ctx, limit)
+	return m.current().mruManager.GetMRUItems(ctx, limit)
 }
 
 func (m *Manager) RemoveMRUItem(ctx context.Context, pluginID, title, subTitle string) error {
-	return m.mruManager.RemoveMRUItem(ctx, pluginID, title, subTitle)
+	return m.current().mruManager.RemoveMRUItem(ctx, pluginID, title, subTitle)
 }
 
 func (m *Manager) CleanupOldMRUItems(ctx context.Context, keepCount int) error {
-	return m.mruManager.CleanupOldMRUItems(ctx, keepCount)
+	return m.current().mruManager.CleanupOldMRUItems(ctx, keepCount)
 }
 
 // StartMRUCleanup starts a background goroutine to periodically clean up old MRU items
@@ -20,17 +20,17 @@ type PluginSetting struct {
 	// So don't use this directly, use Instance.GetQueryCommands instead
 	QueryCommands *PluginSettingValue[[]PluginQueryCommand]
 
-	store                     *PluginSettingStore
+	store                     SettingStore
 	defaultSettingsInMetadata map[string]string
 }
 
-func NewPluginSetting(store *PluginSettingStore, defaultSettingsInMetadata map[string]string) *PluginSetting {
+func NewPluginSetting(store SettingStore, pluginId string, defaultSettingsInMetadata map[string]string) *PluginSetting {
 	return &PluginSetting{
 		store:                     store,
 		defaultSettingsInMetadata: defaultSettingsInMetadata,
-		Disabled:                  NewPluginSettingValue(store, "Disabled", false),
-		TriggerKeywords:           NewPluginSettingValue(store, "TriggerKeywords", []string{}),
-		QueryCommands:             NewPluginSettingValue(store, "QueryCommands", []PluginQueryCommand{}),
+		Disabled:                  NewPluginSettingValue(store, pluginId, "Disabled", false),
+		TriggerKeywords:           NewPluginSettingValue(store, pluginId, "TriggerKeywords", []string{}),
+		QueryCommands:             NewPluginSettingValue(store, pluginId, "QueryCommands", []PluginQueryCommand{}),
 	}
 }
 
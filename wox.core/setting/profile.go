@@ -0,0 +1,301 @@
+package setting
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SettingProfile is a named set of settings that replace the settings while the profile is active, e.g. "work" or "presentation"
+type SettingProfile struct {
+	Name           string
+	Settings       map[string]string            // overridden Wox settings by key in their stored form, see profileWoxSettingKeys
+	PluginSettings map[string]map[string]string // overridden plugin settings by plugin id and key, see profilePluginSettingKeys
+	Rules          []SettingProfileRule         // the profile is switched to when all rules match, a profile without rules is only switched to by hand
+}
+
+type SettingProfileRuleType = string
+
+const (
+	SettingProfileRuleTypeWifi    SettingProfileRuleType = "wifi"    // Value is the SSID of the connected Wi-Fi network
+	SettingProfileRuleTypeTime    SettingProfileRuleType = "time"    // Value is a time range of the day, e.g. "09:00-18:00", or "22:00-07:00" over midnight
+	SettingProfileRuleTypeDisplay SettingProfileRuleType = "display" // Value is the number of displays, e.g. "2", or the display layout, e.g. "1920x1080+2560x1440"
+)
+
+type SettingProfileRule struct {
+	Type  SettingProfileRuleType
+	Value string
+}
+
+// profileWoxSettingKeys are the Wox settings a profile can override
+var profileWoxSettingKeys = []string{"ThemeId", "QueryHotkeys", "QueryShortcuts", "HideOnLostFocus", "ShowPosition"}
+
+// profilePluginSettingKeys are the plugin settings a profile can override, so a profile can enable and disable plugins
+var profilePluginSettingKeys = []string{"Disabled"}
+
+// profileEnvironment is what the profile rules are matched against
+type profileEnvironment struct {
+	Now           time.Time
+	WifiSSID      string // empty when not connected to Wi-Fi
+	DisplayLayout string // see SettingProfileRuleTypeDisplay
+}
+
+func (p SettingProfile) getOverride(pluginId string, key string) (string, bool) {
+	if pluginId == "" {
+		value, ok := p.Settings[key]
+		return value, ok
+	}
+	value, ok := p.PluginSettings[pluginId][key]
+	return value, ok
+}
+
+func (p *SettingProfile) setOverride(pluginId string, key string, value string) {
+	if pluginId == "" {
+		p.Settings[key] = value
+		return
+	}
+	p.PluginSettings[pluginId][key] = value
+}
+
+// clone copies the overrides, the profile can be changed without changing the cached setting value
+func (p SettingProfile) clone() SettingProfile {
+	cloned := p
+	cloned.Settings = maps.Clone(p.Settings)
+	cloned.PluginSettings = make(map[string]map[string]string, len(p.PluginSettings))
+	for pluginId, settings := range p.PluginSettings {
+		cloned.PluginSettings[pluginId] = maps.Clone(settings)
+	}
+	cloned.Rules = slices.Clone(p.Rules)
+	return cloned
+}
+
+func findProfile(profiles []SettingProfile, name string) (SettingProfile, bool) {
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return SettingProfile{}, false
+}
+
+func isProfileSettingKey(pluginId string, key string) bool {
+	if pluginId == "" {
+		return slices.Contains(profileWoxSettingKeys, key)
+	}
+	return slices.Contains(profilePluginSettingKeys, key)
+}
+
+// validateProfiles checks the profiles before they are saved, every problem is reported at once
+func validateProfiles(profiles []SettingProfile) error {
+	var errs []error
+	names := map[string]bool{}
+	for _, profile := range profiles {
+		if strings.TrimSpace(profile.Name) == "" {
+			errs = append(errs, errors.New("profile name is empty"))
+			continue
+		}
+		if names[profile.Name] {
+			errs = append(errs, fmt.Errorf("profile %s: name is used by another profile", profile.Name))
+		}
+		names[profile.Name] = true
+
+		for key := range profile.Settings {
+			if !isProfileSettingKey("", key) {
+				errs = append(errs, fmt.Errorf("profile %s: setting %s can't be overridden", profile.Name, key))
+			}
+		}
+		for pluginId, settings := range profile.PluginSettings {
+			for key := range settings {
+				if !isProfileSettingKey(pluginId, key) {
+					errs = append(errs, fmt.Errorf("profile %s: setting %s of plugin %s can't be overridden", profile.Name, key, pluginId))
+				}
+			}
+		}
+		for _, rule := range profile.Rules {
+			if err := rule.validate(); err != nil {
+				errs = append(errs, fmt.Errorf("profile %s: %w", profile.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r SettingProfileRule) validate() error {
+	switch r.Type {
+	case SettingProfileRuleTypeWifi, SettingProfileRuleTypeDisplay:
+		if strings.TrimSpace(r.Value) == "" {
+			return fmt.Errorf("%s rule has no value", r.Type)
+		}
+	case SettingProfileRuleTypeTime:
+		if _, _, err := parseTimeRange(r.Value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown rule type: %s", r.Type)
+	}
+	return nil
+}
+
+func (r SettingProfileRule) match(env profileEnvironment) bool {
+	switch r.Type {
+	case SettingProfileRuleTypeWifi:
+		return env.WifiSSID != "" && env.WifiSSID == strings.TrimSpace(r.Value)
+	case SettingProfileRuleTypeTime:
+		start, end, err := parseTimeRange(r.Value)
+		if err != nil {
+			return false
+		}
+		now := env.Now.Hour()*60 + env.Now.Minute()
+		if start <= end {
+			return now >= start && now < end
+		}
+		return now >= start || now < end
+	case SettingProfileRuleTypeDisplay:
+		value := strings.TrimSpace(r.Value)
+		if env.DisplayLayout == "" {
+			return false
+		}
+		if count, err := strconv.Atoi(value); err == nil {
+			return count == strings.Count(env.DisplayLayout, "+")+1
+		}
+		return value == env.DisplayLayout
+	}
+	return false
+}
+
+// matchProfile returns the first profile with rules of which all rules match, or false when no profile matches
+func matchProfile(profiles []SettingProfile, env profileEnvironment) (string, bool) {
+	for _, profile := range profiles {
+		if len(profile.Rules) == 0 {
+			continue
+		}
+		matched := true
+		for _, rule := range profile.Rules {
+			if !rule.match(env) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return profile.Name, true
+		}
+	}
+	return "", false
+}
+
+// parseTimeRange parses "HH:MM-HH:MM" into minutes of the day
+func parseTimeRange(value string) (int, int, error) {
+	startText, endText, found := strings.Cut(value, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid time range %s, expected HH:MM-HH:MM", value)
+	}
+	start, startErr := time.Parse("15:04", strings.TrimSpace(startText))
+	end, endErr := time.Parse("15:04", strings.TrimSpace(endText))
+	if startErr != nil || endErr != nil {
+		return 0, 0, fmt.Errorf("invalid time range %s, expected HH:MM-HH:MM", value)
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), nil
+}
+
+// profileLayer is the active profile, shared by the stores layered on top of the Wox setting store and the plugin setting stores
+type profileLayer struct {
+	name     string // empty when no profile is active
+	profiles *WoxSettingValue[[]SettingProfile]
+	lock     sync.Mutex
+}
+
+// getProfile returns the active profile, a profile without overrides when no profile is active
+func (l *profileLayer) getProfile() SettingProfile {
+	if l == nil || l.name == "" || l.profiles == nil {
+		return SettingProfile{}
+	}
+	profile, _ := findProfile(l.profiles.Get(), l.name)
+	return profile
+}
+
+func (l *profileLayer) getOverride(pluginId string, key string) (string, bool) {
+	if l == nil || l.name == "" || l.profiles == nil || !isProfileSettingKey(pluginId, key) {
+		return "", false
+	}
+	profile, found := findProfile(l.profiles.Get(), l.name)
+	if !found {
+		return "", false
+	}
+	return profile.getOverride(pluginId, key)
+}
+
+// setOverride saves a setting overridden by the active profile to the profile, it returns false when the profile doesn't override the setting
+func (l *profileLayer) setOverride(pluginId string, key string, value string) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.getOverride(pluginId, key); !ok {
+		return false, nil
+	}
+	profiles := slices.Clone(l.profiles.Get())
+	for i := range profiles {
+		if profiles[i].Name == l.name {
+			profiles[i] = profiles[i].clone()
+			profiles[i].setOverride(pluginId, key, value)
+		}
+	}
+	return true, l.profiles.Set(profiles)
+}
+
+// newProfileWoxSetting returns the Wox setting read through the active profile. The profiles themselves can't be overridden, they are read from the store.
+func newProfileWoxSetting(store SettingStore) (*WoxSetting, *profileLayer) {
+	layer := &profileLayer{}
+	woxSetting := NewWoxSetting(newProfileSettingStore(store, "", layer))
+	layer.profiles = woxSetting.Profiles
+	layer.name = woxSetting.ActiveProfile.Get()
+	return woxSetting, layer
+}
+
+// profileSettingStore layers the settings overridden by the active profile on top of a setting store.
+// An overridden setting is read from and saved to the profile, any other setting goes to the store below.
+type profileSettingStore struct {
+	store    SettingStore
+	pluginId string // empty when the store below is the Wox setting store
+	layer    *profileLayer
+}
+
+func newProfileSettingStore(store SettingStore, pluginId string, layer *profileLayer) *profileSettingStore {
+	return &profileSettingStore{
+		store:    store,
+		pluginId: pluginId,
+		layer:    layer,
+	}
+}
+
+func (s *profileSettingStore) Get(key string, target interface{}) error {
+	if value, ok := s.layer.getOverride(s.pluginId, key); ok {
+		return deserializeValue(value, target)
+	}
+	return s.store.Get(key, target)
+}
+
+func (s *profileSettingStore) Set(key string, value interface{}) error {
+	if isProfileSettingKey(s.pluginId, key) {
+		strValue, err := serializeValue(value)
+		if err != nil {
+			return fmt.Errorf("failed to serialize value: %w", err)
+		}
+		if overridden, err := s.layer.setOverride(s.pluginId, key, strValue); overridden || err != nil {
+			return err
+		}
+	}
+	return s.store.Set(key, value)
+}
+
+// Delete deletes the setting from the store below, the profile keeps its override
+func (s *profileSettingStore) Delete(key string) error {
+	return s.store.Delete(key)
+}
@@ -0,0 +1,108 @@
+package setting
+
+import (
+	"testing"
+	"time"
+	"wox/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileSettingStore(t *testing.T) {
+	db := newSyncTestDB(t)
+	base := NewWoxSetting(NewWoxSettingStore(db))
+	require.NoError(t, base.ThemeId.Set("light"))
+	require.NoError(t, base.HideOnLostFocus.Set(true))
+	require.NoError(t, base.Profiles.Set([]SettingProfile{{
+		Name:           "presentation",
+		Settings:       map[string]string{"ThemeId": "dark", "HideOnLostFocus": "false"},
+		PluginSettings: map[string]map[string]string{"clipboard": {"Disabled": "true"}},
+	}}))
+	require.NoError(t, base.ActiveProfile.Set("presentation"))
+
+	woxSetting, layer := newProfileWoxSetting(NewWoxSettingStore(db))
+	assert.Equal(t, "dark", woxSetting.ThemeId.Get())
+	assert.False(t, woxSetting.HideOnLostFocus.Get())
+	assert.Equal(t, 10, woxSetting.MaxResultCount.Get())
+
+	// an overridden setting is saved to the profile, the stored setting stays
+	require.NoError(t, woxSetting.ThemeId.Set("solarized"))
+	require.NoError(t, woxSetting.MaxResultCount.Set(20))
+	stored := NewWoxSetting(NewWoxSettingStore(db))
+	assert.Equal(t, "light", stored.ThemeId.Get())
+	assert.Equal(t, 20, stored.MaxResultCount.Get())
+	assert.Equal(t, "solarized", stored.Profiles.Get()[0].Settings["ThemeId"])
+
+	pluginSetting := NewPluginSetting(newProfileSettingStore(NewPluginSettingStore(db, "clipboard"), "clipboard", layer), "clipboard", nil)
+	assert.True(t, pluginSetting.Disabled.Get())
+	otherPluginSetting := NewPluginSetting(newProfileSettingStore(NewPluginSettingStore(db, "calculator"), "calculator", layer), "calculator", nil)
+	assert.False(t, otherPluginSetting.Disabled.Get())
+
+	require.NoError(t, stored.ActiveProfile.Set(""))
+	woxSetting, _ = newProfileWoxSetting(NewWoxSettingStore(db))
+	assert.Equal(t, "light", woxSetting.ThemeId.Get())
+	assert.True(t, woxSetting.HideOnLostFocus.Get())
+
+	var activeProfile database.WoxSetting
+	require.NoError(t, db.Where("key = ?", "ActiveProfile").First(&activeProfile).Error)
+	var oplogCount int64
+	require.NoError(t, db.Model(&database.Oplog{}).Where("entity_id = ?", "ActiveProfile").Count(&oplogCount).Error)
+	assert.Zero(t, oplogCount, "the active profile is chosen per device")
+}
+
+func TestProfileRules(t *testing.T) {
+	at := func(clock string) time.Time {
+		now, err := time.Parse("15:04", clock)
+		require.NoError(t, err)
+		return now
+	}
+	profiles := []SettingProfile{
+		{Name: "presentation"},
+		{Name: "work", Rules: []SettingProfileRule{{Type: SettingProfileRuleTypeWifi, Value: "office"}, {Type: SettingProfileRuleTypeTime, Value: "09:00-18:00"}}},
+		{Name: "night", Rules: []SettingProfileRule{{Type: SettingProfileRuleTypeTime, Value: "22:00-07:00"}}},
+		{Name: "desk", Rules: []SettingProfileRule{{Type: SettingProfileRuleTypeDisplay, Value: "2"}}},
+	}
+
+	matched, _ := matchProfile(profiles, profileEnvironment{Now: at("10:00"), WifiSSID: "office"})
+	assert.Equal(t, "work", matched)
+	matched, _ = matchProfile(profiles, profileEnvironment{Now: at("19:00"), WifiSSID: "office"})
+	assert.Equal(t, "", matched)
+	matched, _ = matchProfile(profiles, profileEnvironment{Now: at("06:30")})
+	assert.Equal(t, "night", matched)
+	matched, _ = matchProfile(profiles, profileEnvironment{Now: at("12:00"), DisplayLayout: "1920x1080+2560x1440"})
+	assert.Equal(t, "desk", matched)
+
+	// a profile switched to by hand stays until the rules match something else
+	env := profileEnvironment{Now: at("12:00")}
+	_, shouldSwitch, lastMatched := nextProfileByRules(profiles, "presentation", env, "", false)
+	assert.False(t, shouldSwitch)
+	env.WifiSSID = "office"
+	name, shouldSwitch, lastMatched := nextProfileByRules(profiles, "presentation", env, lastMatched, true)
+	assert.True(t, shouldSwitch)
+	assert.Equal(t, "work", name)
+	_, shouldSwitch, lastMatched = nextProfileByRules(profiles, "presentation", env, lastMatched, true)
+	assert.False(t, shouldSwitch)
+
+	// a profile switched to by its rules is left when they don't match any more
+	env.WifiSSID = ""
+	name, shouldSwitch, _ = nextProfileByRules(profiles, "work", env, lastMatched, true)
+	assert.True(t, shouldSwitch)
+	assert.Equal(t, "", name)
+}
+
+func TestProfileValidation(t *testing.T) {
+	assert.NoError(t, validateProfiles([]SettingProfile{{Name: "work", Settings: map[string]string{"ThemeId": "dark"}, Rules: []SettingProfileRule{{Type: SettingProfileRuleTypeTime, Value: "09:00-18:00"}}}}))
+
+	err := validateProfiles([]SettingProfile{
+		{Name: "work", Settings: map[string]string{"LangCode": "en_US"}},
+		{Name: "work", PluginSettings: map[string]map[string]string{"clipboard": {"TriggerKeywords": "[]"}}},
+		{Name: "night", Rules: []SettingProfileRule{{Type: SettingProfileRuleTypeTime, Value: "late"}, {Type: "battery"}}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "setting LangCode can't be overridden")
+	assert.Contains(t, err.Error(), "name is used by another profile")
+	assert.Contains(t, err.Error(), "setting TriggerKeywords of plugin clipboard can't be overridden")
+	assert.Contains(t, err.Error(), "invalid time range late")
+	assert.Contains(t, err.Error(), "unknown rule type: battery")
+}
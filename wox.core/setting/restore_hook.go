@@ -0,0 +1,85 @@
+package setting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"wox/database"
+)
+
+// RestoreHook lets a part of Wox that keeps user data in memory, or files of the user data directory open, follow a live restore.
+// Quiesce runs in reverse registration order before the user data directory is swapped, Reload runs in registration order
+// after the database is reopened and WoxSetting is reloaded. If a Reload fails, the previous user data is put back and reloaded.
+type RestoreHook struct {
+	Name    string
+	Quiesce func(ctx context.Context) error
+	Reload  func(ctx context.Context) error
+}
+
+// RegisterRestoreHook adds a hook, register in startup order so reloading a restore starts things the way Wox starts them
+func (m *Manager) RegisterRestoreHook(hook RestoreHook) {
+	m.restoreLock.Lock()
+	defer m.restoreLock.Unlock()
+
+	m.restoreHooks = append(m.restoreHooks, hook)
+}
+
+// quiesce stops every hook, if one fails the hooks stopped before it are reloaded
+func (m *Manager) quiesce(ctx context.Context) error {
+	for i := len(m.restoreHooks) - 1; i >= 0; i-- {
+		hook := m.restoreHooks[i]
+		if hook.Quiesce == nil {
+			continue
+		}
+
+		logger.Info(ctx, fmt.Sprintf("quiescing %s for restore", hook.Name))
+		if err := hook.Quiesce(ctx); err != nil {
+			for _, stoppedHook := range m.restoreHooks[i+1:] {
+				if stoppedHook.Reload == nil {
+					continue
+				}
+				if reloadErr := stoppedHook.Reload(ctx); reloadErr != nil {
+					logger.Error(ctx, fmt.Sprintf("failed to reload %s: %s", stoppedHook.Name, reloadErr.Error()))
+				}
+			}
+			return fmt.Errorf("failed to quiesce %s: %w", hook.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// reopen opens the database of the current user data directory and reloads everything from it
+func (m *Manager) reopen(ctx context.Context, stopOnError bool) error {
+	if err := database.Init(ctx); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	return m.reload(ctx, stopOnError)
+}
+
+// reload loads WoxSetting and MRU from the database and reloads every hook. Without stopOnError it keeps going after a failed hook,
+// a rollback wants as much of Wox back as possible.
+func (m *Manager) reload(ctx context.Context, stopOnError bool) error {
+	m.migrateSecrets(database.GetDB())
+	m.loadSettings(database.GetDB())
+
+	var errs []error
+	for _, hook := range m.restoreHooks {
+		if hook.Reload == nil {
+			continue
+		}
+
+		logger.Info(ctx, fmt.Sprintf("reloading %s after restore", hook.Name))
+		if err := hook.Reload(ctx); err != nil {
+			err = fmt.Errorf("failed to reload %s: %w", hook.Name, err)
+			if stopOnError {
+				return err
+			}
+			logger.Error(ctx, err.Error())
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
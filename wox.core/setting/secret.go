@@ -0,0 +1,255 @@
+package setting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"wox/util/keyring"
+)
+
+// secretRefPrefix marks a secret stored in the OS keyring, the database keeps the reference, e.g. "keyring:setting/AIProviders/openai/ApiKey"
+const secretRefPrefix = "keyring:"
+
+// secretWoxSettingFields are the Wox settings with secrets, see hideSecrets for the fields
+var secretWoxSettingFields = map[string][]string{
+	"AIProviders":         {"ApiKey"},
+	"BackupPassphrase":    {""},
+	"SettingSyncPassword": {""},
+}
+
+// pluginSecretFields holds the secret fields of the loaded plugins by plugin id, they are defined by the setting definitions of a plugin
+var pluginSecretFields sync.Map
+
+func getPluginSecretFields(pluginId string, key string) []string {
+	fields, ok := pluginSecretFields.Load(pluginId)
+	if !ok {
+		return nil
+	}
+
+	// a platform specific setting has the secret fields of its setting
+	key, _, _ = strings.Cut(key, "@")
+	return fields.(map[string][]string)[key]
+}
+
+func isSecretRef(value string) bool {
+	return strings.HasPrefix(value, secretRefPrefix)
+}
+
+// hideSecrets saves the secrets of a stored value to the keyring, and returns the value with references in place of the secrets.
+// The field "" means the value is a secret as a whole, any other field is a secret field of every item of a json list.
+// Items are referenced by name rather than by position, so a synced or imported reference never points to the secret of another item.
+func hideSecrets(scope string, value string, fields []string) (string, error) {
+	if len(fields) == 0 || value == "" {
+		return value, nil
+	}
+
+	if slices.Contains(fields, "") {
+		if isSecretRef(value) {
+			return value, nil
+		}
+		return saveSecret(scope, value)
+	}
+
+	items, ok := parseSecretItems(value)
+	if !ok {
+		return value, nil
+	}
+	changed := false
+	for i, item := range items {
+		for _, field := range fields {
+			fieldValue, exists := item[field]
+			if !exists || isEmptySecret(fieldValue) {
+				continue
+			}
+			var ref string
+			if json.Unmarshal(fieldValue, &ref) == nil && isSecretRef(ref) {
+				continue
+			}
+
+			// the field is saved as json, so a secret that is not a string, e.g. a list of env vars, keeps its type
+			ref, err := saveSecret(scope+"/"+getSecretItemId(items, i)+"/"+field, string(fieldValue))
+			if err != nil {
+				return "", err
+			}
+			item[field], _ = json.Marshal(ref)
+			changed = true
+		}
+	}
+	if !changed {
+		return value, nil
+	}
+
+	hidden, err := json.Marshal(items)
+	if err != nil {
+		return "", err
+	}
+	return string(hidden), nil
+}
+
+// revealSecrets returns a stored value with the secrets in place of their references, a missing secret is empty
+func revealSecrets(value string, fields []string) string {
+	if len(fields) == 0 || value == "" {
+		return value
+	}
+
+	if slices.Contains(fields, "") {
+		if !isSecretRef(value) {
+			return value
+		}
+		secret, _ := keyring.Get(strings.TrimPrefix(value, secretRefPrefix))
+		return secret
+	}
+
+	items, ok := parseSecretItems(value)
+	if !ok {
+		return value
+	}
+	changed := false
+	for _, item := range items {
+		for _, field := range fields {
+			var ref string
+			if json.Unmarshal(item[field], &ref) != nil || !isSecretRef(ref) {
+				continue
+			}
+
+			secret, err := keyring.Get(strings.TrimPrefix(ref, secretRefPrefix))
+			if err != nil || !json.Valid([]byte(secret)) {
+				item[field] = json.RawMessage("null")
+			} else {
+				item[field] = json.RawMessage(secret)
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return value
+	}
+
+	revealed, err := json.Marshal(items)
+	if err != nil {
+		return value
+	}
+	return string(revealed)
+}
+
+// hasPlainSecrets reports whether a stored value still has secrets in plain text, e.g. saved by an older version
+func hasPlainSecrets(value string, fields []string) bool {
+	if len(fields) == 0 || value == "" {
+		return false
+	}
+	if slices.Contains(fields, "") {
+		return !isSecretRef(value)
+	}
+
+	items, _ := parseSecretItems(value)
+	for _, item := range items {
+		for _, field := range fields {
+			fieldValue, exists := item[field]
+			if !exists || isEmptySecret(fieldValue) {
+				continue
+			}
+			var ref string
+			if json.Unmarshal(fieldValue, &ref) != nil || !isSecretRef(ref) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deleteSecrets removes the secrets referenced by a stored value from the keyring, except the ones still referenced by keptValue
+func deleteSecrets(value string, keptValue string, fields []string) error {
+	kept := getSecretRefs(keptValue, fields)
+	var errs []error
+	for _, ref := range getSecretRefs(value, fields) {
+		if slices.Contains(kept, ref) {
+			continue
+		}
+		if err := keyring.Delete(strings.TrimPrefix(ref, secretRefPrefix)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func getSecretRefs(value string, fields []string) []string {
+	if len(fields) == 0 || value == "" {
+		return nil
+	}
+	if slices.Contains(fields, "") {
+		if isSecretRef(value) {
+			return []string{value}
+		}
+		return nil
+	}
+
+	var refs []string
+	items, _ := parseSecretItems(value)
+	for _, item := range items {
+		for _, field := range fields {
+			var ref string
+			if json.Unmarshal(item[field], &ref) == nil && isSecretRef(ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+func saveSecret(key string, secret string) (string, error) {
+	if existing, err := keyring.Get(key); err != nil || existing != secret {
+		if err := keyring.Set(key, secret); err != nil {
+			return "", fmt.Errorf("failed to save secret to keyring: %w", err)
+		}
+	}
+	return secretRefPrefix + key, nil
+}
+
+func parseSecretItems(value string) ([]map[string]json.RawMessage, bool) {
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &items); err != nil {
+		return nil, false
+	}
+	return items, true
+}
+
+// getSecretItemId returns the name of an item, or its position when it has no name. Items with the same name are numbered.
+func getSecretItemId(items []map[string]json.RawMessage, index int) string {
+	name := getSecretItemName(items[index])
+	if name == "" {
+		return strconv.Itoa(index)
+	}
+
+	sameNameCount := 0
+	for i := 0; i < index; i++ {
+		if getSecretItemName(items[i]) == name {
+			sameNameCount++
+		}
+	}
+	if sameNameCount > 0 {
+		return fmt.Sprintf("%s#%d", name, sameNameCount)
+	}
+	return name
+}
+
+func getSecretItemName(item map[string]json.RawMessage) string {
+	for _, field := range []string{"Name", "name"} {
+		var name string
+		if json.Unmarshal(item[field], &name) == nil && name != "" {
+			return strings.ReplaceAll(name, "/", "_")
+		}
+	}
+	return ""
+}
+
+func isEmptySecret(value json.RawMessage) bool {
+	switch strings.TrimSpace(string(value)) {
+	case "", "null", `""`, "[]", "{}":
+		return true
+	}
+	return false
+}
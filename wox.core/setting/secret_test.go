@@ -0,0 +1,105 @@
+package setting
+
+import (
+	"testing"
+	"wox/database"
+	"wox/util/keyring"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretHideReveal(t *testing.T) {
+	newSyncTestDB(t)
+
+	hidden, err := hideSecrets("setting/AIProviders", `[{"Name":"openai","ApiKey":"sk-1"},{"Name":"openai","ApiKey":"sk-2"},{"Name":"groq","ApiKey":""}]`, []string{"ApiKey"})
+	require.NoError(t, err)
+	assert.NotContains(t, hidden, "sk-")
+	assert.Contains(t, hidden, `"keyring:setting/AIProviders/openai/ApiKey"`)
+	assert.Contains(t, hidden, `"keyring:setting/AIProviders/openai#1/ApiKey"`)
+	assert.False(t, hasPlainSecrets(hidden, []string{"ApiKey"}))
+
+	revealed := revealSecrets(hidden, []string{"ApiKey"})
+	assert.JSONEq(t, `[{"Name":"openai","ApiKey":"sk-1"},{"Name":"openai","ApiKey":"sk-2"},{"Name":"groq","ApiKey":""}]`, revealed)
+
+	// a secret that is not a string keeps its type
+	hidden, err = hideSecrets("plugin/chat/mcp", `[{"name":"fs","environmentVariables":["TOKEN=1"]}]`, []string{"environmentVariables"})
+	require.NoError(t, err)
+	assert.NotContains(t, hidden, "TOKEN")
+	assert.JSONEq(t, `[{"name":"fs","environmentVariables":["TOKEN=1"]}]`, revealSecrets(hidden, []string{"environmentVariables"}))
+
+	hidden, err = hideSecrets("setting/BackupPassphrase", "secret", []string{""})
+	require.NoError(t, err)
+	assert.Equal(t, "keyring:setting/BackupPassphrase", hidden)
+	assert.Equal(t, "secret", revealSecrets(hidden, []string{""}))
+}
+
+func TestSecretWoxSettingStore(t *testing.T) {
+	db := newSyncTestDB(t)
+	woxSetting := NewWoxSetting(NewWoxSettingStore(db))
+	require.NoError(t, woxSetting.AIProviders.Set([]AIProvider{{Name: "openai", ApiKey: "sk-store"}, {Name: "groq", ApiKey: "gsk-store"}}))
+
+	var stored database.WoxSetting
+	require.NoError(t, db.Where("key = ?", "AIProviders").First(&stored).Error)
+	assert.NotContains(t, stored.Value, "sk-store")
+	var oplog database.Oplog
+	require.NoError(t, db.Where("entity_id = ?", "AIProviders").Last(&oplog).Error)
+	assert.NotContains(t, oplog.Value, "sk-store")
+
+	reloaded := NewWoxSetting(NewWoxSettingStore(db))
+	assert.Equal(t, "sk-store", reloaded.AIProviders.Get()[0].ApiKey)
+
+	// a removed provider takes its secret with it
+	require.NoError(t, woxSetting.AIProviders.Set([]AIProvider{{Name: "openai", ApiKey: "sk-store"}}))
+	_, err := keyring.Get("setting/AIProviders/groq/ApiKey")
+	assert.ErrorIs(t, err, keyring.ErrNotFound)
+	value, err := keyring.Get("setting/AIProviders/openai/ApiKey")
+	require.NoError(t, err)
+	assert.Equal(t, `"sk-store"`, value)
+}
+
+func TestSecretMigration(t *testing.T) {
+	db := newSyncTestDB(t)
+	saveWoxSetting(t, db, "old", "AIProviders", `[{"Name":"openai","ApiKey":"sk-plain"}]`)
+	saveWoxSetting(t, db, "old", "SettingSyncPassword", "plain-password")
+
+	store := NewWoxSettingStore(db)
+	migrated, err := store.migrateSecrets()
+	require.NoError(t, err)
+	assert.Equal(t, 2, migrated)
+
+	var stored []database.WoxSetting
+	require.NoError(t, db.Find(&stored).Error)
+	for _, setting := range stored {
+		assert.NotContains(t, setting.Value, "plain")
+	}
+	woxSetting := NewWoxSetting(store)
+	assert.Equal(t, "sk-plain", woxSetting.AIProviders.Get()[0].ApiKey)
+	assert.Equal(t, "plain-password", woxSetting.SettingSyncPassword.Get())
+
+	migrated, err = store.migrateSecrets()
+	require.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+}
+
+func TestSecretPluginSettingStore(t *testing.T) {
+	db := newSyncTestDB(t)
+	pluginSecretFields.Store("secret-plugin", map[string][]string{"token": {""}})
+	t.Cleanup(func() { pluginSecretFields.Delete("secret-plugin") })
+
+	store := NewPluginSettingStore(db, "secret-plugin")
+	require.NoError(t, store.Set("token@linux", "plugin-token"))
+	require.NoError(t, store.Set("name", "plain"))
+
+	var stored database.PluginSetting
+	require.NoError(t, db.Where("plugin_id = ? AND key = ?", "secret-plugin", "token@linux").First(&stored).Error)
+	assert.Equal(t, "keyring:plugin/secret-plugin/token@linux", stored.Value)
+
+	var token string
+	require.NoError(t, store.Get("token@linux", &token))
+	assert.Equal(t, "plugin-token", token)
+
+	require.NoError(t, store.Delete("token@linux"))
+	_, err := keyring.Get("plugin/secret-plugin/token@linux")
+	assert.ErrorIs(t, err, keyring.ErrNotFound)
+}
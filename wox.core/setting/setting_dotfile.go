@@ -0,0 +1,177 @@
+package setting
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"wox/database"
+	"wox/util"
+
+	"github.com/fsnotify/fsnotify"
+	"gorm.io/gorm"
+)
+
+// SetPluginSettingValidator sets how plugin settings of a dotfile are validated, the plugins know their setting definitions
+func (m *Manager) SetPluginSettingValidator(validator func(ctx context.Context, pluginId string, key string, value string) error) {
+	m.restoreLock.Lock()
+	defer m.restoreLock.Unlock()
+
+	m.pluginSettingValidator = validator
+}
+
+// ExportDotfile returns the settings as a yaml file that can be kept in a dotfiles repository
+func (m *Manager) ExportDotfile(ctx context.Context, secretMode DotfileSecretMode) ([]byte, error) {
+	m.restoreLock.Lock()
+	defer m.restoreLock.Unlock()
+
+	db := database.GetDB()
+	return exportDotfile(db, newStoredWoxSetting(db), secretMode)
+}
+
+// PreviewDotfile validates a dotfile and returns the settings an import would change, nothing is saved
+func (m *Manager) PreviewDotfile(ctx context.Context, data []byte) ([]DotfileChange, error) {
+	m.restoreLock.Lock()
+	defer m.restoreLock.Unlock()
+
+	db := database.GetDB()
+	updates, err := planDotfile(db, newStoredWoxSetting(db), data, m.getPluginSettingValidator(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return getDotfileChanges(updates), nil
+}
+
+// ImportDotfile validates a dotfile and saves the settings it changes, nothing is saved if any setting is invalid
+func (m *Manager) ImportDotfile(ctx context.Context, data []byte) ([]DotfileChange, error) {
+	m.restoreLock.Lock()
+	defer m.restoreLock.Unlock()
+
+	db := database.GetDB()
+	updates, err := planDotfile(db, newStoredWoxSetting(db), data, m.getPluginSettingValidator(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		return []DotfileChange{}, nil
+	}
+
+	logger.Info(ctx, fmt.Sprintf("importing dotfile, %d settings changed", len(updates)))
+	changes, applyErr := applyDotfile(db, updates)
+	if len(changes) > 0 {
+		m.notifySettingChanges(ctx, m.reloadChangedSettings(db, changes))
+	}
+	if applyErr != nil {
+		return nil, applyErr
+	}
+
+	return getDotfileChanges(updates), nil
+}
+
+// newStoredWoxSetting returns the Wox setting as stored, a dotfile never has the settings of the active profile in place of them
+func newStoredWoxSetting(db *gorm.DB) *WoxSetting {
+	return NewWoxSetting(NewWoxSettingStore(db))
+}
+
+func (m *Manager) getPluginSettingValidator(ctx context.Context) func(pluginId string, key string, value string) error {
+	if m.pluginSettingValidator == nil {
+		return nil
+	}
+	return func(pluginId string, key string, value string) error {
+		return m.pluginSettingValidator(ctx, pluginId, key, value)
+	}
+}
+
+func getDotfileChanges(updates []dotfileUpdate) []DotfileChange {
+	changes := make([]DotfileChange, 0, len(updates))
+	for _, update := range updates {
+		changes = append(changes, update.change)
+	}
+	return changes
+}
+
+// WatchDotfile imports the dotfile of the DotfilePath setting, and again whenever it changes.
+// It is called again when the setting changes, the previous dotfile is not watched anymore.
+func (m *Manager) WatchDotfile(ctx context.Context) {
+	m.dotfileLock.Lock()
+	defer m.dotfileLock.Unlock()
+
+	if m.dotfileWatcher != nil {
+		m.dotfileWatcher.Close()
+		m.dotfileWatcher = nil
+	}
+
+	dotfilePath := m.GetWoxSetting(ctx).DotfilePath.Get()
+	if dotfilePath == "" {
+		return
+	}
+	dotfilePath = filepath.Clean(dotfilePath)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to create dotfile watcher: %s", err.Error()))
+		return
+	}
+	// editors often save by replacing the file, so its folder is watched
+	if err := watcher.Add(filepath.Dir(dotfilePath)); err != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to watch dotfile %s: %s", dotfilePath, err.Error()))
+		watcher.Close()
+		return
+	}
+	m.dotfileWatcher = watcher
+
+	logger.Info(ctx, fmt.Sprintf("watching dotfile: %s", dotfilePath))
+	m.importDotfileFile(ctx, dotfilePath)
+
+	util.Go(ctx, "watch dotfile", func() {
+		var debounceTimer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != dotfilePath || event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+					continue
+				}
+				// an editor may write a file in several steps
+				if debounceTimer != nil {
+					debounceTimer.Stop()
+				}
+				debounceTimer = time.AfterFunc(500*time.Millisecond, func() {
+					m.importDotfileFile(util.NewTraceContext(), dotfilePath)
+				})
+			case watchErr, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Error(ctx, fmt.Sprintf("dotfile watcher error: %s", watchErr.Error()))
+			}
+		}
+	})
+}
+
+func (m *Manager) importDotfileFile(ctx context.Context, dotfilePath string) {
+	data, readErr := os.ReadFile(dotfilePath)
+	if os.IsNotExist(readErr) {
+		return
+	}
+	if readErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to read dotfile: %s", readErr.Error()))
+		return
+	}
+
+	changes, importErr := m.ImportDotfile(ctx, data)
+	if importErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to import dotfile %s: %s", dotfilePath, importErr.Error()))
+		return
+	}
+	for _, change := range changes {
+		if change.PluginId == "" {
+			logger.Info(ctx, fmt.Sprintf("dotfile changed setting %s", change.Key))
+		} else {
+			logger.Info(ctx, fmt.Sprintf("dotfile changed setting %s of plugin %s", change.Key, change.PluginId))
+		}
+	}
+}
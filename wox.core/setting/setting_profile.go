@@ -0,0 +1,189 @@
+package setting
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+	"wox/database"
+	"wox/util"
+	"wox/util/wifi"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+const profileRuleInterval = 30 * time.Second
+
+func (m *Manager) GetProfiles(ctx context.Context) []SettingProfile {
+	return m.current().woxSetting.Profiles.Get()
+}
+
+// GetActiveProfile returns the name of the active profile, empty when no profile is active
+func (m *Manager) GetActiveProfile(ctx context.Context) string {
+	return m.current().woxSetting.ActiveProfile.Get()
+}
+
+// SwitchProfile activates a profile, an empty name goes back to the settings without a profile
+func (m *Manager) SwitchProfile(ctx context.Context, name string) error {
+	m.restoreLock.Lock()
+	defer m.restoreLock.Unlock()
+
+	if name != "" {
+		if _, found := findProfile(m.current().woxSetting.Profiles.Get(), name); !found {
+			return fmt.Errorf("profile %s not found", name)
+		}
+	}
+	if m.current().woxSetting.ActiveProfile.Get() == name {
+		return nil
+	}
+
+	logger.Info(ctx, fmt.Sprintf("switching to profile: %s", name))
+	previous := m.current().profileLayer.getProfile()
+	if err := m.current().woxSetting.ActiveProfile.Set(name); err != nil {
+		return err
+	}
+	m.reloadProfile(ctx, previous)
+	return nil
+}
+
+// SaveProfiles replaces the profiles, the active profile is left when it is removed
+func (m *Manager) SaveProfiles(ctx context.Context, profiles []SettingProfile) error {
+	if err := validateProfiles(profiles); err != nil {
+		return err
+	}
+
+	m.restoreLock.Lock()
+	defer m.restoreLock.Unlock()
+
+	previous := m.current().profileLayer.getProfile()
+	if err := m.current().woxSetting.Profiles.Set(profiles); err != nil {
+		return err
+	}
+	if _, found := findProfile(profiles, m.current().woxSetting.ActiveProfile.Get()); !found {
+		if err := m.current().woxSetting.ActiveProfile.Set(""); err != nil {
+			return err
+		}
+	}
+	m.reloadProfile(ctx, previous)
+	return nil
+}
+
+// reloadProfile reloads the settings after the active profile or its overrides changed, and notifies the settings it changed.
+// Must be called with restoreLock held.
+func (m *Manager) reloadProfile(ctx context.Context, previous SettingProfile) {
+	db := database.GetDB()
+	m.loadSettings(db)
+	if changes := m.getProfileChanges(db, previous, m.current().profileLayer.getProfile()); len(changes) > 0 {
+		m.notifySettingChanges(ctx, changes)
+	}
+}
+
+// reloadChangedSettings reloads the settings after they are changed in the database, e.g. by a sync.
+// When the profiles are changed, the settings the active profile overrides differently are added to the changes.
+// Must be called with restoreLock held.
+func (m *Manager) reloadChangedSettings(db *gorm.DB, changes []SettingChange) []SettingChange {
+	previous := m.current().profileLayer.getProfile()
+	m.loadSettings(db)
+
+	profilesChanged := lo.ContainsBy(changes, func(change SettingChange) bool {
+		return change.PluginId == "" && change.Key == "Profiles"
+	})
+	if profilesChanged {
+		changes = append(changes, m.getProfileChanges(db, previous, m.current().profileLayer.getProfile())...)
+	}
+	return changes
+}
+
+// getProfileChanges returns the settings overridden by either profile, with their values after the switch
+func (m *Manager) getProfileChanges(db *gorm.DB, previous SettingProfile, current SettingProfile) []SettingChange {
+	var changes []SettingChange
+
+	keys := lo.Union(lo.Keys(previous.Settings), lo.Keys(current.Settings))
+	slices.Sort(keys)
+	woxSettingStore := newProfileSettingStore(NewWoxSettingStore(db), "", m.current().profileLayer)
+	for _, key := range keys {
+		var value string
+		woxSettingStore.Get(key, &value)
+		changes = append(changes, SettingChange{Key: key, Value: value})
+	}
+
+	pluginIds := lo.Union(lo.Keys(previous.PluginSettings), lo.Keys(current.PluginSettings))
+	slices.Sort(pluginIds)
+	for _, pluginId := range pluginIds {
+		keys := lo.Union(lo.Keys(previous.PluginSettings[pluginId]), lo.Keys(current.PluginSettings[pluginId]))
+		slices.Sort(keys)
+		pluginSettingStore := newProfileSettingStore(NewPluginSettingStore(db, pluginId), pluginId, m.current().profileLayer)
+		for _, key := range keys {
+			var value string
+			pluginSettingStore.Get(key, &value)
+			changes = append(changes, SettingChange{PluginId: pluginId, Key: key, Value: value})
+		}
+	}
+
+	return changes
+}
+
+// StartProfileRules switches to the profile of which the rules match, getDisplayLayout describes the connected displays, see SettingProfileRuleTypeDisplay
+func (m *Manager) StartProfileRules(ctx context.Context, getDisplayLayout func() string) {
+	util.Go(ctx, "profile rules", func() {
+		ticker := time.NewTicker(profileRuleInterval)
+		defer ticker.Stop()
+
+		lastMatched, checked := "", false
+		for range ticker.C {
+			profiles := m.GetWoxSetting(ctx).Profiles.Get()
+			if !lo.ContainsBy(profiles, func(profile SettingProfile) bool { return len(profile.Rules) > 0 }) {
+				checked = false
+				continue
+			}
+
+			env := getProfileEnvironment(profiles, getDisplayLayout)
+			name, shouldSwitch, matched := nextProfileByRules(profiles, m.GetActiveProfile(ctx), env, lastMatched, checked)
+			lastMatched, checked = matched, true
+			if !shouldSwitch {
+				continue
+			}
+			if err := m.SwitchProfile(util.NewTraceContext(), name); err != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to switch profile by rules: %s", err.Error()))
+			}
+		}
+	})
+}
+
+// getProfileEnvironment only looks up what the rules need, e.g. the Wi-Fi network is not looked up without a Wi-Fi rule
+func getProfileEnvironment(profiles []SettingProfile, getDisplayLayout func() string) profileEnvironment {
+	hasRule := func(ruleType SettingProfileRuleType) bool {
+		return lo.ContainsBy(profiles, func(profile SettingProfile) bool {
+			return lo.ContainsBy(profile.Rules, func(rule SettingProfileRule) bool { return rule.Type == ruleType })
+		})
+	}
+
+	env := profileEnvironment{Now: time.Now()}
+	if hasRule(SettingProfileRuleTypeWifi) {
+		env.WifiSSID, _ = wifi.GetSSID()
+	}
+	if hasRule(SettingProfileRuleTypeDisplay) && getDisplayLayout != nil {
+		env.DisplayLayout = getDisplayLayout()
+	}
+	return env
+}
+
+// nextProfileByRules decides the profile to switch to. Rules only switch when their result changes, so a profile switched to by hand stays until then.
+// When no profile matches any more, a profile with rules is left, a profile without rules was switched to by hand and stays.
+func nextProfileByRules(profiles []SettingProfile, active string, env profileEnvironment, lastMatched string, checked bool) (string, bool, string) {
+	matched, _ := matchProfile(profiles, env)
+	if checked && matched == lastMatched {
+		return "", false, matched
+	}
+	if matched == active {
+		return "", false, matched
+	}
+	if matched == "" {
+		activeProfile, found := findProfile(profiles, active)
+		if found && len(activeProfile.Rules) == 0 {
+			return "", false, matched
+		}
+	}
+	return matched, true, matched
+}
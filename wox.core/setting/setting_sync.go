@@ -0,0 +1,76 @@
+package setting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"wox/database"
+	"wox/util"
+)
+
+const settingSyncInterval = 5 * time.Minute
+
+func (m *Manager) StartSettingSync(ctx context.Context) {
+	util.Go(ctx, "setting sync", func() {
+		ticker := time.NewTicker(settingSyncInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !m.GetWoxSetting(ctx).EnableSettingSync.Get() {
+				continue
+			}
+
+			if syncErr := m.SyncSettings(util.NewTraceContext()); syncErr != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to sync settings: %s", syncErr.Error()))
+			}
+		}
+	})
+}
+
+// SyncSettings applies the setting changes of other devices and shares the changes of this device
+func (m *Manager) SyncSettings(ctx context.Context) error {
+	transport, transportErr := m.getSyncTransport(ctx)
+	if transportErr != nil {
+		return transportErr
+	}
+
+	// a restore replaces the database, they must not run at the same time
+	m.restoreLock.Lock()
+	defer m.restoreLock.Unlock()
+
+	logger.Info(ctx, "syncing settings")
+	db := database.GetDB()
+	result, syncErr := NewSyncEngine(db, transport, getSyncDeviceId()).Sync(ctx)
+	if syncErr != nil {
+		return syncErr
+	}
+	logger.Info(ctx, fmt.Sprintf("settings synced, pushed %d changes, applied %d changes", result.Pushed, len(result.Changes)))
+
+	if len(result.Changes) == 0 {
+		return nil
+	}
+
+	m.notifySettingChanges(ctx, m.reloadChangedSettings(db, result.Changes))
+
+	return nil
+}
+
+func (m *Manager) getSyncTransport(ctx context.Context) (SyncTransport, error) {
+	woxSetting := m.GetWoxSetting(ctx)
+	switch woxSetting.SettingSyncType.Get() {
+	case SettingSyncTypeFolder:
+		folder := woxSetting.SettingSyncFolder.Get()
+		if folder == "" {
+			return nil, errors.New("setting sync folder is empty")
+		}
+		return &FolderSyncTransport{Directory: folder}, nil
+	case SettingSyncTypeWebDAV:
+		return &WebDAVSyncTransport{
+			Url:      woxSetting.SettingSyncUrl.Get(),
+			Username: woxSetting.SettingSyncUsername.Get(),
+			Password: woxSetting.SettingSyncPassword.Get(),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown setting sync type: %s", woxSetting.SettingSyncType.Get())
+}
@@ -6,8 +6,8 @@ import (
 	"reflect"
 	"strconv"
 	"wox/database"
-	"wox/util"
 
+	"github.com/samber/lo"
 	"gorm.io/gorm"
 )
 
@@ -35,37 +35,70 @@ func (s *WoxSettingStore) Get(key string, target interface{}) error {
 		return err
 	}
 
-	return deserializeValue(setting.Value, target)
+	return deserializeValue(revealSecrets(setting.Value, secretWoxSettingFields[key]), target)
 }
 
+// Set saves the secrets of a setting to the OS keyring, the database only keeps references to them
 func (s *WoxSettingStore) Set(key string, value interface{}) error {
 	strValue, err := serializeValue(value)
 	if err != nil {
 		return fmt.Errorf("failed to serialize value: %w", err)
 	}
+	strValue, err = hideSecrets("setting/"+key, strValue, secretWoxSettingFields[key])
+	if err != nil {
+		return err
+	}
 
-	return s.db.Save(&database.WoxSetting{Key: key, Value: strValue}).Error
-}
+	previousValue := ""
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		previousValue = getStoredValue(tx.Where("key = ?", key), &database.WoxSetting{})
+		if err := tx.Save(&database.WoxSetting{Key: key, Value: strValue}).Error; err != nil {
+			return err
+		}
+		return logWoxSettingOplog(tx, getSyncDeviceId(), key, oplogOperationUpdate, strValue)
+	})
+	if err != nil {
+		return err
+	}
 
-func (s *WoxSettingStore) Delete(key string) error {
-	return s.db.Delete(&database.WoxSetting{Key: key}).Error
+	return deleteSecrets(previousValue, strValue, secretWoxSettingFields[key])
 }
 
-func (s *WoxSettingStore) LogOplog(key string, value interface{}) error {
-	strValue, err := serializeValue(value)
+func (s *WoxSettingStore) Delete(key string) error {
+	previousValue := ""
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		previousValue = getStoredValue(tx.Where("key = ?", key), &database.WoxSetting{})
+		result := tx.Delete(&database.WoxSetting{Key: key})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return logWoxSettingOplog(tx, getSyncDeviceId(), key, oplogOperationDelete, "")
+	})
 	if err != nil {
-		return fmt.Errorf("failed to serialize value for oplog: %w", err)
+		return err
 	}
 
-	oplog := database.Oplog{
-		EntityType: "setting",
-		EntityID:   key,
-		Operation:  "update",
-		Value:      strValue,
-		Timestamp:  util.GetSystemTimestamp(),
+	return deleteSecrets(previousValue, "", secretWoxSettingFields[key])
+}
+
+// migrateSecrets moves the secrets saved in plain text by older versions or by a sync to the OS keyring
+func (s *WoxSettingStore) migrateSecrets() (int, error) {
+	var woxSettings []database.WoxSetting
+	if err := s.db.Where("key IN ?", lo.Keys(secretWoxSettingFields)).Find(&woxSettings).Error; err != nil {
+		return 0, err
 	}
 
-	return s.db.Create(&oplog).Error
+	migrated := 0
+	for _, woxSetting := range woxSettings {
+		if !hasPlainSecrets(woxSetting.Value, secretWoxSettingFields[woxSetting.Key]) {
+			continue
+		}
+		if err := s.Set(woxSetting.Key, woxSetting.Value); err != nil {
+			return migrated, fmt.Errorf("failed to migrate secrets of %s: %w", woxSetting.Key, err)
+		}
+		migrated++
+	}
+	return migrated, nil
 }
 
 // PluginSettingStore defines the interface for plugin settings
@@ -87,20 +120,83 @@ func (s *PluginSettingStore) Get(key string, target interface{}) error {
 		return err
 	}
 
-	return deserializeValue(setting.Value, target)
+	return deserializeValue(revealSecrets(setting.Value, getPluginSecretFields(s.pluginId, key)), target)
 }
 
+// Set saves the secrets of a setting to the OS keyring, the database only keeps references to them
 func (s *PluginSettingStore) Set(key string, value interface{}) error {
 	strValue, err := serializeValue(value)
 	if err != nil {
 		return fmt.Errorf("failed to serialize plugin setting value: %w", err)
 	}
+	secretFields := getPluginSecretFields(s.pluginId, key)
+	strValue, err = hideSecrets("plugin/"+s.pluginId+"/"+key, strValue, secretFields)
+	if err != nil {
+		return err
+	}
 
-	return s.db.Save(&database.PluginSetting{PluginID: s.pluginId, Key: key, Value: strValue}).Error
+	previousValue := ""
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		previousValue = getStoredValue(tx.Where("plugin_id = ? AND key = ?", s.pluginId, key), &database.PluginSetting{})
+		if err := tx.Save(&database.PluginSetting{PluginID: s.pluginId, Key: key, Value: strValue}).Error; err != nil {
+			return err
+		}
+		if isLocalOnlyPluginSetting(s.pluginId, key) {
+			return nil
+		}
+		return writeOplog(tx, getSyncDeviceId(), oplogEntityPluginSetting, s.pluginId, key, oplogOperationUpdate, strValue)
+	})
+	if err != nil {
+		return err
+	}
+
+	return deleteSecrets(previousValue, strValue, secretFields)
 }
 
 func (s *PluginSettingStore) Delete(key string) error {
-	return s.db.Delete(&database.PluginSetting{PluginID: s.pluginId, Key: key}).Error
+	previousValue := ""
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		previousValue = getStoredValue(tx.Where("plugin_id = ? AND key = ?", s.pluginId, key), &database.PluginSetting{})
+		result := tx.Delete(&database.PluginSetting{PluginID: s.pluginId, Key: key})
+		if result.Error != nil || result.RowsAffected == 0 || isLocalOnlyPluginSetting(s.pluginId, key) {
+			return result.Error
+		}
+		return writeOplog(tx, getSyncDeviceId(), oplogEntityPluginSetting, s.pluginId, key, oplogOperationDelete, "")
+	})
+	if err != nil {
+		return err
+	}
+
+	return deleteSecrets(previousValue, "", getPluginSecretFields(s.pluginId, key))
+}
+
+// migrateSecrets moves the secrets of this plugin saved in plain text, e.g. before the setting was defined as secret, to the OS keyring
+func (s *PluginSettingStore) migrateSecrets() (int, error) {
+	var pluginSettings []database.PluginSetting
+	if err := s.db.Where("plugin_id = ?", s.pluginId).Find(&pluginSettings).Error; err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, pluginSetting := range pluginSettings {
+		if !hasPlainSecrets(pluginSetting.Value, getPluginSecretFields(s.pluginId, pluginSetting.Key)) {
+			continue
+		}
+		if err := s.Set(pluginSetting.Key, pluginSetting.Value); err != nil {
+			return migrated, fmt.Errorf("failed to migrate secrets of %s: %w", pluginSetting.Key, err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// getStoredValue returns the stored value of the setting the query finds, or empty if it is not saved yet
+func getStoredValue(query *gorm.DB, model interface{}) string {
+	var values []string
+	if err := query.Model(model).Limit(1).Pluck("value", &values).Error; err != nil || len(values) == 0 {
+		return ""
+	}
+	return values[0]
 }
 
 func serializeValue(value interface{}) (string, error) {
@@ -0,0 +1,480 @@
+package setting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"wox/database"
+	"wox/util"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	oplogEntitySetting       = "setting"
+	oplogEntityPluginSetting = "plugin_setting"
+
+	oplogOperationUpdate = "update"
+	oplogOperationDelete = "delete"
+
+	syncFileVersion   = 1
+	syncFileExtension = ".json"
+)
+
+// localOnlySettingKeys describe this machine or its usage, they are never synced
+var localOnlySettingKeys = []string{
+	"EnableAutostart",
+	"CustomPythonPath",
+	"CustomNodejsPath",
+	"LastWindowX",
+	"LastWindowY",
+	"QueryHistories",
+	"ActionedResults",
+	"BackupPassphrase",
+	"EnableSettingSync",
+	"SettingSyncType",
+	"SettingSyncFolder",
+	"SettingSyncUrl",
+	"SettingSyncUsername",
+	"SettingSyncPassword",
+	"DotfilePath",
+	"ActiveProfile",
+}
+
+// localOnlyPluginSettingKeys are the plugin settings by plugin id that stay on this machine, they are never synced nor exported to the dotfile
+var localOnlyPluginSettingKeys = map[string][]string{
+	// clipboard favorites keep the copied content and refer to image files of this machine
+	"5f815d98-27f5-488d-a756-c317ea39935b": {"favorites"},
+	// the exchange rate cache of the converter, older versions saved it as a plugin setting
+	"a48dc5f0-dab9-4112-b883-b68129d6782b": {"exchangeRates"},
+}
+
+func isLocalOnlyPluginSetting(pluginId string, key string) bool {
+	return slices.Contains(localOnlyPluginSettingKeys[pluginId], key)
+}
+
+// isLocalOnlyOplog reports whether the oplog changes a setting that is never synced
+func isLocalOnlyOplog(oplog database.Oplog) bool {
+	switch oplog.EntityType {
+	case oplogEntitySetting:
+		return slices.Contains(localOnlySettingKeys, oplog.EntityID)
+	case oplogEntityPluginSetting:
+		return isLocalOnlyPluginSetting(oplog.EntityID, oplog.Key)
+	}
+	return false
+}
+
+// platformValueFields maps a platform to its field in the stored PlatformValue, every platform is synced on its own
+var platformValueFields = map[string]string{
+	string(util.PlatformMacOS):   "MacValue",
+	string(util.PlatformWindows): "WinValue",
+	string(util.PlatformLinux):   "LinuxValue",
+}
+
+var syncDeviceId string
+var syncDeviceIdOnce sync.Once
+
+// SettingChange is a setting changed by a sync or a dotfile import
+type SettingChange struct {
+	PluginId string // empty for Wox settings
+	Key      string // plugin setting keys keep their platform suffix, e.g. "IndexDirectories@darwin"
+	Value    string // stored value, empty when the setting is deleted
+	Platform string // platform of a PlatformValue change
+	Deleted  bool
+}
+
+// syncFile is what a device writes to the sync location, it holds the latest change of every key the device changed
+type syncFile struct {
+	Version  int
+	DeviceId string
+	Ops      []syncOp
+}
+
+type syncOp struct {
+	EntityType string
+	EntityID   string
+	Key        string
+	Operation  string
+	Value      string
+	Timestamp  int64
+	Clock      int64
+}
+
+type SyncResult struct {
+	Pushed  int // changes of this device written to the sync location
+	Changes []SettingChange
+}
+
+// SyncEngine syncs settings between devices. Every change is recorded in the oplog with a lamport clock,
+// and a conflict is resolved per key: the change with the higher clock wins, the device id breaks a tie.
+type SyncEngine struct {
+	db        *gorm.DB
+	transport SyncTransport
+	deviceId  string
+}
+
+func NewSyncEngine(db *gorm.DB, transport SyncTransport, deviceId string) *SyncEngine {
+	return &SyncEngine{
+		db:        db,
+		transport: transport,
+		deviceId:  deviceId,
+	}
+}
+
+// Sync applies the newer changes of other devices, then writes the changes of this device
+func (e *SyncEngine) Sync(ctx context.Context) (SyncResult, error) {
+	var result SyncResult
+
+	if err := seedOplog(e.db, e.deviceId); err != nil {
+		return result, fmt.Errorf("failed to seed oplog: %w", err)
+	}
+
+	names, listErr := e.transport.List(ctx)
+	if listErr != nil {
+		return result, fmt.Errorf("failed to list sync files: %w", listErr)
+	}
+
+	for _, name := range names {
+		if !strings.HasSuffix(name, syncFileExtension) || name == e.deviceId+syncFileExtension {
+			continue
+		}
+
+		data, readErr := e.transport.Read(ctx, name)
+		if readErr != nil {
+			return result, fmt.Errorf("failed to read sync file %s: %w", name, readErr)
+		}
+		var file syncFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return result, fmt.Errorf("failed to parse sync file %s: %w", name, err)
+		}
+		// conflict copies made by file sync tools, e.g. "<id>.sync-conflict-xxx.json", are not a device
+		if file.DeviceId+syncFileExtension != name {
+			continue
+		}
+		if file.Version > syncFileVersion {
+			return result, fmt.Errorf("sync file %s is written by a newer version of Wox", name)
+		}
+
+		changes, applyErr := e.applyOps(file)
+		if applyErr != nil {
+			return result, applyErr
+		}
+		result.Changes = append(result.Changes, changes...)
+	}
+
+	pushed, pushErr := e.push(ctx, slices.Contains(names, e.deviceId+syncFileExtension))
+	if pushErr != nil {
+		return result, pushErr
+	}
+	result.Pushed = pushed
+
+	return result, nil
+}
+
+func (e *SyncEngine) applyOps(file syncFile) ([]SettingChange, error) {
+	var changes []SettingChange
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		for _, op := range file.Ops {
+			oplog := database.Oplog{
+				EntityType:    op.EntityType,
+				EntityID:      op.EntityID,
+				Key:           op.Key,
+				Operation:     op.Operation,
+				Value:         op.Value,
+				Timestamp:     op.Timestamp,
+				DeviceID:      file.DeviceId,
+				Clock:         op.Clock,
+				SyncedToCloud: true,
+			}
+			if isLocalOnlyOplog(oplog) {
+				continue
+			}
+
+			latest, found, err := getLatestOplog(tx, oplog.EntityType, oplog.EntityID, oplog.Key)
+			if err != nil {
+				return err
+			}
+			if found && !isNewerOplog(oplog, latest) {
+				continue
+			}
+
+			change, err := applyOplog(tx, oplog)
+			if err != nil {
+				return fmt.Errorf("failed to apply %s %s of device %s: %w", oplog.EntityType, oplog.EntityID, file.DeviceId, err)
+			}
+			if err := replaceOplog(tx, oplog); err != nil {
+				return err
+			}
+			changes = append(changes, change)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// push writes the changes made on this device, if anything changed since the last push or the sync location has no file of this device
+func (e *SyncEngine) push(ctx context.Context, hasRemoteFile bool) (int, error) {
+	var unsyncedCount int64
+	if err := e.db.Model(&database.Oplog{}).Where("device_id = ? AND synced_to_cloud = ?", e.deviceId, false).Count(&unsyncedCount).Error; err != nil {
+		return 0, err
+	}
+	if unsyncedCount == 0 && hasRemoteFile {
+		return 0, nil
+	}
+
+	var oplogs []database.Oplog
+	if err := e.db.Where("device_id = ?", e.deviceId).Order("clock, id").Find(&oplogs).Error; err != nil {
+		return 0, err
+	}
+
+	file := syncFile{Version: syncFileVersion, DeviceId: e.deviceId, Ops: []syncOp{}}
+	for _, oplog := range oplogs {
+		// changes recorded before the setting became local only
+		if isLocalOnlyOplog(oplog) {
+			continue
+		}
+		file.Ops = append(file.Ops, syncOp{
+			EntityType: oplog.EntityType,
+			EntityID:   oplog.EntityID,
+			Key:        oplog.Key,
+			Operation:  oplog.Operation,
+			Value:      oplog.Value,
+			Timestamp:  oplog.Timestamp,
+			Clock:      oplog.Clock,
+		})
+	}
+	data, marshalErr := json.Marshal(file)
+	if marshalErr != nil {
+		return 0, marshalErr
+	}
+	if err := e.transport.Write(ctx, e.deviceId+syncFileExtension, data); err != nil {
+		return 0, fmt.Errorf("failed to write sync file: %w", err)
+	}
+
+	// only mark what was written, a change made meanwhile is pushed next time
+	ids := make([]uint, 0, len(oplogs))
+	for _, oplog := range oplogs {
+		ids = append(ids, oplog.ID)
+	}
+	if len(ids) > 0 {
+		if err := e.db.Model(&database.Oplog{}).Where("id IN ?", ids).Update("synced_to_cloud", true).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	return int(unsyncedCount), nil
+}
+
+// getSyncDeviceId returns the id of this device, it is created on first use
+func getSyncDeviceId() string {
+	syncDeviceIdOnce.Do(func() {
+		idPath := util.GetLocation().GetSyncDeviceIdPath()
+		if data, err := os.ReadFile(idPath); err == nil && strings.TrimSpace(string(data)) != "" {
+			syncDeviceId = strings.TrimSpace(string(data))
+			return
+		}
+
+		// if the id can't be saved, this device gets a new id on next start, its changes are still synced
+		syncDeviceId = uuid.NewString()
+		os.WriteFile(idPath, []byte(syncDeviceId), 0644)
+	})
+	return syncDeviceId
+}
+
+// logWoxSettingOplog records a change of a Wox setting, a PlatformValue is recorded for the current platform only
+func logWoxSettingOplog(tx *gorm.DB, deviceId string, key string, operation string, value string) error {
+	if slices.Contains(localOnlySettingKeys, key) {
+		return nil
+	}
+
+	if operation == oplogOperationUpdate {
+		if platformValues, ok := splitPlatformValue(value); ok {
+			platform := util.GetCurrentPlatform()
+			return writeOplog(tx, deviceId, oplogEntitySetting, key, platform, operation, platformValues[platform])
+		}
+	}
+
+	return writeOplog(tx, deviceId, oplogEntitySetting, key, "", operation, value)
+}
+
+// writeOplog records a change made on this device, only the latest change of a key is kept
+func writeOplog(tx *gorm.DB, deviceId string, entityType string, entityId string, key string, operation string, value string) error {
+	latest, found, err := getLatestOplog(tx, entityType, entityId, key)
+	if err != nil {
+		return err
+	}
+	if found && latest.Operation == operation && latest.Value == value {
+		return nil
+	}
+
+	var maxClock int64
+	if err := tx.Model(&database.Oplog{}).Select("COALESCE(MAX(clock), 0)").Scan(&maxClock).Error; err != nil {
+		return err
+	}
+
+	return replaceOplog(tx, database.Oplog{
+		EntityType: entityType,
+		EntityID:   entityId,
+		Key:        key,
+		Operation:  operation,
+		Value:      value,
+		Timestamp:  util.GetSystemTimestamp(),
+		DeviceID:   deviceId,
+		Clock:      maxClock + 1,
+	})
+}
+
+// seedOplog records the settings saved before they were tracked, at clock 0, so any tracked change wins over them
+func seedOplog(tx *gorm.DB, deviceId string) error {
+	var woxSettings []database.WoxSetting
+	if err := tx.Where("key NOT IN (?)", tx.Model(&database.Oplog{}).Select("entity_id").Where("entity_type = ?", oplogEntitySetting)).Find(&woxSettings).Error; err != nil {
+		return err
+	}
+	var pluginSettings []database.PluginSetting
+	if err := tx.Where("NOT EXISTS (?)", tx.Model(&database.Oplog{}).Select("1").Where("entity_type = ? AND entity_id = plugin_settings.plugin_id AND key = plugin_settings.key", oplogEntityPluginSetting)).Find(&pluginSettings).Error; err != nil {
+		return err
+	}
+
+	timestamp := util.GetSystemTimestamp()
+	var oplogs []database.Oplog
+	for _, woxSetting := range woxSettings {
+		if slices.Contains(localOnlySettingKeys, woxSetting.Key) {
+			continue
+		}
+		oplog := database.Oplog{EntityType: oplogEntitySetting, EntityID: woxSetting.Key, Operation: oplogOperationUpdate, Value: woxSetting.Value, Timestamp: timestamp, DeviceID: deviceId}
+		if platformValues, ok := splitPlatformValue(woxSetting.Value); ok {
+			oplog.Key = util.GetCurrentPlatform()
+			oplog.Value = platformValues[oplog.Key]
+		}
+		oplogs = append(oplogs, oplog)
+	}
+	for _, pluginSetting := range pluginSettings {
+		if isLocalOnlyPluginSetting(pluginSetting.PluginID, pluginSetting.Key) {
+			continue
+		}
+		oplogs = append(oplogs, database.Oplog{EntityType: oplogEntityPluginSetting, EntityID: pluginSetting.PluginID, Key: pluginSetting.Key, Operation: oplogOperationUpdate, Value: pluginSetting.Value, Timestamp: timestamp, DeviceID: deviceId})
+	}
+	if len(oplogs) == 0 {
+		return nil
+	}
+
+	return tx.CreateInBatches(oplogs, 100).Error
+}
+
+func getLatestOplog(tx *gorm.DB, entityType string, entityId string, key string) (database.Oplog, bool, error) {
+	var oplogs []database.Oplog
+	err := tx.Where("entity_type = ? AND entity_id = ? AND key = ?", entityType, entityId, key).Order("clock DESC, device_id DESC").Limit(1).Find(&oplogs).Error
+	if err != nil || len(oplogs) == 0 {
+		return database.Oplog{}, false, err
+	}
+	return oplogs[0], true, nil
+}
+
+func replaceOplog(tx *gorm.DB, oplog database.Oplog) error {
+	if err := tx.Where("entity_type = ? AND entity_id = ? AND key = ?", oplog.EntityType, oplog.EntityID, oplog.Key).Delete(&database.Oplog{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&oplog).Error
+}
+
+// isNewerOplog resolves a conflict, last writer wins by lamport clock, the device id breaks a tie the same way on every device
+func isNewerOplog(oplog database.Oplog, than database.Oplog) bool {
+	if oplog.Clock != than.Clock {
+		return oplog.Clock > than.Clock
+	}
+	return oplog.DeviceID > than.DeviceID
+}
+
+// applyOplog writes a change of another device to the settings
+func applyOplog(tx *gorm.DB, oplog database.Oplog) (SettingChange, error) {
+	change := SettingChange{Value: oplog.Value, Deleted: oplog.Operation == oplogOperationDelete}
+
+	switch oplog.EntityType {
+	case oplogEntitySetting:
+		change.Key = oplog.EntityID
+		change.Platform = oplog.Key
+		if change.Deleted {
+			return change, tx.Delete(&database.WoxSetting{Key: oplog.EntityID}).Error
+		}
+
+		value := oplog.Value
+		if oplog.Key != "" {
+			var existing []database.WoxSetting
+			if err := tx.Where("key = ?", oplog.EntityID).Limit(1).Find(&existing).Error; err != nil {
+				return change, err
+			}
+			existingValue := ""
+			if len(existing) > 0 {
+				existingValue = existing[0].Value
+			}
+			merged, mergeErr := mergePlatformValue(existingValue, oplog.Key, oplog.Value)
+			if mergeErr != nil {
+				return change, mergeErr
+			}
+			value = merged
+		}
+		return change, tx.Save(&database.WoxSetting{Key: oplog.EntityID, Value: value}).Error
+	case oplogEntityPluginSetting:
+		change.PluginId = oplog.EntityID
+		change.Key = oplog.Key
+		if change.Deleted {
+			return change, tx.Delete(&database.PluginSetting{PluginID: oplog.EntityID, Key: oplog.Key}).Error
+		}
+		return change, tx.Save(&database.PluginSetting{PluginID: oplog.EntityID, Key: oplog.Key, Value: oplog.Value}).Error
+	}
+
+	return change, fmt.Errorf("unknown entity type: %s", oplog.EntityType)
+}
+
+// splitPlatformValue returns the value of every platform if value is a stored PlatformValue
+func splitPlatformValue(value string) (map[string]string, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &fields); err != nil || len(fields) != len(platformValueFields) {
+		return nil, false
+	}
+
+	values := map[string]string{}
+	for platform, field := range platformValueFields {
+		fieldValue, ok := fields[field]
+		if !ok {
+			return nil, false
+		}
+		values[platform] = string(fieldValue)
+	}
+	return values, true
+}
+
+// mergePlatformValue sets the value of one platform in a stored PlatformValue, the other platforms keep their values
+func mergePlatformValue(existing string, platform string, value string) (string, error) {
+	field, ok := platformValueFields[platform]
+	if !ok {
+		return "", fmt.Errorf("unknown platform: %s", platform)
+	}
+	if !json.Valid([]byte(value)) {
+		return "", errors.New("invalid platform value")
+	}
+
+	fields := map[string]json.RawMessage{}
+	if existing != "" {
+		if err := json.Unmarshal([]byte(existing), &fields); err != nil {
+			return "", fmt.Errorf("failed to parse platform value: %w", err)
+		}
+	}
+	fields[field] = json.RawMessage(value)
+
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(merged), nil
+}
@@ -0,0 +1,197 @@
+package setting
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"wox/database"
+	"wox/util"
+	"wox/util/keyring"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// mockKeyringOnce keeps the secrets of all tests in memory, the test databases share one keyring
+var mockKeyringOnce sync.Once
+
+func newSyncTestDB(t *testing.T) *gorm.DB {
+	mockKeyringOnce.Do(keyring.MockInit)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "wox.db")), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&database.WoxSetting{}, &database.PluginSetting{}, &database.Oplog{}))
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+// saveWoxSetting saves a setting the way WoxSettingStore does, with the device id of a test device
+func saveWoxSetting(t *testing.T, db *gorm.DB, deviceId string, key string, value string) {
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&database.WoxSetting{Key: key, Value: value}).Error; err != nil {
+			return err
+		}
+		return logWoxSettingOplog(tx, deviceId, key, oplogOperationUpdate, value)
+	}))
+}
+
+func loadWoxSetting(t *testing.T, db *gorm.DB, key string) string {
+	var woxSetting database.WoxSetting
+	require.NoError(t, db.Where("key = ?", key).First(&woxSetting).Error)
+	return woxSetting.Value
+}
+
+func syncDevice(t *testing.T, db *gorm.DB, transport SyncTransport, deviceId string) SyncResult {
+	result, err := NewSyncEngine(db, transport, deviceId).Sync(context.Background())
+	require.NoError(t, err)
+	return result
+}
+
+func TestSyncLastWriterWins(t *testing.T) {
+	transport := &FolderSyncTransport{Directory: t.TempDir()}
+	dbA, dbB := newSyncTestDB(t), newSyncTestDB(t)
+
+	saveWoxSetting(t, dbA, "a", "ThemeId", "dark")
+	saveWoxSetting(t, dbB, "b", "ThemeId", "light")
+
+	// both changes have clock 1, the device id breaks the tie the same way on both devices
+	syncDevice(t, dbA, transport, "a")
+	result := syncDevice(t, dbB, transport, "b")
+	assert.Empty(t, result.Changes)
+	result = syncDevice(t, dbA, transport, "a")
+	assert.Equal(t, []SettingChange{{Key: "ThemeId", Value: "light"}}, result.Changes)
+	assert.Equal(t, "light", loadWoxSetting(t, dbA, "ThemeId"))
+
+	// a later change has a higher clock
+	saveWoxSetting(t, dbA, "a", "ThemeId", "blue")
+	syncDevice(t, dbA, transport, "a")
+	syncDevice(t, dbB, transport, "b")
+	assert.Equal(t, "blue", loadWoxSetting(t, dbB, "ThemeId"))
+
+	// nothing changed, nothing is applied or pushed again
+	result = syncDevice(t, dbB, transport, "b")
+	assert.Empty(t, result.Changes)
+	assert.Equal(t, 0, result.Pushed)
+}
+
+func TestSyncSkipsLocalOnlySettings(t *testing.T) {
+	transport := &FolderSyncTransport{Directory: t.TempDir()}
+	dbA, dbB := newSyncTestDB(t), newSyncTestDB(t)
+
+	saveWoxSetting(t, dbA, "a", "LastWindowX", "100")
+	saveWoxSetting(t, dbA, "a", "QueryShortcuts", `[{"Shortcut":"wi","Query":"wpm install"}]`)
+	syncDevice(t, dbA, transport, "a")
+	syncDevice(t, dbB, transport, "b")
+
+	assert.Equal(t, `[{"Shortcut":"wi","Query":"wpm install"}]`, loadWoxSetting(t, dbB, "QueryShortcuts"))
+	var count int64
+	require.NoError(t, dbB.Model(&database.WoxSetting{}).Where("key = ?", "LastWindowX").Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestSyncPlatformValue(t *testing.T) {
+	transport := &FolderSyncTransport{Directory: t.TempDir()}
+	dbA, dbB := newSyncTestDB(t), newSyncTestDB(t)
+
+	saveWoxSetting(t, dbB, "b", "MainHotkey", `{"MacValue":"b-mac","WinValue":"b-win","LinuxValue":"b-linux"}`)
+	syncDevice(t, dbB, transport, "b")
+	saveWoxSetting(t, dbA, "a", "SelectionHotkey", `{"MacValue":"","WinValue":"","LinuxValue":""}`)
+	syncDevice(t, dbA, transport, "a")
+	saveWoxSetting(t, dbA, "a", "MainHotkey", `{"MacValue":"a-mac","WinValue":"a-win","LinuxValue":"a-linux"}`)
+	syncDevice(t, dbA, transport, "a")
+	result := syncDevice(t, dbB, transport, "b")
+
+	// only the value of the platform device a runs on is synced, the other platforms keep the values of device b
+	platform := util.GetCurrentPlatform()
+	require.Len(t, result.Changes, 2)
+	assert.Contains(t, result.Changes, SettingChange{Key: "MainHotkey", Value: `"a-` + platformShortName(platform) + `"`, Platform: platform})
+	values, ok := splitPlatformValue(loadWoxSetting(t, dbB, "MainHotkey"))
+	require.True(t, ok)
+	for valuePlatform, value := range values {
+		if valuePlatform == platform {
+			assert.Equal(t, `"a-`+platformShortName(valuePlatform)+`"`, value)
+		} else {
+			assert.Equal(t, `"b-`+platformShortName(valuePlatform)+`"`, value)
+		}
+	}
+}
+
+func platformShortName(platform string) string {
+	return map[string]string{"darwin": "mac", "windows": "win", "linux": "linux"}[platform]
+}
+
+func TestSyncPluginSettings(t *testing.T) {
+	transport := &FolderSyncTransport{Directory: t.TempDir()}
+	dbA, dbB := newSyncTestDB(t), newSyncTestDB(t)
+
+	// settings saved before sync was tracked are seeded and synced too
+	require.NoError(t, dbA.Save(&database.PluginSetting{PluginID: "websearch", Key: "webSearches", Value: `[{"Keyword":"g"}]`}).Error)
+	require.NoError(t, dbA.Save(&database.PluginSetting{PluginID: "websearch", Key: "old", Value: "1"}).Error)
+	// the clipboard favorites stay on this machine
+	clipboardPluginId := "5f815d98-27f5-488d-a756-c317ea39935b"
+	require.NoError(t, dbA.Save(&database.PluginSetting{PluginID: clipboardPluginId, Key: "favorites", Value: `[{"Content":"a"}]`}).Error)
+	require.NoError(t, NewPluginSettingStore(dbA, clipboardPluginId).Set("favorites", `[{"Content":"b"}]`))
+	syncDevice(t, dbA, transport, "a")
+	syncDevice(t, dbB, transport, "b")
+
+	var pluginSettings []database.PluginSetting
+	require.NoError(t, dbB.Order("key").Find(&pluginSettings).Error)
+	assert.Equal(t, []database.PluginSetting{{PluginID: "websearch", Key: "old", Value: "1"}, {PluginID: "websearch", Key: "webSearches", Value: `[{"Keyword":"g"}]`}}, pluginSettings)
+
+	require.NoError(t, dbA.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&database.PluginSetting{PluginID: "websearch", Key: "old"}).Error; err != nil {
+			return err
+		}
+		return writeOplog(tx, "a", oplogEntityPluginSetting, "websearch", "old", oplogOperationDelete, "")
+	}))
+	syncDevice(t, dbA, transport, "a")
+	result := syncDevice(t, dbB, transport, "b")
+	assert.Equal(t, []SettingChange{{PluginId: "websearch", Key: "old", Deleted: true}}, result.Changes)
+	var count int64
+	require.NoError(t, dbB.Model(&database.PluginSetting{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestSyncIgnoresConflictCopies(t *testing.T) {
+	transport := &FolderSyncTransport{Directory: t.TempDir()}
+	dbA, dbB := newSyncTestDB(t), newSyncTestDB(t)
+
+	saveWoxSetting(t, dbA, "a", "ThemeId", "dark")
+	syncDevice(t, dbA, transport, "a")
+	data, err := transport.Read(context.Background(), "a.json")
+	require.NoError(t, err)
+	require.NoError(t, transport.Write(context.Background(), "a.sync-conflict-20250101.json", data))
+
+	result := syncDevice(t, dbB, transport, "b")
+	assert.Len(t, result.Changes, 1)
+}
+
+func TestWebDAVSyncTransport(t *testing.T) {
+	fake := httptest.NewServer(&webdav.Handler{FileSystem: webdav.NewMemFS(), LockSystem: webdav.NewMemLS()})
+	defer fake.Close()
+	ctx := context.Background()
+
+	// the collection doesn't exist yet
+	transport := &WebDAVSyncTransport{Url: fake.URL + "/wox sync/", Client: fake.Client()}
+	names, err := transport.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	dbA, dbB := newSyncTestDB(t), newSyncTestDB(t)
+	saveWoxSetting(t, dbA, "a", "ThemeId", "dark")
+	syncDevice(t, dbA, transport, "a")
+	syncDevice(t, dbB, transport, "b")
+	assert.Equal(t, "dark", loadWoxSetting(t, dbB, "ThemeId"))
+
+	names, err = transport.List(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a.json", "b.json"}, names)
+}
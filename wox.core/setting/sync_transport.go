@@ -0,0 +1,205 @@
+package setting
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"wox/util"
+)
+
+type SettingSyncType = string
+
+const (
+	SettingSyncTypeFolder SettingSyncType = "folder" // a folder synced by another tool, e.g. Syncthing or Dropbox
+	SettingSyncTypeWebDAV SettingSyncType = "webdav"
+)
+
+// SyncTransport stores the sync files of all devices in one flat location
+type SyncTransport interface {
+	List(ctx context.Context) ([]string, error)
+	Read(ctx context.Context, name string) ([]byte, error)
+	Write(ctx context.Context, name string, data []byte) error
+}
+
+// FolderSyncTransport keeps the sync files in a local folder that is synced between devices by another tool
+type FolderSyncTransport struct {
+	Directory string
+}
+
+func (f *FolderSyncTransport) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(f.Directory)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		// skip temp files, ours and the ones of sync tools
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+func (f *FolderSyncTransport) Read(ctx context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(f.Directory, name))
+}
+
+// Write replaces the file by renaming, so a sync tool never picks up a half written file
+func (f *FolderSyncTransport) Write(ctx context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(f.Directory, os.ModePerm); err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(f.Directory, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), filepath.Join(f.Directory, name))
+}
+
+// WebDAVSyncTransport keeps the sync files in a WebDAV collection, e.g. Nextcloud or a NAS
+type WebDAVSyncTransport struct {
+	Url      string // url of the collection
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+type webDAVMultiStatus struct {
+	Responses []struct {
+		Href string `xml:"href"`
+	} `xml:"response"`
+}
+
+func (w *WebDAVSyncTransport) List(ctx context.Context) ([]string, error) {
+	body := `<?xml version="1.0" encoding="utf-8"?><propfind xmlns="DAV:"><prop><resourcetype/></prop></propfind>`
+	resp, err := w.do(ctx, "PROPFIND", "", strings.NewReader(body), map[string]string{"Depth": "1", "Content-Type": "application/xml"})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("failed to list %s: %s", w.Url, resp.Status)
+	}
+
+	var multiStatus webDAVMultiStatus
+	if err := xml.NewDecoder(resp.Body).Decode(&multiStatus); err != nil {
+		return nil, fmt.Errorf("failed to parse webdav response: %w", err)
+	}
+
+	var names []string
+	for _, response := range multiStatus.Responses {
+		// the collection itself and sub collections end with a slash
+		if strings.HasSuffix(response.Href, "/") {
+			continue
+		}
+		href, unescapeErr := url.PathUnescape(response.Href)
+		if unescapeErr != nil {
+			continue
+		}
+		names = append(names, path.Base(href))
+	}
+	return names, nil
+}
+
+func (w *WebDAVSyncTransport) Read(ctx context.Context, name string) ([]byte, error) {
+	resp, err := w.do(ctx, http.MethodGet, name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read %s: %s", name, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Write creates the collection when it doesn't exist yet
+func (w *WebDAVSyncTransport) Write(ctx context.Context, name string, data []byte) error {
+	status, err := w.put(ctx, name, data)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound || status == http.StatusConflict {
+		resp, mkcolErr := w.do(ctx, "MKCOL", "", nil, nil)
+		if mkcolErr != nil {
+			return mkcolErr
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			return fmt.Errorf("failed to create %s: %s", w.Url, resp.Status)
+		}
+		status, err = w.put(ctx, name, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	if status != http.StatusOK && status != http.StatusCreated && status != http.StatusNoContent {
+		return fmt.Errorf("failed to write %s: %d", name, status)
+	}
+	return nil
+}
+
+func (w *WebDAVSyncTransport) put(ctx context.Context, name string, data []byte) (int, error) {
+	resp, err := w.do(ctx, http.MethodPut, name, bytes.NewReader(data), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (w *WebDAVSyncTransport) do(ctx context.Context, method string, name string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	if w.Url == "" {
+		return nil, errors.New("webdav url is empty")
+	}
+
+	requestUrl := strings.TrimSuffix(w.Url, "/") + "/" + url.PathEscape(name)
+	req, err := http.NewRequestWithContext(ctx, method, requestUrl, body)
+	if err != nil {
+		return nil, err
+	}
+	if w.Username != "" || w.Password != "" {
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = util.GetHTTPClient(ctx) // follows the proxy setting
+	}
+	return client.Do(req)
+}
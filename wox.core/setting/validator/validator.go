@@ -14,4 +14,21 @@ type PluginSettingValidator struct {
 
 type PluginSettingValidatorValue interface {
 	GetValidatorType() PluginSettingValidatorType
+	Validate(value string) error
+}
+
+// Validate checks a setting value the same way as the setting view does
+func (p PluginSettingValidator) Validate(value string) error {
+	if p.Value != nil {
+		return p.Value.Validate(value)
+	}
+
+	switch p.Type {
+	case PluginSettingValidatorTypeNotEmpty:
+		return (&PluginSettingValidatorNotEmpty{}).Validate(value)
+	case PluginSettingValidatorTypeIsNumber:
+		return (&PluginSettingValidatorIsNumber{}).Validate(value)
+	}
+
+	return nil
 }
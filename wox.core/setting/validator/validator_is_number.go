@@ -1,5 +1,10 @@
 package validator
 
+import (
+	"errors"
+	"strconv"
+)
+
 type PluginSettingValidatorIsNumber struct {
 	IsInteger bool
 	IsFloat   bool
@@ -8,3 +13,17 @@ type PluginSettingValidatorIsNumber struct {
 func (p *PluginSettingValidatorIsNumber) GetValidatorType() PluginSettingValidatorType {
 	return PluginSettingValidatorTypeIsNumber
 }
+
+func (p *PluginSettingValidatorIsNumber) Validate(value string) error {
+	if p.IsInteger {
+		if _, err := strconv.Atoi(value); err != nil {
+			return errors.New("value must be an integer")
+		}
+	} else if p.IsFloat {
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return errors.New("value must be a number")
+		}
+	}
+
+	return nil
+}
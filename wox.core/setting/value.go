@@ -1,6 +1,7 @@
 package setting
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 	"wox/util"
@@ -71,7 +72,7 @@ func (p *PlatformValue[T]) Set(t T) {
 	panic("unknown platform")
 }
 
-func NewWoxSettingValue[T any](store *WoxSettingStore, key string, defaultValue T) *WoxSettingValue[T] {
+func NewWoxSettingValue[T any](store SettingStore, key string, defaultValue T) *WoxSettingValue[T] {
 	return &WoxSettingValue[T]{
 		SettingValue: &SettingValue[T]{
 			settingStore: store,
@@ -81,7 +82,7 @@ func NewWoxSettingValue[T any](store *WoxSettingStore, key string, defaultValue
 	}
 }
 
-func NewWoxSettingValueWithValidator[T any](store *WoxSettingStore, key string, defaultValue T, validator ValidatorFunc[T]) *WoxSettingValue[T] {
+func NewWoxSettingValueWithValidator[T any](store SettingStore, key string, defaultValue T, validator ValidatorFunc[T]) *WoxSettingValue[T] {
 	return &WoxSettingValue[T]{
 		SettingValue: &SettingValue[T]{
 			settingStore: store,
@@ -92,7 +93,7 @@ func NewWoxSettingValueWithValidator[T any](store *WoxSettingStore, key string,
 	}
 }
 
-func NewPlatformValue[T any](store *WoxSettingStore, key string, winValue T, macValue T, linuxValue T) *PlatformValue[T] {
+func NewPlatformValue[T any](store SettingStore, key string, winValue T, macValue T, linuxValue T) *PlatformValue[T] {
 	return &PlatformValue[T]{
 		WoxSettingValue: NewWoxSettingValue(store, key, struct {
 			MacValue   T
@@ -106,14 +107,14 @@ func NewPlatformValue[T any](store *WoxSettingStore, key string, winValue T, mac
 	}
 }
 
-func NewPluginSettingValue[T any](store *PluginSettingStore, key string, defaultValue T) *PluginSettingValue[T] {
+func NewPluginSettingValue[T any](store SettingStore, pluginId string, key string, defaultValue T) *PluginSettingValue[T] {
 	return &PluginSettingValue[T]{
 		SettingValue: &SettingValue[T]{
 			settingStore: store,
 			key:          key,
 			defaultValue: defaultValue,
 		},
-		pluginId: store.pluginId,
+		pluginId: pluginId,
 	}
 }
 
@@ -171,3 +172,40 @@ func (v *SettingValue[T]) Set(newValue T) error {
 	v.isLoaded = true
 	return nil
 }
+
+// anySettingValue lets a setting be read and written without knowing its type, e.g. by the dotfile import
+type anySettingValue interface {
+	getKey() string
+	getAny() any
+	parseAny(data []byte) (any, error)
+	setAny(value any) error
+}
+
+func (v *SettingValue[T]) getKey() string {
+	return v.key
+}
+
+// getAny returns the stored value, a PlatformValue returns the values of all platforms
+func (v *SettingValue[T]) getAny() any {
+	return v.Get()
+}
+
+// parseAny decodes a json value into the type of the setting and validates it
+func (v *SettingValue[T]) parseAny(data []byte) (any, error) {
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	if v.validator != nil && !v.validator(value) {
+		return nil, fmt.Errorf("invalid value: %s", string(data))
+	}
+	return value, nil
+}
+
+func (v *SettingValue[T]) setAny(value any) error {
+	typedValue, ok := value.(T)
+	if !ok {
+		return fmt.Errorf("invalid value type for %s", v.key)
+	}
+	return v.Set(typedValue)
+}
@@ -27,6 +27,7 @@ type WoxSetting struct {
 	ShowPosition         *WoxSettingValue[PositionType]
 	AIProviders          *WoxSettingValue[[]AIProvider]
 	EnableAutoBackup     *WoxSettingValue[bool]
+	BackupPassphrase     *WoxSettingValue[string] // backups are encrypted with it when it is not empty
 	EnableAutoUpdate     *WoxSettingValue[bool]
 	CustomPythonPath     *PlatformValue[string]
 	CustomNodejsPath     *PlatformValue[string]
@@ -35,6 +36,23 @@ type WoxSetting struct {
 	HttpProxyEnabled *PlatformValue[bool]
 	HttpProxyUrl     *PlatformValue[string]
 
+	// Settings sync, these are never synced themselves
+	EnableSettingSync   *WoxSettingValue[bool]
+	SettingSyncType     *WoxSettingValue[SettingSyncType]
+	SettingSyncFolder   *WoxSettingValue[string] // folder of SettingSyncTypeFolder
+	SettingSyncUrl      *WoxSettingValue[string] // collection url of SettingSyncTypeWebDAV
+	SettingSyncUsername *WoxSettingValue[string]
+	SettingSyncPassword *WoxSettingValue[string]
+
+	DotfilePath *WoxSettingValue[string] // settings file that is watched and applied when it changes, empty to disable
+
+	// Stores of plugins, themes and AI commands
+	StoreUrls *WoxSettingValue[[]StoreUrl]
+
+	// Setting profiles, the active profile is chosen per device
+	Profiles      *WoxSettingValue[[]SettingProfile]
+	ActiveProfile *WoxSettingValue[string] // empty when no profile is active
+
 	// UI related
 	AppWidth       *WoxSettingValue[int]
 	MaxResultCount *WoxSettingValue[int]
@@ -87,6 +105,28 @@ func (q *QueryShortcut) PlaceholderCount() int {
 	return len(regexp.MustCompile(`(?m){\d}`).FindAllString(q.Query, -1))
 }
 
+type StoreType = string
+
+const (
+	StoreTypePlugin    StoreType = "plugin"
+	StoreTypeTheme     StoreType = "theme"
+	StoreTypeAICommand StoreType = "ai_command"
+)
+
+// StoreUrl is a store of plugins, themes or AI commands.
+// Url is a http(s) url or a file:// url, e.g. file:///mnt/team/store-plugin.json for a store shared by a team
+type StoreUrl struct {
+	Type StoreType
+	Name string
+	Url  string
+}
+
+var defaultStoreUrls = []StoreUrl{
+	{Type: StoreTypePlugin, Name: "Wox Official Plugin Store", Url: "https://raw.githubusercontent.com/Wox-launcher/Wox/master/store-plugin.json"},
+	{Type: StoreTypeTheme, Name: "Wox Official Theme Store", Url: "https://raw.githubusercontent.com/Wox-launcher/Wox/master/store-theme.json"},
+	{Type: StoreTypeAICommand, Name: "Wox Official AI Command Store", Url: "https://raw.githubusercontent.com/Wox-launcher/Wox/master/store-ai-command.json"},
+}
+
 type AIProvider struct {
 	Name   common.ProviderName // see ai.ProviderName
 	ApiKey string
@@ -119,7 +159,7 @@ type QueryHistory struct {
 	Timestamp int64
 }
 
-func NewWoxSetting(store *WoxSettingStore) *WoxSetting {
+func NewWoxSetting(store SettingStore) *WoxSetting {
 	usePinYin := false
 	defaultLangCode := i18n.LangCodeEnUs
 	switchInputMethodABC := false
@@ -152,14 +192,27 @@ func NewWoxSetting(store *WoxSettingStore) *WoxSetting {
 		CustomPythonPath: NewPlatformValue(store, "CustomPythonPath", "", "", ""),
 		CustomNodejsPath: NewPlatformValue(store, "CustomNodejsPath", "", "", ""),
 		EnableAutoBackup: NewWoxSettingValue(store, "EnableAutoBackup", true),
+		BackupPassphrase: NewWoxSettingValue(store, "BackupPassphrase", ""),
 		EnableAutoUpdate: NewWoxSettingValue(store, "EnableAutoUpdate", true),
-		LastWindowX:      NewWoxSettingValue(store, "LastWindowX", -1),
-		LastWindowY:      NewWoxSettingValue(store, "LastWindowY", -1),
-		QueryHotkeys:     NewPlatformValue(store, "QueryHotkeys", []QueryHotkey{}, []QueryHotkey{}, []QueryHotkey{}),
-		QueryShortcuts:   NewWoxSettingValue(store, "QueryShortcuts", []QueryShortcut{}),
-		AIProviders:      NewWoxSettingValue(store, "AIProviders", []AIProvider{}),
-		QueryHistories:   NewWoxSettingValue(store, "QueryHistories", []QueryHistory{}),
-		PinedResults:     NewWoxSettingValue(store, "PinedResults", util.NewHashMap[ResultHash, bool]()),
-		ActionedResults:  NewWoxSettingValue(store, "ActionedResults", util.NewHashMap[ResultHash, []ActionedResult]()),
+
+		EnableSettingSync:   NewWoxSettingValue(store, "EnableSettingSync", false),
+		SettingSyncType:     NewWoxSettingValue(store, "SettingSyncType", SettingSyncTypeFolder),
+		SettingSyncFolder:   NewWoxSettingValue(store, "SettingSyncFolder", ""),
+		SettingSyncUrl:      NewWoxSettingValue(store, "SettingSyncUrl", ""),
+		SettingSyncUsername: NewWoxSettingValue(store, "SettingSyncUsername", ""),
+		SettingSyncPassword: NewWoxSettingValue(store, "SettingSyncPassword", ""),
+		DotfilePath:         NewWoxSettingValue(store, "DotfilePath", ""),
+		StoreUrls:           NewWoxSettingValue(store, "StoreUrls", defaultStoreUrls),
+		Profiles:            NewWoxSettingValue(store, "Profiles", []SettingProfile{}),
+		ActiveProfile:       NewWoxSettingValue(store, "ActiveProfile", ""),
+
+		LastWindowX:     NewWoxSettingValue(store, "LastWindowX", -1),
+		LastWindowY:     NewWoxSettingValue(store, "LastWindowY", -1),
+		QueryHotkeys:    NewPlatformValue(store, "QueryHotkeys", []QueryHotkey{}, []QueryHotkey{}, []QueryHotkey{}),
+		QueryShortcuts:  NewWoxSettingValue(store, "QueryShortcuts", []QueryShortcut{}),
+		AIProviders:     NewWoxSettingValue(store, "AIProviders", []AIProvider{}),
+		QueryHistories:  NewWoxSettingValue(store, "QueryHistories", []QueryHistory{}),
+		PinedResults:    NewWoxSettingValue(store, "PinedResults", util.NewHashMap[ResultHash, bool]()),
+		ActionedResults: NewWoxSettingValue(store, "ActionedResults", util.NewHashMap[ResultHash, []ActionedResult]()),
 	}
 }
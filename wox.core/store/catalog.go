@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"wox/setting"
+	"wox/util"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Kind describes the items of a type of store, e.g. the plugins of the plugin stores
+type Kind[T any] struct {
+	Type       setting.StoreType
+	GetId      func(item T) string
+	GetName    func(item T) string
+	GetVersion func(item T) string
+
+	// GetInstalledVersion returns the version of the installed item, false when the item is not installed
+	GetInstalledVersion func(ctx context.Context, id string) (string, bool)
+
+	// Normalize is applied to every item read from a store, optional
+	Normalize func(item T) T
+}
+
+// Catalog is the merged list of the items of all stores of a type, see setting.StoreUrl
+type Catalog[T any] struct {
+	kind        Kind[T]
+	items       []T
+	storeItems  map[string][]T // items of every store url, kept for a store that can't be read on the next refresh
+	lock        sync.RWMutex
+	refreshLock sync.Mutex
+}
+
+func NewCatalog[T any](kind Kind[T]) *Catalog[T] {
+	return &Catalog[T]{kind: kind}
+}
+
+func (c *Catalog[T]) Type() setting.StoreType {
+	return c.kind.Type
+}
+
+// Refresh rebuilds the catalog from the current stores of its type, so the items of a removed store are dropped.
+// A store that can't be read keeps the items it had on the previous refresh.
+func (c *Catalog[T]) Refresh(ctx context.Context) {
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	items, storeItems := c.read(ctx, GetStoreUrls(ctx, c.kind.Type), c.storeItems)
+
+	c.lock.Lock()
+	c.items = items
+	c.storeItems = storeItems
+	c.lock.Unlock()
+}
+
+// read returns the items of the stores and the items of every store url, an item in several stores is taken from the store with its newest version.
+// The previous items of a store are used when the store can't be read.
+func (c *Catalog[T]) read(ctx context.Context, storeUrls []setting.StoreUrl, previous map[string][]T) ([]T, map[string][]T) {
+	var items []T
+	storeItemsByUrl := map[string][]T{}
+	for _, storeUrl := range storeUrls {
+		storeItems, readErr := c.readStore(ctx, storeUrl)
+		if readErr != nil {
+			util.GetLogger().Error(ctx, readErr.Error())
+			previousItems, found := previous[storeUrl.Url]
+			if !found {
+				continue
+			}
+			util.GetLogger().Info(ctx, fmt.Sprintf("keep %d %s items of %s store", len(previousItems), c.kind.Type, storeUrl.Name))
+			storeItems = previousItems
+		}
+		storeItemsByUrl[storeUrl.Url] = storeItems
+
+		for _, item := range storeItems {
+			index := slices.IndexFunc(items, func(existing T) bool { return c.kind.GetId(existing) == c.kind.GetId(item) })
+			if index == -1 {
+				items = append(items, item)
+				continue
+			}
+			if IsNewerVersion(c.kind.GetVersion(item), c.kind.GetVersion(items[index])) {
+				util.GetLogger().Info(ctx, fmt.Sprintf("use %s(%s) from %s store instead of version %s", c.kind.GetName(item), c.kind.GetVersion(item), storeUrl.Name, c.kind.GetVersion(items[index])))
+				items[index] = item
+			}
+		}
+	}
+
+	util.GetLogger().Info(ctx, fmt.Sprintf("found %d %s items from stores", len(items), c.kind.Type))
+	return items, storeItemsByUrl
+}
+
+func (c *Catalog[T]) readStore(ctx context.Context, storeUrl setting.StoreUrl) ([]T, error) {
+	util.GetLogger().Info(ctx, fmt.Sprintf("start to get %s manifest from %s(%s)", c.kind.Type, storeUrl.Name, storeUrl.Url))
+	data, readErr := ReadUrl(ctx, storeUrl.Url)
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to get %s manifest from %s store: %w", c.kind.Type, storeUrl.Name, readErr)
+	}
+
+	var storeItems []T
+	if unmarshalErr := json.Unmarshal(data, &storeItems); unmarshalErr != nil {
+		return nil, fmt.Errorf("failed to parse %s manifest from %s store: %w", c.kind.Type, storeUrl.Name, unmarshalErr)
+	}
+
+	if c.kind.Normalize != nil {
+		for i, item := range storeItems {
+			storeItems[i] = c.kind.Normalize(item)
+		}
+	}
+	return storeItems, nil
+}
+
+func (c *Catalog[T]) GetItems() []T {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return slices.Clone(c.items)
+}
+
+func (c *Catalog[T]) Find(id string) (T, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	index := slices.IndexFunc(c.items, func(item T) bool { return c.kind.GetId(item) == id })
+	if index == -1 {
+		var empty T
+		return empty, false
+	}
+	return c.items[index], true
+}
+
+// Search returns the items whose name matches the keyword, all items when the keyword is empty
+func (c *Catalog[T]) Search(keyword string) []T {
+	return slices.DeleteFunc(c.GetItems(), func(item T) bool {
+		return keyword != "" && !util.IsStringMatch(c.kind.GetName(item), keyword, false)
+	})
+}
+
+// IsUpgradable reports whether the item is installed in an older version
+func (c *Catalog[T]) IsUpgradable(ctx context.Context, item T) bool {
+	installedVersion, installed := c.kind.GetInstalledVersion(ctx, c.kind.GetId(item))
+	return installed && IsNewerVersion(c.kind.GetVersion(item), installedVersion)
+}
+
+// GetUpdates returns the items that are installed in an older version
+func (c *Catalog[T]) GetUpdates(ctx context.Context) []T {
+	return slices.DeleteFunc(c.GetItems(), func(item T) bool {
+		return !c.IsUpgradable(ctx, item)
+	})
+}
+
+func (c *Catalog[T]) getUpdateIds(ctx context.Context) map[string]string {
+	updates := map[string]string{}
+	for _, item := range c.GetUpdates(ctx) {
+		updates[fmt.Sprintf("%s@%s", c.kind.GetId(item), c.kind.GetVersion(item))] = c.kind.GetName(item)
+	}
+	return updates
+}
+
+// IsNewerVersion reports whether version is a newer semantic version than other, versions that can't be parsed are never newer
+func IsNewerVersion(version string, other string) bool {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	o, err := semver.NewVersion(other)
+	if err != nil {
+		return false
+	}
+	return v.GreaterThan(o)
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"wox/setting"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStoreItem struct {
+	Id      string
+	Name    string
+	Version string
+}
+
+func newTestCatalog(installed map[string]string) *Catalog[testStoreItem] {
+	return NewCatalog(Kind[testStoreItem]{
+		Type:       setting.StoreTypePlugin,
+		GetId:      func(item testStoreItem) string { return item.Id },
+		GetName:    func(item testStoreItem) string { return item.Name },
+		GetVersion: func(item testStoreItem) string { return item.Version },
+		GetInstalledVersion: func(ctx context.Context, id string) (string, bool) {
+			version, ok := installed[id]
+			return version, ok
+		},
+	})
+}
+
+func writeTestStore(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "store.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return "file://" + filepath.ToSlash(path)
+}
+
+func TestCatalogRead(t *testing.T) {
+	official := writeTestStore(t, `[{"Id": "a", "Name": "Clipboard", "Version": "1.0.0"}, {"Id": "b", "Name": "Calendar", "Version": "2.0.0"}]`)
+	team := writeTestStore(t, `[{"Id": "a", "Name": "Clipboard", "Version": "1.2.0"}, {"Id": "b", "Name": "Calendar", "Version": "1.0.0"}, {"Id": "c", "Name": "Team tools", "Version": "0.1.0"}]`)
+
+	catalog := newTestCatalog(nil)
+	items, storeItems := catalog.read(context.Background(), []setting.StoreUrl{
+		{Name: "official", Url: official},
+		{Name: "broken", Url: "file:///not/existing/store.json"},
+		{Name: "team", Url: team},
+	}, nil)
+
+	assert.Equal(t, []testStoreItem{
+		{Id: "a", Name: "Clipboard", Version: "1.2.0"},
+		{Id: "b", Name: "Calendar", Version: "2.0.0"},
+		{Id: "c", Name: "Team tools", Version: "0.1.0"},
+	}, items, "an item in several stores comes from the store with its newest version, a store that can't be read is skipped")
+	assert.Len(t, storeItems, 2)
+}
+
+func TestCatalogReadKeepsItemsOfFailedStores(t *testing.T) {
+	official := writeTestStore(t, `[{"Id": "a", "Name": "Clipboard", "Version": "1.0.0"}]`)
+	team := writeTestStore(t, `[{"Id": "c", "Name": "Team tools", "Version": "0.1.0"}]`)
+
+	catalog := newTestCatalog(nil)
+	_, storeItems := catalog.read(context.Background(), []setting.StoreUrl{{Name: "official", Url: official}, {Name: "team", Url: team}}, nil)
+
+	require.NoError(t, os.Remove(strings.TrimPrefix(team, "file://")))
+	items, _ := catalog.read(context.Background(), []setting.StoreUrl{{Name: "team", Url: team}}, storeItems)
+	assert.Equal(t, []testStoreItem{{Id: "c", Name: "Team tools", Version: "0.1.0"}}, items, "a store that can't be read keeps its items, a removed store drops out")
+}
+
+func TestCatalogUpdates(t *testing.T) {
+	catalog := newTestCatalog(map[string]string{"a": "1.0.0", "b": "2.0.0", "c": "dev"})
+	catalog.items = []testStoreItem{
+		{Id: "a", Name: "Clipboard", Version: "1.2.0"},
+		{Id: "b", Name: "Calendar", Version: "2.0.0"},
+		{Id: "c", Name: "Team tools", Version: "0.1.0"},
+		{Id: "d", Name: "Not installed", Version: "1.0.0"},
+	}
+
+	assert.Equal(t, []testStoreItem{{Id: "a", Name: "Clipboard", Version: "1.2.0"}}, catalog.GetUpdates(context.Background()))
+	assert.Len(t, catalog.Search("cal"), 1)
+	assert.Len(t, catalog.Search(""), 4)
+
+	var reported [][]string
+	manager := &Manager{notifiedUpdates: map[string]bool{}}
+	manager.CheckUpdates(context.Background(), catalog)
+	manager.OnUpdatesAvailable(func(ctx context.Context, storeType setting.StoreType, names []string) {
+		reported = append(reported, names)
+	})
+	manager.CheckUpdates(context.Background(), catalog)
+	manager.CheckUpdates(context.Background(), catalog)
+	assert.Equal(t, [][]string{{"Clipboard"}}, reported, "an update is reported once, and only once there is a callback")
+}
+
+// refreshCountingCatalog counts the refreshes of a catalog without stores
+type refreshCountingCatalog struct {
+	refreshed atomic.Int32
+}
+
+func (c *refreshCountingCatalog) Type() setting.StoreType { return setting.StoreTypeTheme }
+
+func (c *refreshCountingCatalog) Refresh(ctx context.Context) { c.refreshed.Add(1) }
+
+func (c *refreshCountingCatalog) getUpdateIds(ctx context.Context) map[string]string { return nil }
+
+func TestRegisterLoadsCatalog(t *testing.T) {
+	catalog := &refreshCountingCatalog{}
+	manager := &Manager{notifiedUpdates: map[string]bool{}}
+	manager.Register(context.Background(), catalog)
+	assert.Equal(t, int32(1), catalog.refreshed.Load(), "the catalog is loaded before Register returns")
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+	"wox/setting"
+	"wox/util"
+)
+
+const refreshInterval = 10 * time.Minute
+
+var managerInstance *Manager
+var managerOnce sync.Once
+
+// RegisteredCatalog is a Catalog of any type of item
+type RegisteredCatalog interface {
+	Type() setting.StoreType
+	Refresh(ctx context.Context)
+	getUpdateIds(ctx context.Context) map[string]string
+}
+
+// Manager refreshes the catalogs of plugins, themes and AI commands in the background and reports the updates of installed items
+type Manager struct {
+	catalogs           []RegisteredCatalog
+	notifiedUpdates    map[string]bool // id@version of the updates that were reported already
+	onUpdatesAvailable func(ctx context.Context, storeType setting.StoreType, names []string)
+	lock               sync.Mutex
+	startOnce          sync.Once
+}
+
+func GetStoreManager() *Manager {
+	managerOnce.Do(func() {
+		managerInstance = &Manager{
+			notifiedUpdates: map[string]bool{},
+		}
+		setting.GetSettingManager().RegisterSettingChangeListener(managerInstance.onSettingsChanged)
+	})
+	return managerInstance
+}
+
+// GetStoreUrls returns the stores of the type from the StoreUrls setting
+func GetStoreUrls(ctx context.Context, storeType setting.StoreType) []setting.StoreUrl {
+	return slices.DeleteFunc(setting.GetSettingManager().GetWoxSetting(ctx).StoreUrls.Get(), func(storeUrl setting.StoreUrl) bool {
+		return storeUrl.Type != storeType
+	})
+}
+
+// OnUpdatesAvailable sets the callback that gets the names of the installed items with a new update, every update is reported once
+func (m *Manager) OnUpdatesAvailable(callback func(ctx context.Context, storeType setting.StoreType, names []string)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.onUpdatesAvailable = callback
+}
+
+// Register loads the catalog before it returns, so the catalog can be queried right away,
+// and then refreshes it every 10 minutes in the background together with the other catalogs
+func (m *Manager) Register(ctx context.Context, catalog RegisteredCatalog) {
+	m.refresh(ctx, catalog)
+
+	m.lock.Lock()
+	m.catalogs = append(m.catalogs, catalog)
+	m.lock.Unlock()
+
+	m.startOnce.Do(func() {
+		util.Go(ctx, "refresh stores", func() {
+			for range time.NewTicker(refreshInterval).C {
+				m.lock.Lock()
+				catalogs := slices.Clone(m.catalogs)
+				m.lock.Unlock()
+
+				for _, catalog := range catalogs {
+					m.refresh(util.NewTraceContext(), catalog)
+				}
+			}
+		})
+	})
+}
+
+// RefreshAll refreshes all catalogs in the background, e.g. after the StoreUrls setting changed
+func (m *Manager) RefreshAll(ctx context.Context) {
+	m.lock.Lock()
+	catalogs := slices.Clone(m.catalogs)
+	m.lock.Unlock()
+
+	util.Go(ctx, "refresh stores", func() {
+		for _, catalog := range catalogs {
+			m.refresh(util.NewTraceContext(), catalog)
+		}
+	})
+}
+
+func (m *Manager) onSettingsChanged(ctx context.Context, changes []setting.SettingChange) {
+	if slices.ContainsFunc(changes, func(change setting.SettingChange) bool { return change.PluginId == "" && change.Key == "StoreUrls" }) {
+		m.RefreshAll(ctx)
+	}
+}
+
+func (m *Manager) refresh(ctx context.Context, catalog RegisteredCatalog) {
+	catalog.Refresh(ctx)
+	m.CheckUpdates(ctx, catalog)
+}
+
+// CheckUpdates reports the updates of the catalog that were not reported yet
+func (m *Manager) CheckUpdates(ctx context.Context, catalog RegisteredCatalog) {
+	updates := catalog.getUpdateIds(ctx)
+
+	m.lock.Lock()
+	callback := m.onUpdatesAvailable
+	var names []string
+	for _, id := range slices.Sorted(maps.Keys(updates)) {
+		// updates found before there is a callback are reported on a later check
+		if callback != nil && !m.notifiedUpdates[id] {
+			m.notifiedUpdates[id] = true
+			names = append(names, updates[id])
+		}
+	}
+	m.lock.Unlock()
+
+	if len(names) == 0 {
+		return
+	}
+	util.GetLogger().Info(ctx, fmt.Sprintf("found %d %s updates: %v", len(names), catalog.Type(), names))
+	callback(ctx, catalog.Type(), names)
+}
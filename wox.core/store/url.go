@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"wox/util"
+)
+
+// ReadUrl reads a http(s) url, or a file:// url of a store shared on disk
+func ReadUrl(ctx context.Context, rawUrl string) ([]byte, error) {
+	if path, isFile := getFilePath(rawUrl); isFile {
+		return os.ReadFile(path)
+	}
+	return util.HttpGet(ctx, rawUrl)
+}
+
+// Download saves a http(s) url, or a file:// url of a store shared on disk, to dest
+func Download(ctx context.Context, rawUrl string, dest string, progressCallback func(downloaded int64, total int64)) error {
+	path, isFile := getFilePath(rawUrl)
+	if !isFile {
+		return util.HttpDownloadWithProgress(ctx, rawUrl, dest, progressCallback)
+	}
+
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	copied, err := io.Copy(out, source)
+	if err != nil {
+		return err
+	}
+	if progressCallback != nil {
+		progressCallback(copied, copied)
+	}
+	return nil
+}
+
+// getFilePath returns the path of a file:// url, e.g. file:///home/wox/store.json, file:///C:/store.json or file://server/share/store.json
+func getFilePath(rawUrl string) (string, bool) {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil || !strings.EqualFold(parsed.Scheme, "file") {
+		return "", false
+	}
+
+	path := parsed.Path
+	if parsed.Host != "" && parsed.Host != "localhost" {
+		path = "//" + parsed.Host + path
+	} else if util.IsWindows() && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), true
+}
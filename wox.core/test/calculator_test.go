@@ -240,3 +240,69 @@ func TestCalculatorAdvanced(t *testing.T) {
 
 	suite.RunQueryTests(tests)
 }
+
+func TestCalculatorSession(t *testing.T) {
+	suite := NewTestSuite(t)
+
+	tests := []QueryTest{
+		{
+			Name:           "Assignment",
+			Query:          "rate = 0.07",
+			ExpectedTitle:  "0.07",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Multiple statements",
+			Query:          "price = 200; price * 0.07",
+			ExpectedTitle:  "14",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Previous result",
+			Query:          "2 * 3; ans + 1",
+			ExpectedTitle:  "7",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "User function used in the same query",
+			Query:          "f(x) = x^2+1; f(3)",
+			ExpectedTitle:  "10",
+			ExpectedAction: "Copy result",
+		},
+	}
+
+	suite.RunQueryTests(tests)
+}
+
+func TestCalculatorProgrammer(t *testing.T) {
+	suite := NewTestSuite(t)
+
+	tests := []QueryTest{
+		{
+			Name:           "Hex literal",
+			Query:          "0xFF + 1",
+			ExpectedTitle:  "256",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Convert to hex",
+			Query:          "255 in hex",
+			ExpectedTitle:  "0xFF",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Bitwise operators",
+			Query:          "0b1100 ^ 0b1010 | 1 << 4",
+			ExpectedTitle:  "22",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Two's complement width",
+			Query:          "-1 in hex u8",
+			ExpectedTitle:  "0xFF",
+			ExpectedAction: "Copy result",
+		},
+	}
+
+	suite.RunQueryTests(tests)
+}
@@ -157,6 +157,18 @@ func TestConverterCurrency(t *testing.T) {
 			ShouldSkip: ShouldSkipNetworkTests(),
 			SkipReason: "Network connectivity required for exchange rates",
 		},
+		{
+			Name:           "Currency symbol to code",
+			Query:          "€10 in chf",
+			ExpectedTitle:  "",
+			ExpectedAction: "Copy result",
+			TitleCheck: func(title string) bool {
+				return strings.HasSuffix(title, " CHF") && title[0] >= '0' && title[0] <= '9'
+			},
+			Timeout:    30 * time.Second,
+			ShouldSkip: ShouldSkipNetworkTests(),
+			SkipReason: "Network connectivity required for exchange rates",
+		},
 		// Complex crypto percentage calculations are not supported
 		// {
 		// 	Name:           "complex crypto convert",
@@ -174,3 +186,96 @@ func TestConverterCurrency(t *testing.T) {
 
 	suite.RunQueryTests(tests)
 }
+
+func TestConverterUnits(t *testing.T) {
+	suite := NewTestSuite(t)
+
+	tests := []QueryTest{
+		{
+			Name:           "Length",
+			Query:          "10 km in miles",
+			ExpectedTitle:  "6.213712 mi",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Length tokenized as duration",
+			Query:          "10 m to ft",
+			ExpectedTitle:  "32.808399 ft",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Length sum",
+			Query:          "1 km + 500 m",
+			ExpectedTitle:  "1.5 km",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Mass",
+			Query:          "1 kg - 200 g to lb",
+			ExpectedTitle:  "1.763698 lb",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Volume",
+			Query:          "3 cups in ml",
+			ExpectedTitle:  "709.76471 mL",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Temperature",
+			Query:          "72 f to c",
+			ExpectedTitle:  "22.222222 °C",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Negative temperature",
+			Query:          "-40 c to f",
+			ExpectedTitle:  "-40 °F",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Area",
+			Query:          "2 ha in km2",
+			ExpectedTitle:  "0.02 km²",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Speed",
+			Query:          "100 km/h to mph",
+			ExpectedTitle:  "62.137119 mph",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Pressure",
+			Query:          "1 atm to kPa",
+			ExpectedTitle:  "101.325 kPa",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Energy",
+			Query:          "1 kWh to MJ",
+			ExpectedTitle:  "3.6 MJ",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Data size IEC to SI",
+			Query:          "5 GiB to MB",
+			ExpectedTitle:  "5368.70912 MB",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Data size bits",
+			Query:          "100 Mb to MB",
+			ExpectedTitle:  "12.5 MB",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Angle",
+			Query:          "180 deg to rad",
+			ExpectedTitle:  "3.141593 rad",
+			ExpectedAction: "Copy result",
+		},
+	}
+
+	suite.RunQueryTests(tests)
+}
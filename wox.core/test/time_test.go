@@ -130,3 +130,39 @@ func TestTimeZoneConversions(t *testing.T) {
 
 	suite.RunQueryTests(tests)
 }
+
+func TestDateArithmetic(t *testing.T) {
+	suite := NewTestSuite(t)
+
+	tests := []QueryTest{
+		{
+			Name:           "Business days",
+			Query:          "2025-03-01 + 45 business days",
+			ExpectedTitle:  "2025-05-02 (Friday)",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Date difference",
+			Query:          "2025-12-25 - 2025-03-05",
+			ExpectedTitle:  "9 months 20 days",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "ISO week",
+			Query:          "week of 2024-12-30",
+			ExpectedTitle:  "Week 1 (2025-W01)",
+			ExpectedAction: "Copy result",
+		},
+		{
+			Name:           "Meeting planner",
+			Query:          "3pm PST in Berlin, Tokyo",
+			ExpectedTitle:  "",
+			ExpectedAction: "Copy result",
+			TitleCheck: func(title string) bool {
+				return strings.HasPrefix(title, "Berlin ") && strings.Contains(title, ", Tokyo ")
+			},
+		},
+	}
+
+	suite.RunQueryTests(tests)
+}
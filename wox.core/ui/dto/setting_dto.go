@@ -24,10 +24,23 @@ type WoxSettingDto struct {
 	HttpProxyUrl         string
 	ShowPosition         setting.PositionType
 	EnableAutoBackup     bool
+	EnableBackupEncrypt  bool // the passphrase itself is never sent to the UI
 	EnableAutoUpdate     bool
 	CustomPythonPath     string
 	CustomNodejsPath     string
 
+	EnableSettingSync      bool
+	SettingSyncType        setting.SettingSyncType
+	SettingSyncFolder      string
+	SettingSyncUrl         string
+	SettingSyncUsername    string
+	HasSettingSyncPassword bool // the password itself is never sent to the UI
+
+	DotfilePath string
+
+	Profiles      []setting.SettingProfile
+	ActiveProfile string
+
 	// UI related
 	AppWidth       int
 	MaxResultCount int
@@ -11,13 +11,17 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"wox/common"
 	"wox/i18n"
 	"wox/plugin"
 	"wox/resource"
 	"wox/setting"
+	"wox/store"
 	"wox/updater"
 	"wox/util"
+	"wox/util/appearance"
 	"wox/util/autostart"
 	"wox/util/hotkey"
 	"wox/util/ime"
@@ -25,7 +29,6 @@ import (
 	"wox/util/shell"
 	"wox/util/tray"
 
-	"github.com/Masterminds/semver/v3"
 	"github.com/fsnotify/fsnotify"
 	"github.com/google/uuid"
 	"github.com/mitchellh/go-homedir"
@@ -37,6 +40,9 @@ var managerInstance *Manager
 var managerOnce sync.Once
 var logger *util.Log
 
+// appearanceCheckInterval is how often the OS appearance is checked while the current theme has light and dark fields, when the OS can't notify its changes
+const appearanceCheckInterval = 3 * time.Second
+
 type Manager struct {
 	mainHotkey       *hotkey.Hotkey
 	selectionHotkey  *hotkey.Hotkey
@@ -44,8 +50,10 @@ type Manager struct {
 	ui               common.UI
 	serverPort       int
 	uiProcess        *os.Process
-	themes           *util.HashMap[string, common.Theme]
+	themes           *util.HashMap[string, common.Theme] // installed themes as they are saved, see resolveTheme
 	systemThemeIds   []string
+	appearance       atomic.Value                 // appearance.Light or appearance.Dark of the OS
+	previewTheme     atomic.Pointer[common.Theme] // theme that is not installed and shown instead of the current theme, e.g. a theme that is being edited
 	isUIReadyHandled bool
 
 	activeWindowName string          // active window name before wox is activated
@@ -83,31 +91,24 @@ func (m *Manager) Start(ctx context.Context) error {
 		m.systemThemeIds = append(m.systemThemeIds, theme.ThemeId)
 	}
 
-	//load user themes
-	userThemesDirectory := util.GetLocation().GetThemeDirectory()
-	dirEntry, readErr := os.ReadDir(userThemesDirectory)
-	if readErr != nil {
-		return readErr
+	loadErr := m.loadUserThemes(ctx)
+	if loadErr != nil {
+		return loadErr
 	}
-	for _, entry := range dirEntry {
-		if entry.IsDir() {
-			continue
-		}
 
-		themeData, readThemeErr := os.ReadFile(userThemesDirectory + "/" + entry.Name())
-		if readThemeErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to read user theme: %s, %s", entry.Name(), readThemeErr.Error()))
-			continue
-		}
+	m.appearance.Store(appearance.Get())
+	util.Go(ctx, "watch os appearance", func() {
+		m.watchAppearance(ctx)
+	})
 
-		theme, themeErr := m.parseTheme(string(themeData))
-		if themeErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to parse user theme: %s, %s", entry.Name(), themeErr.Error()))
-			continue
-		}
-		m.themes.Store(theme.ThemeId, theme)
-	}
+	// user themes and the current theme change when a backup is restored
+	setting.GetSettingManager().RegisterRestoreHook(setting.RestoreHook{
+		Name:   "themes",
+		Reload: m.reloadUserThemes,
+	})
+	setting.GetSettingManager().RegisterSettingChangeListener(m.onSettingsChanged)
 
+	userThemesDirectory := util.GetLocation().GetThemeDirectory()
 	if util.IsDev() {
 		var onThemeChange = func(e fsnotify.Event) {
 			var themePath = e.Name
@@ -156,6 +157,13 @@ func (m *Manager) Start(ctx context.Context) error {
 		})
 	}
 
+	store.GetStoreManager().OnUpdatesAvailable(func(ctx context.Context, storeType setting.StoreType, names []string) {
+		m.GetUI(ctx).Notify(ctx, common.NotifyMsg{
+			Text:           fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "store_updates_available_"+storeType), strings.Join(names, ", ")),
+			DisplaySeconds: 8,
+		})
+	})
+
 	util.Go(ctx, "start store manager", func() {
 		GetStoreManager().Start(util.NewTraceContext())
 	})
@@ -328,10 +336,88 @@ func (m *Manager) StartUIApp(ctx context.Context) error {
 	return nil
 }
 
+func (m *Manager) loadUserThemes(ctx context.Context) error {
+	userThemesDirectory := util.GetLocation().GetThemeDirectory()
+	dirEntry, readErr := os.ReadDir(userThemesDirectory)
+	if readErr != nil {
+		return readErr
+	}
+	for _, entry := range dirEntry {
+		if entry.IsDir() {
+			continue
+		}
+
+		themeData, readThemeErr := os.ReadFile(userThemesDirectory + "/" + entry.Name())
+		if readThemeErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to read user theme: %s, %s", entry.Name(), readThemeErr.Error()))
+			continue
+		}
+
+		theme, themeErr := m.parseTheme(string(themeData))
+		if themeErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to parse user theme: %s, %s", entry.Name(), themeErr.Error()))
+			continue
+		}
+		m.themes.Store(theme.ThemeId, theme)
+	}
+
+	// validate after all themes are loaded, a user theme may extend another user theme
+	for _, theme := range m.themes.FilterList(func(key string, theme common.Theme) bool {
+		return !theme.IsSystem
+	}) {
+		m.validateLoadedTheme(ctx, theme)
+	}
+
+	return nil
+}
+
+// validateLoadedTheme reports the problems of a theme installed before, the theme is kept so the current theme doesn't disappear.
+// Themes installed before the theme fields were validated may miss fields added later, they get these fields from the default theme.
+func (m *Manager) validateLoadedTheme(ctx context.Context, theme common.Theme) {
+	validateErr := common.ValidateTheme(theme, m.themes.Load)
+	if validateErr == nil {
+		return
+	}
+
+	if theme.Extends == "" {
+		completedTheme := theme
+		completedTheme.Extends = setting.DefaultThemeId
+		if common.ValidateTheme(completedTheme, m.themes.Load) == nil {
+			logger.Warn(ctx, fmt.Sprintf("user theme %s is incomplete, the missing fields come from the default theme: %s", theme.ThemeName, validateErr.Error()))
+			m.themes.Store(theme.ThemeId, completedTheme)
+			return
+		}
+	}
+
+	logger.Error(ctx, fmt.Sprintf("invalid user theme %s: %s", theme.ThemeName, validateErr.Error()))
+}
+
+// reloadUserThemes replaces the user themes with the ones in the theme directory and applies the current theme
+func (m *Manager) reloadUserThemes(ctx context.Context) error {
+	for _, theme := range m.themes.FilterList(func(key string, theme common.Theme) bool {
+		return !theme.IsSystem
+	}) {
+		m.themes.Delete(theme.ThemeId)
+	}
+
+	loadErr := m.loadUserThemes(ctx)
+	if loadErr != nil {
+		return loadErr
+	}
+
+	currentTheme := m.GetCurrentTheme(ctx)
+	if currentTheme.ThemeId == "" {
+		m.ChangeToDefaultTheme(ctx)
+		return nil
+	}
+	m.ChangeTheme(ctx, currentTheme)
+	return nil
+}
+
 func (m *Manager) GetCurrentTheme(ctx context.Context) common.Theme {
 	woxSetting := setting.GetSettingManager().GetWoxSetting(ctx)
 	if v, ok := m.themes.Load(woxSetting.ThemeId.Get()); ok {
-		return v
+		return m.resolveTheme(ctx, v)
 	}
 
 	return common.Theme{}
@@ -340,17 +426,110 @@ func (m *Manager) GetCurrentTheme(ctx context.Context) common.Theme {
 func (m *Manager) GetAllThemes(ctx context.Context) []common.Theme {
 	var themes []common.Theme
 	m.themes.Range(func(key string, value common.Theme) bool {
-		themes = append(themes, value)
+		themes = append(themes, m.resolveTheme(ctx, value))
 		return true
 	})
 	return themes
 }
 
+// resolveTheme returns the theme with the fields of the themes it extends, its variables and the fields of the OS appearance applied, see common.ResolveTheme
+func (m *Manager) resolveTheme(ctx context.Context, theme common.Theme) common.Theme {
+	resolved, resolveErr := m.ResolveTheme(theme)
+	if resolveErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to resolve theme %s: %s", theme.ThemeName, resolveErr.Error()))
+		return theme
+	}
+	return resolved
+}
+
+// ResolveTheme resolves a theme for the current OS appearance, the theme doesn't need to be installed, e.g. a theme that is being edited
+func (m *Manager) ResolveTheme(theme common.Theme) (common.Theme, error) {
+	currentAppearance, _ := m.appearance.Load().(string)
+	if currentAppearance == "" {
+		currentAppearance = appearance.Light
+	}
+	return common.ResolveTheme(theme, currentAppearance, m.themes.Load)
+}
+
+// IsThemeInstalled reports whether the theme is a system theme or a theme in the theme directory
+func (m *Manager) IsThemeInstalled(themeId string) bool {
+	_, ok := m.themes.Load(themeId)
+	return ok
+}
+
+// resolveInstalledTheme resolves the installed theme of the given theme, which may be resolved for another appearance already
+func (m *Manager) resolveInstalledTheme(ctx context.Context, theme common.Theme) common.Theme {
+	if installed, ok := m.themes.Load(theme.ThemeId); ok {
+		theme = installed
+	}
+	if common.IsThemeAppearanceDependent(theme, m.themes.Load) {
+		m.appearance.Store(appearance.Get())
+	}
+	return m.resolveTheme(ctx, theme)
+}
+
+// ValidateTheme checks a theme before it is installed, see common.ValidateTheme
+func (m *Manager) ValidateTheme(theme common.Theme) error {
+	return common.ValidateTheme(theme, m.themes.Load)
+}
+
+// watchAppearance applies the current theme again when the OS switches between light and dark.
+// Without notifications from the OS, the appearance is checked every few seconds while the current theme changes with it.
+func (m *Manager) watchAppearance(ctx context.Context) {
+	watchErr := appearance.Watch(ctx, func(currentAppearance appearance.Appearance) {
+		m.onAppearanceChanged(util.NewTraceContext(), currentAppearance)
+	})
+	if watchErr == nil {
+		return
+	}
+	logger.Warn(ctx, fmt.Sprintf("failed to watch os appearance, check it periodically instead: %s", watchErr.Error()))
+
+	ticker := time.NewTicker(appearanceCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, ok := m.getAppearanceDependentTheme(ctx); ok || m.previewTheme.Load() != nil {
+			m.onAppearanceChanged(util.NewTraceContext(), appearance.Get())
+		}
+	}
+}
+
+// onAppearanceChanged applies the previewed theme again, or the current theme when it changes with the appearance
+func (m *Manager) onAppearanceChanged(ctx context.Context, currentAppearance appearance.Appearance) {
+	if previousAppearance, _ := m.appearance.Load().(string); previousAppearance == currentAppearance {
+		return
+	}
+	logger.Info(ctx, fmt.Sprintf("os appearance changed to %s", currentAppearance))
+	m.appearance.Store(currentAppearance)
+
+	if previewTheme := m.previewTheme.Load(); previewTheme != nil {
+		m.ChangeTheme(ctx, *previewTheme)
+		return
+	}
+	if currentTheme, ok := m.getAppearanceDependentTheme(ctx); ok {
+		m.ChangeTheme(ctx, currentTheme)
+	}
+}
+
+// getAppearanceDependentTheme returns the current theme when it has light and dark fields
+func (m *Manager) getAppearanceDependentTheme(ctx context.Context) (common.Theme, bool) {
+	currentTheme, ok := m.themes.Load(setting.GetSettingManager().GetWoxSetting(ctx).ThemeId.Get())
+	return currentTheme, ok && common.IsThemeAppearanceDependent(currentTheme, m.themes.Load)
+}
+
 func (m *Manager) AddTheme(ctx context.Context, theme common.Theme) {
 	m.themes.Store(theme.ThemeId, theme)
 	m.ChangeTheme(ctx, theme)
 }
 
+// UpdateTheme replaces an installed theme, the theme is applied again when it is the current theme
+func (m *Manager) UpdateTheme(ctx context.Context, theme common.Theme) {
+	m.themes.Store(theme.ThemeId, theme)
+	if setting.GetSettingManager().GetWoxSetting(ctx).ThemeId.Get() == theme.ThemeId {
+		m.ChangeTheme(ctx, theme)
+	}
+}
+
 func (m *Manager) RemoveTheme(ctx context.Context, theme common.Theme) {
 	m.themes.Delete(theme.ThemeId)
 }
@@ -375,7 +554,7 @@ func (m *Manager) RestoreTheme(ctx context.Context) {
 
 func (m *Manager) GetThemeById(themeId string) common.Theme {
 	if v, ok := m.themes.Load(themeId); ok {
-		return v
+		return m.resolveTheme(util.NewTraceContext(), v)
 	}
 	return common.Theme{}
 }
@@ -450,18 +629,10 @@ func (m *Manager) IsSystemTheme(id string) bool {
 	return lo.Contains(m.systemThemeIds, id)
 }
 
-func (m *Manager) IsThemeUpgradable(id string, version string) bool {
-	theme := m.GetThemeById(id)
-	if theme.ThemeId != "" {
-		existingVersion, existingErr := semver.NewVersion(theme.Version)
-		currentVersion, currentErr := semver.NewVersion(version)
-		if existingErr != nil && currentErr != nil && existingVersion != nil && currentVersion != nil {
-			if existingVersion.GreaterThan(currentVersion) {
-				return true
-			}
-		}
-	}
-	return false
+// IsThemeUpgradable reports whether the theme store has a newer version of the installed theme
+func (m *Manager) IsThemeUpgradable(ctx context.Context, id string) bool {
+	storeTheme, found := lo.Find(GetStoreManager().GetThemes(), func(theme common.Theme) bool { return theme.ThemeId == id })
+	return found && GetStoreManager().IsUpgradable(ctx, storeTheme)
 }
 
 func (m *Manager) ShowTray() {
@@ -536,6 +707,38 @@ func (m *Manager) PostSettingUpdate(ctx context.Context, key string, value strin
 		updater.CheckForUpdates(ctx)
 	case "AIProviders":
 		plugin.GetPluginManager().GetUI().ReloadChatResources(ctx, "models")
+	case "DotfilePath":
+		setting.GetSettingManager().WatchDotfile(ctx)
+	}
+}
+
+// onSettingsChanged applies the Wox settings changed by a settings sync or a dotfile import, the same way as the settings changed in the setting view
+func (m *Manager) onSettingsChanged(ctx context.Context, changes []setting.SettingChange) {
+	woxSetting := setting.GetSettingManager().GetWoxSetting(ctx)
+	for _, change := range changes {
+		if change.PluginId != "" {
+			continue
+		}
+		if change.Platform != "" && change.Platform != util.GetCurrentPlatform() {
+			continue
+		}
+
+		switch change.Key {
+		case "ShowTray":
+			m.PostSettingUpdate(ctx, change.Key, strconv.FormatBool(woxSetting.ShowTray.Get()))
+		case "MainHotkey":
+			m.PostSettingUpdate(ctx, change.Key, woxSetting.MainHotkey.Get())
+		case "SelectionHotkey":
+			m.PostSettingUpdate(ctx, change.Key, woxSetting.SelectionHotkey.Get())
+		case "QueryHotkeys":
+			m.PostSettingUpdate(ctx, change.Key, "")
+		case "LangCode":
+			m.PostSettingUpdate(ctx, change.Key, string(woxSetting.LangCode.Get()))
+		case "AIProviders":
+			m.PostSettingUpdate(ctx, change.Key, "")
+		case "ThemeId":
+			m.ChangeTheme(ctx, m.GetCurrentTheme(ctx))
+		}
 	}
 }
 
@@ -615,6 +818,17 @@ func (m *Manager) ProcessDeeplink(ctx context.Context, deeplink string) {
 		m.ui.ToggleApp(ctx)
 	}
 
+	if strings.HasPrefix(command, "profile/") {
+		profileName, unescapeErr := url.PathUnescape(strings.TrimPrefix(command, "profile/"))
+		if unescapeErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to unescape profile name: %s", unescapeErr.Error()))
+			return
+		}
+		if switchErr := setting.GetSettingManager().SwitchProfile(ctx, profileName); switchErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to switch profile: %s", switchErr.Error()))
+		}
+	}
+
 	if strings.HasPrefix(command, "plugin/") {
 		pluginID := strings.TrimPrefix(command, "plugin/")
 		if pluginID != "" {
@@ -17,6 +17,7 @@ import (
 	"wox/i18n"
 	"wox/plugin"
 	"wox/setting"
+	"wox/store"
 	"wox/ui/dto"
 	"wox/updater"
 	"wox/util"
@@ -53,6 +54,10 @@ var routers = map[string]func(w http.ResponseWriter, r *http.Request){
 	"/setting/userdata/location":        handleUserDataLocation,
 	"/setting/userdata/location/update": handleUserDataLocationUpdate,
 	"/setting/position":                 handleSaveWindowPosition,
+	"/setting/sync":                     handleSettingSync,
+	"/setting/dotfile/export":           handleSettingDotfileExport,
+	"/setting/dotfile/preview":          handleSettingDotfilePreview,
+	"/setting/dotfile/import":           handleSettingDotfileImport,
 	"/runtime/status":                   handleRuntimeStatus,
 
 	// events
@@ -339,7 +344,7 @@ func handleThemeStore(w http.ResponseWriter, r *http.Request) {
 		isInstalled := lo.ContainsBy(GetUIManager().GetAllThemes(ctx), func(item common.Theme) bool {
 			return item.ThemeId == storeTheme.ThemeId
 		})
-		themes[i].IsUpgradable = GetUIManager().IsThemeUpgradable(storeTheme.ThemeId, storeTheme.Version)
+		themes[i].IsUpgradable = GetUIManager().IsThemeUpgradable(ctx, storeTheme.ThemeId)
 		themes[i].IsInstalled = isInstalled
 		themes[i].IsSystem = GetUIManager().IsSystemTheme(storeTheme.ThemeId)
 	}
@@ -360,7 +365,7 @@ func handleThemeInstalled(w http.ResponseWriter, r *http.Request) {
 
 	for i, storeTheme := range themes {
 		themes[i].IsInstalled = true
-		themes[i].IsUpgradable = GetUIManager().IsThemeUpgradable(storeTheme.ThemeId, storeTheme.Version)
+		themes[i].IsUpgradable = GetUIManager().IsThemeUpgradable(ctx, storeTheme.ThemeId)
 		themes[i].IsSystem = GetUIManager().IsSystemTheme(storeTheme.ThemeId)
 	}
 	writeSuccessResponse(w, themes)
@@ -481,9 +486,19 @@ func handleSettingWox(w http.ResponseWriter, r *http.Request) {
 	settingDto.HttpProxyUrl = woxSetting.HttpProxyUrl.Get()
 	settingDto.ShowPosition = woxSetting.ShowPosition.Get()
 	settingDto.EnableAutoBackup = woxSetting.EnableAutoBackup.Get()
+	settingDto.EnableBackupEncrypt = woxSetting.BackupPassphrase.Get() != ""
 	settingDto.EnableAutoUpdate = woxSetting.EnableAutoUpdate.Get()
 	settingDto.CustomPythonPath = woxSetting.CustomPythonPath.Get()
 	settingDto.CustomNodejsPath = woxSetting.CustomNodejsPath.Get()
+	settingDto.EnableSettingSync = woxSetting.EnableSettingSync.Get()
+	settingDto.SettingSyncType = woxSetting.SettingSyncType.Get()
+	settingDto.SettingSyncFolder = woxSetting.SettingSyncFolder.Get()
+	settingDto.SettingSyncUrl = woxSetting.SettingSyncUrl.Get()
+	settingDto.SettingSyncUsername = woxSetting.SettingSyncUsername.Get()
+	settingDto.HasSettingSyncPassword = woxSetting.SettingSyncPassword.Get() != ""
+	settingDto.DotfilePath = woxSetting.DotfilePath.Get()
+	settingDto.Profiles = woxSetting.Profiles.Get()
+	settingDto.ActiveProfile = woxSetting.ActiveProfile.Get()
 
 	settingDto.AppWidth = woxSetting.AppWidth.Get()
 	settingDto.MaxResultCount = woxSetting.MaxResultCount.Get()
@@ -567,12 +582,51 @@ func handleSettingWoxUpdate(w http.ResponseWriter, r *http.Request) {
 		woxSetting.AIProviders.Set(aiProviders)
 	case "EnableAutoBackup":
 		woxSetting.EnableAutoBackup.Set(vb)
+	case "BackupPassphrase":
+		woxSetting.BackupPassphrase.Set(vs)
 	case "EnableAutoUpdate":
 		woxSetting.EnableAutoUpdate.Set(vb)
 	case "CustomPythonPath":
 		woxSetting.CustomPythonPath.Set(vs)
 	case "CustomNodejsPath":
 		woxSetting.CustomNodejsPath.Set(vs)
+	case "EnableSettingSync":
+		woxSetting.EnableSettingSync.Set(vb)
+	case "SettingSyncType":
+		woxSetting.SettingSyncType.Set(setting.SettingSyncType(vs))
+	case "SettingSyncFolder":
+		woxSetting.SettingSyncFolder.Set(vs)
+	case "SettingSyncUrl":
+		woxSetting.SettingSyncUrl.Set(vs)
+	case "SettingSyncUsername":
+		woxSetting.SettingSyncUsername.Set(vs)
+	case "SettingSyncPassword":
+		woxSetting.SettingSyncPassword.Set(vs)
+	case "DotfilePath":
+		woxSetting.DotfilePath.Set(vs)
+	case "StoreUrls":
+		var storeUrls []setting.StoreUrl
+		if err := json.Unmarshal([]byte(vs), &storeUrls); err != nil {
+			writeErrorResponse(w, err.Error())
+			return
+		}
+		woxSetting.StoreUrls.Set(storeUrls)
+		store.GetStoreManager().RefreshAll(ctx)
+	case "Profiles":
+		var profiles []setting.SettingProfile
+		if err := json.Unmarshal([]byte(vs), &profiles); err != nil {
+			writeErrorResponse(w, err.Error())
+			return
+		}
+		if err := setting.GetSettingManager().SaveProfiles(ctx, profiles); err != nil {
+			writeErrorResponse(w, err.Error())
+			return
+		}
+	case "ActiveProfile":
+		if err := setting.GetSettingManager().SwitchProfile(ctx, vs); err != nil {
+			writeErrorResponse(w, err.Error())
+			return
+		}
 
 	case "HttpProxyEnabled":
 		woxSetting.HttpProxyEnabled.Set(vb)
@@ -731,7 +785,8 @@ func handleBackupRestore(w http.ResponseWriter, r *http.Request) {
 	}
 
 	backupId := idResult.String()
-	restoreErr := setting.GetSettingManager().Restore(util.NewTraceContext(), backupId)
+	passphrase := gjson.GetBytes(body, "passphrase").String()
+	restoreErr := setting.GetSettingManager().Restore(util.NewTraceContext(), backupId, passphrase)
 	if restoreErr != nil {
 		writeErrorResponse(w, restoreErr.Error())
 		return
@@ -750,6 +805,66 @@ func handleBackupAll(w http.ResponseWriter, r *http.Request) {
 	writeSuccessResponse(w, backups)
 }
 
+func handleSettingSync(w http.ResponseWriter, r *http.Request) {
+	syncErr := setting.GetSettingManager().SyncSettings(util.NewTraceContext())
+	if syncErr != nil {
+		writeErrorResponse(w, syncErr.Error())
+		return
+	}
+
+	writeSuccessResponse(w, "")
+}
+
+func handleSettingDotfileExport(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	secretMode := gjson.GetBytes(body, "secretMode").String()
+	if secretMode == "" {
+		secretMode = setting.DotfileSecretModeRedact
+	}
+
+	data, exportErr := setting.GetSettingManager().ExportDotfile(util.NewTraceContext(), secretMode)
+	if exportErr != nil {
+		writeErrorResponse(w, exportErr.Error())
+		return
+	}
+
+	writeSuccessResponse(w, string(data))
+}
+
+func handleSettingDotfilePreview(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	contentResult := gjson.GetBytes(body, "content")
+	if !contentResult.Exists() {
+		writeErrorResponse(w, "content is empty")
+		return
+	}
+
+	changes, previewErr := setting.GetSettingManager().PreviewDotfile(util.NewTraceContext(), []byte(contentResult.String()))
+	if previewErr != nil {
+		writeErrorResponse(w, previewErr.Error())
+		return
+	}
+
+	writeSuccessResponse(w, changes)
+}
+
+func handleSettingDotfileImport(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	contentResult := gjson.GetBytes(body, "content")
+	if !contentResult.Exists() {
+		writeErrorResponse(w, "content is empty")
+		return
+	}
+
+	changes, importErr := setting.GetSettingManager().ImportDotfile(util.NewTraceContext(), []byte(contentResult.String()))
+	if importErr != nil {
+		writeErrorResponse(w, importErr.Error())
+		return
+	}
+
+	writeSuccessResponse(w, changes)
+}
+
 func handleBackupFolder(w http.ResponseWriter, r *http.Request) {
 	backupDir := util.GetLocation().GetBackupDirectory()
 
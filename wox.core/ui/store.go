@@ -2,123 +2,94 @@ package ui
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path"
 	"sync"
-	"time"
 	"wox/common"
 	"wox/i18n"
+	"wox/setting"
+	"wox/store"
 	"wox/util"
 
-	"github.com/samber/lo"
 	"github.com/tidwall/pretty"
 )
 
-type storeManifest struct {
-	Name string
-	Url  string
-}
-
 var storeInstance *Store
 var storeOnce sync.Once
 
 type Store struct {
-	themes []common.Theme
+	catalog *store.Catalog[common.Theme]
 }
 
 func GetStoreManager() *Store {
 	storeOnce.Do(func() {
-		storeInstance = &Store{}
+		storeInstance = &Store{
+			catalog: store.NewCatalog(store.Kind[common.Theme]{
+				Type:       setting.StoreTypeTheme,
+				GetId:      func(theme common.Theme) string { return theme.ThemeId },
+				GetName:    func(theme common.Theme) string { return theme.ThemeName },
+				GetVersion: func(theme common.Theme) string { return theme.Version },
+				GetInstalledVersion: func(ctx context.Context, id string) (string, bool) {
+					theme, found := GetUIManager().themes.Load(id)
+					if !found || theme.IsSystem {
+						return "", false
+					}
+					return theme.Version, true
+				},
+			}),
+		}
 	})
 	return storeInstance
 }
 
-func (s *Store) getStoreManifests(ctx context.Context) []storeManifest {
-	return []storeManifest{
-		{
-			Name: "Wox Official Theme Store",
-			Url:  "https://raw.githubusercontent.com/Wox-launcher/Wox/master/store-theme.json",
-		},
-	}
-}
-
+// Start loads the themes of the theme stores, and updates them in the background every 10 minutes
 func (s *Store) Start(ctx context.Context) {
-	s.themes = s.GetStoreThemes(ctx)
-
-	util.Go(ctx, "load theme plugins", func() {
-		for range time.NewTicker(time.Minute * 10).C {
-			pluginManifests := s.GetStoreThemes(util.NewTraceContext())
-			if len(pluginManifests) > 0 {
-				s.themes = pluginManifests
-			}
-		}
-	})
+	store.GetStoreManager().Register(ctx, s.catalog)
 }
 
-func (s *Store) GetStoreThemes(ctx context.Context) []common.Theme {
-	var storeThemeManifests []common.Theme
-
-	for _, store := range s.getStoreManifests(ctx) {
-		themeManifest, manifestErr := s.GetStoreTheme(ctx, store)
-		if manifestErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to get theme manifest from %s store: %s", store.Name, manifestErr.Error()))
-			continue
-		}
+func (s *Store) Install(ctx context.Context, theme common.Theme) error {
+	logger.Info(ctx, fmt.Sprintf("start to install theme %s(%s)", theme.ThemeId, theme.ThemeAuthor))
 
-		for _, manifest := range themeManifest {
-			_, found := lo.Find(storeThemeManifests, func(m common.Theme) bool {
-				return manifest.ThemeId == m.ThemeId
-			})
-			if found {
-				//skip duplicated theme
-				continue
-			}
+	if validateErr := GetUIManager().ValidateTheme(theme); validateErr != nil {
+		return fmt.Errorf("invalid theme %s: %w", theme.ThemeName, validateErr)
+	}
 
-			storeThemeManifests = append(storeThemeManifests, manifest)
-		}
+	if err := s.save(theme); err != nil {
+		return err
 	}
 
-	logger.Info(ctx, fmt.Sprintf("found %d themes from stores", len(storeThemeManifests)))
-	return storeThemeManifests
+	GetUIManager().AddTheme(ctx, theme)
+
+	return nil
 }
 
-func (s *Store) GetStoreTheme(ctx context.Context, store storeManifest) ([]common.Theme, error) {
-	logger.Info(ctx, fmt.Sprintf("start to get theme manifest from %s(%s)", store.Name, store.Url))
+// Upgrade replaces an installed theme with its newer version from the store, the current theme stays the current theme
+func (s *Store) Upgrade(ctx context.Context, theme common.Theme) error {
+	logger.Info(ctx, fmt.Sprintf("start to upgrade theme %s to %s", theme.ThemeName, theme.Version))
 
-	response, getErr := util.HttpGet(ctx, store.Url)
-	if getErr != nil {
-		return nil, getErr
+	if validateErr := GetUIManager().ValidateTheme(theme); validateErr != nil {
+		return fmt.Errorf("invalid theme %s: %w", theme.ThemeName, validateErr)
 	}
 
-	var storeThemeManifests []common.Theme
-	unmarshalErr := json.Unmarshal(response, &storeThemeManifests)
-	if unmarshalErr != nil {
-		return nil, unmarshalErr
+	if err := s.save(theme); err != nil {
+		return err
 	}
 
-	return storeThemeManifests, nil
-}
+	GetUIManager().UpdateTheme(ctx, theme)
 
-func (s *Store) Install(ctx context.Context, theme common.Theme) error {
-	logger.Info(ctx, fmt.Sprintf("start to install theme %s(%s)", theme.ThemeId, theme.ThemeAuthor))
+	return nil
+}
 
+func (s *Store) save(theme common.Theme) error {
 	themePath := path.Join(util.GetLocation().GetThemeDirectory(), fmt.Sprintf("%s.json", theme.ThemeId))
 
-	themeJson, err := json.Marshal(theme)
+	themeJson, err := theme.SourceJSON()
 	if err != nil {
 		return err
 	}
 
-	writeErr := os.WriteFile(themePath, pretty.Pretty(themeJson), os.ModePerm)
-	if writeErr != nil {
-		return writeErr
-	}
-
-	GetUIManager().AddTheme(ctx, theme)
-
-	return nil
+	return os.WriteFile(themePath, pretty.Pretty(themeJson), os.ModePerm)
 }
 
 func (s *Store) Uninstall(ctx context.Context, theme common.Theme) error {
@@ -141,5 +112,15 @@ func (s *Store) Uninstall(ctx context.Context, theme common.Theme) error {
 }
 
 func (s *Store) GetThemes() []common.Theme {
-	return s.themes
+	return s.catalog.GetItems()
+}
+
+// IsUpgradable reports whether the store theme is installed in an older version
+func (s *Store) IsUpgradable(ctx context.Context, theme common.Theme) bool {
+	return s.catalog.IsUpgradable(ctx, theme)
+}
+
+// GetUpdates returns the store themes that are installed in an older version
+func (s *Store) GetUpdates(ctx context.Context) []common.Theme {
+	return s.catalog.GetUpdates(ctx)
 }
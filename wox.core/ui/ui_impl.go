@@ -66,15 +66,22 @@ func (u *uiImpl) GetServerPort(ctx context.Context) int {
 }
 
 func (u *uiImpl) ChangeTheme(ctx context.Context, theme common.Theme) {
-	logger.Info(ctx, fmt.Sprintf("change theme: %s", theme.ThemeName))
-	woxSetting := setting.GetSettingManager().GetWoxSetting(ctx)
-	woxSetting.ThemeId.Set(theme.ThemeId)
-	u.invokeWebsocketMethod(ctx, "ChangeTheme", theme)
+	// a theme that is not installed, e.g. a theme that is being edited, is only previewed and doesn't become the current theme
+	if GetUIManager().IsThemeInstalled(theme.ThemeId) {
+		logger.Info(ctx, fmt.Sprintf("change theme: %s", theme.ThemeName))
+		woxSetting := setting.GetSettingManager().GetWoxSetting(ctx)
+		woxSetting.ThemeId.Set(theme.ThemeId)
+		GetUIManager().previewTheme.Store(nil)
+	} else {
+		logger.Info(ctx, fmt.Sprintf("preview theme: %s", theme.ThemeName))
+		GetUIManager().previewTheme.Store(&theme)
+	}
+	u.invokeWebsocketMethod(ctx, "ChangeTheme", GetUIManager().resolveInstalledTheme(ctx, theme))
 }
 
-func (u *uiImpl) InstallTheme(ctx context.Context, theme common.Theme) {
+func (u *uiImpl) InstallTheme(ctx context.Context, theme common.Theme) error {
 	logger.Info(ctx, fmt.Sprintf("install theme: %s", theme.ThemeName))
-	GetStoreManager().Install(ctx, theme)
+	return GetStoreManager().Install(ctx, theme)
 }
 
 func (u *uiImpl) UninstallTheme(ctx context.Context, theme common.Theme) {
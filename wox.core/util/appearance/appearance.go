@@ -0,0 +1,31 @@
+package appearance
+
+import "context"
+
+// Appearance is the light or dark appearance of the OS
+type Appearance = string
+
+const (
+	Light Appearance = "light"
+	Dark  Appearance = "dark"
+)
+
+// Get returns the appearance of the OS, light when it can't be detected
+func Get() Appearance {
+	if isDark() {
+		return Dark
+	}
+	return Light
+}
+
+// Watch calls onChange with the new appearance every time the OS switches between light and dark, until the context is done.
+// It returns an error right away when the OS can't notify the changes, the caller has to check the appearance itself then.
+func Watch(ctx context.Context, onChange func(appearance Appearance)) error {
+	last := Get()
+	return watch(ctx, func() {
+		if current := Get(); current != last {
+			last = current
+			onChange(current)
+		}
+	})
+}
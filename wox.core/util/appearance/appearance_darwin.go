@@ -0,0 +1,54 @@
+package appearance
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Foundation
+
+void observeAppearance();
+*/
+import "C"
+import (
+	"context"
+	"strings"
+	"sync"
+	"wox/util/shell"
+)
+
+var (
+	observeOnce sync.Once
+	changes     = make(chan struct{}, 1)
+)
+
+//export appearanceChanged
+func appearanceChanged() {
+	// called on the main thread, a change that is not handled yet covers this one
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
+
+// isDark reads the global AppleInterfaceStyle, which is only set in dark mode
+func isDark() bool {
+	output, err := shell.RunOutput("defaults", "read", "-g", "AppleInterfaceStyle")
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(string(output)), "dark")
+}
+
+// watch observes the AppleInterfaceThemeChangedNotification the system posts when it switches between light and dark
+func watch(ctx context.Context, onChange func()) error {
+	observeOnce.Do(func() {
+		C.observeAppearance()
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changes:
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,15 @@
+#import <Foundation/Foundation.h>
+
+extern void appearanceChanged();
+
+void observeAppearance() {
+    // distributed notifications are delivered on the run loop of the thread that observes them
+    dispatch_async(dispatch_get_main_queue(), ^{
+        [[NSDistributedNotificationCenter defaultCenter] addObserverForName:@"AppleInterfaceThemeChangedNotification"
+                                                                     object:nil
+                                                                      queue:[NSOperationQueue mainQueue]
+                                                                 usingBlock:^(NSNotification *notification) {
+                                                                   appearanceChanged();
+                                                                 }];
+    });
+}
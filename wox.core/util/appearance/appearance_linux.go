@@ -0,0 +1,85 @@
+package appearance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"wox/util/shell"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	portalService   = "org.freedesktop.portal.Desktop"
+	portalPath      = "/org/freedesktop/portal/desktop"
+	portalSettings  = "org.freedesktop.portal.Settings"
+	portalNamespace = "org.freedesktop.appearance"
+)
+
+// isDark reads the color scheme of the desktop portal, then the color scheme of GNOME and desktops following it, older desktops only have a dark gtk theme
+func isDark() bool {
+	if conn, err := dbus.SessionBus(); err == nil {
+		var value dbus.Variant
+		// color-scheme is 0 without a preference, 1 for dark and 2 for light
+		if err := conn.Object(portalService, portalPath).Call(portalSettings+".ReadOne", 0, portalNamespace, "color-scheme").Store(&value); err == nil {
+			if colorScheme, ok := value.Value().(uint32); ok && colorScheme != 0 {
+				return colorScheme == 1
+			}
+		}
+	}
+
+	if output, err := shell.RunOutput("gsettings", "get", "org.gnome.desktop.interface", "color-scheme"); err == nil {
+		if strings.Contains(string(output), "prefer-dark") {
+			return true
+		}
+	}
+	if output, err := shell.RunOutput("gsettings", "get", "org.gnome.desktop.interface", "gtk-theme"); err == nil {
+		return strings.Contains(strings.ToLower(string(output)), "dark")
+	}
+	return false
+}
+
+// watch listens to the SettingChanged signal of the desktop portal, which also forwards the GNOME interface settings
+func watch(ctx context.Context, onChange func()) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+	var hasPortal bool
+	if err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, portalService).Store(&hasPortal); err != nil {
+		return err
+	}
+	if !hasPortal {
+		return fmt.Errorf("%s is not running", portalService)
+	}
+
+	matchOptions := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(portalPath),
+		dbus.WithMatchInterface(portalSettings),
+		dbus.WithMatchMember("SettingChanged"),
+	}
+	if err := conn.AddMatchSignal(matchOptions...); err != nil {
+		return err
+	}
+	defer conn.RemoveMatchSignal(matchOptions...)
+
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case signal := <-signals:
+			if signal.Name != portalSettings+".SettingChanged" || len(signal.Body) < 2 {
+				continue
+			}
+			namespace, _ := signal.Body[0].(string)
+			key, _ := signal.Body[1].(string)
+			if (namespace == portalNamespace && key == "color-scheme") || (namespace == "org.gnome.desktop.interface" && (key == "color-scheme" || key == "gtk-theme")) {
+				onChange()
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package appearance
+
+import (
+	"context"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const personalizeKey = `Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`
+
+// isDark reads whether apps use the light theme, the setting is missing before Windows 10
+func isDark() bool {
+	key, err := registry.OpenKey(registry.CURRENT_USER, personalizeKey, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer key.Close()
+
+	value, _, err := key.GetIntegerValue("AppsUseLightTheme")
+	if err != nil {
+		return false
+	}
+	return value == 0
+}
+
+// watch waits for the values of the Personalize key to change, the notification has to be requested again after every change
+func watch(ctx context.Context, onChange func()) error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, personalizeKey, registry.NOTIFY)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+
+	changed, err := windows.CreateEvent(nil, 0, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(changed)
+	done, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(done)
+
+	stop := context.AfterFunc(ctx, func() { windows.SetEvent(done) })
+	defer stop()
+
+	for {
+		if err := windows.RegNotifyChangeKeyValue(windows.Handle(key), false, windows.REG_NOTIFY_CHANGE_LAST_SET, changed, true); err != nil {
+			return err
+		}
+		event, err := windows.WaitForMultipleObjects([]windows.Handle{changed, done}, false, windows.INFINITE)
+		if err != nil {
+			return err
+		}
+		if event != windows.WAIT_OBJECT_0 {
+			return nil
+		}
+		onChange()
+	}
+}
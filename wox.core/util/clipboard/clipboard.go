@@ -24,6 +24,8 @@ const (
 	ClipboardTypeText  Type = "text"
 	ClipboardTypeImage Type = "image"
 	ClipboardTypeFile  Type = "file"
+	// ClipboardTypeRichText is text with its formatted alternatives, e.g. copied from a browser or a word processor
+	ClipboardTypeRichText Type = "richtext"
 )
 
 type Data interface {
@@ -31,6 +33,9 @@ type Data interface {
 	String() string
 	MarshalJSON() ([]byte, error)
 	UnmarshalJSON([]byte) error
+	// IsConcealed reports whether the copying app marked the change as concealed or transient, password managers mark copied passwords
+	// with org.nspasteboard.ConcealedType on macOS or ExcludeClipboardContentFromMonitorProcessing on Windows. Only set on the data passed to the watchers.
+	IsConcealed() bool
 }
 
 func Read() (Data, error) {
@@ -53,6 +58,21 @@ func ReadFilesAndText() (Data, error) {
 	}
 
 	textData, txtErr := readText()
+	htmlData, htmlErr := readHTML()
+	rtfData, rtfErr := readRTF()
+	if htmlErr == nil || rtfErr == nil {
+		if txtErr != nil && htmlErr == nil {
+			textData, txtErr = HTMLToMarkdown(htmlData), nil
+		}
+		if txtErr == nil {
+			return &RichTextData{
+				Text: textData,
+				HTML: htmlData,
+				RTF:  rtfData,
+			}, nil
+		}
+	}
+
 	if txtErr == nil {
 		return &TextData{
 			Text: textData,
@@ -69,6 +89,12 @@ func Write(data Data) error {
 	if data.GetType() == ClipboardTypeImage {
 		return writeImageData(data.(*ImageData).Image)
 	}
+	if data.GetType() == ClipboardTypeFile {
+		return writeFilePaths(data.(*FilePathData).FilePaths)
+	}
+	if data.GetType() == ClipboardTypeRichText {
+		return writeRichTextData(data.(*RichTextData))
+	}
 
 	return errors.New("not implemented")
 }
@@ -95,11 +121,13 @@ func watchChange() {
 	}()
 
 	if isClipboardChanged() {
+		concealed := isConcealed()
 		data, err := Read()
 		if err != nil {
 			fmt.Printf("clipboard changed, but failed to read clipboard data: %s", err)
 			return
 		}
+		markConcealed(data, concealed)
 
 		for _, cb := range watchList {
 			go func() {
@@ -115,6 +143,20 @@ func watchChange() {
 	}
 }
 
+// markConcealed keeps the concealed marker on the data itself, the watchers run in their own goroutines and may read it after the next change
+func markConcealed(data Data, concealed bool) {
+	switch d := data.(type) {
+	case *TextData:
+		d.Concealed = concealed
+	case *RichTextData:
+		d.Concealed = concealed
+	case *FilePathData:
+		d.Concealed = concealed
+	case *ImageData:
+		d.Concealed = concealed
+	}
+}
+
 func WriteText(text string) error {
 	return Write(&TextData{
 		Text: text,
@@ -122,7 +164,8 @@ func WriteText(text string) error {
 }
 
 type TextData struct {
-	Text string
+	Text      string
+	Concealed bool
 }
 
 func (t *TextData) GetType() Type {
@@ -133,6 +176,10 @@ func (t *TextData) String() string {
 	return t.Text
 }
 
+func (t *TextData) IsConcealed() bool {
+	return t.Concealed
+}
+
 func (t *TextData) MarshalJSON() ([]byte, error) {
 	var mapData = make(map[string]string)
 	mapData["text"] = t.Text
@@ -151,8 +198,51 @@ func (t *TextData) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// RichTextData keeps the plain text together with the html and rtf formats of the same content, either format may be empty
+type RichTextData struct {
+	Text      string
+	HTML      string
+	RTF       string
+	Concealed bool
+}
+
+func (r *RichTextData) GetType() Type {
+	return ClipboardTypeRichText
+}
+
+func (r *RichTextData) String() string {
+	return r.Text
+}
+
+func (r *RichTextData) IsConcealed() bool {
+	return r.Concealed
+}
+
+func (r *RichTextData) MarshalJSON() ([]byte, error) {
+	var mapData = make(map[string]string)
+	mapData["text"] = r.Text
+	mapData["html"] = r.HTML
+	mapData["rtf"] = r.RTF
+	mapData["type"] = string(r.GetType())
+	return json.Marshal(mapData)
+}
+
+func (r *RichTextData) UnmarshalJSON(data []byte) error {
+	var mapData = make(map[string]string)
+	err := json.Unmarshal(data, &mapData)
+	if err != nil {
+		return err
+	}
+
+	r.Text = mapData["text"]
+	r.HTML = mapData["html"]
+	r.RTF = mapData["rtf"]
+	return nil
+}
+
 type FilePathData struct {
 	FilePaths []string
+	Concealed bool
 }
 
 func (f *FilePathData) GetType() Type {
@@ -163,6 +253,10 @@ func (f *FilePathData) String() string {
 	return strings.Join(f.FilePaths, ";")
 }
 
+func (f *FilePathData) IsConcealed() bool {
+	return f.Concealed
+}
+
 func (f *FilePathData) MarshalJSON() ([]byte, error) {
 	var mapData = make(map[string]string)
 	mapData["filePaths"] = strings.Join(f.FilePaths, "``")
@@ -182,7 +276,8 @@ func (f *FilePathData) UnmarshalJSON(data []byte) error {
 }
 
 type ImageData struct {
-	Image image.Image
+	Image     image.Image
+	Concealed bool
 }
 
 func (i *ImageData) GetType() Type {
@@ -194,6 +289,10 @@ func (i *ImageData) String() string {
 	return fmt.Sprintf("image(%dx%d)", b.Dx(), b.Dy())
 }
 
+func (i *ImageData) IsConcealed() bool {
+	return i.Concealed
+}
+
 func (i *ImageData) MarshalJSON() ([]byte, error) {
 	buf := new(bytes.Buffer)
 	err := png.Encode(buf, i.Image)
@@ -7,10 +7,15 @@ package clipboard
 
 const char* GetClipboardText();
 char* GetAllClipboardFilePaths();
+char* GetClipboardHTML();
+char* GetClipboardRTF();
 unsigned char *GetClipboardImage(size_t *length);
 void WriteClipboardText(const char *text);
 void WriteClipboardImage(const char *imageData, int length);
+void WriteClipboardFilePaths(const char *filePaths);
+void WriteClipboardRichText(const char *text, const char *html, const char *rtf);
 _Bool hasClipboardChanged();
+_Bool isClipboardConcealed();
 */
 import "C"
 import (
@@ -46,6 +51,26 @@ func readFilePaths() ([]string, error) {
 	return nil, noDataErr
 }
 
+func readHTML() (string, error) {
+	cstr := C.GetClipboardHTML()
+	if cstr != nil {
+		defer C.free(unsafe.Pointer(cstr))
+		return C.GoString(cstr), nil
+	}
+
+	return "", noDataErr
+}
+
+func readRTF() (string, error) {
+	cstr := C.GetClipboardRTF()
+	if cstr != nil {
+		defer C.free(unsafe.Pointer(cstr))
+		return C.GoString(cstr), nil
+	}
+
+	return "", noDataErr
+}
+
 func readImage() (image.Image, error) {
 	var length C.size_t
 	imageData := C.GetClipboardImage(&length)
@@ -83,6 +108,34 @@ func writeImageData(img image.Image) error {
 	return nil
 }
 
+func writeFilePaths(filePaths []string) error {
+	if len(filePaths) == 0 {
+		return noDataErr
+	}
+
+	cPaths := C.CString(strings.Join(filePaths, "\n"))
+	defer C.free(unsafe.Pointer(cPaths))
+	C.WriteClipboardFilePaths(cPaths)
+
+	return nil
+}
+
+func writeRichTextData(data *RichTextData) error {
+	cText := C.CString(data.Text)
+	defer C.free(unsafe.Pointer(cText))
+	cHtml := C.CString(data.HTML)
+	defer C.free(unsafe.Pointer(cHtml))
+	cRtf := C.CString(data.RTF)
+	defer C.free(unsafe.Pointer(cRtf))
+	C.WriteClipboardRichText(cText, cHtml, cRtf)
+
+	return nil
+}
+
+func isConcealed() bool {
+	return bool(C.isClipboardConcealed())
+}
+
 func isClipboardChanged() bool {
 	return bool(C.hasClipboardChanged())
 }
@@ -15,6 +15,13 @@ _Bool hasClipboardChanged() {
     return 0;
 }
 
+// isClipboardConcealed checks the marker of password managers, see http://nspasteboard.org
+_Bool isClipboardConcealed() {
+    NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
+    NSArray *types = [pasteboard types];
+    return [types containsObject:@"org.nspasteboard.ConcealedType"] || [types containsObject:@"org.nspasteboard.TransientType"];
+}
+
 const char* GetClipboardText() {
     @try {
         NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
@@ -99,4 +106,65 @@ void WriteClipboardImage(const char *imageData, int length) {
     NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
     [pasteboard clearContents];
     [pasteboard writeObjects:@[image]];
-}
\ No newline at end of file
+}
+void WriteClipboardFilePaths(const char *filePaths) {
+    NSString *allPaths = [NSString stringWithUTF8String:filePaths];
+    NSMutableArray *urls = [NSMutableArray array];
+    for (NSString *path in [allPaths componentsSeparatedByString:@"\n"]) {
+        if ([path length] > 0) {
+            [urls addObject:[NSURL fileURLWithPath:path]];
+        }
+    }
+
+    NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
+    [pasteboard clearContents];
+    [pasteboard writeObjects:urls];
+}
+
+char* GetClipboardHTML() {
+    @try {
+        NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
+        NSString *html = [pasteboard stringForType:NSPasteboardTypeHTML];
+        if (html == nil || [html length] == 0) {
+            return NULL;
+        }
+
+        return strdup([html UTF8String]);
+    }
+    @catch (NSException *exception) {
+        NSLog(@"Exception occurred: %@, %@", exception, [exception userInfo]);
+        return NULL;
+    }
+}
+
+char* GetClipboardRTF() {
+    @try {
+        NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
+        NSData *rtf = [pasteboard dataForType:NSPasteboardTypeRTF];
+        if (rtf == nil || [rtf length] == 0) {
+            return NULL;
+        }
+
+        char *bytes = (char *)malloc([rtf length] + 1);
+        memcpy(bytes, [rtf bytes], [rtf length]);
+        bytes[[rtf length]] = '\0';
+        return bytes;
+    }
+    @catch (NSException *exception) {
+        NSLog(@"Exception occurred: %@, %@", exception, [exception userInfo]);
+        return NULL;
+    }
+}
+
+void WriteClipboardRichText(const char *text, const char *html, const char *rtf) {
+    NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
+    [pasteboard clearContents];
+    [pasteboard setString:[NSString stringWithUTF8String:text] forType:NSPasteboardTypeString];
+    if (strlen(html) > 0) {
+        [pasteboard setString:[NSString stringWithUTF8String:html] forType:NSPasteboardTypeHTML];
+    }
+    if (strlen(rtf) > 0) {
+        NSData *rtfData = [NSData dataWithBytes:rtf length:strlen(rtf)];
+        [pasteboard setData:rtfData forType:NSPasteboardTypeRTF];
+    }
+}
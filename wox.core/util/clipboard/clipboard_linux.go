@@ -10,6 +10,14 @@ func readFilePaths() ([]string, error) {
 	return nil, notImplement
 }
 
+func readHTML() (string, error) {
+	return "", notImplement
+}
+
+func readRTF() (string, error) {
+	return "", notImplement
+}
+
 func readImage() (image.Image, error) {
 	return nil, notImplement
 }
@@ -22,6 +30,18 @@ func writeImageData(img image.Image) error {
 	return notImplement
 }
 
+func writeFilePaths(filePaths []string) error {
+	return notImplement
+}
+
+func writeRichTextData(data *RichTextData) error {
+	return notImplement
+}
+
+func isConcealed() bool {
+	return false
+}
+
 func isClipboardChanged() bool {
 	return false
 }
@@ -23,12 +23,14 @@ var (
 	setClipboardData           = user32.MustFindProc("SetClipboardData")
 	isClipboardFormatAvailable = user32.MustFindProc("IsClipboardFormatAvailable")
 	getClipboardSequenceNumber = user32.MustFindProc("GetClipboardSequenceNumber")
+	registerClipboardFormat    = user32.MustFindProc("RegisterClipboardFormatW")
 
 	kernel32 = syscall.NewLazyDLL("kernel32")
 	gLock    = kernel32.NewProc("GlobalLock")
 	gUnlock  = kernel32.NewProc("GlobalUnlock")
 	gAlloc   = kernel32.NewProc("GlobalAlloc")
 	gFree    = kernel32.NewProc("GlobalFree")
+	gSize    = kernel32.NewProc("GlobalSize")
 	memMove  = kernel32.NewProc("RtlMoveMemory")
 
 	shell32       = syscall.NewLazyDLL("shell32.dll")
@@ -241,6 +243,191 @@ func readFilePaths() ([]string, error) {
 	return fileNames, nil
 }
 
+// writeFilePaths puts the files as CF_HDROP, a DROPFILES header followed by the double null terminated list of paths, see:
+// https://learn.microsoft.com/en-us/windows/win32/shell/clipboard#cf_hdrop
+func writeFilePaths(filePaths []string) error {
+	if len(filePaths) == 0 {
+		return noDataErr
+	}
+
+	const dropFilesHeaderSize = 20 // pFiles, pt.x, pt.y, fNC, fWide
+	var paths []uint16
+	for _, filePath := range filePaths {
+		s, err := syscall.UTF16FromString(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to convert path to UTF16: %w", err)
+		}
+		paths = append(paths, s...)
+	}
+	paths = append(paths, 0)
+
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, [5]uint32{dropFilesHeaderSize, 0, 0, 0, 1})
+	binary.Write(buf, binary.LittleEndian, paths)
+
+	r, _, err := openClipboard.Call(0)
+	if r == 0 {
+		return fmt.Errorf("failed to open clipboard: %w", err)
+	}
+	defer closeClipboard.Call()
+
+	r, _, err = emptyClipboard.Call()
+	if r == 0 {
+		return fmt.Errorf("failed to clear clipboard: %w", err)
+	}
+
+	hMem, _, err := gAlloc.Call(gmemMoveable, uintptr(buf.Len()))
+	if hMem == 0 {
+		return fmt.Errorf("failed to allocate global memory: %w", err)
+	}
+
+	p, _, err := gLock.Call(hMem)
+	if p == 0 {
+		gFree.Call(hMem)
+		return fmt.Errorf("failed to lock global memory: %w", err)
+	}
+	memMove.Call(p, uintptr(unsafe.Pointer(&buf.Bytes()[0])), uintptr(buf.Len()))
+	gUnlock.Call(hMem)
+
+	v, _, err := setClipboardData.Call(cFmtHdrop, hMem)
+	if v == 0 {
+		gFree.Call(hMem)
+		return fmt.Errorf("failed to set clipboard data: %w", err)
+	}
+
+	return nil
+}
+
+// getRegisteredFormat returns the id of a clipboard format registered by name, e.g. "HTML Format"
+func getRegisteredFormat(name string) (uintptr, error) {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+	format, _, err := registerClipboardFormat.Call(uintptr(unsafe.Pointer(namePtr)))
+	if format == 0 {
+		return 0, fmt.Errorf("failed to register clipboard format %s: %w", name, err)
+	}
+	return format, nil
+}
+
+// readFormatBytes reads the raw bytes of a clipboard format, without the null terminator
+func readFormatBytes(formatName string) ([]byte, error) {
+	format, err := getRegisteredFormat(formatName)
+	if err != nil {
+		return nil, err
+	}
+
+	r, _, _ := isClipboardFormatAvailable.Call(format)
+	if r == 0 {
+		return nil, noDataErr
+	}
+
+	r, _, err = openClipboard.Call(0)
+	if r == 0 {
+		return nil, fmt.Errorf("failed to open clipboard: %w", err)
+	}
+	defer closeClipboard.Call()
+
+	hMem, _, err := getClipboardData.Call(format)
+	if hMem == 0 {
+		return nil, fmt.Errorf("failed to get clipboard data: %w", err)
+	}
+
+	p, _, err := gLock.Call(hMem)
+	if p == 0 {
+		return nil, fmt.Errorf("failed to lock global memory: %w", err)
+	}
+	defer gUnlock.Call(hMem)
+
+	size, _, _ := gSize.Call(hMem)
+	data := make([]byte, size)
+	copy(data, unsafe.Slice((*byte)(unsafe.Pointer(p)), size))
+	return bytes.TrimRight(data, "\x00"), nil
+}
+
+func readHTML() (string, error) {
+	data, err := readFormatBytes("HTML Format")
+	if err != nil {
+		return "", err
+	}
+	return parseCFHTML(data)
+}
+
+func readRTF() (string, error) {
+	data, err := readFormatBytes("Rich Text Format")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// setFormatBytes puts data in the opened clipboard, the clipboard owns the memory once it is set
+func setFormatBytes(format uintptr, data []byte) error {
+	hMem, _, err := gAlloc.Call(gmemMoveable, uintptr(len(data)))
+	if hMem == 0 {
+		return fmt.Errorf("failed to allocate global memory: %w", err)
+	}
+
+	p, _, err := gLock.Call(hMem)
+	if p == 0 {
+		gFree.Call(hMem)
+		return fmt.Errorf("failed to lock global memory: %w", err)
+	}
+	memMove.Call(p, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)))
+	gUnlock.Call(hMem)
+
+	v, _, err := setClipboardData.Call(format, hMem)
+	if v == 0 {
+		gFree.Call(hMem)
+		return fmt.Errorf("failed to set clipboard data: %w", err)
+	}
+	return nil
+}
+
+func writeRichTextData(data *RichTextData) error {
+	htmlFormat, err := getRegisteredFormat("HTML Format")
+	if err != nil {
+		return err
+	}
+	rtfFormat, err := getRegisteredFormat("Rich Text Format")
+	if err != nil {
+		return err
+	}
+
+	r, _, err := openClipboard.Call(0)
+	if r == 0 {
+		return fmt.Errorf("failed to open clipboard: %w", err)
+	}
+	defer closeClipboard.Call()
+
+	r, _, err = emptyClipboard.Call()
+	if r == 0 {
+		return fmt.Errorf("failed to clear clipboard: %w", err)
+	}
+
+	text, err := syscall.UTF16FromString(data.Text)
+	if err != nil {
+		return fmt.Errorf("failed to convert string to UTF16: %w", err)
+	}
+	textBytes := unsafe.Slice((*byte)(unsafe.Pointer(&text[0])), len(text)*2)
+	if err := setFormatBytes(cFmtUnicodeText, textBytes); err != nil {
+		return err
+	}
+	if data.HTML != "" {
+		if err := setFormatBytes(htmlFormat, append(buildCFHTML(data.HTML), 0)); err != nil {
+			return err
+		}
+	}
+	if data.RTF != "" {
+		if err := setFormatBytes(rtfFormat, append([]byte(data.RTF), 0)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func readImage() (image.Image, error) {
 	return readBmpImage()
 }
@@ -300,6 +487,23 @@ func bmpToPng(bmpBuf *bytes.Buffer) (image.Image, error) {
 	return newImage, nil
 }
 
+// isConcealed checks the formats apps put on the clipboard to keep it out of clipboard managers, see:
+// https://learn.microsoft.com/en-us/windows/win32/dataxchg/clipboard-formats#cloud-clipboard-and-clipboard-history-formats
+func isConcealed() bool {
+	if format, err := getRegisteredFormat("ExcludeClipboardContentFromMonitorProcessing"); err == nil {
+		if r, _, _ := isClipboardFormatAvailable.Call(format); r != 0 {
+			return true
+		}
+	}
+
+	// the DWORD is 0 when the content must not be kept, readFormatBytes trims the zero bytes
+	if data, err := readFormatBytes("CanIncludeInClipboardHistory"); err == nil && len(data) == 0 {
+		return true
+	}
+
+	return false
+}
+
 func isClipboardChanged() bool {
 	r, _, _ := getClipboardSequenceNumber.Call()
 	if r == 0 {
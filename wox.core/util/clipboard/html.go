@@ -0,0 +1,244 @@
+package clipboard
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var cfHtmlOffsetPattern = regexp.MustCompile(`(?m)^(StartHTML|EndHTML|StartFragment|EndFragment):(-?\d+)\r?$`)
+var markdownBlankLinesPattern = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// parseCFHTML extracts the copied fragment of the Windows "HTML Format", which prefixes the html with a header of byte offsets, see:
+// https://learn.microsoft.com/en-us/windows/win32/dataxchg/html-clipboard-format
+func parseCFHTML(data []byte) (string, error) {
+	offsets := map[string]int{}
+	for _, match := range cfHtmlOffsetPattern.FindAllSubmatch(data, -1) {
+		offset, _ := strconv.Atoi(string(match[2]))
+		offsets[string(match[1])] = offset
+	}
+
+	start, end := offsets["StartFragment"], offsets["EndFragment"]
+	if start <= 0 || end <= start || end > len(data) {
+		start, end = offsets["StartHTML"], offsets["EndHTML"]
+	}
+	if start <= 0 || end <= start || end > len(data) {
+		return "", fmt.Errorf("invalid html clipboard header")
+	}
+
+	return string(data[start:end]), nil
+}
+
+// buildCFHTML wraps an html fragment with the header of the Windows "HTML Format"
+func buildCFHTML(fragment string) []byte {
+	const headerFormat = "Version:0.9\r\nStartHTML:%010d\r\nEndHTML:%010d\r\nStartFragment:%010d\r\nEndFragment:%010d\r\n"
+	const prefix = "<html><body>\r\n<!--StartFragment-->"
+	const suffix = "<!--EndFragment-->\r\n</body></html>"
+
+	headerLen := len(fmt.Sprintf(headerFormat, 0, 0, 0, 0))
+	startFragment := headerLen + len(prefix)
+	endFragment := startFragment + len(fragment)
+	endHTML := endFragment + len(suffix)
+
+	return []byte(fmt.Sprintf(headerFormat, headerLen, endHTML, startFragment, endFragment) + prefix + fragment + suffix)
+}
+
+// HTMLToMarkdown converts copied html to markdown for previews, the elements markdown can't express are reduced to their text
+func HTMLToMarkdown(htmlContent string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(htmlContent), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return htmlContent
+	}
+
+	w := &markdownWriter{}
+	for _, node := range nodes {
+		writeMarkdown(w, node, "")
+	}
+
+	markdown := markdownBlankLinesPattern.ReplaceAllString(w.sb.String(), "\n\n")
+	return strings.TrimSpace(markdown)
+}
+
+// markdownWriter remembers the last written byte, so collapsed whitespace isn't written at the start of a line
+type markdownWriter struct {
+	sb   strings.Builder
+	last byte
+}
+
+func (w *markdownWriter) write(str string) {
+	if str == "" {
+		return
+	}
+	w.sb.WriteString(str)
+	w.last = str[len(str)-1]
+}
+
+func (w *markdownWriter) writeText(text string) {
+	text = whitespacePattern.ReplaceAllString(text, " ")
+	if w.last == 0 || w.last == '\n' || w.last == ' ' {
+		text = strings.TrimLeft(text, " ")
+	}
+	w.write(text)
+}
+
+// writeMarkdown writes a node and its children, indent is prefixed to the lines of nested lists and quotes
+func writeMarkdown(w *markdownWriter, node *html.Node, indent string) {
+	switch node.Type {
+	case html.TextNode:
+		w.writeText(node.Data)
+		return
+	case html.CommentNode, html.DoctypeNode:
+		return
+	case html.ElementNode:
+	default:
+		writeMarkdownChildren(w, node, indent)
+		return
+	}
+
+	switch node.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Title, atom.Meta:
+		return
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(node.Data[1] - '0')
+		w.write("\n\n" + indent + strings.Repeat("#", level) + " ")
+		writeMarkdownChildren(w, node, indent)
+		w.write("\n\n")
+	case atom.P, atom.Div, atom.Section, atom.Article:
+		w.write("\n\n" + indent)
+		writeMarkdownChildren(w, node, indent)
+		w.write("\n\n")
+	case atom.Br:
+		w.write("  \n" + indent)
+	case atom.Hr:
+		w.write("\n\n" + indent + "---\n\n")
+	case atom.Strong, atom.B:
+		writeMarkdownWrapped(w, node, indent, "**")
+	case atom.Em, atom.I:
+		writeMarkdownWrapped(w, node, indent, "_")
+	case atom.S, atom.Del, atom.Strike:
+		writeMarkdownWrapped(w, node, indent, "~~")
+	case atom.Code:
+		w.write("`" + textContent(node) + "`")
+	case atom.Pre:
+		w.write("\n\n" + indent + "```\n" + strings.TrimRight(textContent(node), "\n") + "\n" + indent + "```\n\n")
+	case atom.A:
+		href := getAttribute(node, "href")
+		if href == "" {
+			writeMarkdownChildren(w, node, indent)
+			return
+		}
+		w.write("[")
+		writeMarkdownChildren(w, node, indent)
+		w.write("](" + href + ")")
+	case atom.Img:
+		w.write("![" + getAttribute(node, "alt") + "](" + getAttribute(node, "src") + ")")
+	case atom.Ul, atom.Ol:
+		w.write("\n")
+		number := 1
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.DataAtom != atom.Li {
+				continue
+			}
+			marker := "- "
+			if node.DataAtom == atom.Ol {
+				marker = fmt.Sprintf("%d. ", number)
+				number++
+			}
+			w.write("\n" + indent + marker)
+			writeMarkdownChildren(w, child, indent+"  ")
+		}
+		w.write("\n\n")
+	case atom.Blockquote:
+		w.write("\n\n" + indent + "> ")
+		writeMarkdownChildren(w, node, indent+"> ")
+		w.write("\n\n")
+	case atom.Table:
+		writeMarkdownTable(w, node, indent)
+	default:
+		writeMarkdownChildren(w, node, indent)
+	}
+}
+
+func writeMarkdownChildren(w *markdownWriter, node *html.Node, indent string) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeMarkdown(w, child, indent)
+	}
+}
+
+func writeMarkdownWrapped(w *markdownWriter, node *html.Node, indent string, marker string) {
+	inner := &markdownWriter{}
+	writeMarkdownChildren(inner, node, indent)
+	text := strings.TrimSpace(inner.sb.String())
+	if text == "" {
+		return
+	}
+	w.write(marker + text + marker)
+}
+
+// writeMarkdownTable writes the rows of a table, the first row is the header
+func writeMarkdownTable(w *markdownWriter, table *html.Node, indent string) {
+	var rows [][]string
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.DataAtom != atom.Tr {
+				walk(child)
+				continue
+			}
+			var cells []string
+			for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+					inner := &markdownWriter{}
+					writeMarkdownChildren(inner, cell, "")
+					cells = append(cells, strings.ReplaceAll(strings.Join(strings.Fields(inner.sb.String()), " "), "|", "\\|"))
+				}
+			}
+			rows = append(rows, cells)
+		}
+	}
+	walk(table)
+	if len(rows) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	w.write("\n\n")
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		w.write(indent + "| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			w.write(indent + "|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	w.write("\n")
+}
+
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var sb strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(textContent(child))
+	}
+	return sb.String()
+}
+
+func getAttribute(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package clipboard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		html     string
+		expected string
+	}{
+		{"<p>Hello <b>bold</b> and <i>italic</i></p>", "Hello **bold** and _italic_"},
+		{"<h2>Title</h2><p>text</p>", "## Title\n\ntext"},
+		{`<a href="https://wox.run">Wox</a>`, "[Wox](https://wox.run)"},
+		{"<ul><li>one</li><li>two <code>x</code></li></ul>", "- one\n- two `x`"},
+		{"<ol><li>first</li><li>second<ul><li>nested</li></ul></li></ol>", "1. first\n2. second\n\n  - nested"},
+		{"<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2|3</td></tr></table>", "| a | b |\n| --- | --- |\n| 1 | 2\\|3 |"},
+		{"<style>p{}</style><p>  spaced\n  text  </p>", "spaced text"},
+		{"line<br>break", "line  \nbreak"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, HTMLToMarkdown(tt.html), tt.html)
+	}
+}
+
+func TestCFHTML(t *testing.T) {
+	fragment := "<b>héllo</b>"
+	data := buildCFHTML(fragment)
+
+	parsed, err := parseCFHTML(data)
+	require.NoError(t, err)
+	assert.Equal(t, fragment, parsed)
+
+	_, err = parseCFHTML([]byte("<b>no header</b>"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,7 @@
+// Package desktop talks to the Linux desktop session: power management through logind, the screensaver,
+// the session manager, notification daemons, the freedesktop trash and MIME application associations.
+package desktop
+
+import "errors"
+
+var ErrNotSupported = errors.New("not supported on this desktop")
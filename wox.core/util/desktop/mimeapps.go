@@ -0,0 +1,383 @@
+package desktop
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"wox/util/shell"
+)
+
+// DesktopEntry is an application desktop file, see the freedesktop Desktop Entry spec
+type DesktopEntry struct {
+	Id        string // desktop file id, e.g. org.gnome.TextEditor.desktop
+	Path      string
+	Name      string
+	Exec      string
+	Icon      string
+	MimeTypes []string
+}
+
+// MimeAppsIndex resolves the applications that can open a MIME type from mimeapps.list and the installed desktop entries.
+// Loading scans all application directories, so callers should keep the index around for a while.
+type MimeAppsIndex struct {
+	entries  map[string]*DesktopEntry // by desktop file id
+	defaults map[string][]string      // [Default Applications], highest precedence first
+	added    map[string][]string      // [Added Associations] and MimeType= of desktop entries, highest precedence first
+}
+
+// LoadMimeAppsIndex reads the desktop entries and the mimeapps.list files in the order of the
+// freedesktop MIME Applications Associations spec
+func LoadMimeAppsIndex() *MimeAppsIndex {
+	index := &MimeAppsIndex{
+		entries:  map[string]*DesktopEntry{},
+		defaults: map[string][]string{},
+		added:    map[string][]string{},
+	}
+
+	var desktopIds []string
+	for _, applicationDir := range getApplicationDirectories() {
+		for _, entry := range loadDesktopEntries(applicationDir) {
+			// the first directory wins, $XDG_DATA_HOME overrides system applications
+			if _, exists := index.entries[entry.Id]; exists {
+				continue
+			}
+			index.entries[entry.Id] = entry
+			desktopIds = append(desktopIds, entry.Id)
+		}
+	}
+
+	// removed associations only hide associations from files with lower precedence
+	removed := map[string]map[string]bool{}
+	isRemoved := func(mimeType string, desktopId string) bool {
+		return removed[mimeType] != nil && removed[mimeType][desktopId]
+	}
+
+	for _, listPath := range getMimeAppsListPaths() {
+		groups := parseIniFile(listPath)
+		for mimeType, ids := range groups["Default Applications"] {
+			index.defaults[mimeType] = append(index.defaults[mimeType], splitList(ids)...)
+		}
+		for mimeType, ids := range groups["Added Associations"] {
+			for _, id := range splitList(ids) {
+				if !isRemoved(mimeType, id) {
+					index.added[mimeType] = append(index.added[mimeType], id)
+				}
+			}
+		}
+		for mimeType, ids := range groups["Removed Associations"] {
+			if removed[mimeType] == nil {
+				removed[mimeType] = map[string]bool{}
+			}
+			for _, id := range splitList(ids) {
+				removed[mimeType][id] = true
+			}
+		}
+	}
+
+	for _, id := range desktopIds {
+		for _, mimeType := range index.entries[id].MimeTypes {
+			if !isRemoved(mimeType, id) {
+				index.added[mimeType] = append(index.added[mimeType], id)
+			}
+		}
+	}
+
+	return index
+}
+
+// GetApplications returns the installed applications for the MIME type, the default application first.
+// Specific text types also get the applications for text/plain.
+func (m *MimeAppsIndex) GetApplications(mimeType string) []*DesktopEntry {
+	mimeTypes := []string{mimeType}
+	if strings.HasPrefix(mimeType, "text/") && mimeType != "text/plain" {
+		mimeTypes = append(mimeTypes, "text/plain")
+	}
+
+	var applications []*DesktopEntry
+	seen := map[string]bool{}
+	appendEntry := func(id string) {
+		entry, exists := m.entries[id]
+		if !exists || seen[id] {
+			return
+		}
+		seen[id] = true
+		applications = append(applications, entry)
+	}
+
+	for _, t := range mimeTypes {
+		for _, id := range m.defaults[t] {
+			appendEntry(id)
+		}
+	}
+	for _, t := range mimeTypes {
+		for _, id := range m.added[t] {
+			appendEntry(id)
+		}
+	}
+	return applications
+}
+
+// GetMimeType guesses the MIME type by the shared-mime-info globs for the extension, falling back to content sniffing
+func GetMimeType(path string) string {
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		return "inode/directory"
+	}
+
+	if mimeType := mime.TypeByExtension(filepath.Ext(path)); mimeType != "" {
+		return stripMimeParameters(mimeType)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer file.Close()
+
+	buffer := make([]byte, 512)
+	n, _ := file.Read(buffer)
+	return stripMimeParameters(http.DetectContentType(buffer[:n]))
+}
+
+// BuildExecArgs expands the field codes of the Exec key for a single file
+func (d *DesktopEntry) BuildExecArgs(filePath string) []string {
+	var args []string
+	hasFileCode := false
+	for _, arg := range splitExecLine(d.Exec) {
+		switch arg {
+		case "%f", "%F", "%u", "%U":
+			args = append(args, filePath)
+			hasFileCode = true
+			continue
+		case "%i":
+			if d.Icon != "" {
+				args = append(args, "--icon", d.Icon)
+			}
+			continue
+		}
+
+		var sb strings.Builder
+		for i := 0; i < len(arg); i++ {
+			if arg[i] != '%' || i+1 >= len(arg) {
+				sb.WriteByte(arg[i])
+				continue
+			}
+			i++
+			switch arg[i] {
+			case '%':
+				sb.WriteByte('%')
+			case 'f', 'F', 'u', 'U':
+				sb.WriteString(filePath)
+				hasFileCode = true
+			case 'c':
+				sb.WriteString(d.Name)
+			case 'k':
+				sb.WriteString(d.Path)
+			}
+			// deprecated field codes (%d, %D, %n, %N, %v, %m) are removed
+		}
+		if sb.Len() > 0 {
+			args = append(args, sb.String())
+		}
+	}
+
+	if !hasFileCode && len(args) > 0 {
+		args = append(args, filePath)
+	}
+	return args
+}
+
+// Launch opens the file with the application
+func (d *DesktopEntry) Launch(filePath string) error {
+	args := d.BuildExecArgs(filePath)
+	if len(args) == 0 {
+		return fmt.Errorf("desktop entry %s has no Exec key", d.Id)
+	}
+	_, err := shell.Run(args[0], args[1:]...)
+	return err
+}
+
+func loadDesktopEntries(applicationDir string) []*DesktopEntry {
+	var entries []*DesktopEntry
+	filepath.WalkDir(applicationDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".desktop") {
+			return nil
+		}
+
+		group := parseIniFile(path)["Desktop Entry"]
+		if group == nil || group["Type"] != "Application" || group["Hidden"] == "true" || group["Exec"] == "" {
+			return nil
+		}
+		// terminal applications would need a terminal emulator to run
+		if group["Terminal"] == "true" {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(applicationDir, path)
+		entries = append(entries, &DesktopEntry{
+			Id:        strings.ReplaceAll(filepath.ToSlash(relPath), "/", "-"),
+			Path:      path,
+			Name:      group["Name"],
+			Exec:      group["Exec"],
+			Icon:      group["Icon"],
+			MimeTypes: splitList(group["MimeType"]),
+		})
+		return nil
+	})
+	return entries
+}
+
+// parseIniFile parses the key=value groups of desktop entries and mimeapps.list, localized keys are skipped
+func parseIniFile(path string) map[string]map[string]string {
+	groups := map[string]map[string]string{}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return groups
+	}
+	defer file.Close()
+
+	var current map[string]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := line[1 : len(line)-1]
+			if groups[name] == nil {
+				groups[name] = map[string]string{}
+			}
+			current = groups[name]
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found || current == nil {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if strings.Contains(key, "[") {
+			continue
+		}
+		if _, exists := current[key]; !exists {
+			current[key] = strings.TrimSpace(value)
+		}
+	}
+	return groups
+}
+
+// splitExecLine splits the Exec value into arguments, double quotes group arguments and \ escapes the next character inside them
+func splitExecLine(exec string) []string {
+	var args []string
+	var current strings.Builder
+	inQuotes := false
+	hasArg := false
+
+	for i := 0; i < len(exec); i++ {
+		c := exec[i]
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case c == '\\' && inQuotes && i+1 < len(exec):
+			i++
+			current.WriteByte(exec[i])
+		case (c == ' ' || c == '\t') && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteByte(c)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func stripMimeParameters(mimeType string) string {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	return strings.TrimSpace(mimeType)
+}
+
+func getApplicationDirectories() []string {
+	var directories []string
+	for _, dataDir := range getDataDirectories() {
+		directories = append(directories, filepath.Join(dataDir, "applications"))
+	}
+	return directories
+}
+
+// getMimeAppsListPaths returns the mimeapps.list files in order of precedence, desktop specific files first
+func getMimeAppsListPaths() []string {
+	var desktopNames []string
+	for _, desktopName := range strings.Split(os.Getenv("XDG_CURRENT_DESKTOP"), ":") {
+		if desktopName != "" {
+			desktopNames = append(desktopNames, strings.ToLower(desktopName))
+		}
+	}
+
+	var directories []string
+	directories = append(directories, getConfigDirectories()...)
+	directories = append(directories, getApplicationDirectories()...)
+
+	var paths []string
+	for _, directory := range directories {
+		for _, desktopName := range desktopNames {
+			paths = append(paths, filepath.Join(directory, desktopName+"-mimeapps.list"))
+		}
+		paths = append(paths, filepath.Join(directory, "mimeapps.list"))
+	}
+	return paths
+}
+
+// getDataDirectories returns $XDG_DATA_HOME followed by $XDG_DATA_DIRS
+func getDataDirectories() []string {
+	homeDir, _ := os.UserHomeDir()
+	return getXDGDirectories("XDG_DATA_HOME", filepath.Join(homeDir, ".local", "share"), "XDG_DATA_DIRS", "/usr/local/share:/usr/share")
+}
+
+// getConfigDirectories returns $XDG_CONFIG_HOME followed by $XDG_CONFIG_DIRS
+func getConfigDirectories() []string {
+	homeDir, _ := os.UserHomeDir()
+	return getXDGDirectories("XDG_CONFIG_HOME", filepath.Join(homeDir, ".config"), "XDG_CONFIG_DIRS", "/etc/xdg")
+}
+
+func getXDGDirectories(homeEnv string, homeDefault string, dirsEnv string, dirsDefault string) []string {
+	home := os.Getenv(homeEnv)
+	if home == "" || !filepath.IsAbs(home) {
+		home = homeDefault
+	}
+	directories := []string{home}
+
+	dirs := os.Getenv(dirsEnv)
+	if dirs == "" {
+		dirs = dirsDefault
+	}
+	for _, dir := range strings.Split(dirs, ":") {
+		if dir != "" && filepath.IsAbs(dir) {
+			directories = append(directories, dir)
+		}
+	}
+	return directories
+}
@@ -0,0 +1,97 @@
+package desktop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func desktopEntryIds(entries []*DesktopEntry) []string {
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.Id)
+	}
+	return ids
+}
+
+func TestMimeAppsIndex(t *testing.T) {
+	configHome := t.TempDir()
+	dataHome := t.TempDir()
+	dataDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(t.TempDir(), "none"))
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("XDG_DATA_DIRS", dataDir)
+	t.Setenv("XDG_CURRENT_DESKTOP", "GNOME")
+
+	writeTestFile(t, filepath.Join(dataDir, "applications", "gedit.desktop"),
+		"[Desktop Entry]\nType=Application\nName=Text Editor\nName[de]=Texteditor\nExec=gedit %U\nMimeType=text/plain;\n")
+	writeTestFile(t, filepath.Join(dataDir, "applications", "kde4", "kate.desktop"),
+		"[Desktop Entry]\nType=Application\nName=Kate\nExec=kate -b %U\nMimeType=text/plain;text/x-go;\n")
+	writeTestFile(t, filepath.Join(dataDir, "applications", "vim.desktop"),
+		"[Desktop Entry]\nType=Application\nName=Vim\nExec=vim %F\nTerminal=true\nMimeType=text/plain;\n")
+	writeTestFile(t, filepath.Join(dataDir, "applications", "hidden.desktop"),
+		"[Desktop Entry]\nType=Application\nName=Hidden\nExec=hidden\nHidden=true\nMimeType=text/plain;\n")
+	writeTestFile(t, filepath.Join(dataDir, "applications", "code.desktop"),
+		"[Desktop Entry]\nType=Application\nName=Code (system)\nExec=code %F\nMimeType=text/plain;\n")
+	// the user's desktop file overrides the system one with the same id
+	writeTestFile(t, filepath.Join(dataHome, "applications", "code.desktop"),
+		"[Desktop Entry]\nType=Application\nName=Code\nExec=code --new-window %F\nMimeType=text/plain;\n")
+
+	writeTestFile(t, filepath.Join(configHome, "gnome-mimeapps.list"),
+		"[Default Applications]\ntext/plain=missing.desktop;kde4-kate.desktop;\n")
+	writeTestFile(t, filepath.Join(configHome, "mimeapps.list"),
+		"[Added Associations]\ntext/x-go=code.desktop;\n[Removed Associations]\ntext/plain=gedit.desktop;\n")
+
+	index := LoadMimeAppsIndex()
+
+	plainApps := index.GetApplications("text/plain")
+	assert.Equal(t, []string{"kde4-kate.desktop", "code.desktop"}, desktopEntryIds(plainApps))
+	assert.Equal(t, "Code", plainApps[1].Name)
+
+	// specific text types also get the text/plain applications
+	assert.Equal(t, []string{"kde4-kate.desktop", "code.desktop"}, desktopEntryIds(index.GetApplications("text/x-go")))
+
+	assert.Empty(t, index.GetApplications("image/png"))
+}
+
+func TestBuildExecArgs(t *testing.T) {
+	tests := []struct {
+		exec     string
+		expected []string
+	}{
+		{"gedit %U", []string{"gedit", "/tmp/a b.txt"}},
+		{"kate -b %f", []string{"kate", "-b", "/tmp/a b.txt"}},
+		{"app --icon-arg %i %c", []string{"app", "--icon-arg", "--icon", "app-icon", "My App", "/tmp/a b.txt"}},
+		{`sh -c "echo \"%%done\""`, []string{"sh", "-c", `echo "%done"`, "/tmp/a b.txt"}},
+		{"viewer --file=%f %d", []string{"viewer", "--file=/tmp/a b.txt"}},
+		{"noargs", []string{"noargs", "/tmp/a b.txt"}},
+	}
+
+	for _, tt := range tests {
+		entry := DesktopEntry{Name: "My App", Icon: "app-icon", Exec: tt.exec}
+		assert.Equal(t, tt.expected, entry.BuildExecArgs("/tmp/a b.txt"), tt.exec)
+	}
+}
+
+func TestGetMimeType(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, "inode/directory", GetMimeType(dir))
+
+	noExtension := filepath.Join(dir, "README")
+	writeTestFile(t, noExtension, "just some text")
+	assert.Equal(t, "text/plain", GetMimeType(noExtension))
+
+	html := filepath.Join(dir, "index.html")
+	writeTestFile(t, html, "<html></html>")
+	assert.Equal(t, "text/html", GetMimeType(html))
+}
@@ -0,0 +1,202 @@
+package desktop
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	login1Service        = "org.freedesktop.login1"
+	login1Path           = "/org/freedesktop/login1"
+	login1Manager        = "org.freedesktop.login1.Manager"
+	login1Session        = "org.freedesktop.login1.Session"
+	login1CurrentSession = "/org/freedesktop/login1/session/auto" // the session of the caller, or the user's display session
+)
+
+// LockScreen locks the session through the screensaver of the desktop, falling back to logind
+func LockScreen() error {
+	conn, err := dbus.SessionBus()
+	if err == nil {
+		screenSavers := []struct {
+			service string
+			path    string
+		}{
+			{"org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver"}, // KDE, XFCE, LXQt...
+			{"org.gnome.ScreenSaver", "/org/gnome/ScreenSaver"},
+			{"org.cinnamon.ScreenSaver", "/org/cinnamon/ScreenSaver"},
+			{"org.mate.ScreenSaver", "/org/mate/ScreenSaver"},
+		}
+		for _, screenSaver := range screenSavers {
+			if !hasOwner(conn, screenSaver.service) {
+				continue
+			}
+			call := conn.Object(screenSaver.service, dbus.ObjectPath(screenSaver.path)).Call(screenSaver.service+".Lock", 0)
+			if call.Err == nil {
+				return nil
+			}
+		}
+	}
+
+	// logind emits the Lock signal, the desktop's lock screen listens to it
+	return callLogindSession("Lock")
+}
+
+// Logout ends the desktop session, using the session manager so applications can save their state
+func Logout() error {
+	conn, err := dbus.SessionBus()
+	if err == nil {
+		if hasOwner(conn, "org.gnome.SessionManager") {
+			// mode 1 = no confirmation dialog, we already asked
+			call := conn.Object("org.gnome.SessionManager", "/org/gnome/SessionManager").Call("org.gnome.SessionManager.Logout", 0, uint32(1))
+			if call.Err == nil {
+				return nil
+			}
+		}
+		if hasOwner(conn, "org.kde.Shutdown") {
+			call := conn.Object("org.kde.Shutdown", "/Shutdown").Call("org.kde.Shutdown.logout", 0)
+			if call.Err == nil {
+				return nil
+			}
+		}
+		if hasOwner(conn, "org.kde.ksmserver") {
+			// confirm = 0 (no), type = 0 (logout), mode = 0 (default)
+			call := conn.Object("org.kde.ksmserver", "/KSMServer").Call("org.kde.KSMServerInterface.logout", 0, int32(0), int32(0), int32(0))
+			if call.Err == nil {
+				return nil
+			}
+		}
+	}
+
+	return callLogindSession("Terminate")
+}
+
+func Suspend() error {
+	return callLogindPowerAction("Suspend", "CanSuspend")
+}
+
+func Hibernate() error {
+	return callLogindPowerAction("Hibernate", "CanHibernate")
+}
+
+func Reboot() error {
+	return callLogindPowerAction("Reboot", "CanReboot")
+}
+
+func PowerOff() error {
+	return callLogindPowerAction("PowerOff", "CanPowerOff")
+}
+
+// ToggleDoNotDisturb toggles notification banners of the running notification daemon.
+// Returns whether do not disturb is enabled afterwards.
+func ToggleDoNotDisturb() (bool, error) {
+	currentDesktop := strings.ToLower(os.Getenv("XDG_CURRENT_DESKTOP"))
+
+	if strings.Contains(currentDesktop, "gnome") || strings.Contains(currentDesktop, "unity") || strings.Contains(currentDesktop, "budgie") {
+		if _, err := exec.LookPath("gsettings"); err == nil {
+			output, err := exec.Command("gsettings", "get", "org.gnome.desktop.notifications", "show-banners").Output()
+			if err != nil {
+				return false, fmt.Errorf("failed to read notification settings: %w", err)
+			}
+			enableDnd := strings.TrimSpace(string(output)) == "true"
+			if err := exec.Command("gsettings", "set", "org.gnome.desktop.notifications", "show-banners", fmt.Sprintf("%t", !enableDnd)).Run(); err != nil {
+				return false, fmt.Errorf("failed to change notification settings: %w", err)
+			}
+			return enableDnd, nil
+		}
+	}
+
+	if _, err := exec.LookPath("dunstctl"); err == nil {
+		if err := exec.Command("dunstctl", "set-paused", "toggle").Run(); err == nil {
+			output, err := exec.Command("dunstctl", "is-paused").Output()
+			return strings.TrimSpace(string(output)) == "true", err
+		}
+	}
+
+	if _, err := exec.LookPath("makoctl"); err == nil {
+		if err := exec.Command("makoctl", "mode", "-t", "do-not-disturb").Run(); err == nil {
+			output, err := exec.Command("makoctl", "mode").Output()
+			return strings.Contains(string(output), "do-not-disturb"), err
+		}
+	}
+
+	if _, err := exec.LookPath("swaync-client"); err == nil {
+		output, err := exec.Command("swaync-client", "--toggle-dnd", "--skip-wait").Output()
+		if err == nil {
+			return strings.TrimSpace(string(output)) == "true", nil
+		}
+	}
+
+	return false, ErrNotSupported
+}
+
+// ShowItemsInFileManager reveals the files through the FileManager1 interface (Nautilus, Dolphin, Nemo, Thunar...),
+// falling back to opening the parent folder
+func ShowItemsInFileManager(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	// no owner check, the file manager is usually started on demand by dbus activation
+	conn, err := dbus.SessionBus()
+	if err == nil {
+		var uris []string
+		for _, path := range paths {
+			uris = append(uris, (&url.URL{Scheme: "file", Path: path}).String())
+		}
+		call := conn.Object("org.freedesktop.FileManager1", "/org/freedesktop/FileManager1").Call("org.freedesktop.FileManager1.ShowItems", 0, uris, "")
+		if call.Err == nil {
+			return nil
+		}
+	}
+
+	return exec.Command("xdg-open", filepath.Dir(paths[0])).Start()
+}
+
+func callLogindSession(method string) error {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return fmt.Errorf("failed to connect to system bus: %w", err)
+	}
+
+	call := conn.Object(login1Service, login1CurrentSession).Call(login1Session+"."+method, 0)
+	if call.Err != nil {
+		return fmt.Errorf("logind %s failed: %w", method, call.Err)
+	}
+	return nil
+}
+
+// callLogindPowerAction checks whether the action is allowed before calling it, interactive is set so polkit can ask for a password
+func callLogindPowerAction(method string, canMethod string) error {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return fmt.Errorf("failed to connect to system bus: %w", err)
+	}
+
+	manager := conn.Object(login1Service, login1Path)
+
+	var can string
+	if err := manager.Call(login1Manager+"."+canMethod, 0).Store(&can); err == nil {
+		// yes, no, challenge (needs authentication) or na (not supported by hardware or config)
+		if can == "no" || can == "na" {
+			return fmt.Errorf("%w: logind reports %s=%s", ErrNotSupported, canMethod, can)
+		}
+	}
+
+	call := manager.Call(login1Manager+"."+method, 0, true)
+	if call.Err != nil {
+		return fmt.Errorf("logind %s failed: %w", method, call.Err)
+	}
+	return nil
+}
+
+func hasOwner(conn *dbus.Conn, service string) bool {
+	var hasOwner bool
+	err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, service).Store(&hasOwner)
+	return err == nil && hasOwner
+}
@@ -0,0 +1,35 @@
+//go:build !linux
+
+package desktop
+
+func LockScreen() error {
+	return ErrNotSupported
+}
+
+func Logout() error {
+	return ErrNotSupported
+}
+
+func Suspend() error {
+	return ErrNotSupported
+}
+
+func Hibernate() error {
+	return ErrNotSupported
+}
+
+func Reboot() error {
+	return ErrNotSupported
+}
+
+func PowerOff() error {
+	return ErrNotSupported
+}
+
+func ToggleDoNotDisturb() (bool, error) {
+	return false, ErrNotSupported
+}
+
+func ShowItemsInFileManager(paths []string) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,292 @@
+package desktop
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// GetHomeTrashDirectory returns the home trash defined by the freedesktop Trash spec, $XDG_DATA_HOME/Trash
+func GetHomeTrashDirectory() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" || !filepath.IsAbs(dataHome) {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// GetTrashDirectories returns the home trash and the trash directories of mounted volumes that exist for the current user.
+// Volume trashes are either $topdir/.Trash/$uid (only if .Trash is a sticky, non symlinked directory) or $topdir/.Trash-$uid.
+func GetTrashDirectories() ([]string, error) {
+	homeTrash, err := GetHomeTrashDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	directories := []string{homeTrash}
+	uid := strconv.Itoa(os.Getuid())
+	for _, topDir := range getMountPoints("/proc/self/mounts") {
+		sharedTrash := filepath.Join(topDir, ".Trash")
+		if info, err := os.Lstat(sharedTrash); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+			if isDirectory(filepath.Join(sharedTrash, uid)) {
+				directories = append(directories, filepath.Join(sharedTrash, uid))
+			}
+		}
+		if isDirectory(filepath.Join(topDir, ".Trash-"+uid)) {
+			directories = append(directories, filepath.Join(topDir, ".Trash-"+uid))
+		}
+	}
+
+	return directories, nil
+}
+
+// EmptyTrashDirectory permanently deletes everything in a trash directory, i.e. the entries in files/, their
+// .trashinfo files in info/ and the directorysizes cache. The trash directory itself is kept.
+// Returns the number of trashed items that were removed.
+func EmptyTrashDirectory(trashDir string) (int, error) {
+	if !isDirectory(trashDir) {
+		return 0, nil
+	}
+
+	var errs []error
+	removed := 0
+
+	filesDir := filepath.Join(trashDir, "files")
+	fileEntries, err := os.ReadDir(filesDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	for _, entry := range fileEntries {
+		if err := removeAllWritable(filepath.Join(filesDir, entry.Name())); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+	}
+
+	// info files are removed after their files, so a failed removal still has its restore information
+	infoDir := filepath.Join(trashDir, "info")
+	infoEntries, err := os.ReadDir(infoDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	for _, entry := range infoEntries {
+		name, isTrashInfo := strings.CutSuffix(entry.Name(), ".trashinfo")
+		if !isTrashInfo {
+			continue
+		}
+		if _, statErr := os.Lstat(filepath.Join(filesDir, name)); statErr == nil {
+			continue
+		}
+		if err := os.Remove(filepath.Join(infoDir, entry.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := os.Remove(filepath.Join(trashDir, "directorysizes")); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, err)
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+// EmptyAllTrash empties the home trash and all volume trashes of the current user
+func EmptyAllTrash() (int, error) {
+	directories, err := GetTrashDirectories()
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	removed := 0
+	for _, directory := range directories {
+		count, emptyErr := EmptyTrashDirectory(directory)
+		removed += count
+		if emptyErr != nil {
+			errs = append(errs, fmt.Errorf("failed to empty %s: %w", directory, emptyErr))
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+// MoveToTrash moves the file or directory into the trash, following the freedesktop Trash spec.
+// Files on other volumes than the home trash go into the trash of their volume. Returns the path inside the trash.
+func MoveToTrash(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(absPath); err != nil {
+		return "", err
+	}
+
+	homeTrash, err := GetHomeTrashDirectory()
+	if err != nil {
+		return "", err
+	}
+
+	trashedPath, err := moveToTrashDirectory(absPath, homeTrash, "")
+	if !errors.Is(err, syscall.EXDEV) {
+		return trashedPath, err
+	}
+
+	topDir := getMountPointOf(absPath, getMountPoints("/proc/self/mounts"))
+	if topDir == "" {
+		return "", fmt.Errorf("failed to find the volume of %s: %w", absPath, err)
+	}
+	volumeTrash, err := getVolumeTrashDirectory(topDir)
+	if err != nil {
+		return "", err
+	}
+	return moveToTrashDirectory(absPath, volumeTrash, topDir)
+}
+
+// moveToTrashDirectory writes the .trashinfo file first and then moves the file, so a trashed file always has restore information.
+// For volume trashes the original path is stored relative to the volume's top directory.
+func moveToTrashDirectory(absPath string, trashDir string, topDir string) (string, error) {
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", err
+		}
+	}
+
+	originalPath := absPath
+	if topDir != "" {
+		if rel, relErr := filepath.Rel(topDir, absPath); relErr == nil {
+			originalPath = rel
+		}
+	}
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: filepath.ToSlash(originalPath)}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+
+	baseName := filepath.Base(absPath)
+	ext := filepath.Ext(baseName)
+	for i := 1; i < 10000; i++ {
+		name := baseName
+		if i > 1 {
+			name = fmt.Sprintf("%s.%d%s", strings.TrimSuffix(baseName, ext), i, ext)
+		}
+
+		// O_EXCL makes the info file the lock for the name, so concurrent trash operations don't overwrite each other
+		infoPath := filepath.Join(infoDir, name+".trashinfo")
+		infoFile, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, writeErr := infoFile.WriteString(info)
+		closeErr := infoFile.Close()
+		if err := errors.Join(writeErr, closeErr); err != nil {
+			os.Remove(infoPath)
+			return "", err
+		}
+
+		trashedPath := filepath.Join(filesDir, name)
+		if _, err := os.Lstat(trashedPath); err == nil {
+			// a file without info, leave it alone and try the next name
+			os.Remove(infoPath)
+			continue
+		}
+		if err := os.Rename(absPath, trashedPath); err != nil {
+			os.Remove(infoPath)
+			return "", err
+		}
+		os.Remove(filepath.Join(trashDir, "directorysizes"))
+		return trashedPath, nil
+	}
+
+	return "", fmt.Errorf("failed to find a free name in %s", trashDir)
+}
+
+// getVolumeTrashDirectory returns $topdir/.Trash/$uid if the admin created a valid shared trash, otherwise $topdir/.Trash-$uid
+func getVolumeTrashDirectory(topDir string) (string, error) {
+	uid := strconv.Itoa(os.Getuid())
+
+	sharedTrash := filepath.Join(topDir, ".Trash")
+	if info, err := os.Lstat(sharedTrash); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		userTrash := filepath.Join(sharedTrash, uid)
+		if err := os.MkdirAll(userTrash, 0700); err == nil {
+			return userTrash, nil
+		}
+	}
+
+	userTrash := filepath.Join(topDir, ".Trash-"+uid)
+	if err := os.MkdirAll(userTrash, 0700); err != nil {
+		return "", fmt.Errorf("failed to create trash directory on %s: %w", topDir, err)
+	}
+	return userTrash, nil
+}
+
+// getMountPointOf returns the longest mount point containing the path
+func getMountPointOf(path string, mountPoints []string) string {
+	best := ""
+	for _, mountPoint := range mountPoints {
+		rel, err := filepath.Rel(mountPoint, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(mountPoint) > len(best) {
+			best = mountPoint
+		}
+	}
+	return best
+}
+
+// removeAllWritable is os.RemoveAll, but read-only directories inside the trash are made writable first,
+// otherwise trashed read-only folders (e.g. go module caches) could never be removed
+func removeAllWritable(path string) error {
+	err := os.RemoveAll(path)
+	if err == nil {
+		return nil
+	}
+
+	filepath.WalkDir(path, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr == nil && d.IsDir() {
+			os.Chmod(p, 0700)
+		}
+		return nil
+	})
+	return os.RemoveAll(path)
+}
+
+func getMountPoints(mountsFile string) []string {
+	file, err := os.Open(mountsFile)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var mountPoints []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[1] == "/" {
+			continue
+		}
+		// spaces and tabs in mount points are octal escaped
+		mountPoint := strings.NewReplacer(`\040`, " ", `\011`, "\t", `\134`, `\`).Replace(fields[1])
+		mountPoints = append(mountPoints, mountPoint)
+	}
+	return mountPoints
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
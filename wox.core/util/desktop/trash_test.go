@@ -0,0 +1,149 @@
+package desktop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTrashedItem(t *testing.T, trashDir string, name string, isDir bool) {
+	t.Helper()
+
+	filePath := filepath.Join(trashDir, "files", name)
+	if isDir {
+		require.NoError(t, os.MkdirAll(filepath.Join(filePath, "nested"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(filePath, "nested", "a.txt"), []byte("a"), 0644))
+	} else {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+		require.NoError(t, os.WriteFile(filePath, []byte("content"), 0644))
+	}
+
+	info := "[Trash Info]\nPath=/home/user/" + name + "\nDeletionDate=2024-01-01T10:00:00\n"
+	require.NoError(t, os.MkdirAll(filepath.Join(trashDir, "info"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(trashDir, "info", name+".trashinfo"), []byte(info), 0644))
+}
+
+func TestGetHomeTrashDirectory(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	trashDir, err := GetHomeTrashDirectory()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dataHome, "Trash"), trashDir)
+
+	// relative paths are invalid per the XDG base directory spec and must be ignored
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("XDG_DATA_HOME", "relative/share")
+	trashDir, err = GetHomeTrashDirectory()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(homeDir, ".local", "share", "Trash"), trashDir)
+}
+
+func TestEmptyTrashDirectory(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	trashDir, err := GetHomeTrashDirectory()
+	require.NoError(t, err)
+
+	writeTrashedItem(t, trashDir, "report.pdf", false)
+	writeTrashedItem(t, trashDir, "project", true)
+	writeTrashedItem(t, trashDir, "report.2.pdf", false)
+	require.NoError(t, os.WriteFile(filepath.Join(trashDir, "directorysizes"), []byte("4096 1700000000 project\n"), 0644))
+
+	// a read-only trashed directory must still be removable
+	require.NoError(t, os.Chmod(filepath.Join(trashDir, "files", "project", "nested"), 0555))
+
+	removed, err := EmptyTrashDirectory(trashDir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, removed)
+
+	files, err := os.ReadDir(filepath.Join(trashDir, "files"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	infos, err := os.ReadDir(filepath.Join(trashDir, "info"))
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+
+	assert.NoFileExists(t, filepath.Join(trashDir, "directorysizes"))
+	assert.DirExists(t, trashDir)
+}
+
+func TestEmptyTrashDirectoryRemovesOrphanInfo(t *testing.T) {
+	trashDir := filepath.Join(t.TempDir(), "Trash")
+	require.NoError(t, os.MkdirAll(filepath.Join(trashDir, "info"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(trashDir, "info", "gone.txt.trashinfo"), []byte("[Trash Info]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(trashDir, "info", "notes"), []byte("not a trashinfo"), 0644))
+
+	removed, err := EmptyTrashDirectory(trashDir)
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+	assert.NoFileExists(t, filepath.Join(trashDir, "info", "gone.txt.trashinfo"))
+	assert.FileExists(t, filepath.Join(trashDir, "info", "notes"))
+}
+
+func TestEmptyTrashDirectoryMissing(t *testing.T) {
+	removed, err := EmptyTrashDirectory(filepath.Join(t.TempDir(), "does-not-exist"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
+
+func TestGetMountPoints(t *testing.T) {
+	mounts := filepath.Join(t.TempDir(), "mounts")
+	content := "/dev/sda1 / ext4 rw 0 0\n" +
+		"/dev/sdb1 /media/user/My\\040Disk vfat rw 0 0\n" +
+		"tmpfs /tmp tmpfs rw 0 0\n"
+	require.NoError(t, os.WriteFile(mounts, []byte(content), 0644))
+
+	assert.Equal(t, []string{"/media/user/My Disk", "/tmp"}, getMountPoints(mounts))
+}
+
+func TestMoveToTrash(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	trashDir, err := GetHomeTrashDirectory()
+	require.NoError(t, err)
+
+	workDir := t.TempDir()
+	first := filepath.Join(workDir, "my report.pdf")
+	require.NoError(t, os.WriteFile(first, []byte("first"), 0644))
+
+	trashedPath, err := MoveToTrash(first)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(trashDir, "files", "my report.pdf"), trashedPath)
+	assert.NoFileExists(t, first)
+	assert.FileExists(t, trashedPath)
+
+	info, err := os.ReadFile(filepath.Join(trashDir, "info", "my report.pdf.trashinfo"))
+	require.NoError(t, err)
+	assert.Contains(t, string(info), "[Trash Info]\n")
+	assert.Contains(t, string(info), "Path="+filepath.ToSlash(workDir)+"/my%20report.pdf\n")
+	assert.Regexp(t, `DeletionDate=\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\n`, string(info))
+
+	// a second file with the same name gets a numbered name in the trash
+	require.NoError(t, os.WriteFile(first, []byte("second"), 0644))
+	trashedPath, err = MoveToTrash(first)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(trashDir, "files", "my report.2.pdf"), trashedPath)
+	assert.FileExists(t, filepath.Join(trashDir, "info", "my report.2.pdf.trashinfo"))
+
+	removed, err := EmptyTrashDirectory(trashDir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+}
+
+func TestMoveToTrashMissingFile(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	_, err := MoveToTrash(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestGetMountPointOf(t *testing.T) {
+	mountPoints := []string{"/media/user/disk", "/media/user/disk2", "/media"}
+	assert.Equal(t, "/media/user/disk", getMountPointOf("/media/user/disk/a/b.txt", mountPoints))
+	assert.Equal(t, "/media/user/disk2", getMountPointOf("/media/user/disk2/a.txt", mountPoints))
+	assert.Equal(t, "/media", getMountPointOf("/media/other/a.txt", mountPoints))
+	assert.Equal(t, "", getMountPointOf("/home/user/a.txt", mountPoints))
+}
@@ -7,3 +7,8 @@ func SimulateCopy() error {
 func SimulatePaste() error {
 	return simulatePaste()
 }
+
+// SimulateLeftArrow presses the left arrow key count times, e.g. to move the cursor back after pasting
+func SimulateLeftArrow(count int) error {
+	return simulateLeftArrow(count)
+}
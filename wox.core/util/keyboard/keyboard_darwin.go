@@ -47,6 +47,29 @@ const char* simulatePaste() {
 
     return NULL;
 }
+
+const char* simulateLeftArrow() {
+    CGEventRef pressLeft = CGEventCreateKeyboardEvent(NULL, (CGKeyCode)123, true);
+    if (pressLeft == NULL) return "Unable to create press event for Left";
+
+    CGEventRef releaseLeft = CGEventCreateKeyboardEvent(NULL, (CGKeyCode)123, false);
+    if (releaseLeft == NULL) {
+        CFRelease(pressLeft);
+        return "Unable to create release event for Left";
+    }
+
+    // the arrow keys carry the function and numeric pad flags, without them some apps ignore the event
+    CGEventSetFlags(pressLeft, kCGEventFlagMaskSecondaryFn | kCGEventFlagMaskNumericPad);
+    CGEventSetFlags(releaseLeft, kCGEventFlagMaskSecondaryFn | kCGEventFlagMaskNumericPad);
+
+    CGEventPost(kCGHIDEventTap, pressLeft);
+    CGEventPost(kCGHIDEventTap, releaseLeft);
+
+    CFRelease(pressLeft);
+    CFRelease(releaseLeft);
+
+    return NULL;
+}
 */
 import "C"
 import "fmt"
@@ -70,3 +93,15 @@ func simulatePaste() error {
 
 	return nil
 }
+
+func simulateLeftArrow(count int) error {
+	for i := 0; i < count; i++ {
+		err := C.simulateLeftArrow()
+		if err != nil {
+			errMsg := C.GoString(err)
+			return fmt.Errorf("failed to send Left: %v", errMsg)
+		}
+	}
+
+	return nil
+}
@@ -9,3 +9,7 @@ func simulateCopy() error {
 func simulatePaste() error {
 	return errors.New("not implemented")
 }
+
+func simulateLeftArrow(count int) error {
+	return errors.New("not implemented")
+}
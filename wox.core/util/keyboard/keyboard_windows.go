@@ -59,6 +59,26 @@ const char* simulateCtrlV() {
 
     return NULL;
 }
+
+const char* simulateLeft() {
+    INPUT ip[2];
+    ZeroMemory(ip, sizeof(ip));
+
+    ip[0].type = INPUT_KEYBOARD;
+    ip[0].ki.wVk = VK_LEFT;
+    ip[0].ki.dwFlags = KEYEVENTF_EXTENDEDKEY;
+
+    ip[1].type = INPUT_KEYBOARD;
+    ip[1].ki.wVk = VK_LEFT;
+    ip[1].ki.dwFlags = KEYEVENTF_EXTENDEDKEY | KEYEVENTF_KEYUP;
+
+    UINT res = SendInput(2, ip, sizeof(INPUT));
+    if (res != 2) {
+        return "Failed to send all input events";
+    }
+
+    return NULL;
+}
 */
 import "C"
 import (
@@ -90,6 +110,20 @@ func simulatePaste() error {
 	return nil
 }
 
+func simulateLeftArrow(count int) error {
+	waitCtrlRelease()
+
+	for i := 0; i < count; i++ {
+		err := C.simulateLeft()
+		if err != nil {
+			errMsg := C.GoString(err)
+			return fmt.Errorf("failed to send Left: %v", errMsg)
+		}
+	}
+
+	return nil
+}
+
 // when ctrl is pressed, we should wait until ctrl is released to simulate ctrl+c or ctrl+v
 func waitCtrlRelease() {
 	for i := 0; i < 20; i++ {
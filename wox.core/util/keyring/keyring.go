@@ -0,0 +1,103 @@
+package keyring
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned when no secret is stored for a key
+var ErrNotFound = errors.New("secret not found")
+
+// service groups the secrets of Wox in the OS keyring
+const service = "Wox"
+
+type store interface {
+	get(key string) (string, error)
+	set(key string, value string) error
+	delete(key string) error
+}
+
+var systemStore store = newSystemStore()
+var fallbackStore store
+var fallbackStoreOnce sync.Once
+
+// getFallbackStore is created on first use, the location is not initialized when this package is loaded
+func getFallbackStore() store {
+	fallbackStoreOnce.Do(func() {
+		if fallbackStore == nil {
+			fallbackStore = newFileStore()
+		}
+	})
+	return fallbackStore
+}
+
+// Get returns the secret of a key, from the OS keyring or from the encrypted file when the keyring was not available
+func Get(key string) (string, error) {
+	value, err := systemStore.get(key)
+	if err == nil {
+		return value, nil
+	}
+
+	return getFallbackStore().get(key)
+}
+
+// Set stores a secret in the OS keyring, the encrypted file is used when the keyring is not available, e.g. Linux without a Secret Service
+func Set(key string, value string) error {
+	if err := systemStore.set(key, value); err == nil {
+		// a secret saved while the keyring was not available is not needed anymore
+		if deleteErr := getFallbackStore().delete(key); deleteErr != nil && !errors.Is(deleteErr, ErrNotFound) {
+			return deleteErr
+		}
+		return nil
+	}
+
+	return getFallbackStore().set(key, value)
+}
+
+// Delete removes a secret, it is not an error when there is no secret for the key
+func Delete(key string) error {
+	systemErr := systemStore.delete(key)
+	fallbackErr := getFallbackStore().delete(key)
+	if fallbackErr != nil && !errors.Is(fallbackErr, ErrNotFound) {
+		return fallbackErr
+	}
+	if systemErr != nil && !errors.Is(systemErr, ErrNotFound) && errors.Is(fallbackErr, ErrNotFound) {
+		return systemErr
+	}
+	return nil
+}
+
+// MockInit keeps secrets in memory instead of the OS keyring, for tests
+func MockInit() {
+	systemStore = newMemoryStore()
+	fallbackStoreOnce.Do(func() {})
+	fallbackStore = newMemoryStore()
+}
+
+type memoryStore struct {
+	secrets sync.Map
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{}
+}
+
+func (m *memoryStore) get(key string) (string, error) {
+	value, ok := m.secrets.Load(key)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value.(string), nil
+}
+
+func (m *memoryStore) set(key string, value string) error {
+	m.secrets.Store(key, value)
+	return nil
+}
+
+func (m *memoryStore) delete(key string) error {
+	if _, loaded := m.secrets.LoadAndDelete(key); !loaded {
+		return ErrNotFound
+	}
+	return nil
+}
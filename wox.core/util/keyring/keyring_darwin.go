@@ -0,0 +1,67 @@
+package keyring
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	securityPath = "/usr/bin/security"
+
+	// secrets are saved hex encoded, so any secret can be passed to the security command
+	keychainEncodingPrefix = "wox-hex:"
+)
+
+// keychainStore keeps the secrets in the login keychain, through the security command line tool
+type keychainStore struct{}
+
+func newSystemStore() store {
+	return &keychainStore{}
+}
+
+func (k *keychainStore) get(key string) (string, error) {
+	out, err := exec.Command(securityPath, "find-generic-password", "-s", service, "-a", key, "-w").CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(out), "could not be found") {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read keychain: %s", strings.TrimSpace(string(out)))
+	}
+
+	encoded := strings.TrimSpace(string(out))
+	if !strings.HasPrefix(encoded, keychainEncodingPrefix) {
+		return encoded, nil
+	}
+	value, decodeErr := hex.DecodeString(strings.TrimPrefix(encoded, keychainEncodingPrefix))
+	if decodeErr != nil {
+		return "", fmt.Errorf("failed to decode keychain secret: %w", decodeErr)
+	}
+	return string(value), nil
+}
+
+// set passes the secret through stdin, so it is not visible in the process list
+func (k *keychainStore) set(key string, value string) error {
+	cmd := exec.Command(securityPath, "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", quoteSecurityArg(service), quoteSecurityArg(key), quoteSecurityArg(keychainEncodingPrefix+hex.EncodeToString([]byte(value)))))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write keychain: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (k *keychainStore) delete(key string) error {
+	out, err := exec.Command(securityPath, "delete-generic-password", "-s", service, "-a", key).CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(out), "could not be found") {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete from keychain: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func quoteSecurityArg(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
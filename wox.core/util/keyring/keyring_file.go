@@ -0,0 +1,149 @@
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"wox/util"
+)
+
+// fileStore keeps the secrets encrypted in one file. The key is in a file next to it that only the user can read,
+// so the secrets are not in plain text in the database, but they are only as safe as the key file.
+// Like the OS keyring, both files are in the Wox data directory and not part of backups, a restored backup only has the references.
+type fileStore struct {
+	path    string
+	keyPath string
+	lock    sync.Mutex
+}
+
+func newFileStore() *fileStore {
+	return &fileStore{
+		path:    util.GetLocation().GetSecretsPath(),
+		keyPath: util.GetLocation().GetSecretsKeyPath(),
+	}
+}
+
+func (f *fileStore) get(key string) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	secrets, err := f.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (f *fileStore) set(key string, value string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+	secrets[key] = value
+	return f.write(secrets)
+}
+
+func (f *fileStore) delete(key string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, key)
+	return f.write(secrets)
+}
+
+func (f *fileStore) read() (map[string]string, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := f.getCipher(false)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, errors.New("secrets file is damaged")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets file: %w", err)
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// write replaces the file by renaming, so the secrets are never half written
+func (f *fileStore) write(secrets map[string]string) error {
+	aead, err := f.getCipher(true)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data := base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, nil))
+
+	tempPath := f.path + ".tmp"
+	if err := os.WriteFile(tempPath, []byte(data), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, f.path)
+}
+
+func (f *fileStore) getCipher(create bool) (cipher.AEAD, error) {
+	key, err := os.ReadFile(f.keyPath)
+	if os.IsNotExist(err) && create {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(f.keyPath), os.ModePerm); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(f.keyPath, key, 0600); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read secrets key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
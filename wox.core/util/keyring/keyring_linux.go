@@ -0,0 +1,194 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretServiceName      = "org.freedesktop.secrets"
+	secretServicePath      = "/org/freedesktop/secrets"
+	secretServiceInterface = "org.freedesktop.Secret.Service"
+	secretItemInterface    = "org.freedesktop.Secret.Item"
+	secretPromptInterface  = "org.freedesktop.Secret.Prompt"
+	secretNoPrompt         = dbus.ObjectPath("/")
+	secretPromptTimeout    = 2 * time.Minute
+)
+
+// secretServiceSecret is the Secret struct of the Secret Service API
+type secretServiceSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// secretServiceStore keeps the secrets in the Secret Service of the desktop, e.g. GNOME Keyring or KWallet
+type secretServiceStore struct{}
+
+func newSystemStore() store {
+	return &secretServiceStore{}
+}
+
+func (s *secretServiceStore) get(key string) (string, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return "", err
+	}
+
+	item, err := s.findItem(conn, key)
+	if err != nil {
+		return "", err
+	}
+	session, err := s.openSession(conn)
+	if err != nil {
+		return "", err
+	}
+	defer s.closeSession(conn, session)
+
+	var secret secretServiceSecret
+	if err := conn.Object(secretServiceName, item).Call(secretItemInterface+".GetSecret", 0, session).Store(&secret); err != nil {
+		return "", err
+	}
+	return string(secret.Value), nil
+}
+
+func (s *secretServiceStore) set(key string, value string) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+
+	var collection dbus.ObjectPath
+	if err := s.service(conn).Call(secretServiceInterface+".ReadAlias", 0, "default").Store(&collection); err != nil {
+		return err
+	}
+	if collection == secretNoPrompt {
+		return errors.New("secret service has no default collection")
+	}
+	if err := s.unlock(conn, collection); err != nil {
+		return err
+	}
+
+	session, err := s.openSession(conn)
+	if err != nil {
+		return err
+	}
+	defer s.closeSession(conn, session)
+
+	properties := map[string]dbus.Variant{
+		secretItemInterface + ".Label":      dbus.MakeVariant(fmt.Sprintf("%s: %s", service, key)),
+		secretItemInterface + ".Attributes": dbus.MakeVariant(s.attributes(key)),
+	}
+	secret := secretServiceSecret{Session: session, Value: []byte(value), ContentType: "text/plain"}
+	var item, prompt dbus.ObjectPath
+	if err := conn.Object(secretServiceName, collection).Call("org.freedesktop.Secret.Collection.CreateItem", 0, properties, secret, true).Store(&item, &prompt); err != nil {
+		return err
+	}
+	return s.prompt(conn, prompt)
+}
+
+func (s *secretServiceStore) delete(key string) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+
+	item, err := s.findItem(conn, key)
+	if err != nil {
+		return err
+	}
+	var prompt dbus.ObjectPath
+	if err := conn.Object(secretServiceName, item).Call(secretItemInterface+".Delete", 0).Store(&prompt); err != nil {
+		return err
+	}
+	return s.prompt(conn, prompt)
+}
+
+func (s *secretServiceStore) service(conn *dbus.Conn) dbus.BusObject {
+	return conn.Object(secretServiceName, secretServicePath)
+}
+
+func (s *secretServiceStore) attributes(key string) map[string]string {
+	return map[string]string{"service": service, "username": key}
+}
+
+// findItem returns the unlocked item of a key, a locked item is unlocked first, which may ask the user for a password
+func (s *secretServiceStore) findItem(conn *dbus.Conn, key string) (dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	if err := s.service(conn).Call(secretServiceInterface+".SearchItems", 0, s.attributes(key)).Store(&unlocked, &locked); err != nil {
+		return "", err
+	}
+	if len(unlocked) > 0 {
+		return unlocked[0], nil
+	}
+	if len(locked) == 0 {
+		return "", ErrNotFound
+	}
+
+	if err := s.unlock(conn, locked[0]); err != nil {
+		return "", err
+	}
+	return locked[0], nil
+}
+
+func (s *secretServiceStore) unlock(conn *dbus.Conn, object dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := s.service(conn).Call(secretServiceInterface+".Unlock", 0, []dbus.ObjectPath{object}).Store(&unlocked, &prompt); err != nil {
+		return err
+	}
+	return s.prompt(conn, prompt)
+}
+
+// prompt shows a prompt of the secret service and waits until the user completes it
+func (s *secretServiceStore) prompt(conn *dbus.Conn, prompt dbus.ObjectPath) error {
+	if prompt == secretNoPrompt || prompt == "" {
+		return nil
+	}
+
+	matchOptions := []dbus.MatchOption{dbus.WithMatchObjectPath(prompt), dbus.WithMatchInterface(secretPromptInterface), dbus.WithMatchMember("Completed")}
+	if err := conn.AddMatchSignal(matchOptions...); err != nil {
+		return err
+	}
+	defer conn.RemoveMatchSignal(matchOptions...)
+	signals := make(chan *dbus.Signal, 1)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	if err := conn.Object(secretServiceName, prompt).Call(secretPromptInterface+".Prompt", 0, "").Err; err != nil {
+		return err
+	}
+
+	timeout := time.After(secretPromptTimeout)
+	for {
+		select {
+		case signal := <-signals:
+			if signal.Path != prompt || len(signal.Body) == 0 {
+				continue
+			}
+			if dismissed, _ := signal.Body[0].(bool); dismissed {
+				return errors.New("secret service prompt is dismissed")
+			}
+			return nil
+		case <-timeout:
+			return errors.New("secret service prompt timed out")
+		}
+	}
+}
+
+func (s *secretServiceStore) openSession(conn *dbus.Conn) (dbus.ObjectPath, error) {
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	if err := s.service(conn).Call(secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session); err != nil {
+		return "", err
+	}
+	return session, nil
+}
+
+func (s *secretServiceStore) closeSession(conn *dbus.Conn, session dbus.ObjectPath) {
+	conn.Object(secretServiceName, session).Call("org.freedesktop.Secret.Session.Close", 0)
+}
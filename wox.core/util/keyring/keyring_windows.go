@@ -0,0 +1,116 @@
+package keyring
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+var (
+	advapi32        = windows.NewLazySystemDLL("advapi32.dll")
+	procCredReadW   = advapi32.NewProc("CredReadW")
+	procCredWriteW  = advapi32.NewProc("CredWriteW")
+	procCredDeleteW = advapi32.NewProc("CredDeleteW")
+	procCredFree    = advapi32.NewProc("CredFree")
+)
+
+// credential is the CREDENTIALW struct of the Credential Manager API
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// credentialManagerStore keeps the secrets as generic credentials in the Credential Manager
+type credentialManagerStore struct{}
+
+func newSystemStore() store {
+	return &credentialManagerStore{}
+}
+
+func (c *credentialManagerStore) get(key string) (string, error) {
+	targetName, err := windows.UTF16PtrFromString(c.targetName(key))
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+	ret, _, callErr := procCredReadW.Call(uintptr(unsafe.Pointer(targetName)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		return "", c.convertError(callErr)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func (c *credentialManagerStore) set(key string, value string) error {
+	targetName, err := windows.UTF16PtrFromString(c.targetName(key))
+	if err != nil {
+		return err
+	}
+	userName, err := windows.UTF16PtrFromString(key)
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(value)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         targetName,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           userName,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	ret, _, callErr := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ret == 0 {
+		return c.convertError(callErr)
+	}
+	return nil
+}
+
+func (c *credentialManagerStore) delete(key string) error {
+	targetName, err := windows.UTF16PtrFromString(c.targetName(key))
+	if err != nil {
+		return err
+	}
+
+	ret, _, callErr := procCredDeleteW.Call(uintptr(unsafe.Pointer(targetName)), credTypeGeneric, 0)
+	if ret == 0 {
+		return c.convertError(callErr)
+	}
+	return nil
+}
+
+func (c *credentialManagerStore) targetName(key string) string {
+	return service + ":" + key
+}
+
+func (c *credentialManagerStore) convertError(err error) error {
+	if errors.Is(err, windows.ERROR_NOT_FOUND) {
+		return ErrNotFound
+	}
+	return err
+}
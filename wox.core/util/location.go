@@ -210,6 +210,21 @@ func (l *Location) GetImageCacheDirectory() string {
 	return path.Join(l.GetCacheDirectory(), "images")
 }
 
+// GetSyncDeviceIdPath is outside of the user data directory, so a restored backup or a synced user data directory keeps the id of this device
+func (l *Location) GetSyncDeviceIdPath() string {
+	return path.Join(l.woxDataDirectory, "sync_device_id")
+}
+
+// GetSecretsPath is the encrypted secrets file used when the OS keyring is not available, it is outside of the user data directory so backups don't contain secrets.
+// A backup restored on another computer therefore has empty secrets until they are entered again.
+func (l *Location) GetSecretsPath() string {
+	return path.Join(l.woxDataDirectory, "secrets")
+}
+
+func (l *Location) GetSecretsKeyPath() string {
+	return path.Join(l.woxDataDirectory, "secrets.key")
+}
+
 func (l *Location) GetBackupDirectory() string {
 	return path.Join(l.woxDataDirectory, "backup")
 }
@@ -1,8 +1,28 @@
 package screen
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxScreens is the most screens GetAllScreens returns
+const maxScreens = 16
+
 type Size struct {
 	Width  int
 	Height int
 	X      int
 	Y      int
 }
+
+// GetDisplayLayout describes the connected displays by their sizes, e.g. "1920x1080+2560x1440".
+// The sizes are sorted, so rearranging the displays doesn't change the layout.
+func GetDisplayLayout() string {
+	var sizes []string
+	for _, screen := range GetAllScreens() {
+		sizes = append(sizes, fmt.Sprintf("%dx%d", screen.Width, screen.Height))
+	}
+	slices.Sort(sizes)
+	return strings.Join(sizes, "+")
+}
@@ -13,6 +13,7 @@ typedef struct {
 
 ScreenInfo getMouseScreenSize();
 ScreenInfo getActiveScreenSize();
+int getAllScreens(ScreenInfo* screens, int max);
 */
 import "C"
 
@@ -35,3 +36,19 @@ func GetActiveScreen() Size {
 		Y:      int(screenInfo.y),
 	}
 }
+
+func GetAllScreens() []Size {
+	var screenInfos [maxScreens]C.ScreenInfo
+	count := int(C.getAllScreens(&screenInfos[0], maxScreens))
+
+	screens := make([]Size, 0, count)
+	for _, screenInfo := range screenInfos[:count] {
+		screens = append(screens, Size{
+			Width:  int(screenInfo.width),
+			Height: int(screenInfo.height),
+			X:      int(screenInfo.x),
+			Y:      int(screenInfo.y),
+		})
+	}
+	return screens
+}
@@ -89,3 +89,19 @@ ScreenInfo getActiveScreenSize() {
                       .x = visibleFrame.origin.x,
                       .y = topY};
 }
+
+// getAllScreens returns the full frame of every screen, in AppKit coordinates
+int getAllScreens(ScreenInfo *screens, int max) {
+  int count = 0;
+  for (NSScreen *screen in [NSScreen screens]) {
+    if (count >= max) {
+      break;
+    }
+    NSRect frame = [screen frame];
+    screens[count++] = (ScreenInfo){.width = frame.size.width,
+                                    .height = frame.size.height,
+                                    .x = frame.origin.x,
+                                    .y = frame.origin.y};
+  }
+  return count;
+}
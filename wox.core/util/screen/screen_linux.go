@@ -49,6 +49,33 @@ func GetMouseScreenGtk() (Size, error) {
 	}, nil
 }
 
+func GetAllScreens() []Size {
+	if gtk.InitCheck(nil) == nil {
+		if display, err := gdk.DisplayGetDefault(); err == nil {
+			var screens []Size
+			for i := 0; i < display.GetNMonitors(); i++ {
+				monitor, monitorErr := display.GetMonitor(i)
+				if monitorErr != nil {
+					continue
+				}
+				geometry := monitor.GetGeometry()
+				screens = append(screens, Size{
+					Width:  geometry.GetWidth(),
+					Height: geometry.GetHeight(),
+					X:      geometry.GetX(),
+					Y:      geometry.GetY(),
+				})
+			}
+			if len(screens) > 0 {
+				return screens
+			}
+		}
+	}
+
+	// X11 only knows the whole screen
+	return []Size{GetMouseScreen()}
+}
+
 func GetMouseScreen() Size {
 	// Give gtk a try, as it considers DPI and scaling of the screen
 	size, err := GetMouseScreenGtk()
@@ -130,6 +130,28 @@ ScreenInfo getActiveScreenSize() {
     // Fallback to mouse screen
     return getMouseScreenSize();
 }
+
+typedef struct {
+    ScreenInfo* screens;
+    int max;
+    int count;
+} ScreenList;
+
+BOOL CALLBACK collectScreen(HMONITOR hMonitor, HDC hdc, LPRECT rect, LPARAM data) {
+    ScreenList* list = (ScreenList*)data;
+    if (list->count >= list->max) {
+        return FALSE;
+    }
+    list->screens[list->count++] = (ScreenInfo){.width = rect->right - rect->left, .height = rect->bottom - rect->top, .x = rect->left, .y = rect->top};
+    return TRUE;
+}
+
+// getAllScreens returns the physical size of every monitor, they only describe the display layout so no DPI conversion is needed
+int getAllScreens(ScreenInfo* screens, int max) {
+    ScreenList list = {screens, max, 0};
+    EnumDisplayMonitors(NULL, NULL, collectScreen, (LPARAM)&list);
+    return list.count;
+}
 */
 import "C"
 
@@ -152,3 +174,19 @@ func GetActiveScreen() Size {
 		Y:      int(screenInfo.y),
 	}
 }
+
+func GetAllScreens() []Size {
+	var screenInfos [maxScreens]C.ScreenInfo
+	count := int(C.getAllScreens(&screenInfos[0], maxScreens))
+
+	screens := make([]Size, 0, count)
+	for _, screenInfo := range screenInfos[:count] {
+		screens = append(screens, Size{
+			Width:  int(screenInfo.width),
+			Height: int(screenInfo.height),
+			X:      int(screenInfo.x),
+			Y:      int(screenInfo.y),
+		})
+	}
+	return screens
+}
@@ -0,0 +1,6 @@
+package wifi
+
+import "errors"
+
+// ErrNotConnected is returned when the device is not connected to a Wi-Fi network
+var ErrNotConnected = errors.New("not connected to wifi")
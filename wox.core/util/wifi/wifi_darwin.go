@@ -0,0 +1,51 @@
+package wifi
+
+import (
+	"strings"
+	"wox/util/shell"
+)
+
+// GetSSID returns the SSID of the connected Wi-Fi network. The Wi-Fi interface is looked up, it is not always en0.
+func GetSSID() (string, error) {
+	device, err := getWifiDevice()
+	if err != nil {
+		return "", err
+	}
+
+	// networksetup doesn't report the network on recent macOS versions, ipconfig still does
+	if output, err := shell.RunOutput("ipconfig", "getsummary", device); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			if ssid, ok := strings.CutPrefix(strings.TrimSpace(line), "SSID : "); ok && ssid != "" {
+				return ssid, nil
+			}
+		}
+	}
+
+	output, err := shell.RunOutput("networksetup", "-getairportnetwork", device)
+	if err != nil {
+		return "", err
+	}
+	_, ssid, found := strings.Cut(strings.TrimSpace(string(output)), "Current Wi-Fi Network: ")
+	if !found || ssid == "" {
+		return "", ErrNotConnected
+	}
+	return ssid, nil
+}
+
+func getWifiDevice() (string, error) {
+	output, err := shell.RunOutput("networksetup", "-listallhardwareports")
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(string(output), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "Hardware Port: Wi-Fi" || i+1 >= len(lines) {
+			continue
+		}
+		if device, ok := strings.CutPrefix(strings.TrimSpace(lines[i+1]), "Device: "); ok {
+			return device, nil
+		}
+	}
+	return "", ErrNotConnected
+}
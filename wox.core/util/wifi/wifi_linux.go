@@ -0,0 +1,29 @@
+package wifi
+
+import (
+	"strings"
+	"wox/util/shell"
+)
+
+// GetSSID returns the SSID of the connected Wi-Fi network, through NetworkManager or iwgetid when NetworkManager is not used
+func GetSSID() (string, error) {
+	if output, err := shell.RunOutput("nmcli", "-t", "-f", "active,ssid", "dev", "wifi"); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			if ssid, ok := strings.CutPrefix(line, "yes:"); ok && ssid != "" {
+				// nmcli escapes the separator in terse output
+				return strings.ReplaceAll(ssid, `\:`, ":"), nil
+			}
+		}
+		return "", ErrNotConnected
+	}
+
+	output, err := shell.RunOutput("iwgetid", "-r")
+	if err != nil {
+		return "", err
+	}
+	ssid := strings.TrimSpace(string(output))
+	if ssid == "" {
+		return "", ErrNotConnected
+	}
+	return ssid, nil
+}
@@ -0,0 +1,26 @@
+package wifi
+
+import (
+	"strings"
+	"wox/util/shell"
+)
+
+// GetSSID returns the SSID of the connected Wi-Fi network
+func GetSSID() (string, error) {
+	output, err := shell.RunOutput("netsh", "wlan", "show", "interfaces")
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		name, value, found := strings.Cut(line, ":")
+		// BSSID is on its own line, and the SSID line is only there when connected
+		if !found || strings.TrimSpace(name) != "SSID" {
+			continue
+		}
+		if ssid := strings.TrimSpace(value); ssid != "" {
+			return ssid, nil
+		}
+	}
+	return "", ErrNotConnected
+}
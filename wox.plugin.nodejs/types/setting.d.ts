@@ -24,6 +24,7 @@ export interface PluginSettingDefinitionItem {
   Value: PluginSettingDefinitionValue
   DisabledInPlatforms: Platform[]
   IsPlatformSpecific: boolean // if true, this setting may be different in different platforms
+  IsSecret?: boolean // if true, the value is kept in the OS keyring instead of the Wox database, e.g. an api key
 }
 
 export interface MetadataCommand {
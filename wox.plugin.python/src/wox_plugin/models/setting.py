@@ -142,6 +142,7 @@ class PluginSettingDefinitionItem:
     value: PluginSettingDefinitionValue
     disabled_in_platforms: List[str] = field(default_factory=list)
     is_platform_specific: bool = field(default=False)
+    is_secret: bool = field(default=False)
 
     def to_dict(self) -> Dict[str, Any]:
         """Convert to dictionary with camelCase naming"""
@@ -150,6 +151,7 @@ class PluginSettingDefinitionItem:
             "Value": self.value.to_dict(),
             "DisabledInPlatforms": self.disabled_in_platforms,
             "IsPlatformSpecific": self.is_platform_specific,
+            "IsSecret": self.is_secret,
         }
 
     def to_json(self) -> str:
@@ -198,6 +200,7 @@ class PluginSettingDefinitionItem:
             value=value,
             disabled_in_platforms=data.get("DisabledInPlatforms", []),
             is_platform_specific=data.get("IsPlatformSpecific", False),
+            is_secret=data.get("IsSecret", False),
         )
 
     @classmethod
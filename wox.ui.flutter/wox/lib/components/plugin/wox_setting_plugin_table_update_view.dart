@@ -394,6 +394,7 @@ class _WoxSettingPluginTableUpdateState extends State<WoxSettingPluginTableUpdat
             child: WoxTextField(
               controller: textboxEditingController[column.key],
               maxLines: column.textMaxLines,
+              obscureText: column.isSecret,
               onChanged: (value) {
                 updateValue(column.key, value);
 
@@ -116,8 +116,19 @@ class WoxSettingPluginTable extends WoxSettingPluginItem {
     );
   }
 
+  // maskSecret hides the values of a secret column, a text list keeps one masked line per value
+  dynamic maskSecret(dynamic value) {
+    if (value is List) {
+      return value.map((e) => maskSecret(e)).toList();
+    }
+    return value == "" ? "" : "••••••••";
+  }
+
   Widget buildRowCell(PluginSettingValueTableColumn column, Map<String, dynamic> row) {
     var value = row[column.key] ?? "";
+    if (column.isSecret) {
+      value = maskSecret(value);
+    }
 
     if (column.type == PluginSettingValueType.pluginSettingValueTableColumnTypeText) {
       return columnWidth(
@@ -9,9 +9,10 @@ import 'wox_setting_plugin_item_view.dart';
 
 class WoxSettingPluginTextBox extends WoxSettingPluginItem {
   final PluginSettingValueTextBox item;
+  final bool isSecret;
   final controller = TextEditingController();
 
-  WoxSettingPluginTextBox({super.key, required this.item, required super.value, required super.onUpdate}) {
+  WoxSettingPluginTextBox({super.key, required this.item, this.isSecret = false, required super.value, required super.onUpdate}) {
     controller.text = getSetting(item.key);
     if (item.maxLines < 1) {
       item.maxLines = 1;
@@ -40,6 +41,7 @@ class WoxSettingPluginTextBox extends WoxSettingPluginItem {
           },
           child: WoxTextField(
             maxLines: item.maxLines,
+            obscureText: isSecret,
             controller: controller,
             width: item.style.width > 0 ? item.style.width.toDouble() : 100,
             onChanged: (value) {
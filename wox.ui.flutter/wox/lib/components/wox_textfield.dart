@@ -10,6 +10,7 @@ class WoxTextField extends StatelessWidget {
   final ValueChanged<String>? onSubmitted;
   final int maxLines;
   final int? minLines;
+  final bool obscureText;
   final bool autofocus;
   final TextStyle? style;
   final TextStyle? hintStyle;
@@ -28,6 +29,7 @@ class WoxTextField extends StatelessWidget {
     this.onSubmitted,
     this.maxLines = 1,
     this.minLines,
+    this.obscureText = false,
     this.autofocus = false,
     this.style,
     this.hintStyle,
@@ -48,8 +50,9 @@ class WoxTextField extends StatelessWidget {
       onChanged: onChanged,
       onEditingComplete: onEditingComplete,
       onSubmitted: onSubmitted,
-      maxLines: maxLines,
+      maxLines: obscureText ? 1 : maxLines,
       minLines: minLines,
+      obscureText: obscureText,
       autofocus: autofocus,
       focusNode: focusNode,
       textAlignVertical: TextAlignVertical.center,
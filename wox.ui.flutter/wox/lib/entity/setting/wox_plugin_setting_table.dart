@@ -58,6 +58,7 @@ class PluginSettingValueTableColumn {
   late int textMaxLines; // Only used when Type is PluginSettingValueTableColumnTypeText
   late bool hideInTable; // Hide this column in the table, but still show it in the setting dialog
   late bool hideInUpdate; // Hide this column in the update dialog
+  late bool isSecret; // The values are kept in the OS keyring, they are masked in the table and in the update dialog
   late List<PluginSettingValidatorItem> validators;
 
   PluginSettingValueTableColumn.fromJson(Map<String, dynamic> json) {
@@ -77,6 +78,7 @@ class PluginSettingValueTableColumn {
     }
     hideInTable = json['HideInTable'] ?? false;
     hideInUpdate = json['HideInUpdate'] ?? false;
+    isSecret = json['IsSecret'] ?? false;
 
     if (json['Validators'] != null) {
       validators = (json['Validators'] as List).map((e) => PluginSettingValidatorItem.fromJson(e)).toList();
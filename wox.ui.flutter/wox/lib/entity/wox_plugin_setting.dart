@@ -13,6 +13,7 @@ class PluginSettingDefinitionItem {
   late dynamic value;
   late List<String> disabledInPlatforms;
   late bool isPlatformSpecific;
+  late bool isSecret; // the value is kept in the OS keyring, it is masked in the setting view
 
   PluginSettingDefinitionItem.fromJson(Map<String, dynamic> json) {
     if (json['DisabledInPlatforms'] == null) {
@@ -21,6 +22,7 @@ class PluginSettingDefinitionItem {
       disabledInPlatforms = (json['DisabledInPlatforms'] as List).map((e) => e.toString()).toList();
     }
     isPlatformSpecific = json['IsPlatformSpecific'];
+    isSecret = json['IsSecret'] ?? false;
     type = json['Type'];
 
     if (type == "checkbox") {
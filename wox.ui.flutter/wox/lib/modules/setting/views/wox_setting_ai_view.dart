@@ -43,6 +43,7 @@ class WoxSettingAIView extends WoxSettingBaseView {
                         "Tooltip": "i18n:ui_ai_providers_api_key_tooltip",
                         "Type": "text",
                         "TextMaxLines": 1,
+                        "IsSecret": true,
                       },
                       {
                         "Key": "Host",
@@ -537,6 +537,7 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
                     return WoxSettingPluginTextBox(
                       value: plugin.setting.settings[e.value.key] ?? "",
                       item: e.value as PluginSettingValueTextBox,
+                      isSecret: e.isSecret,
                       onUpdate: (key, value) async {
                         await controller.updatePluginSetting(plugin.id, key, value);
                       },